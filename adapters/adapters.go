@@ -19,3 +19,13 @@ type AssetWriter interface {
 type Runner interface {
 	Run(cmd *cobra.Command, adapter Reader) error
 }
+
+// TrashReader is implemented by the readers that can be asked for the trashed assets only
+type TrashReader interface {
+	TrashedOnly() bool
+}
+
+// InputSizer is implemented by the readers that can give the size of their files before browsing them
+type InputSizer interface {
+	InputSize(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,53 @@
+package folder
+
+import "testing"
+
+func TestFolderAlbum(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  AlbumFolderMode
+		depth int
+		dir   string
+		want  string
+	}{
+		{name: "none", mode: FolderModeNone, dir: "2023/Holidays", want: ""},
+		{name: "folder, root files", mode: FolderModeFolder, dir: ".", want: "photos"},
+		{name: "folder", mode: FolderModeFolder, dir: "2023/Holidays", want: "Holidays"},
+		{name: "path, root files", mode: FolderModePath, dir: ".", want: "photos"},
+		{name: "path, depth 0", mode: FolderModePath, dir: "2023/Holidays", want: "photos / 2023 / Holidays"},
+		{name: "path, depth 1", mode: FolderModePath, depth: 1, dir: "2023/Holidays", want: "Holidays"},
+		{name: "path, depth 2", mode: FolderModePath, depth: 2, dir: "2023/Holidays", want: "2023 / Holidays"},
+		{name: "path, depth 1, root files", mode: FolderModePath, depth: 1, dir: ".", want: "photos"},
+		{name: "path, depth over the path length", mode: FolderModePath, depth: 5, dir: "2023/Holidays", want: "photos / 2023 / Holidays"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ifc := &ImportFolderCmd{UsePathAsAlbumName: tt.mode, AlbumFolderDepth: tt.depth, AlbumNamePathSeparator: " / "}
+			if got := ifc.folderAlbum("photos", tt.dir); got != tt.want {
+				t.Errorf("folderAlbum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAlbumFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifc     *ImportFolderCmd
+		wantErr bool
+	}{
+		{name: "none", ifc: &ImportFolderCmd{UsePathAsAlbumName: FolderModeNone}},
+		{name: "path with depth", ifc: &ImportFolderCmd{UsePathAsAlbumName: FolderModePath, AlbumFolderDepth: 1}},
+		{name: "depth without --folder-as-album", ifc: &ImportFolderCmd{UsePathAsAlbumName: FolderModeNone, AlbumFolderDepth: 1}, wantErr: true},
+		{name: "depth with FOLDER", ifc: &ImportFolderCmd{UsePathAsAlbumName: FolderModeFolder, AlbumFolderDepth: 1}, wantErr: true},
+		{name: "negative depth", ifc: &ImportFolderCmd{UsePathAsAlbumName: FolderModePath, AlbumFolderDepth: -1}, wantErr: true},
+		{name: "into-album and folder-as-album", ifc: &ImportFolderCmd{UsePathAsAlbumName: FolderModePath, ImportIntoAlbum: "Trip"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.ifc.validateAlbumFlags(); (err != nil) != tt.wantErr {
+				t.Errorf("validateAlbumFlags() = %v, want an error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/simulot/immich-go/internal/filetypes"
 	"github.com/simulot/immich-go/internal/gen"
 	"github.com/simulot/immich-go/internal/groups"
+	"github.com/simulot/immich-go/internal/lastrun"
 	"github.com/simulot/immich-go/internal/namematcher"
 	"github.com/simulot/immich-go/internal/worker"
 	"github.com/spf13/cobra"
@@ -26,8 +27,10 @@ type ImportFolderCmd struct {
 	// CLI flags
 	UsePathAsAlbumName     AlbumFolderMode
 	AlbumNamePathSeparator string
+	AlbumFolderDepth       int // Number of trailing folders kept in the album name with --folder-as-album=PATH, 0 for the full path
 	ImportIntoAlbum        string
 	BannedFiles            namematcher.List
+	ExcludedPaths          namematcher.PathList // Paths skipped during the walk, relative to the source root
 	Recursive              bool
 	InclusionFlags         cliflags.InclusionFlags
 	IgnoreSideCarFiles     bool
@@ -36,6 +39,15 @@ type ImportFolderCmd struct {
 	PicasaAlbum            bool
 	ICloudTakeout          bool
 	ICloudMemoriesAsAlbums bool
+	SinceLastRun           bool   // Skip the files not modified since the last successful run
+	ForceFull              bool   // Ignore the last run marker
+	FromStdin              bool   // Read the list of files from the standard input
+	NullSeparator          bool   // The file names read from the standard input are separated by NUL characters
+	BaseDir                string // Folder used to resolve the relative paths read from the standard input
+	LinkLivePhotos         bool   // Link the live photos images with their video
+	SkipMotionVideos       bool   // Skip the videos exported next to the motion photos
+	FSRetries              int    // Number of retries of a failing directory read
+	DateSource             cliflags.DateSourceFlags
 	shared.StackOptions
 
 	// Internal fields
@@ -52,6 +64,9 @@ type ImportFolderCmd struct {
 	picasaAlbums            *gen.SyncMap[string, PicasaAlbum] // ap[string]PicasaAlbum
 	icloudMetas             *gen.SyncMap[string, iCloudMeta]
 	icloudMetaPass          bool
+	lastRuns                *lastrun.Store // last run markers, when --since-last-run is set
+	lastRunKey              string         // key of the current source in the last run markers
+	notBefore               time.Time      // files modified before are skipped
 }
 
 func (ifc *ImportFolderCmd) RegisterFlags(flags *pflag.FlagSet, cmd *cobra.Command) {
@@ -61,19 +76,33 @@ func (ifc *ImportFolderCmd) RegisterFlags(flags *pflag.FlagSet, cmd *cobra.Comma
 	ifc.BannedFiles, _ = namematcher.New(shared.DefaultBannedFiles...)
 
 	flags.Var(&ifc.BannedFiles, "ban-file", "Exclude a file based on a pattern (case-insensitive). Can be specified multiple times.")
+	flags.Var(&ifc.ExcludedPaths, "exclude-path", "Skip the files and folders matching this glob pattern, relative to the source folder (e.g. '**/@eaDir', '#recycle'). ** matches any number of folders. Can be specified multiple times")
 	flags.StringVar(&ifc.ImportIntoAlbum, "into-album", "", "Specify an album to import all files into")
 	flags.Var(&ifc.UsePathAsAlbumName, "folder-as-album", "Import all files in albums defined by the folder structure. Can be set to 'FOLDER' to use the folder name as the album name, or 'PATH' to use the full path as the album name")
+	flags.IntVar(&ifc.AlbumFolderDepth, "album-folder-depth", 0, "Number of trailing folders used for the album name with --folder-as-album=PATH (0 for the full path, 1 for the leaf folder only)")
 	flags.StringVar(&ifc.AlbumNamePathSeparator, "album-path-joiner", " / ", "Specify a string to use when joining multiple folder names to create an album name (e.g. ' ',' - ')")
 	flags.BoolVar(&ifc.Recursive, "recursive", true, "Explore the folder and all its sub-folders")
 	flags.BoolVar(&ifc.IgnoreSideCarFiles, "ignore-sidecar-files", false, "Don't upload sidecar with the photo.")
 	flags.BoolVar(&ifc.FolderAsTags, "folder-as-tags", false, "Use the folder structure as tags, (ex: the file  holiday/summer 2024/file.jpg will have the tag holiday/summer 2024)")
 	flags.BoolVar(&ifc.TakeDateFromFilename, "date-from-name", true, "Use the date from the filename if the date isn't available in the metadata (Only for jpg, mp4, heic, dng, cr2, cr3, arw, raf, nef, mov)")
+	flags.BoolVar(&ifc.SinceLastRun, "since-last-run", false, "Only consider the files modified since the last successful run with the same source")
+	flags.BoolVar(&ifc.ForceFull, "force-full", false, "Ignore the last run marker and consider all files. Used with --since-last-run")
+	flags.BoolVar(&ifc.SkipMotionVideos, "skip-motion-videos", false, "Skip the MP4 videos exported next to the Android motion photos, the server extracts the video from the image")
+	flags.IntVar(&ifc.FSRetries, "fs-retries", 3, "Number of retries of a directory read failing with a transient error, like on a flaky network mount")
+
+	if cmd.Name() == "from-folder" {
+		flags.BoolVar(&ifc.FromStdin, "from-stdin", false, "Read the list of files to import from the standard input, one path per line, instead of walking folders")
+		flags.BoolVarP(&ifc.NullSeparator, "null", "0", false, "With --from-stdin, the paths are separated by NUL characters (like find -print0)")
+		flags.StringVar(&ifc.BaseDir, "base-dir", "", "With --from-stdin, folder used to resolve the relative paths (default: the current folder)")
+	}
 
 	if cmd.Parent() != nil && cmd.Parent().Name() == "upload" {
 		ifc.StackOptions.RegisterFlags(flags)
+		flags.BoolVar(&ifc.LinkLivePhotos, "link-live-photos", false, "Link the live photos images (HEIC or JPG) with their MOV video on the server")
 	}
 
 	ifc.InclusionFlags.RegisterFlags(flags, "") // selection per extension
+	ifc.DateSource.RegisterFlags(flags)
 	ifc.ICloudTakeout = false
 	ifc.PicasaAlbum = false
 	switch cmd.Name() {
@@ -90,12 +119,17 @@ func NewFromFolderCommand(ctx context.Context, parent *cobra.Command, app *app.A
 	cmd := &cobra.Command{
 		Use:   "from-folder [flags] <path>...",
 		Short: "Upload photos from a folder",
-		Args:  cobra.MinimumNArgs(1),
 	}
 	cmd.SetContext(ctx)
 	flags := cmd.Flags()
 	o := ImportFolderCmd{}
 	o.RegisterFlags(flags, cmd)
+	cmd.Args = func(cmd *cobra.Command, args []string) error {
+		if o.FromStdin {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	}
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		return o.run(cmd, args, app, runner)
 	}
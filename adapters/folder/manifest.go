@@ -0,0 +1,153 @@
+package folder
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	gohash "hash"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+// ManifestName is the name of the checksum manifest written at the root of the archive
+const ManifestName = "manifest.sha256"
+
+// manifestHash gives a hash to compute the checksum of a file while it's written,
+// or nil when the manifest isn't requested
+func (w *LocalAssetWriter) manifestHash() gohash.Hash {
+	if !w.Manifest {
+		return nil
+	}
+	return sha256.New()
+}
+
+// teeHash sends what is written to the file into the hash too
+func teeHash(f io.Writer, h gohash.Hash) io.Writer {
+	if h == nil {
+		return f
+	}
+	return io.MultiWriter(f, h)
+}
+
+// recordSum keeps the checksum of the written file for the manifest
+func (w *LocalAssetWriter) recordSum(name string, h gohash.Hash) {
+	if h == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.sums == nil {
+		w.sums = map[string]string{}
+	}
+	w.sums[name] = hex.EncodeToString(h.Sum(nil))
+}
+
+// LoadManifest reads the manifest left by a previous run, if any.
+// Its files are kept in the new manifest, unless they are written again.
+func (w *LocalAssetWriter) LoadManifest(name string) error {
+	f, err := w.WriteToFS.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.sums == nil {
+		w.sums = map[string]string{}
+	}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// the format of sha256sum: the checksum, a space, a space or a star for the binary mode, the file name
+		line := s.Text()
+		escaped := strings.HasPrefix(line, "\\")
+		if escaped {
+			line = line[1:]
+		}
+		sum, file, ok := strings.Cut(line, " ")
+		if !ok || len(file) < 2 {
+			continue
+		}
+		file = file[1:]
+		if escaped {
+			if file, ok = unescapeManifestName(file); !ok {
+				continue
+			}
+		}
+		w.sums[file] = sum
+	}
+	return s.Err()
+}
+
+// manifestNameEscaper escapes the file names like sha256sum does
+var manifestNameEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+
+// manifestLine gives the line of the file in the manifest. Like sha256sum, the name having a backslash,
+// a new line or a carriage return is escaped, and the line starts with a backslash.
+func manifestLine(sum, file string) string {
+	if strings.ContainsAny(file, "\\\n\r") {
+		return "\\" + sum + "  " + manifestNameEscaper.Replace(file) + "\n"
+	}
+	return sum + "  " + file + "\n"
+}
+
+// unescapeManifestName reads the name escaped by sha256sum. It returns false for an invalid escape sequence.
+func unescapeManifestName(s string) (string, bool) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", false
+		}
+		switch s[i] {
+		case '\\':
+			sb.WriteByte('\\')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
+
+// WriteManifest writes the checksums of the archived files in the format of sha256sum,
+// to be checked with `sha256sum -c` from the archive's folder. It returns the number of files listed.
+func (w *LocalAssetWriter) WriteManifest(name string) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var sb strings.Builder
+	for _, file := range slices.Sorted(maps.Keys(w.sums)) {
+		sb.WriteString(manifestLine(w.sums[file], file))
+	}
+
+	part := name + partSuffix
+	f, err := fshelper.OpenFile(w.WriteToFS, part, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	_, err = io.WriteString(f, sb.String())
+	err = errors.Join(err, f.Close())
+	if err != nil {
+		_ = fshelper.Remove(w.WriteToFS, part)
+		return 0, err
+	}
+	return len(w.sums), fshelper.Rename(w.WriteToFS, part, name)
+}
@@ -0,0 +1,117 @@
+package folder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/simulot/immich-go/internal/fshelper/osfs"
+)
+
+func TestManifestLine(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"2023/IMG_0001.jpg", "abcd  2023/IMG_0001.jpg\n"},
+		{`a\b.jpg`, `\abcd  a\\b.jpg` + "\n"},
+		{"c\nd.jpg", `\abcd  c\nd.jpg` + "\n"},
+		{"e\rf.jpg", `\abcd  e\rf.jpg` + "\n"},
+	}
+	for _, tt := range tests {
+		if got := manifestLine("abcd", tt.file); got != tt.want {
+			t.Errorf("manifestLine(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+	for _, s := range []string{`a\`, `a\x`} {
+		if _, ok := unescapeManifestName(s); ok {
+			t.Errorf("unescapeManifestName(%q) accepted", s)
+		}
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	sums := map[string]string{
+		"2023/IMG_0001.jpg":   "0001",
+		"2023/with space.jpg": "0002",
+		`back\slash.jpg`:      "0003",
+		"new\nline.jpg":       "0004",
+		`\n not a new line`:   "0005",
+	}
+	w := &LocalAssetWriter{WriteToFS: osfs.DirFS(dir), sums: sums}
+	n, err := w.WriteManifest(ManifestName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(sums) {
+		t.Errorf("WriteManifest() = %d, want %d", n, len(sums))
+	}
+
+	r := &LocalAssetWriter{WriteToFS: osfs.DirFS(dir)}
+	if err := r.LoadManifest(ManifestName); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.sums) != len(sums) {
+		t.Errorf("read %d files, want %d: %q", len(r.sums), len(sums), r.sums)
+	}
+	for file, sum := range sums {
+		if r.sums[file] != sum {
+			t.Errorf("%q: read %q, want %q", file, r.sums[file], sum)
+		}
+	}
+}
+
+// TestManifestSha256sum checks the manifest against the sha256sum command, when it's installed
+func TestManifestSha256sum(t *testing.T) {
+	sha256sum, err := exec.LookPath("sha256sum")
+	if err != nil {
+		t.Skip("sha256sum isn't installed")
+	}
+	dir := t.TempDir()
+	w := &LocalAssetWriter{WriteToFS: osfs.DirFS(dir), sums: map[string]string{}}
+	var names []string
+	for i, name := range []string{"plain.jpg", `back\slash.jpg`, "new\nline.jpg"} {
+		content := bytes.Repeat([]byte{byte(i)}, 10)
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o600); err != nil {
+			t.Skipf("the file system doesn't accept the name %q: %v", name, err)
+		}
+		h := sha256.Sum256(content)
+		w.sums[name] = hex.EncodeToString(h[:])
+		names = append(names, name)
+	}
+
+	// sha256sum reads the manifest written
+	if _, err := w.WriteManifest(ManifestName); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(sha256sum, "--check", "--strict", ManifestName)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("sha256sum --check: %v\n%s", err, out)
+	}
+
+	// the manifest written by sha256sum is read
+	cmd = exec.Command(sha256sum, names...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "other.sha256"), out, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r := &LocalAssetWriter{WriteToFS: osfs.DirFS(dir)}
+	if err := r.LoadManifest("other.sha256"); err != nil {
+		t.Fatal(err)
+	}
+	for name, sum := range w.sums {
+		if r.sums[name] != sum {
+			t.Errorf("%q: read %q, want %q", name, r.sums[name], sum)
+		}
+	}
+}
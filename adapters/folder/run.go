@@ -4,16 +4,18 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/simulot/immich-go/adapters"
+	"github.com/simulot/immich-go/adapters/shared"
 	"github.com/simulot/immich-go/app"
 	"github.com/simulot/immich-go/internal/assets"
-	"github.com/simulot/immich-go/internal/exif"
 	"github.com/simulot/immich-go/internal/exif/sidecars/jsonsidecar"
 	"github.com/simulot/immich-go/internal/exif/sidecars/xmpsidecar"
 	"github.com/simulot/immich-go/internal/fileevent"
@@ -25,16 +27,18 @@ import (
 	"github.com/simulot/immich-go/internal/groups"
 	"github.com/simulot/immich-go/internal/groups/burst"
 	"github.com/simulot/immich-go/internal/groups/epsonfastfoto"
+	"github.com/simulot/immich-go/internal/groups/livephoto"
 	"github.com/simulot/immich-go/internal/groups/series"
+	"github.com/simulot/immich-go/internal/lastrun"
 	"github.com/simulot/immich-go/internal/namematcher"
 	"github.com/simulot/immich-go/internal/worker"
 	"github.com/spf13/cobra"
 )
 
 func (ifc *ImportFolderCmd) run(cmd *cobra.Command, args []string, app *app.Application, runner adapters.Runner) error {
-	var err error
-	if ifc.ImportIntoAlbum != "" && ifc.UsePathAsAlbumName != FolderModeNone {
-		return errors.New("cannot use both --into-album and --folder-as-album flags")
+	err := ifc.validateAlbumFlags()
+	if err != nil {
+		return err
 	}
 
 	ifc.app = app
@@ -42,10 +46,24 @@ func (ifc *ImportFolderCmd) run(cmd *cobra.Command, args []string, app *app.Appl
 	ifc.tz = app.GetTZ()
 	// ifc.InclusionFlags.SetIncludeTypeExtensions()
 
-	// parse arguments and generate a fs.FS per argument
-	ifc.fsyss, err = fshelper.ParsePath(args)
-	if err != nil {
-		return err
+	if !ifc.FromStdin && (ifc.NullSeparator || ifc.BaseDir != "") {
+		return errors.New("--null and --base-dir can only be used with --from-stdin")
+	}
+
+	if ifc.FromStdin {
+		// the files are listed on the standard input
+		ifc.fsyss, err = ifc.readStdinList(cmd)
+		if err != nil {
+			return err
+		}
+		// the listed files can be in any sub-folder
+		ifc.Recursive = true
+	} else {
+		// parse arguments and generate a fs.FS per argument
+		ifc.fsyss, err = fshelper.ParsePath(args)
+		if err != nil {
+			return err
+		}
 	}
 	if len(ifc.fsyss) == 0 {
 		app.Log().Message("No file found matching the pattern: %s", strings.Join(args, ","))
@@ -85,6 +103,9 @@ func (ifc *ImportFolderCmd) run(cmd *cobra.Command, args []string, app *app.Appl
 		ifc.InclusionFlags.DateRange.SetTZ(ifc.tz)
 	}
 
+	if ifc.LinkLivePhotos {
+		ifc.groupers = append(ifc.groupers, livephoto.Group)
+	}
 	if ifc.ManageEpsonFastFoto {
 		ifc.groupers = append(ifc.groupers, epsonfastfoto.Group{}.Group)
 	}
@@ -93,13 +114,96 @@ func (ifc *ImportFolderCmd) run(cmd *cobra.Command, args []string, app *app.Appl
 	}
 	ifc.groupers = append(ifc.groupers, series.Group)
 
+	if ifc.SinceLastRun {
+		err = ifc.loadLastRun(cmd, args)
+		if err != nil {
+			return err
+		}
+	}
+
 	// callback the caller
+	start := time.Now()
 	err = runner.Run(cmd, ifc)
+	if err == nil && ifc.lastRuns != nil {
+		ifc.saveLastRun(start)
+	}
 	return err
 }
 
+// readStdinList creates a FS with the files listed on the standard input
+func (ifc *ImportFolderCmd) readStdinList(cmd *cobra.Command) ([]fs.FS, error) {
+	sep := byte('\n')
+	if ifc.NullSeparator {
+		sep = 0
+	}
+	files, err := fshelper.ReadFileList(cmd.InOrStdin(), sep)
+	if err != nil {
+		return nil, fmt.Errorf("can't read the file list from the standard input: %w", err)
+	}
+	ifc.app.Log().Info("file list read from the standard input", "files", len(files))
+	fsys, err := fshelper.NewFileListFS(ifc.BaseDir, files)
+	if err != nil {
+		return nil, err
+	}
+	return []fs.FS{fsys}, nil
+}
+
+// loadLastRun gets the time of the last successful run with the same command and sources
+func (ifc *ImportFolderCmd) loadLastRun(cmd *cobra.Command, args []string) error {
+	sources := make([]string, 0, len(args))
+	for _, a := range args {
+		abs, err := filepath.Abs(a)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, abs)
+	}
+	if ifc.FromStdin {
+		sources = append(sources, "<stdin>")
+	}
+	sort.Strings(sources)
+	ifc.lastRunKey = cmd.CommandPath() + ": " + strings.Join(sources, ", ")
+
+	var err error
+	ifc.lastRuns, err = lastrun.Load(lastrun.DefaultPath())
+	if err != nil {
+		return fmt.Errorf("can't read the last run markers: %w", err)
+	}
+	t, ok := ifc.lastRuns.Get(ifc.lastRunKey)
+	switch {
+	case !ok:
+		ifc.app.Log().Info("no previous run found, all files are considered", "source", ifc.lastRunKey)
+	case ifc.ForceFull:
+		ifc.app.Log().Info("--force-full is set, all files are considered", "last run", t)
+	default:
+		ifc.notBefore = t
+		ifc.app.Log().Info("only the files modified since the last run are considered", "last run", t)
+	}
+	return nil
+}
+
+// saveLastRun advances the last run marker when the run has finished without errors
+func (ifc *ImportFolderCmd) saveLastRun(start time.Time) {
+	if ifc.app.DryRun {
+		return
+	}
+	if ifc.processor != nil && ifc.processor.Logger().TotalErrors() > 0 {
+		ifc.app.Log().Warn("the run had errors, the last run marker is not updated")
+		return
+	}
+	ifc.lastRuns.Set(ifc.lastRunKey, start)
+	if err := ifc.lastRuns.Save(); err != nil {
+		ifc.app.Log().Error("can't save the last run marker", "error", err)
+	}
+}
+
 const icloudMetadataExt = ".csv"
 
+// InputSize gives the size of the files of the input folders, the files not uploaded included
+func (ifc *ImportFolderCmd) InputSize(ctx context.Context) (int64, error) {
+	return fshelper.TotalSize(ctx, ifc.fsyss)
+}
+
 func (ifc *ImportFolderCmd) Browse(ctx context.Context) chan *assets.Group {
 	gOut := make(chan *assets.Group)
 	go func() {
@@ -130,7 +234,8 @@ func (ifc *ImportFolderCmd) concurrentParseDir(ctx context.Context, fsys fs.FS,
 		defer ifc.wg.Done()
 		err := ifc.parseDir(ctx, fsys, dir, gOut)
 		if err != nil {
-			ifc.app.Log().Error(err.Error())
+			// the context canceled errors are filtered out by the log
+			ifc.app.Log().Error("can't browse the folder", "dir", dir, "error", err)
 			cancel(err)
 		}
 	})
@@ -150,9 +255,14 @@ func (ifc *ImportFolderCmd) parseDir(ctx context.Context, fsys fs.FS, dir string
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
-		entries, err = fs.ReadDir(fsys, dir)
+		entries, err = fshelper.ReadDirRetry(ctx, fsys, dir, ifc.FSRetries)
 		if err != nil {
-			return err
+			if ctx.Err() != nil {
+				return err
+			}
+			// the other directories are read
+			ifc.processor.RecordNonAsset(ctx, fshelper.FSName(fsys, dir), 0, fileevent.ErrorFileAccess, "error", err.Error())
+			return nil
 		}
 	}
 
@@ -165,6 +275,13 @@ func (ifc *ImportFolderCmd) parseDir(ctx context.Context, fsys fs.FS, dir string
 			continue
 		}
 
+		if ifc.ExcludedPaths.Match(name) {
+			if !ifc.icloudMetaPass {
+				ifc.processor.RecordNonAsset(ctx, fshelper.FSName(fsys, name), 0, fileevent.DiscardedByPathExclude, "reason", "excluded file")
+			}
+			continue
+		}
+
 		// process csv files on icloud meta pass
 		if ifc.icloudMetaPass && ext == icloudMetadataExt {
 			if strings.HasSuffix(strings.ToLower(dir), "albums") {
@@ -253,7 +370,7 @@ func (ifc *ImportFolderCmd) parseDir(ctx context.Context, fsys fs.FS, dir string
 		if !ifc.InclusionFlags.IncludedExtensions.Include(ext) {
 			// Get file size for discarded asset
 			if info, err := fs.Stat(fsys, name); err == nil {
-				ifc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(fsys, name), info.Size(), fileevent.DiscardedFiltered, "extension not included")
+				ifc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(fsys, name), info.Size(), fileevent.DiscardedByExtension, "extension not included")
 			}
 			continue
 		}
@@ -261,11 +378,25 @@ func (ifc *ImportFolderCmd) parseDir(ctx context.Context, fsys fs.FS, dir string
 		if ifc.InclusionFlags.ExcludedExtensions.Exclude(ext) {
 			// Get file size for discarded asset
 			if info, err := fs.Stat(fsys, name); err == nil {
-				ifc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(fsys, name), info.Size(), fileevent.DiscardedFiltered, "extension excluded")
+				ifc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(fsys, name), info.Size(), fileevent.DiscardedByExtension, "extension excluded")
 			}
 			continue
 		}
 
+		if info, err := entry.Info(); err == nil {
+			if code, reason, ok := ifc.InclusionFlags.CheckSize(info.Size()); !ok {
+				ifc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(fsys, name), info.Size(), code, reason)
+				continue
+			}
+		}
+
+		if !ifc.notBefore.IsZero() {
+			if info, err := entry.Info(); err == nil && info.ModTime().Before(ifc.notBefore) {
+				ifc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(fsys, name), info.Size(), fileevent.DiscardedByIncremental, "not modified since the last run")
+				continue
+			}
+		}
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -273,7 +404,11 @@ func (ifc *ImportFolderCmd) parseDir(ctx context.Context, fsys fs.FS, dir string
 			// we have a file to process - it's an asset (image or video)
 			a, err := ifc.assetFromFile(ctx, fsys, name)
 			if err != nil {
-				ifc.processor.RecordAssetError(ctx, fshelper.FSName(fsys, name), 0, fileevent.ErrorFileAccess, err)
+				var size int64
+				if info, err := fs.Stat(fsys, name); err == nil {
+					size = info.Size()
+				}
+				ifc.processor.RecordAssetError(ctx, fshelper.FSName(fsys, name), size, fileevent.ErrorFileAccess, err)
 				return err
 			}
 			if a != nil {
@@ -293,6 +428,12 @@ func (ifc *ImportFolderCmd) parseDir(ctx context.Context, fsys fs.FS, dir string
 		base := entry.Name()
 		name := path.Join(dir, base)
 		if entry.IsDir() {
+			if ifc.ExcludedPaths.Match(name) {
+				if !ifc.icloudMetaPass {
+					ifc.processor.RecordNonAsset(ctx, fshelper.FSName(fsys, name), 0, fileevent.DiscardedByPathExclude, "reason", "excluded folder")
+				}
+				continue // Skip this folder and its content
+			}
 			if matchesBanned(ifc.BannedFiles, name, true) {
 				ifc.processor.RecordNonAsset(ctx, fshelper.FSName(fsys, name), 0, fileevent.DiscoveredBanned, "reason", "banned folder")
 				continue // Skip this folder, no error
@@ -304,6 +445,10 @@ func (ifc *ImportFolderCmd) parseDir(ctx context.Context, fsys fs.FS, dir string
 		}
 	}
 
+	if ifc.SkipMotionVideos {
+		as = shared.SkipMotionVideos(ctx, ifc.processor, as)
+	}
+
 	in := make(chan *assets.Asset)
 	go func() {
 		defer close(in)
@@ -363,6 +508,13 @@ func (ifc *ImportFolderCmd) parseDir(ctx context.Context, fsys fs.FS, dir string
 				}
 			}
 
+			// apply --date-source when the sidecar gives the date
+			if md := a.FromSideCar; md != nil {
+				shared.ResolveCaptureDate(ctx, ifc.processor, a, md, ifc.DateSource, ifc.tz)
+			} else {
+				shared.ResolveCaptureDate(ctx, ifc.processor, a, a.FromApplication, ifc.DateSource, ifc.tz)
+			}
+
 			// Read metadata from the file only id needed (date range or take date from filename)
 			if ifc.requiresDateInformation {
 				// try to get date from icloud takeout meta
@@ -377,22 +529,16 @@ func (ifc *ImportFolderCmd) parseDir(ctx context.Context, fsys fs.FS, dir string
 				}
 				if a.CaptureDate.IsZero() {
 					// no date in XMP, JSON, try reading the metadata
-					f, err := a.OpenFile()
-					if err == nil {
-						md, err := exif.GetMetaData(f, a.Ext, ifc.tz)
-						if err != nil {
-							// Metadata extraction failed, but continue processing
-						} else {
-							a.FromSourceFile = a.UseMetadata(md)
-						}
-						if (md == nil || md.DateTaken.IsZero()) && !a.Taken.IsZero() && ifc.TakeDateFromFilename {
-							// no exif, but we have a date in the filename and the TakeDateFromFilename is set
-							a.FromApplication = &assets.Metadata{
-								DateTaken: a.Taken,
-							}
-							a.CaptureDate = a.FromApplication.DateTaken
+					md := shared.EmbeddedMetadata(a, ifc.tz)
+					if md != nil {
+						a.FromSourceFile = a.UseMetadata(md)
+					}
+					if (md == nil || md.DateTaken.IsZero()) && !a.Taken.IsZero() && ifc.TakeDateFromFilename {
+						// no exif, but we have a date in the filename and the TakeDateFromFilename is set
+						a.FromApplication = &assets.Metadata{
+							DateTaken: a.Taken,
 						}
-						f.Close()
+						a.CaptureDate = a.FromApplication.DateTaken
 					}
 				}
 			}
@@ -431,27 +577,9 @@ func (ifc *ImportFolderCmd) parseDir(ctx context.Context, fsys fs.FS, dir string
 						done = true
 					}
 				}
-				if !done && ifc.UsePathAsAlbumName != FolderModeNone && ifc.UsePathAsAlbumName != "" {
-					Album := ""
-					switch ifc.UsePathAsAlbumName {
-					case FolderModeFolder:
-						if dir == "." {
-							Album = fsName
-						} else {
-							Album = filepath.Base(dir)
-						}
-					case FolderModePath:
-						parts := []string{}
-						if fsName != "" {
-							parts = append(parts, fsName)
-						}
-						if dir != "." {
-							parts = append(parts, strings.Split(dir, "/")...)
-						}
-						Album = strings.Join(parts, ifc.AlbumNamePathSeparator)
-					}
-					if Album != "" {
-						a.Albums = []assets.Album{{Title: Album}}
+				if !done {
+					if album := ifc.folderAlbum(fsName, dir); album != "" {
+						a.Albums = []assets.Album{{Title: album}}
 					}
 				}
 			}
@@ -551,3 +679,43 @@ func (ifc *ImportFolderCmd) assetFromFile(_ context.Context, fsys fs.FS, name st
 	a.SetNameInfo(ifc.infoCollector.GetInfo(n))
 	return a, nil
 }
+
+// validateAlbumFlags checks the combination of the album options
+func (ifc *ImportFolderCmd) validateAlbumFlags() error {
+	if ifc.ImportIntoAlbum != "" && ifc.UsePathAsAlbumName != FolderModeNone {
+		return errors.New("cannot use both --into-album and --folder-as-album flags")
+	}
+	if ifc.AlbumFolderDepth < 0 {
+		return fmt.Errorf("invalid value for --album-folder-depth: %d, expected a positive number", ifc.AlbumFolderDepth)
+	}
+	if ifc.AlbumFolderDepth > 0 && ifc.UsePathAsAlbumName != FolderModePath {
+		return errors.New("--album-folder-depth can only be used with --folder-as-album=PATH")
+	}
+	return nil
+}
+
+// folderAlbum gives the album name of the files of the directory for the --folder-as-album option.
+// With PATH, the name is made of the last AlbumFolderDepth folders of the path, all of them when 0.
+// The files at the root of the imported folder get the folder's name.
+func (ifc *ImportFolderCmd) folderAlbum(fsName, dir string) string {
+	switch ifc.UsePathAsAlbumName {
+	case FolderModeFolder:
+		if dir == "." {
+			return fsName
+		}
+		return filepath.Base(dir)
+	case FolderModePath:
+		parts := []string{}
+		if fsName != "" {
+			parts = append(parts, fsName)
+		}
+		if dir != "." {
+			parts = append(parts, strings.Split(dir, "/")...)
+		}
+		if ifc.AlbumFolderDepth > 0 && len(parts) > ifc.AlbumFolderDepth {
+			parts = parts[len(parts)-ifc.AlbumFolderDepth:]
+		}
+		return strings.Join(parts, ifc.AlbumNamePathSeparator)
+	}
+	return ""
+}
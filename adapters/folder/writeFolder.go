@@ -8,11 +8,14 @@ import (
 	"io/fs"
 	"os"
 	"path"
+	"sync"
+	"time"
 
 	"github.com/simulot/immich-go/internal/assets"
 	"github.com/simulot/immich-go/internal/exif/sidecars/jsonsidecar"
 	"github.com/simulot/immich-go/internal/fshelper"
 	"github.com/simulot/immich-go/internal/fshelper/debugfiles"
+	"github.com/simulot/immich-go/internal/fshelper/hash"
 )
 
 // type minimalFSWriter interface {
@@ -23,11 +26,27 @@ import (
 type closer interface {
 	Close() error
 }
+
+// LocalAssetWriter writes assets into a folder tree. WriteAsset can be called concurrently.
 type LocalAssetWriter struct {
-	WriteToFS  fs.FS
+	WriteToFS      fs.FS
+	Resume         bool // Skip the assets already present in the archive
+	VerifyChecksum bool // When resuming, compare the checksum of the archived file too
+	SetMtime       bool // Set the times of the written file to the asset's capture date
+	Manifest       bool // Compute the SHA-256 of the written files for the manifest
+
+	mu         sync.Mutex
 	createdDir map[string]struct{}
+	reserved   map[string]struct{} // names of the files being written
+	sums       map[string]string   // SHA-256 of the written files, by name
 }
 
+// ErrAlreadyArchived is returned by WriteAsset when resuming and the asset is already in the archive
+var ErrAlreadyArchived = errors.New("asset already archived")
+
+// partSuffix is added to the file name during the copy. The file is renamed once complete.
+const partSuffix = ".part"
+
 func NewLocalAssetWriter(fsys fs.FS, writeToPath string) (*LocalAssetWriter, error) {
 	if _, ok := fsys.(fshelper.FSCanWrite); !ok {
 		return nil, errors.New("FS does not support writing")
@@ -35,6 +54,7 @@ func NewLocalAssetWriter(fsys fs.FS, writeToPath string) (*LocalAssetWriter, err
 	return &LocalAssetWriter{
 		WriteToFS:  fsys,
 		createdDir: make(map[string]struct{}),
+		reserved:   make(map[string]struct{}),
 	}, nil
 }
 
@@ -56,15 +76,13 @@ func (w *LocalAssetWriter) WriteGroup(ctx context.Context, group *assets.Group)
 }
 
 func (w *LocalAssetWriter) WriteAsset(ctx context.Context, a *assets.Asset) error {
-	base := a.Base
 	dir := w.pathOfAsset(a)
-	if _, ok := w.createdDir[dir]; !ok {
-		err := fshelper.MkdirAll(w.WriteToFS, dir, 0o755)
-		if err != nil {
-			return err
-		}
-		w.createdDir[dir] = struct{}{}
+	base, err := w.reserveName(dir, a)
+	if err != nil {
+		return err
 	}
+	defer w.releaseName(dir, base)
+
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -79,34 +97,8 @@ func (w *LocalAssetWriter) WriteAsset(ctx context.Context, a *assets.Asset) erro
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			// Add an index to the file name if it already exists, or the XMP or JSON
-			index := 0
-			ext := path.Ext(base)
-			radical := base[:len(base)-len(ext)]
-			for {
-				if index > 0 {
-					base = fmt.Sprintf("%s~%d%s", radical, index, path.Ext(base))
-				}
-				_, err := fs.Stat(w.WriteToFS, path.Join(dir, base))
-				if err == nil {
-					index++
-					continue
-				}
-				_, err = fs.Stat(w.WriteToFS, path.Join(dir, base+".XMP"))
-				if err == nil {
-					index++
-					continue
-				}
-				_, err = fs.Stat(w.WriteToFS, path.Join(dir, base+".JSON"))
-				if err == nil {
-					index++
-					continue
-				}
-				break
-			}
-
 			// write the asset
-			err = fshelper.WriteFile(w.WriteToFS, path.Join(dir, base), r)
+			err = w.writePart(path.Join(dir, base), r, w.fileTime(a))
 			if err != nil {
 				return err
 			}
@@ -126,8 +118,12 @@ func (w *LocalAssetWriter) WriteAsset(ctx context.Context, a *assets.Asset) erro
 				if err != nil {
 					return err
 				}
-				_, err = io.Copy(scw, scr)
+				h := w.manifestHash()
+				_, err = io.Copy(teeHash(scw, h), scr)
 				scw.Close()
+				if err == nil {
+					w.recordSum(path.Join(dir, base+".XMP"), h)
+				}
 			}
 
 			// Having metadata from an Application or immich-go JSON?
@@ -137,8 +133,12 @@ func (w *LocalAssetWriter) WriteAsset(ctx context.Context, a *assets.Asset) erro
 				if err != nil {
 					return err
 				}
-				err = jsonsidecar.Write(a.FromApplication, scw)
+				h := w.manifestHash()
+				err = jsonsidecar.Write(a.FromApplication, teeHash(scw, h))
 				scw.Close()
+				if err == nil {
+					w.recordSum(path.Join(dir, base+".JSON"), h)
+				}
 			}
 
 			return err
@@ -146,11 +146,156 @@ func (w *LocalAssetWriter) WriteAsset(ctx context.Context, a *assets.Asset) erro
 	}
 }
 
+// reserveName chooses the name of the asset in the folder, and reserves it
+// until releaseName is called, so concurrent writers don't pick the same name.
+func (w *LocalAssetWriter) reserveName(dir string, a *assets.Asset) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.createdDir[dir]; !ok {
+		err := fshelper.MkdirAll(w.WriteToFS, dir, 0o755)
+		if err != nil {
+			return "", err
+		}
+		w.createdDir[dir] = struct{}{}
+	}
+
+	// Add an index to the file name if it already exists, or the XMP or JSON
+	base := a.Base
+	index := 0
+	ext := path.Ext(base)
+	radical := base[:len(base)-len(ext)]
+	for {
+		if index > 0 {
+			base = fmt.Sprintf("%s~%d%s", radical, index, path.Ext(base))
+		}
+		if _, ok := w.reserved[path.Join(dir, base)]; ok {
+			index++
+			continue
+		}
+		fi, err := fs.Stat(w.WriteToFS, path.Join(dir, base))
+		if err == nil {
+			if w.Resume && fi.Size() == int64(a.FileSize) {
+				same, err := w.sameChecksum(a, path.Join(dir, base))
+				if err != nil {
+					return "", err
+				}
+				if same {
+					// the name is checked under the lock, no writer can change the file meanwhile
+					if t := w.fileTime(a); !t.IsZero() {
+						if err := w.setTimes(path.Join(dir, base), t); err != nil {
+							return "", err
+						}
+					}
+					return "", ErrAlreadyArchived
+				}
+				// the file is corrupted, overwrite it
+				break
+			}
+			index++
+			continue
+		}
+		_, err = fs.Stat(w.WriteToFS, path.Join(dir, base+".XMP"))
+		if err == nil {
+			index++
+			continue
+		}
+		_, err = fs.Stat(w.WriteToFS, path.Join(dir, base+".JSON"))
+		if err == nil {
+			index++
+			continue
+		}
+		break
+	}
+	w.reserved[path.Join(dir, base)] = struct{}{}
+	return base, nil
+}
+
+func (w *LocalAssetWriter) releaseName(dir, base string) {
+	w.mu.Lock()
+	delete(w.reserved, path.Join(dir, base))
+	w.mu.Unlock()
+}
+
+// writePart copies the asset into a .part file, and renames it once complete.
+// An interrupted copy leaves only the .part file, which is overwritten at the next run.
+// When t isn't zero, the times of the file are set before the rename.
+func (w *LocalAssetWriter) writePart(name string, r io.Reader, t time.Time) error {
+	part := name + partSuffix
+	f, err := fshelper.OpenFile(w.WriteToFS, part, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	debugfiles.TrackOpenFile(f, part)
+	h := w.manifestHash()
+	_, err = io.Copy(teeHash(f, h), r)
+	debugfiles.TrackCloseFile(f)
+	err = errors.Join(err, f.Close())
+	if err != nil {
+		_ = fshelper.Remove(w.WriteToFS, part)
+		return err
+	}
+	if !t.IsZero() {
+		if err := w.setTimes(part, t); err != nil {
+			_ = fshelper.Remove(w.WriteToFS, part)
+			return err
+		}
+	}
+	if err := fshelper.Rename(w.WriteToFS, part, name); err != nil {
+		return err
+	}
+	w.recordSum(name, h)
+	return nil
+}
+
+// fileTime gives the time to set on the asset's file, or the zero time to leave it untouched
+func (w *LocalAssetWriter) fileTime(a *assets.Asset) time.Time {
+	if !w.SetMtime {
+		return time.Time{}
+	}
+	return a.CaptureDate
+}
+
+// setTimes sets the access, modification, and when supported, the creation times of the file
+func (w *LocalAssetWriter) setTimes(name string, t time.Time) error {
+	if err := fshelper.Chtimes(w.WriteToFS, name, t, t); err != nil {
+		return err
+	}
+	if err := fshelper.SetCreationTime(w.WriteToFS, name, t); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		return err
+	}
+	return nil
+}
+
+// sameChecksum tells if the archived file has the checksum given by the server.
+// Without checksum to compare with, the size check is considered enough.
+func (w *LocalAssetWriter) sameChecksum(a *assets.Asset, name string) (bool, error) {
+	if !w.VerifyChecksum || a.Checksum == "" {
+		return true, nil
+	}
+	sum, err := hash.Base64Encode(hash.FileSHA1Hash(w.WriteToFS, name))
+	if err != nil {
+		return false, err
+	}
+	return sum == a.Checksum, nil
+}
+
+// TrashedFolder is the folder where trashed assets are written
+const TrashedFolder = "_trashed"
+
 func (w *LocalAssetWriter) pathOfAsset(a *assets.Asset) string {
+	return ArchiveFolder(a)
+}
+
+// ArchiveFolder gives the folder of the asset in the archive
+func ArchiveFolder(a *assets.Asset) string {
+	p := "no-date"
 	d := a.CaptureDate
-	if d.IsZero() {
-		return "no-date"
+	if !d.IsZero() {
+		p = path.Join(fmt.Sprintf("%04d", d.Year()), fmt.Sprintf("%04d-%02d", d.Year(), d.Month()))
+	}
+	if a.Trashed {
+		p = path.Join(TrashedFolder, p)
 	}
-	p := path.Join(fmt.Sprintf("%04d", d.Year()), fmt.Sprintf("%04d-%02d", d.Year(), d.Month()))
 	return p
 }
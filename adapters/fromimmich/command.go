@@ -73,6 +73,11 @@ func (fic *FromImmichCmd) RegisterFlags(flags *pflag.FlagSet) {
 
 // NewFromImmichCommand creates a new Cobra command for fetching photos from an Immich server.
 // It registers all relevant flags, sets up the command context, and binds the execution logic.
+// TrashedOnly tells if the trashed assets only are read, with --from-trash
+func (fic *FromImmichCmd) TrashedOnly() bool {
+	return fic.OnlyTrashed
+}
+
 func NewFromImmichCommand(ctx context.Context, parent *cobra.Command, app *app.Application, runner adapters.Runner) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "from-immich [flags]",              // Command usage
@@ -394,6 +399,7 @@ func (fic *FromImmichCmd) getAssets(ctx context.Context, grpChan chan *assets.Gr
 			DateTaken:   a.ExifInfo.DateTimeOriginal.Time,
 			Trashed:     a.IsTrashed,
 			Archived:    a.IsArchived,
+			Visibility:  assets.Visibility(a.Visibility),
 			Favorited:   a.IsFavorite,
 			Rating:      byte(a.ExifInfo.Rating),
 			Tags:        asset.Tags,
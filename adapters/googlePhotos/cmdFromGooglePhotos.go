@@ -3,6 +3,7 @@ package gp
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"path/filepath"
 	"regexp"
@@ -36,16 +37,26 @@ type TakeoutCmd struct {
 	ImportFromAlbum    string
 	ImportIntoAlbum    string
 	PartnerSharedAlbum string
+	SharedAlbumSuffix  string // Appended to the names of the albums shared with other users
 	KeepTrashed        bool
 	KeepPartner        bool
 	KeepUntitled       bool
 	KeepArchived       bool
+	PreserveArchived   bool   // Keep the archived state on Immich
+	SkipLocked         bool   // Skip items from the locked folder
+	LockedAlbum        string // Album for the items from the locked folder
 	KeepJSONLess       bool
 	InclusionFlags     cliflags.InclusionFlags
 	BannedFiles        namematcher.List
 	TakeoutTag         bool
 	TakeoutName        string
 	PeopleTag          bool
+	EditedPhotos       string // Which version of the edited photos is imported: original, edited, both or stack
+	FSRetries          int    // Number of retries of a failing directory read
+	WarnUnmapped       bool   // Report the keys of the JSON files that aren't mapped to a metadata field
+	ReportOrphans      bool   // List the JSON files without media and the media without JSON file in the log
+	SkipMotionVideos   bool   // Skip the videos exported next to the motion photos
+	DateSource         cliflags.DateSourceFlags
 	shared.StackOptions
 
 	// internal state
@@ -57,8 +68,11 @@ type TakeoutCmd struct {
 	fsyss          []fs.FS
 	catalogs       map[string]directoryCatalog                // file catalogs by directory in the set of the all takeout parts
 	albums         map[string]assets.Album                    // track album names by folder
+	sharedAlbums   map[string]bool                            // folders of the albums shared with other users
 	fileTracker    *gen.SyncMap[fileKeyTracker, trackingInfo] // map[fileKeyTracker]trackingInfo // key is base name + file size,  value is list of file paths
 	groupers       []groups.Grouper
+	editedPairs    int     // number of edited photos paired with their original
+	orphans        orphans // JSON files without media and media without JSON file
 	// filters        []filters.Filter
 }
 
@@ -71,17 +85,28 @@ func (toc *TakeoutCmd) RegisterFlags(flags *pflag.FlagSet, cmd *cobra.Command) {
 	flags.BoolVar(&toc.KeepUntitled, "include-untitled-albums", false, "Include photos from albums without a title in the import process")
 	flags.BoolVarP(&toc.KeepTrashed, "include-trashed", "t", false, "Import photos that are marked as trashed in Google Photos")
 	flags.BoolVarP(&toc.KeepPartner, "include-partner", "p", true, "Import photos from your partner's Google Photos account")
+	flags.StringVar(&toc.ImportIntoAlbum, "into-album", "", "Put all the photos into this album, created when missing, instead of the takeout's albums")
 	flags.StringVar(&toc.PartnerSharedAlbum, "partner-shared-album", "", "Add partner's photo to the specified album name")
+	flags.StringVar(&toc.SharedAlbumSuffix, "shared-album-suffix", "", "Suffix added to the names of the albums shared with other users in Google Photos, like \"(shared)\", to tell them from the owned ones")
 	flags.BoolVarP(&toc.KeepArchived, "include-archived", "a", true, "Import archived Google Photos")
+	flags.BoolVar(&toc.PreserveArchived, "preserve-archive-state", true, "Set the archived Google Photos in the Immich archive")
+	flags.BoolVar(&toc.SkipLocked, "skip-locked", false, "Skip photos from the Google Photos locked folder")
+	flags.StringVar(&toc.LockedAlbum, "locked-album", "", "Add photos from the Google Photos locked folder to the specified album name")
 	flags.BoolVarP(&toc.KeepJSONLess, "include-unmatched", "u", false, "Import photos that do not have a matching JSON file in the takeout")
 	flags.Var(&toc.BannedFiles, "ban-file", "Exclude a file based on a pattern (case-insensitive). Can be specified multiple times.")
 	flags.BoolVar(&toc.TakeoutTag, "takeout-tag", true, "Tag uploaded photos with a tag \"{takeout}/takeout-YYYYMMDDTHHMMSSZ\"")
 	flags.BoolVar(&toc.PeopleTag, "people-tag", true, "Tag uploaded photos with tags \"people/name\" found in the JSON file")
+	flags.IntVar(&toc.FSRetries, "fs-retries", 3, "Number of retries of a directory read failing with a transient error, like on a flaky network mount")
+	flags.BoolVar(&toc.SkipMotionVideos, "skip-motion-videos", false, "Skip the MP4 videos exported next to the Android motion photos, the server extracts the video from the image")
+	flags.BoolVar(&toc.WarnUnmapped, "warn-unmapped", false, "Log at DEBUG level and count the keys of the takeout JSON files that aren't mapped to a metadata field, to notice the changes of the takeout format")
+	flags.BoolVar(&toc.ReportOrphans, "report-orphans", false, "List in the log the JSON files whose media is missing from the takeout, and the media without JSON file. They are counted in the report in any case")
+	flags.StringVar(&toc.EditedPhotos, "edited-photos", EditedBoth, "Version of the edited photos (-edited suffix) to import: original, edited, both, or both with the edited photo stacked on its original (original|edited|both|stack)")
 	if cmd.Parent() != nil && cmd.Parent().Name() == "upload" {
 		toc.StackOptions.RegisterFlags(flags)
 	}
 
 	toc.InclusionFlags.RegisterFlags(flags, "")
+	toc.DateSource.RegisterFlags(flags)
 }
 
 var _re3digits = regexp.MustCompile(`-\d{3}$`)
@@ -94,10 +119,11 @@ func NewFromGooglePhotosCommand(ctx context.Context, parent *cobra.Command, app
 	}
 	cmd.SetContext(ctx)
 	toc := &TakeoutCmd{
-		app:         app,
-		catalogs:    map[string]directoryCatalog{},
-		albums:      map[string]assets.Album{},
-		fileTracker: gen.NewSyncMap[fileKeyTracker, trackingInfo](), // map[fileKeyTracker]trackingInfo{},
+		app:          app,
+		catalogs:     map[string]directoryCatalog{},
+		albums:       map[string]assets.Album{},
+		sharedAlbums: map[string]bool{},
+		fileTracker:  gen.NewSyncMap[fileKeyTracker, trackingInfo](), // map[fileKeyTracker]trackingInfo{},
 	}
 	toc.RegisterFlags(cmd.Flags(), cmd)
 
@@ -106,6 +132,13 @@ func NewFromGooglePhotosCommand(ctx context.Context, parent *cobra.Command, app
 
 		log := app.Log()
 		toc.processor = app.FileProcessor()
+
+		switch toc.EditedPhotos {
+		case EditedOriginal, EditedEdited, EditedBoth, EditedStack:
+		default:
+			return fmt.Errorf("invalid value for --edited-photos: %q, expected original, edited, both or stack", toc.EditedPhotos)
+		}
+		log.Info("edited photos policy", "edited-photos", toc.EditedPhotos)
 		toc.tz = app.GetTZ()
 
 		// make an fs.FS per zip file or folder given on the CLI
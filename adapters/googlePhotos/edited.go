@@ -0,0 +1,78 @@
+package gp
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+// --edited-photos values
+const (
+	EditedOriginal = "original" // keep only the original photo
+	EditedEdited   = "edited"   // keep only the edited photo
+	EditedBoth     = "both"     // keep both, grouped as before
+	EditedStack    = "stack"    // keep both, the edited photo stacked with its original
+)
+
+const editedSuffix = "-edited"
+
+// applyEditedPolicy pairs the edited photos with their original, and keeps the ones selected by --edited-photos.
+// The edited photo is named after the original with the "-edited" suffix, and shares its JSON.
+// The photos without pair are kept.
+func (toc *TakeoutCmd) applyEditedPolicy(ctx context.Context, entries []*assets.Asset) []*assets.Asset {
+	// the photos by JSON, then by name without extension
+	byJSON := map[*assets.Metadata]map[string]*assets.Asset{}
+	for _, a := range entries {
+		if a.FromApplication == nil {
+			continue
+		}
+		names, ok := byJSON[a.FromApplication]
+		if !ok {
+			names = map[string]*assets.Asset{}
+			byJSON[a.FromApplication] = names
+		}
+		base := path.Base(a.File.Name())
+		names[strings.TrimSuffix(base, path.Ext(base))] = a
+	}
+
+	discarded := map[*assets.Asset]bool{}
+	for _, names := range byJSON {
+		for name, edited := range names {
+			if !strings.HasSuffix(strings.ToLower(name), editedSuffix) {
+				continue
+			}
+			original, ok := names[name[:len(name)-len(editedSuffix)]]
+			if !ok {
+				continue
+			}
+			toc.editedPairs++
+			switch toc.EditedPhotos {
+			case EditedOriginal:
+				discarded[edited] = true
+			case EditedEdited:
+				discarded[original] = true
+			case EditedStack:
+				// stack the edited photo with its original
+				edited.Kind = assets.KindEdited
+				edited.Radical = original.Radical
+			}
+		}
+	}
+	if len(discarded) == 0 {
+		return entries
+	}
+
+	kept := entries[:0]
+	for _, a := range entries {
+		if discarded[a] {
+			toc.processor.RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedEditedPolicy, "--edited-photos="+toc.EditedPhotos)
+			a.Close()
+			continue
+		}
+		kept = append(kept, a)
+	}
+	return kept
+}
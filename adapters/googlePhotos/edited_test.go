@@ -0,0 +1,71 @@
+package gp
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/assettracker"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+func Test_applyEditedPolicy(t *testing.T) {
+	fsys := fstest.MapFS{}
+	md1 := &assets.Metadata{FileName: "PXL_20231006_063000139.jpg"}
+	md2 := &assets.Metadata{FileName: "IMG_0001.jpg"}
+	newAsset := func(name string, md *assets.Metadata) *assets.Asset {
+		return &assets.Asset{
+			File:            fshelper.FSName(fsys, "Photos from 2023/"+name),
+			FromApplication: md,
+			NameInfo:        assets.NameInfo{Radical: name},
+		}
+	}
+
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{policy: EditedBoth, want: []string{"PXL_20231006_063000139.jpg", "PXL_20231006_063000139-edited.jpg", "IMG_0001.jpg", "IMG_0002-edited.jpg"}},
+		{policy: EditedStack, want: []string{"PXL_20231006_063000139.jpg", "PXL_20231006_063000139-edited.jpg", "IMG_0001.jpg", "IMG_0002-edited.jpg"}},
+		{policy: EditedOriginal, want: []string{"PXL_20231006_063000139.jpg", "IMG_0001.jpg", "IMG_0002-edited.jpg"}},
+		{policy: EditedEdited, want: []string{"PXL_20231006_063000139-edited.jpg", "IMG_0001.jpg", "IMG_0002-edited.jpg"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			toc := &TakeoutCmd{
+				EditedPhotos: tt.policy,
+				processor:    fileprocessor.New(assettracker.New(), fileevent.NewRecorder(slog.New(slog.DiscardHandler))),
+			}
+			entries := []*assets.Asset{
+				newAsset("PXL_20231006_063000139.jpg", md1),
+				newAsset("PXL_20231006_063000139-edited.jpg", md1),
+				newAsset("IMG_0001.jpg", md2),
+				newAsset("IMG_0002-edited.jpg", md2), // not the edited version of IMG_0001
+			}
+			got := []string{}
+			for _, a := range toc.applyEditedPolicy(context.Background(), entries) {
+				got = append(got, a.File.Name()[len("Photos from 2023/"):])
+				if a.File.Name() == "Photos from 2023/PXL_20231006_063000139-edited.jpg" {
+					stacked := a.Kind == assets.KindEdited && a.Radical == "PXL_20231006_063000139.jpg"
+					if stacked != (tt.policy == EditedStack) {
+						t.Errorf("policy %s: edited photo stacked = %v, kind %v, radical %q", tt.policy, stacked, a.Kind, a.Radical)
+					}
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if toc.editedPairs != 1 {
+				t.Errorf("got %d pairs, want 1", toc.editedPairs)
+			}
+			if n := toc.processor.Logger().GetCounts()[fileevent.DiscardedEditedPolicy]; n != int64(4-len(tt.want)) {
+				t.Errorf("got %d discarded, want %d", n, 4-len(tt.want))
+			}
+		})
+	}
+}
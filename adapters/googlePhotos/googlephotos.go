@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/simulot/immich-go/adapters/shared"
 	"github.com/simulot/immich-go/internal/assets"
 	"github.com/simulot/immich-go/internal/fileevent"
 	"github.com/simulot/immich-go/internal/filetypes"
@@ -64,6 +65,11 @@ func (af assetFile) LogValue() slog.Value {
 	)
 }
 
+// InputSize gives the size of the files of the takeout archives, the JSON files included
+func (toc *TakeoutCmd) InputSize(ctx context.Context) (int64, error) {
+	return fshelper.TotalSize(ctx, toc.fsyss)
+}
+
 // Prepare scans all files in all walker to build the file catalog of the archive
 // metadata files content is read and kept
 // return a channel of asset groups after the puzzle is solved
@@ -81,21 +87,41 @@ func (toc *TakeoutCmd) Browse(ctx context.Context) chan *assets.Group {
 				return
 			}
 		}
+		for dir := range toc.albums {
+			toc.processor.Logger().RecordSourceAlbum(toc.sharedAlbums[dir])
+		}
 		err := toc.solvePuzzle(ctx)
 		if err != nil {
 			cancel(err)
 			return
 		}
 		err = toc.passTwo(ctx, gOut)
+		toc.app.Log().Info("edited photos", "pairs", toc.editedPairs, "edited-photos", toc.EditedPhotos)
+		toc.reportOrphans()
 		cancel(err)
 	}()
 	return gOut
 }
 
 func (toc *TakeoutCmd) passOneFsWalk(ctx context.Context, w fs.FS) error {
-	err := fs.WalkDir(w, ".", func(name string, d fs.DirEntry, err error) error {
+	var walk fs.WalkDirFunc
+	walk = func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return err
+			if d == nil || !d.IsDir() || ctx.Err() != nil {
+				return err
+			}
+			// the directory can't be read, retry before giving up on it. The walk has done the first read.
+			if toc.FSRetries > 0 {
+				_, err = fshelper.ReadDirRetry(ctx, w, name, toc.FSRetries-1)
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				toc.processor.RecordNonAsset(ctx, fshelper.FSName(w, name), 0, fileevent.ErrorFileAccess, "error", err.Error())
+				return nil
+			}
+			return fs.WalkDir(w, name, walk)
 		}
 
 		select {
@@ -127,12 +153,14 @@ func (toc *TakeoutCmd) passOneFsWalk(ctx context.Context, w fs.FS) error {
 			}
 
 			if !toc.InclusionFlags.IncludedExtensions.Include(ext) {
-				toc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(w, name), finfo.Size(), fileevent.DiscardedFiltered, "extension not included")
+				toc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(w, name), finfo.Size(), fileevent.DiscardedByExtension, "extension not included")
+				toc.filteredMedia(dir, base)
 
 				return nil
 			}
 			if toc.InclusionFlags.ExcludedExtensions.Exclude(ext) {
-				toc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(w, name), finfo.Size(), fileevent.DiscardedFiltered, "extension excluded")
+				toc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(w, name), finfo.Size(), fileevent.DiscardedByExtension, "extension excluded")
+				toc.filteredMedia(dir, base)
 
 				return nil
 			}
@@ -163,6 +191,11 @@ func (toc *TakeoutCmd) passOneFsWalk(ctx context.Context, w fs.FS) error {
 				} else {
 					md, err := fshelper.UnmarshalJSON[GoogleMetaData](b)
 					if err == nil {
+						if toc.WarnUnmapped && (md.isAsset() || md.isAlbum()) {
+							for _, k := range unmappedKeys(b) {
+								toc.processor.Logger().RecordUnmappedField(ctx, fshelper.FSName(w, name), k)
+							}
+						}
 						switch {
 						case md.isAsset():
 							md := md.AsMetadata(fshelper.FSName(w, name), toc.PeopleTag) // Keep metadata
@@ -186,6 +219,11 @@ func (toc *TakeoutCmd) passOneFsWalk(ctx context.Context, w fs.FS) error {
 								a.Longitude = e.Longitude
 							}
 							toc.albums[dir] = a
+							if md.isSharedAlbum() {
+								toc.sharedAlbums[dir] = true
+								toc.processor.RecordNonAsset(ctx, fshelper.FSName(w, name), int64(len(b)), fileevent.DiscoveredSidecar, "type", "shared album metadata", "title", md.Title, "shared_by", md.sharedBy())
+								return nil
+							}
 							toc.processor.RecordNonAsset(ctx, fshelper.FSName(w, name), int64(len(b)), fileevent.DiscoveredSidecar, "type", "album metadata", "title", md.Title)
 						default:
 							toc.processor.RecordNonAsset(ctx, fshelper.FSName(w, name), int64(len(b)), fileevent.DiscoveredUnsupported, "reason", "unknown JSONfile")
@@ -199,6 +237,13 @@ func (toc *TakeoutCmd) passOneFsWalk(ctx context.Context, w fs.FS) error {
 			default:
 
 				t := toc.supportedMedia.TypeFromExt(ext)
+				if t == filetypes.TypeImage || t == filetypes.TypeVideo {
+					if code, reason, ok := toc.InclusionFlags.CheckSize(finfo.Size()); !ok {
+						toc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(w, name), finfo.Size(), code, reason)
+						toc.filteredMedia(dir, base)
+						return nil
+					}
+				}
 				switch t {
 				case filetypes.TypeUseless:
 					toc.processor.RecordNonAsset(ctx, fshelper.FSName(w, name), finfo.Size(), fileevent.DiscoveredUnknown, "reason", "useless file")
@@ -209,6 +254,7 @@ func (toc *TakeoutCmd) passOneFsWalk(ctx context.Context, w fs.FS) error {
 				case filetypes.TypeVideo:
 					if strings.Contains(name, "Failed Videos") {
 						toc.processor.RecordAssetDiscardedImmediately(ctx, fshelper.FSName(w, name), finfo.Size(), fileevent.DiscardedFiltered, "can't upload failed videos")
+						toc.filteredMedia(dir, base)
 						return nil
 					} else {
 						toc.processor.RecordAssetDiscovered(ctx, fshelper.FSName(w, name), finfo.Size(), fileevent.DiscoveredVideo)
@@ -243,8 +289,8 @@ func (toc *TakeoutCmd) passOneFsWalk(ctx context.Context, w fs.FS) error {
 			toc.catalogs[dir] = dirCatalog
 			return nil
 		}
-	})
-	return err
+	}
+	return fs.WalkDir(w, ".", walk)
 }
 
 // solvePuzzle prepares metadata with information collected during pass one for each accepted files
@@ -289,10 +335,12 @@ var matchers = []struct {
 }
 
 func (toc *TakeoutCmd) solvePuzzle(ctx context.Context) error {
+	toc.processor.CheckOrphans()
 	dirs := gen.MapKeysSorted(toc.catalogs)
 	for _, dir := range dirs {
 		cat := toc.catalogs[dir]
 		jsons := gen.MapKeysSorted(cat.jsons)
+		matched := map[string]bool{} // the JSON files associated with a media
 		for _, matcher := range matchers {
 			for _, json := range jsons {
 				md := cat.jsons[json]
@@ -306,6 +354,7 @@ func (toc *TakeoutCmd) solvePuzzle(ctx context.Context) error {
 							i.md = md
 							a := toc.makeAsset(ctx, dir, i, md)
 							cat.matchedFiles[f] = a
+							matched[json] = true
 							toc.processor.RecordNonAsset(ctx, fshelper.FSName(i.fsys, path.Join(dir, i.base)), 0, fileevent.ProcessedAssociatedMetadata, "json", json, "matcher", matcher.name)
 							delete(cat.unMatchedFiles, f)
 						}
@@ -314,12 +363,13 @@ func (toc *TakeoutCmd) solvePuzzle(ctx context.Context) error {
 			}
 		}
 		toc.catalogs[dir] = cat
+		toc.orphanSidecars(ctx, dir, cat, matched)
 		if len(cat.unMatchedFiles) > 0 {
 			files := gen.MapKeys(cat.unMatchedFiles)
 			sort.Strings(files)
 			for _, f := range files {
 				i := cat.unMatchedFiles[f]
-				toc.processor.RecordNonAsset(ctx, fshelper.FSName(i.fsys, path.Join(dir, i.base)), 0, fileevent.ProcessedMissingMetadata)
+				toc.orphanMedia(ctx, dir, i)
 				if toc.KeepJSONLess {
 					a := toc.makeAsset(ctx, dir, i, nil)
 					cat.matchedFiles[f] = a
@@ -377,6 +427,10 @@ func (toc *TakeoutCmd) handleDir(ctx context.Context, dir string, gOut chan *ass
 		}
 		dirEntries = append(dirEntries, a)
 	}
+	dirEntries = toc.applyEditedPolicy(ctx, dirEntries)
+	if toc.SkipMotionVideos {
+		dirEntries = shared.SkipMotionVideos(ctx, toc.processor, dirEntries)
+	}
 
 	// the go routine push all asset to the in chanel for been grouped
 	in := make(chan *assets.Asset)
@@ -395,7 +449,7 @@ func (toc *TakeoutCmd) handleDir(ctx context.Context, dir string, gOut chan *ass
 		})
 
 		for _, a := range dirEntries {
-			if toc.CreateAlbums {
+			if toc.CreateAlbums || toc.ImportIntoAlbum != "" {
 				if toc.ImportIntoAlbum != "" {
 					// Force this album
 					a.Albums = []assets.Album{{Title: toc.ImportIntoAlbum}}
@@ -412,6 +466,9 @@ func (toc *TakeoutCmd) handleDir(ctx context.Context, dir string, gOut chan *ass
 								}
 								title = filepath.Base(p)
 							}
+							if toc.SharedAlbumSuffix != "" && toc.sharedAlbums[p] {
+								title += " " + toc.SharedAlbumSuffix
+							}
 							a.Albums = append(a.Albums, assets.Album{
 								Title:       title,
 								Description: album.Description,
@@ -430,6 +487,13 @@ func (toc *TakeoutCmd) handleDir(ctx context.Context, dir string, gOut chan *ass
 					a.FromApplication.Albums = a.Albums
 				}
 			}
+			// Items from the locked folder are imported with the timeline visibility, in the designated album if any
+			if toc.LockedAlbum != "" && a.Visibility == assets.VisibilityLocked {
+				a.Albums = append(a.Albums, assets.Album{Title: toc.LockedAlbum})
+				if a.FromApplication != nil {
+					a.FromApplication.Albums = a.Albums
+				}
+			}
 			// If the asset has no GPS information, but the album has, use the album's location
 			if a.Latitude == 0 && a.Longitude == 0 {
 				for _, album := range a.Albums {
@@ -512,7 +576,7 @@ func (toc *TakeoutCmd) makeAsset(_ context.Context, dir string, f *assetFile, md
 
 // filterOnMetadata, log discared files and closes the asset
 func (toc *TakeoutCmd) filterOnMetadata(ctx context.Context, a *assets.Asset) fileevent.Code {
-	if !toc.KeepArchived && a.Visibility == assets.VisibilityArchive {
+	if !toc.KeepArchived && a.Archived {
 		toc.processor.RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedFiltered, "discarding archived file")
 		a.Close()
 		return fileevent.DiscardedFiltered
@@ -528,8 +592,8 @@ func (toc *TakeoutCmd) filterOnMetadata(ctx context.Context, a *assets.Asset) fi
 		return fileevent.DiscardedFiltered
 	}
 
-	if toc.InclusionFlags.DateRange.IsSet() && !toc.InclusionFlags.DateRange.InRange(a.CaptureDate) {
-		toc.processor.RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedFiltered, "discarding files out of date range")
+	if toc.SkipLocked && a.Visibility == assets.VisibilityLocked {
+		toc.processor.RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedFiltered, "discarding locked folder file")
 		a.Close()
 		return fileevent.DiscardedFiltered
 	}
@@ -548,5 +612,24 @@ func (toc *TakeoutCmd) filterOnMetadata(ctx context.Context, a *assets.Asset) fi
 			return fileevent.DiscardedFiltered
 		}
 	}
+
+	// the file's date is read only for the assets kept by the filters above
+	shared.ResolveCaptureDate(ctx, toc.processor, a, a.FromApplication, toc.DateSource, toc.tz)
+	if toc.InclusionFlags.DateRange.IsSet() && !toc.InclusionFlags.DateRange.InRange(a.CaptureDate) {
+		toc.processor.RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedFiltered, "discarding files out of date range")
+		a.Close()
+		return fileevent.DiscardedFiltered
+	}
+
+	if a.Archived {
+		if toc.PreserveArchived {
+			toc.processor.RecordNonAsset(ctx, a.File, int64(a.FileSize), fileevent.ProcessedArchived)
+		} else {
+			a.Archived = false
+		}
+	}
+	if a.Visibility == assets.VisibilityLocked {
+		toc.processor.RecordNonAsset(ctx, a.File, int64(a.FileSize), fileevent.ProcessedLocked)
+	}
 	return fileevent.Code(0)
 }
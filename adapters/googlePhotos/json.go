@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/simulot/immich-go/internal/assets"
@@ -13,20 +16,23 @@ import (
 )
 
 type GoogleMetaData struct {
-	Title              string             `json:"title"`
-	Description        string             `json:"description"`
-	Category           string             `json:"category"`
-	Date               *googTimeObject    `json:"date,omitempty"`
-	PhotoTakenTime     *googTimeObject    `json:"photoTakenTime"`
-	GeoDataExif        *googGeoData       `json:"geoDataExif"`
-	GeoData            *googGeoData       `json:"geoData"`
-	Trashed            bool               `json:"trashed,omitempty"`
-	Archived           bool               `json:"archived,omitempty"`
-	URLPresent         googIsPresent      `json:"url,omitempty"`         // true when the file is an asset metadata
-	Favorited          bool               `json:"favorited,omitempty"`   // true when starred in GP
-	Enrichments        *googleEnrichments `json:"enrichments,omitempty"` // Album enrichments
-	People             []Person           `json:"people,omitempty"`      // People tags
-	GooglePhotosOrigin struct {
+	Title               string               `json:"title"`
+	Description         string               `json:"description"`
+	Category            string               `json:"category"`
+	Date                *googTimeObject      `json:"date,omitempty"`
+	PhotoTakenTime      *googTimeObject      `json:"photoTakenTime"`
+	GeoDataExif         *googGeoData         `json:"geoDataExif"`
+	GeoData             *googGeoData         `json:"geoData"`
+	Trashed             bool                 `json:"trashed,omitempty"`
+	Archived            bool                 `json:"archived,omitempty"`
+	InLockedFolder      bool                 `json:"inLockedFolder,omitempty"`      // true when the item was in the locked folder
+	URLPresent          googIsPresent        `json:"url,omitempty"`                 // true when the file is an asset metadata
+	Favorited           bool                 `json:"favorited,omitempty"`           // true when starred in GP
+	Enrichments         *googleEnrichments   `json:"enrichments,omitempty"`         // Album enrichments
+	People              []Person             `json:"people,omitempty"`              // People tags
+	Access              string               `json:"access,omitempty"`              // Set on the shared albums
+	SharedAlbumComments []sharedAlbumComment `json:"sharedAlbumComments,omitempty"` // Comments and likes of the shared albums
+	GooglePhotosOrigin  struct {
 		FromPartnerSharing googIsPresent `json:"fromPartnerSharing,omitempty"` // true when this is a partner's asset
 	} `json:"googlePhotosOrigin"`
 }
@@ -35,6 +41,10 @@ type Person struct {
 	Name string `json:"name"`
 }
 
+type sharedAlbumComment struct {
+	ContentOwnerName string `json:"contentOwnerName"`
+}
+
 func (gmd *GoogleMetaData) UnmarshalJSON(data []byte) error {
 	// test the presence of the key albumData
 	type md GoogleMetaData
@@ -70,11 +80,13 @@ func (gmd GoogleMetaData) LogValue() slog.Value {
 		slog.Any("GeoData", gmd.GeoData),
 		slog.Bool("Trashed", gmd.Trashed),
 		slog.Bool("Archived", gmd.Archived),
+		slog.Bool("InLockedFolder", gmd.InLockedFolder),
 		slog.Bool("URLPresent", bool(gmd.URLPresent)),
 		slog.Bool("Favorited", gmd.Favorited),
 		slog.Any("Enrichments", gmd.Enrichments),
 		slog.Any("People", gmd.People),
 		slog.Bool("FromPartnerSharing", bool(gmd.GooglePhotosOrigin.FromPartnerSharing)),
+		slog.Bool("SharedAlbum", gmd.isSharedAlbum()),
 	)
 }
 
@@ -85,6 +97,7 @@ func (gmd GoogleMetaData) AsMetadata(name fshelper.FSAndName, tagPeople bool) *a
 		Description: gmd.Description,
 		Trashed:     gmd.Trashed,
 		Archived:    gmd.Archived,
+		Locked:      gmd.InLockedFolder,
 		Favorited:   gmd.Favorited,
 		FromPartner: gmd.isPartner(),
 	}
@@ -123,6 +136,26 @@ func (gmd *GoogleMetaData) isAsset() bool {
 	return gmd.PhotoTakenTime.Timestamp != ""
 }
 
+// isSharedAlbum tells if the album is shared with other users.
+// The takeout gives the access and the comments only for the shared albums.
+func (gmd *GoogleMetaData) isSharedAlbum() bool {
+	if !gmd.isAlbum() {
+		return false
+	}
+	return gmd.Access != "" || len(gmd.SharedAlbumComments) > 0
+}
+
+// sharedBy gives the names of the users who commented or liked the shared album
+func (gmd *GoogleMetaData) sharedBy() []string {
+	var names []string
+	for _, c := range gmd.SharedAlbumComments {
+		if c.ContentOwnerName != "" && !slices.Contains(names, c.ContentOwnerName) {
+			names = append(names, c.ContentOwnerName)
+		}
+	}
+	return names
+}
+
 func (gmd *GoogleMetaData) isPartner() bool {
 	if gmd == nil {
 		return false
@@ -130,6 +163,55 @@ func (gmd *GoogleMetaData) isPartner() bool {
 	return bool(gmd.GooglePhotosOrigin.FromPartnerSharing)
 }
 
+// ignoredKeys are the keys of the takeout JSONs known, but not used by immich-go
+var ignoredKeys = []string{
+	"imageViews",
+	"creationTime",
+	"modificationTime",
+	"photoLastModifiedTime",
+	"location",
+	"appSource",
+}
+
+// mappedKeys are the top level keys of the takeout JSONs read by GoogleMetaData, and the ignored ones
+var mappedKeys = func() map[string]bool {
+	keys := map[string]bool{}
+	t := reflect.TypeFor[GoogleMetaData]()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	for _, k := range ignoredKeys {
+		keys[k] = true
+	}
+	return keys
+}()
+
+// unmappedKeys gives the top level keys of the JSON that are not known.
+// They show the changes of the takeout format. The keys of an album are read under albumData.
+func unmappedKeys(b []byte) []string {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil
+	}
+	if album, ok := m["albumData"]; ok {
+		m = nil
+		if err := json.Unmarshal(album, &m); err != nil {
+			return nil
+		}
+	}
+	var keys []string
+	for k := range m {
+		if !mappedKeys[k] {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
 // Key return an expected unique key for the asset
 // based on the title and the timestamp
 func (gmd GoogleMetaData) Key() string {
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/simulot/immich-go/internal/fshelper"
 )
 
 func TestPresentFields(t *testing.T) {
@@ -523,6 +525,58 @@ func TestEnrichedAlbum(t *testing.T) {
 	}
 }
 
+func TestSharedAlbum(t *testing.T) {
+	tcs := []struct {
+		name     string
+		json     string
+		isShared bool
+		sharedBy []string
+	}{
+		{
+			name:     "owned album",
+			json:     `{"title": "Vacation", "date": {"timestamp": "1695394176"}}`,
+			isShared: false,
+		},
+		{
+			name:     "shared album",
+			json:     `{"title": "Vacation", "access": "protected"}`,
+			isShared: true,
+		},
+		{
+			name: "shared album with comments",
+			json: `{
+				"title": "Vacation",
+				"sharedAlbumComments": [
+					{"contentOwnerName": "Alice", "liked": true},
+					{"contentOwnerName": "Bob", "text": "Nice"},
+					{"contentOwnerName": "Alice", "text": "Thanks"}
+				]
+			}`,
+			isShared: true,
+			sharedBy: []string{"Alice", "Bob"},
+		},
+		{
+			name:     "asset",
+			json:     `{"title": "IMG_0001.jpg", "photoTakenTime": {"timestamp": "1695394176"}}`,
+			isShared: false,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var md GoogleMetaData
+			if err := json.Unmarshal([]byte(tc.json), &md); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if md.isSharedAlbum() != tc.isShared {
+				t.Errorf("expected isSharedAlbum to be %t", tc.isShared)
+			}
+			if got := md.sharedBy(); !reflect.DeepEqual(got, tc.sharedBy) {
+				t.Errorf("sharedBy() = %v, want %v", got, tc.sharedBy)
+			}
+		})
+	}
+}
+
 func TestLog(t *testing.T) {
 	tcs := []struct {
 		name string
@@ -651,3 +705,81 @@ func TestSanitizedTitle(t *testing.T) {
 		}
 	}
 }
+
+func TestArchivedAndLockedStates(t *testing.T) {
+	js := `{
+		"title": "IMG_0001.jpg",
+		"photoTakenTime": {"timestamp": "1695394176"},
+		"archived": true,
+		"inLockedFolder": true
+	}`
+	var md GoogleMetaData
+	if err := json.Unmarshal([]byte(js), &md); err != nil {
+		t.Fatal(err)
+	}
+	if !md.Archived {
+		t.Error("expected the archived state")
+	}
+	if !md.InLockedFolder {
+		t.Error("expected the locked folder state")
+	}
+	m := md.AsMetadata(fshelper.FSName(nil, "IMG_0001.jpg"), false)
+	if !m.Archived || !m.Locked {
+		t.Errorf("expected archived and locked metadata, got archived=%v locked=%v", m.Archived, m.Locked)
+	}
+}
+
+func TestUnmappedKeys(t *testing.T) {
+	tcs := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{
+			name: "known keys",
+			json: `{
+				"title": "IMG_0001.jpg",
+				"description": "",
+				"imageViews": "12",
+				"creationTime": {"timestamp": "1695394176"},
+				"photoTakenTime": {"timestamp": "1695394176"},
+				"geoData": {"latitude": 0.0},
+				"url": "https://photos.google.com/photo/xyz",
+				"googlePhotosOrigin": {"mobileUpload": {}}
+			}`,
+		},
+		{
+			name: "new keys",
+			json: `{
+				"title": "IMG_0001.jpg",
+				"photoTakenTime": {"timestamp": "1695394176"},
+				"rating": 4,
+				"captureDevice": "Pixel"
+			}`,
+			want: []string{"captureDevice", "rating"},
+		},
+		{
+			name: "album",
+			json: `{
+				"albumData": {
+					"title": "Vacation",
+					"date": {"timestamp": "1695394176"},
+					"coverPhoto": "IMG_0001.jpg"
+				}
+			}`,
+			want: []string{"coverPhoto"},
+		},
+		{
+			name: "not an object",
+			json: `[1, 2]`,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := unmappedKeys([]byte(tc.json))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("unmappedKeys() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package gp
+
+import (
+	"context"
+	"path"
+	"slices"
+
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+	"github.com/simulot/immich-go/internal/gen"
+)
+
+// orphans collects the JSON files without media, and the media without JSON file.
+// Google's exports often miss some of them, the lists tell how complete the takeout is.
+type orphans struct {
+	filtered map[string][]string // media discarded before the puzzle by base name, by directory
+	sidecars []string            // JSON files whose media is missing
+	media    []string            // media without JSON file
+}
+
+// filteredMedia remembers a media discarded by the filters, its JSON file isn't an orphan
+func (toc *TakeoutCmd) filteredMedia(dir, base string) {
+	if toc.orphans.filtered == nil {
+		toc.orphans.filtered = map[string][]string{}
+	}
+	toc.orphans.filtered[dir] = append(toc.orphans.filtered[dir], base)
+}
+
+// orphanSidecars records the JSON files of the directory matched by no media
+func (toc *TakeoutCmd) orphanSidecars(ctx context.Context, dir string, cat directoryCatalog, matched map[string]bool) {
+	for _, json := range gen.MapKeysSorted(cat.jsons) {
+		if matched[json] || toc.matchesFiltered(dir, json) {
+			continue
+		}
+		md := cat.jsons[json]
+		toc.processor.RecordNonAsset(ctx, md.File, 0, fileevent.ProcessedOrphanSidecar, "title", md.FileName)
+		toc.orphans.sidecars = append(toc.orphans.sidecars, path.Join(dir, json))
+	}
+}
+
+// matchesFiltered tells if the JSON file belongs to a media discarded by the filters
+func (toc *TakeoutCmd) matchesFiltered(dir, json string) bool {
+	for _, f := range toc.orphans.filtered[dir] {
+		for _, matcher := range matchers {
+			if matcher.fn(json, f, toc.supportedMedia) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orphanMedia records a media without JSON file
+func (toc *TakeoutCmd) orphanMedia(ctx context.Context, dir string, i *assetFile) {
+	toc.processor.RecordNonAsset(ctx, fshelper.FSName(i.fsys, path.Join(dir, i.base)), 0, fileevent.ProcessedMissingMetadata)
+	toc.orphans.media = append(toc.orphans.media, path.Join(dir, i.base))
+}
+
+// reportOrphans lists the orphans in the log, with --report-orphans
+func (toc *TakeoutCmd) reportOrphans() {
+	if !toc.ReportOrphans {
+		return
+	}
+	log := toc.app.Log()
+	log.Info("orphan sidecars: JSON files whose media is missing from the takeout", "count", len(toc.orphans.sidecars))
+	for _, f := range slices.Sorted(slices.Values(toc.orphans.sidecars)) {
+		log.Info("orphan sidecar", "file", f)
+	}
+	log.Info("orphan media: files without JSON file, no takeout metadata applied", "count", len(toc.orphans.media))
+	for _, f := range slices.Sorted(slices.Values(toc.orphans.media)) {
+		log.Info("orphan media", "file", f)
+	}
+}
@@ -0,0 +1,106 @@
+package gp
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/assettracker"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/filenames"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/simulot/immich-go/internal/filetypes"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+// TestPuzzleOrphans solves the puzzle of a directory with a matched pair, a JSON without media,
+// a media without JSON, and the JSON of a media discarded by the filters
+func TestPuzzleOrphans(t *testing.T) {
+	const dir = "Takeout/Google Photos/Photos from 2023"
+	fsys := fstest.MapFS{}
+	media := func(names ...string) map[string]*assetFile {
+		m := map[string]*assetFile{}
+		for _, n := range names {
+			m[n] = &assetFile{fsys: fsys, base: n, length: 10}
+		}
+		return m
+	}
+	jsons := func(names ...string) map[string]*assets.Metadata {
+		m := map[string]*assets.Metadata{}
+		for _, n := range names {
+			m[n] = &assets.Metadata{File: fshelper.FSName(fsys, path.Join(dir, n)), FileName: n[:len(n)-len(".json")]}
+		}
+		return m
+	}
+
+	for _, keepJSONLess := range []bool{false, true} {
+		toc := &TakeoutCmd{
+			KeepJSONLess:   keepJSONLess,
+			processor:      fileprocessor.New(assettracker.New(), fileevent.NewRecorder(slog.New(slog.DiscardHandler))),
+			supportedMedia: filetypes.DefaultSupportedMedia,
+			infoCollector:  filenames.NewInfoCollector(time.UTC, filetypes.DefaultSupportedMedia),
+			catalogs: map[string]directoryCatalog{
+				dir: {
+					jsons:          jsons("IMG_0001.jpg.json", "IMG_0002.jpg.json", "IMG_0004.mp4.json"),
+					unMatchedFiles: media("IMG_0001.jpg", "IMG_0003.jpg"),
+					matchedFiles:   map[string]*assets.Asset{},
+				},
+			},
+		}
+		toc.filteredMedia(dir, "IMG_0004.mp4") // discarded by --exclude-extensions
+
+		if err := toc.solvePuzzle(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if want := []string{path.Join(dir, "IMG_0002.jpg.json")}; !slices.Equal(toc.orphans.sidecars, want) {
+			t.Errorf("orphan sidecars %v, want %v", toc.orphans.sidecars, want)
+		}
+		if want := []string{path.Join(dir, "IMG_0003.jpg")}; !slices.Equal(toc.orphans.media, want) {
+			t.Errorf("orphan media %v, want %v", toc.orphans.media, want)
+		}
+		matched := slices.Sorted(func(yield func(string) bool) {
+			for f := range toc.catalogs[dir].matchedFiles {
+				if !yield(f) {
+					return
+				}
+			}
+		})
+		want := []string{"IMG_0001.jpg"}
+		if keepJSONLess {
+			want = append(want, "IMG_0003.jpg") // the orphan media is kept, without metadata
+		}
+		if !slices.Equal(matched, want) {
+			t.Errorf("--include-unmatched=%v: matched %v, want %v", keepJSONLess, matched, want)
+		}
+
+		s := toc.processor.RunSummary("immich-go upload from-google-photos", "completed", nil)
+		if s.OrphanSidecars == nil || *s.OrphanSidecars != 1 || s.OrphanMedia == nil || *s.OrphanMedia != 1 {
+			t.Errorf("summary orphans %v / %v, want 1 / 1", s.OrphanSidecars, s.OrphanMedia)
+		}
+	}
+}
+
+func TestMatchesFiltered(t *testing.T) {
+	toc := &TakeoutCmd{supportedMedia: filetypes.DefaultSupportedMedia}
+	toc.filteredMedia("a", "IMG_0001.mp4")
+	tests := []struct {
+		dir, json string
+		want      bool
+	}{
+		{"a", "IMG_0001.mp4.json", true},
+		{"a", "IMG_0001.mp4.supplemental-metadata.json", true},
+		{"a", "IMG_0002.mp4.json", false},
+		{"b", "IMG_0001.mp4.json", false}, // another directory
+	}
+	for _, tt := range tests {
+		if got := toc.matchesFiltered(tt.dir, tt.json); got != tt.want {
+			t.Errorf("matchesFiltered(%q, %q) = %v, want %v", tt.dir, tt.json, got, tt.want)
+		}
+	}
+}
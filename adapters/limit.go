@@ -0,0 +1,60 @@
+package adapters
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+// LimitedReader stops the browsing of the reader once N assets have been sent, for a quick check on a large source.
+// The groups are kept whole, the last one can exceed the limit. The groups read after the limit,
+// while the browsing stops, are dropped: their assets stay pending in the tracker.
+type LimitedReader struct {
+	Reader
+	Limit   int
+	reached atomic.Bool
+}
+
+func NewLimitedReader(r Reader, limit int) *LimitedReader {
+	return &LimitedReader{Reader: r, Limit: limit}
+}
+
+func (lr *LimitedReader) Browse(ctx context.Context) chan *assets.Group {
+	browseCtx, stop := context.WithCancel(ctx)
+	in := lr.Reader.Browse(browseCtx)
+	out := make(chan *assets.Group)
+	go func() {
+		defer close(out)
+		defer stop()
+		sent := 0
+		for g := range in {
+			if sent >= lr.Limit {
+				for _, a := range g.Assets {
+					a.Close()
+				}
+				continue
+			}
+			select {
+			case out <- g:
+			case <-ctx.Done():
+				for _, a := range g.Assets {
+					a.Close()
+				}
+				stop()
+				continue // drain the reader
+			}
+			sent += len(g.Assets)
+			if sent >= lr.Limit {
+				lr.reached.Store(true)
+				stop()
+			}
+		}
+	}()
+	return out
+}
+
+// Reached tells if the last browsing has sent the limit, the assets after it are left unread.
+func (lr *LimitedReader) Reached() bool {
+	return lr.reached.Load()
+}
@@ -0,0 +1,138 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+// groupsReader sends all its groups, whatever the context, like a source that doesn't check it.
+type groupsReader []*assets.Group
+
+func (r groupsReader) Browse(ctx context.Context) chan *assets.Group {
+	c := make(chan *assets.Group)
+	go func() {
+		defer close(c)
+		for _, g := range r {
+			c <- g
+		}
+	}()
+	return c
+}
+
+func sizedGroups(sizes ...int) groupsReader {
+	var r groupsReader
+	for i, n := range sizes {
+		g := assets.NewGroup(assets.GroupByNone)
+		for j := range n {
+			g.Assets = append(g.Assets, &assets.Asset{OriginalFileName: fmt.Sprintf("%d-%d.jpg", i, j)})
+		}
+		r = append(r, g)
+	}
+	return r
+}
+
+func TestLimitedReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		sizes       []int
+		limit       int
+		wantGroups  int
+		wantReached bool
+	}{
+		{name: "input under the limit", sizes: []int{1, 1, 1}, limit: 5, wantGroups: 3},
+		{name: "input at the limit", sizes: []int{1, 1, 1}, limit: 3, wantGroups: 3, wantReached: true},
+		{name: "input over the limit", sizes: []int{1, 1, 1, 1}, limit: 2, wantGroups: 2, wantReached: true},
+		{name: "last group kept whole", sizes: []int{2, 2, 2}, limit: 3, wantGroups: 2, wantReached: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lr := NewLimitedReader(sizedGroups(tt.sizes...), tt.limit)
+			got := 0
+			for g := range lr.Browse(context.Background()) {
+				if len(g.Assets) != tt.sizes[got] {
+					t.Errorf("group %d: got %d assets, want %d", got, len(g.Assets), tt.sizes[got])
+				}
+				got++
+			}
+			if got != tt.wantGroups {
+				t.Errorf("got %d groups, want %d", got, tt.wantGroups)
+			}
+			if lr.Reached() != tt.wantReached {
+				t.Errorf("Reached() = %v, want %v", lr.Reached(), tt.wantReached)
+			}
+		})
+	}
+}
+
+// openedGroups returns groups of one asset, each with its temporary file opened in dir.
+func openedGroups(t *testing.T, n int) (groupsReader, string) {
+	t.Helper()
+	t.Setenv("IMMICHGO_TEMPDIR", t.TempDir())
+	fsys := fstest.MapFS{}
+	var r groupsReader
+	for i := range n {
+		name := fmt.Sprintf("%d.jpg", i)
+		fsys[name] = &fstest.MapFile{Data: []byte(name)}
+		a := &assets.Asset{File: fshelper.FSName(fsys, name), OriginalFileName: name}
+		f, err := a.OpenFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		r = append(r, assets.NewGroup(assets.GroupByNone, a))
+	}
+	return r, filepath.Join(os.Getenv("IMMICHGO_TEMPDIR"), "immich-go", "temp")
+}
+
+func tempFiles(t *testing.T, dir string) int {
+	t.Helper()
+	e, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(e)
+}
+
+func TestLimitedReaderClosesDropped(t *testing.T) {
+	r, dir := openedGroups(t, 4)
+	var received []*assets.Group
+	for g := range NewLimitedReader(r, 1).Browse(context.Background()) {
+		received = append(received, g)
+	}
+	if len(received) != 1 {
+		t.Fatalf("got %d groups, want 1", len(received))
+	}
+	if n := tempFiles(t, dir); n != 1 {
+		t.Errorf("got %d temporary files, want the one of the sent asset", n)
+	}
+	received[0].Assets[0].Close()
+	if n := tempFiles(t, dir); n != 0 {
+		t.Errorf("got %d temporary files after closing the sent asset, want 0", n)
+	}
+}
+
+func TestLimitedReaderClosesOnCancel(t *testing.T) {
+	r, dir := openedGroups(t, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	lr := NewLimitedReader(r, 10)
+	for g := range lr.Browse(ctx) {
+		// the consumer closes what it receives, the reader the groups it drops
+		for _, a := range g.Assets {
+			a.Close()
+		}
+	}
+	if n := tempFiles(t, dir); n != 0 {
+		t.Errorf("got %d temporary files left after the cancel, want 0", n)
+	}
+	if lr.Reached() {
+		t.Error("Reached() = true after a cancel under the limit")
+	}
+}
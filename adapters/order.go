@@ -0,0 +1,121 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+// --order values
+const (
+	OrderNone     = "none"      // the order of the browsing
+	OrderDateAsc  = "date-asc"  // the oldest assets first
+	OrderDateDesc = "date-desc" // the newest assets first
+	OrderPath     = "path"      // the assets sorted by their path in the input
+)
+
+// ValidateOrder checks the value of --order
+func ValidateOrder(order string) error {
+	switch order {
+	case OrderNone, OrderDateAsc, OrderDateDesc, OrderPath:
+		return nil
+	default:
+		return fmt.Errorf("invalid value for --order: %q, expected date-asc, date-desc, path or none", order)
+	}
+}
+
+// OrderedReader sends the groups of the reader in a deterministic order.
+// All the groups are kept in memory until the browsing ends, then they are sorted and sent.
+// The groups without date come last.
+type OrderedReader struct {
+	Reader
+	Order string
+}
+
+func NewOrderedReader(r Reader, order string) *OrderedReader {
+	return &OrderedReader{Reader: r, Order: order}
+}
+
+func (o *OrderedReader) Browse(ctx context.Context) chan *assets.Group {
+	in := o.Reader.Browse(ctx)
+	if o.Order == OrderNone || o.Order == "" {
+		return in
+	}
+	out := make(chan *assets.Group)
+	go func() {
+		defer close(out)
+		var groups []*assets.Group
+		for g := range in {
+			groups = append(groups, g)
+		}
+		SortGroups(groups, o.Order)
+		for _, g := range groups {
+			select {
+			case out <- g:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// SortGroups sorts the groups in the given order. Equal groups keep the order of the browsing.
+func SortGroups(groups []*assets.Group, order string) {
+	switch order {
+	case OrderDateAsc, OrderDateDesc:
+		slices.SortStableFunc(groups, func(a, b *assets.Group) int {
+			da, db := groupDate(a), groupDate(b)
+			switch {
+			case da.IsZero() || db.IsZero():
+				// the groups without date come last
+				return boolCompare(da.IsZero(), db.IsZero())
+			case order == OrderDateDesc:
+				return db.Compare(da)
+			default:
+				return da.Compare(db)
+			}
+		})
+	case OrderPath:
+		slices.SortStableFunc(groups, func(a, b *assets.Group) int {
+			return strings.Compare(groupPath(a), groupPath(b))
+		})
+	}
+}
+
+// groupDate gives the oldest capture date of the group's assets
+func groupDate(g *assets.Group) time.Time {
+	var d time.Time
+	for _, a := range g.Assets {
+		if !a.CaptureDate.IsZero() && (d.IsZero() || a.CaptureDate.Before(d)) {
+			d = a.CaptureDate
+		}
+	}
+	return d
+}
+
+// groupPath gives the smallest path of the group's assets
+func groupPath(g *assets.Group) string {
+	p := ""
+	for i, a := range g.Assets {
+		if n := a.File.FullName(); i == 0 || n < p {
+			p = n
+		}
+	}
+	return p
+}
+
+func boolCompare(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
@@ -0,0 +1,139 @@
+package adapters
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+type orderAsset struct {
+	path string
+	date string // 2006-01-02, empty for no date
+}
+
+func orderGroups(t *testing.T, groups ...[]orderAsset) []*assets.Group {
+	t.Helper()
+	var r []*assets.Group
+	for _, g := range groups {
+		grp := assets.NewGroup(assets.GroupByNone)
+		for _, oa := range g {
+			a := &assets.Asset{File: fshelper.FSName(nil, oa.path)}
+			if oa.date != "" {
+				d, err := time.Parse(time.DateOnly, oa.date)
+				if err != nil {
+					t.Fatal(err)
+				}
+				a.CaptureDate = d
+			}
+			grp.Assets = append(grp.Assets, a)
+		}
+		r = append(r, grp)
+	}
+	return r
+}
+
+// firstPaths identifies each group by the path of its first asset
+func firstPaths(groups []*assets.Group) []string {
+	var p []string
+	for _, g := range groups {
+		p = append(p, g.Assets[0].File.Name())
+	}
+	return p
+}
+
+func TestSortGroups(t *testing.T) {
+	groups := [][]orderAsset{
+		{{"b/2.jpg", "2023-05-01"}},
+		{{"a/nodate.jpg", ""}},
+		{{"c/stack-2.jpg", "2024-01-01"}, {"a/stack-1.jpg", "2021-03-01"}},
+		{{"b/1.jpg", "2022-07-01"}},
+		{{"z/nodate.jpg", ""}},
+		{{"d/same-date.jpg", "2023-05-01"}},
+	}
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{
+			order: OrderNone,
+			want:  []string{"b/2.jpg", "a/nodate.jpg", "c/stack-2.jpg", "b/1.jpg", "z/nodate.jpg", "d/same-date.jpg"},
+		},
+		{
+			// the stack takes the date of its oldest asset, the equal dates keep the browsing order
+			order: OrderDateAsc,
+			want:  []string{"c/stack-2.jpg", "b/1.jpg", "b/2.jpg", "d/same-date.jpg", "a/nodate.jpg", "z/nodate.jpg"},
+		},
+		{
+			order: OrderDateDesc,
+			want:  []string{"b/2.jpg", "d/same-date.jpg", "b/1.jpg", "c/stack-2.jpg", "a/nodate.jpg", "z/nodate.jpg"},
+		},
+		{
+			// the stack takes the smallest path of its assets
+			order: OrderPath,
+			want:  []string{"a/nodate.jpg", "c/stack-2.jpg", "b/1.jpg", "b/2.jpg", "d/same-date.jpg", "z/nodate.jpg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			g := orderGroups(t, groups...)
+			SortGroups(g, tt.order)
+			if got := firstPaths(g); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderedReader(t *testing.T) {
+	groups := [][]orderAsset{
+		{{"c.jpg", "2023-01-01"}},
+		{{"a.jpg", "2021-01-01"}},
+		{{"b.jpg", "2022-01-01"}},
+	}
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{order: OrderNone, want: []string{"c.jpg", "a.jpg", "b.jpg"}},
+		{order: "", want: []string{"c.jpg", "a.jpg", "b.jpg"}},
+		{order: OrderDateAsc, want: []string{"a.jpg", "b.jpg", "c.jpg"}},
+		{order: OrderDateDesc, want: []string{"c.jpg", "b.jpg", "a.jpg"}},
+		{order: OrderPath, want: []string{"a.jpg", "b.jpg", "c.jpg"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			var got []*assets.Group
+			for g := range NewOrderedReader(groupsReader(orderGroups(t, groups...)), tt.order).Browse(context.Background()) {
+				got = append(got, g)
+			}
+			if p := firstPaths(got); !slices.Equal(p, tt.want) {
+				t.Errorf("got %v, want %v", p, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderedReaderCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewOrderedReader(groupsReader(orderGroups(t, []orderAsset{{"a.jpg", ""}}, []orderAsset{{"b.jpg", ""}})), OrderPath)
+	out := r.Browse(ctx)
+	cancel()
+	// the reader stops sending once the context is canceled, and closes its channel
+	for range out {
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	for _, o := range []string{OrderNone, OrderDateAsc, OrderDateDesc, OrderPath} {
+		if err := ValidateOrder(o); err != nil {
+			t.Errorf("ValidateOrder(%q): %v", o, err)
+		}
+	}
+	if err := ValidateOrder("date"); err == nil {
+		t.Error("ValidateOrder(\"date\"): want an error")
+	}
+}
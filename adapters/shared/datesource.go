@@ -0,0 +1,54 @@
+package shared
+
+import (
+	"context"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	cliflags "github.com/simulot/immich-go/internal/cliFlags"
+	"github.com/simulot/immich-go/internal/exif"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+)
+
+// ResolveCaptureDate compares the capture date of the sidecar's metadata with the date embedded in the file,
+// and applies the --date-source rule. The resolved date replaces the sidecar's one, to be used
+// for the filters and the requests to the server.
+// The dates differing by more than the threshold are recorded as a conflict.
+// The file is read only when the rule needs its date, and once: its metadata is kept in a.FromSourceFile.
+func ResolveCaptureDate(ctx context.Context, processor *fileprocessor.FileProcessor, a *assets.Asset, md *assets.Metadata, flags cliflags.DateSourceFlags, tz *time.Location) {
+	if !flags.NeedsEmbeddedDate() || md == nil || md.DateTaken.IsZero() {
+		return
+	}
+	embedded := EmbeddedMetadata(a, tz)
+	if embedded == nil || embedded.DateTaken.IsZero() {
+		return
+	}
+
+	date, conflict := flags.Resolve(md.DateTaken, embedded.DateTaken)
+	if conflict {
+		processor.Logger().Record(ctx, fileevent.ProcessedDateConflict, a.File,
+			"sidecar", md.DateTaken, "embedded", embedded.DateTaken, "used", date)
+	}
+	md.DateTaken = date
+	a.CaptureDate = date
+}
+
+// EmbeddedMetadata gives the metadata embedded in the file, read at the first call and kept in a.FromSourceFile.
+// It's nil when the file can't be read.
+func EmbeddedMetadata(a *assets.Asset, tz *time.Location) *assets.Metadata {
+	if a.FromSourceFile != nil {
+		return a.FromSourceFile
+	}
+	f, err := a.OpenFile()
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	md, err := exif.GetMetaData(f, a.Ext, tz)
+	if err != nil || md == nil {
+		return nil
+	}
+	a.FromSourceFile = md
+	return md
+}
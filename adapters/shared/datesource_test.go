@@ -0,0 +1,75 @@
+package shared
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/assettracker"
+	cliflags "github.com/simulot/immich-go/internal/cliFlags"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+// countingFS counts the files opened
+type countingFS struct {
+	fs.FS
+	opened int
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	c.opened++
+	return c.FS.Open(name)
+}
+
+func TestResolveCaptureDate(t *testing.T) {
+	embedded := time.Date(2023, 10, 6, 8, 30, 0, int(139*time.Millisecond), time.Local)
+	sidecar := embedded.Add(-24 * time.Hour)
+	tests := []struct {
+		name     string
+		source   cliflags.DateSource
+		sidecar  time.Time
+		want     time.Time
+		opened   int
+		conflict int
+	}{
+		{name: "sidecar", source: cliflags.DateSourceSidecar, sidecar: sidecar, want: sidecar},
+		{name: "no sidecar date", source: cliflags.DateSourceEXIF, want: time.Time{}},
+		{name: "exif", source: cliflags.DateSourceEXIF, sidecar: sidecar, want: embedded, opened: 1, conflict: 1},
+		{name: "oldest", source: cliflags.DateSourceOldest, sidecar: sidecar, want: sidecar, opened: 1, conflict: 1},
+		{name: "newest, same date", source: cliflags.DateSourceNewest, sidecar: embedded, want: embedded, opened: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := &countingFS{FS: os.DirFS("../../internal/exif/DATA")}
+			a := &assets.Asset{File: fshelper.FSName(fsys, "PXL_20231006_063000139.jpg"), CaptureDate: tt.sidecar}
+			a.Ext = ".jpg"
+			md := &assets.Metadata{DateTaken: tt.sidecar}
+			processor := fileprocessor.New(assettracker.New(), fileevent.NewRecorder(slog.New(slog.DiscardHandler)))
+			flags := cliflags.DateSourceFlags{Source: tt.source, Threshold: time.Minute}
+
+			ResolveCaptureDate(context.Background(), processor, a, md, flags, time.Local)
+			if !a.CaptureDate.Equal(tt.want) || !md.DateTaken.Equal(tt.want) {
+				t.Errorf("capture date %s, sidecar %s, want %s", a.CaptureDate, md.DateTaken, tt.want)
+			}
+			if fsys.opened != tt.opened {
+				t.Errorf("the file is opened %d times, want %d", fsys.opened, tt.opened)
+			}
+			if n := processor.Logger().GetCounts()[fileevent.ProcessedDateConflict]; int(n) != tt.conflict {
+				t.Errorf("%d conflicts, want %d", n, tt.conflict)
+			}
+
+			// the embedded metadata is kept, the file isn't read again
+			if tt.opened > 0 {
+				if a.FromSourceFile == nil || EmbeddedMetadata(a, time.Local) != a.FromSourceFile || fsys.opened != tt.opened {
+					t.Error("the embedded metadata isn't kept")
+				}
+			}
+		})
+	}
+}
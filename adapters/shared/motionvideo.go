@@ -0,0 +1,28 @@
+package shared
+
+import (
+	"context"
+	"slices"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/simulot/immich-go/internal/motionphoto"
+)
+
+// SkipMotionVideos removes the videos exported next to the motion photos of the directory,
+// and records them as discarded. The other assets are returned.
+func SkipMotionVideos(ctx context.Context, processor *fileprocessor.FileProcessor, as []*assets.Asset) []*assets.Asset {
+	videos := motionphoto.Videos(as)
+	if len(videos) == 0 {
+		return as
+	}
+	return slices.DeleteFunc(as, func(a *assets.Asset) bool {
+		if !slices.Contains(videos, a) {
+			return false
+		}
+		processor.RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedMotionVideo, "video part of a motion photo")
+		a.Close()
+		return true
+	})
+}
@@ -0,0 +1,107 @@
+package albums
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/simulot/immich-go/app"
+	"github.com/simulot/immich-go/immich"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ListAlbumsCmd lists the albums present on the server
+type ListAlbumsCmd struct {
+	// CLI flags
+	Format string // text or json
+
+	client app.Client
+}
+
+func (lc *ListAlbumsCmd) RegisterFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&lc.Format, "format", "text", "Output format (text|json)")
+}
+
+// albumInfo is the JSON representation of an album
+type albumInfo struct {
+	Name       string    `json:"name"`
+	ID         string    `json:"id"`
+	AssetCount int       `json:"asset_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewListAlbumsCommand adds the list-albums command
+func NewListAlbumsCommand(ctx context.Context, a *app.Application) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list-albums [flags]",
+		Short: "List the albums present on the server",
+		Long:  `List the albums present on the server with their asset count. The list can be used to craft --include-album filters.`,
+		Args:  cobra.NoArgs,
+	}
+
+	lc := &ListAlbumsCmd{}
+	lc.RegisterFlags(cmd.Flags())
+	lc.client.RegisterFlags(cmd.Flags(), "")
+	a.SetMachineOutput(cmd, func() bool { return strings.EqualFold(lc.Format, "json") })
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error { //nolint:contextcheck
+		format := strings.ToLower(lc.Format)
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid value for --format: %q, expected text or json", lc.Format)
+		}
+
+		ctx := cmd.Context()
+		err := lc.client.Open(ctx, a)
+		if err != nil {
+			return err
+		}
+
+		albums, err := lc.client.Immich.GetAllAlbums(ctx)
+		if err != nil {
+			return err
+		}
+		list := albumInfos(albums)
+		if format == "json" {
+			return writeJSON(cmd.OutOrStdout(), list)
+		}
+		return writeText(cmd.OutOrStdout(), list)
+	}
+	return cmd
+}
+
+func albumInfos(albums []immich.AlbumSimplified) []albumInfo {
+	list := make([]albumInfo, 0, len(albums))
+	for _, al := range albums {
+		list = append(list, albumInfo{
+			Name:       al.AlbumName,
+			ID:         al.ID,
+			AssetCount: al.AssetCount,
+			CreatedAt:  al.CreatedAt,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list
+}
+
+func writeJSON(w io.Writer, list []albumInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(list)
+}
+
+func writeText(w io.Writer, list []albumInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tID\tASSETS\tCREATED")
+	for _, al := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", al.Name, al.ID, al.AssetCount, al.CreatedAt.Format(time.DateOnly))
+	}
+	return tw.Flush()
+}
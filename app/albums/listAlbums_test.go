@@ -0,0 +1,62 @@
+package albums
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simulot/immich-go/immich"
+)
+
+func TestListAlbums(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	list := albumInfos([]immich.AlbumSimplified{
+		{ID: "id-2", AlbumName: "vacations", AssetCount: 12, CreatedAt: created},
+		{ID: "id-1", AlbumName: "Birthday", AssetCount: 3, CreatedAt: created},
+	})
+	if len(list) != 2 || list[0].Name != "Birthday" || list[1].Name != "vacations" {
+		t.Fatalf("the albums should be sorted by name, ignoring the case: %+v", list)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := writeJSON(buf, list); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"name": "Birthday", "id": "id-1", "asset_count": 3.0, "created_at": "2024-05-01T10:00:00Z"}
+	for k, v := range want {
+		if got[0][k] != v {
+			t.Errorf("JSON %s = %v, want %v", k, got[0][k], v)
+		}
+	}
+
+	buf.Reset()
+	if err := writeText(buf, list); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+	if f := strings.Fields(lines[0]); strings.Join(f, " ") != "NAME ID ASSETS CREATED" {
+		t.Errorf("unexpected header: %q", lines[0])
+	}
+	if f := strings.Fields(lines[2]); strings.Join(f, " ") != "vacations id-2 12 2024-05-01" {
+		t.Errorf("unexpected row: %q", lines[2])
+	}
+}
+
+func TestListAlbumsEmpty(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	if err := writeJSON(buf, albumInfos(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("an empty list should give an empty JSON array, got %q", buf.String())
+	}
+}
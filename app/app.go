@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/simulot/immich-go/immich"
 	cliflags "github.com/simulot/immich-go/internal/cliFlags"
 	"github.com/simulot/immich-go/internal/config"
 	"github.com/simulot/immich-go/internal/fileprocessor"
@@ -25,10 +26,21 @@ import (
 type Application struct {
 	// CLI flags
 	DryRun         bool
+	ReadOnly       bool // Refuse at the HTTP level the requests that could change the server
 	OnErrors       cliflags.OnErrorsFlag
-	SaveConfig     bool
+	SaveConfig     string // File where the configuration is saved
+	SaveSecrets    bool   // Save the API keys with the configuration
+	ForceSave      bool   // Overwrite the existing configuration file
+	DumpConfig     string // Print the effective configuration and exit: text or json
 	ConcurrentTask int
 	CfgFile        string
+	Notify         Notify
+	SummaryFile    string
+	StatusFile     string
+	RunID          string            // Identifies the run in the JSON outputs, a new UUID when not given
+	MaxMemory      cliflags.ByteSize // Soft limit of the memory used by the process, 0 for no limit
+
+	CheckpointInterval time.Duration // Period of the checkpoints of the summary written during the run, 0 without
 
 	// Internal state
 	log       *Log
@@ -38,15 +50,49 @@ type Application struct {
 
 	sm filetypes.SupportedMedia
 
-	numErrors atomic.Int64 // count the errors occurred during the run
+	numErrors  atomic.Int64 // count the errors occurred during the run
+	authFailed atomic.Bool  // the server has rejected the API key during the run
+
+	clockSkew *time.Duration // measured difference between the server's clock and the local one
+
+	manifestFile string                             // the checksum manifest written by the archive, if any
+	albums       []fileprocessor.AlbumSummary       // the albums created by the upload, for the summary
+	visibility   map[string]int64                   // the uploaded assets by visibility, for the summary
+	limit        int                                // the --limit cap on the number of assets, 0 without
+	dupSets      *int                               // the duplicate sets known by the server, nil when not queried
+	storage      *fileprocessor.StorageSummary      // the space available and needed by the upload, nil when unknown
+	albumCovers  *fileprocessor.AlbumCoversSummary  // the covers set on the created albums, nil when not requested
+	stacks       *fileprocessor.StacksSummary       // the stacks created during the upload, nil when none is requested
+	blocked      *int64                             // the requests refused by --read-only, nil without it
+	invalidMedia int64                              // the files rejected by --validate-media
+	mergedAlbums []fileprocessor.MergedAlbumSummary // the album names merged by --album-name-match
+
+	memory memoryMonitor // peak of the memory used, and throttling near --max-memory
+
+	status      *statusFile  // --status-file updates, nil when not requested
+	checkpoints *checkpoints // --checkpoint-interval records, nil when not requested
+
+	machineOutputs map[*cobra.Command]func() bool // tell if the commands write JSON or gauge values on the standard output
 }
 
 func (app *Application) RegisterFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&app.CfgFile, "config", "", "config file (default is ./immich-go.yaml)")
 	flags.BoolVar(&app.DryRun, "dry-run", false, "dry run")
-	flags.BoolVar(&app.SaveConfig, "save-config", false, "Save the configuration to immich-go.yaml")
-	flags.Var(&app.OnErrors, "on-errors", "What to do when an error occurs (stop, continue, accept N errors at max)")
+	flags.BoolVar(&app.ReadOnly, "read-only", false, "Refuse any request that could change the server, at the HTTP level. Implies --dry-run, the refused requests are logged and counted")
+	flags.StringVar(&app.SaveConfig, "save-config", "", "Save the configuration to this file, immich-go.yaml when no file is given. An existing file is kept unless --force is given")
+	flags.Lookup("save-config").NoOptDefVal = DefaultConfigFile
+	flags.BoolVar(&app.SaveSecrets, "save-secrets", false, "Save the API keys with the configuration, they are left out by default")
+	flags.BoolVar(&app.ForceSave, "force", false, "Overwrite the existing file given to --save-config")
+	flags.StringVar(&app.DumpConfig, "dump-config", "", "Print the effective configuration with the origin of each value, then exit without doing anything (text|json)")
+	flags.Lookup("dump-config").NoOptDefVal = DumpConfigText
+	flags.Var(&app.OnErrors, "on-errors", "What to do when an error occurs: stop at the first error, continue with the next asset, or accept N errors at max (stop|continue|N)")
 	flags.IntVar(&app.ConcurrentTask, "concurrent-tasks", runtime.NumCPU(), "Number of concurrent tasks (1-20)")
+	app.Notify.RegisterFlags(flags)
+	flags.StringVar(&app.SummaryFile, "summary-file", "", "Write the run summary as JSON to this file when the run completes, even on error")
+	flags.StringVar(&app.RunID, "run-id", "", "Identifier of the run, given in the JSON log, the summary, the status file and the plan, to aggregate the outputs of concurrent runs (default: a new UUID)")
+	flags.Var(&app.MaxMemory, "max-memory", "Soft limit of the memory used, like 2G: the garbage collector works harder near the limit, and the new uploads wait for the running ones over it (0 for no limit)")
+	flags.StringVar(&app.StatusFile, "status-file", "", "Update this file with the progress of the run, to be read with the status command")
+	flags.DurationVar(&app.CheckpointInterval, "checkpoint-interval", 0, "Write a checkpoint of the run summary at this interval, with the status in_progress, into the JSON log and the --summary-file (0 to disable)")
 }
 
 func New(ctx context.Context, cmd *cobra.Command) *Application {
@@ -74,14 +120,89 @@ func (app *Application) SetTZ(tz *time.Location) {
 	app.tz = tz
 }
 
+// SetClockSkew records the measured difference between the server's clock and the local one
+func (app *Application) SetClockSkew(skew time.Duration) {
+	app.clockSkew = &skew
+}
+
+// ClockSkew gives the difference between the server's clock and the local one, if it has been measured
+func (app *Application) ClockSkew() (time.Duration, bool) {
+	if app.clockSkew == nil {
+		return 0, false
+	}
+	return *app.clockSkew, true
+}
+
+// SetAlbums records the albums created by the run, given in the summary
+func (app *Application) SetAlbums(albums []fileprocessor.AlbumSummary) {
+	app.albums = albums
+}
+
+// SetVisibility records the number of uploaded assets by visibility, given in the summary
+func (app *Application) SetVisibility(counts map[string]int64) {
+	app.visibility = counts
+}
+
+// SetDuplicateSets records the number of duplicate sets known by the server, given in the summary
+func (app *Application) SetDuplicateSets(n int) {
+	app.dupSets = &n
+}
+
+// SetStorage records the space available for the upload and the space needed by it, given in the summary
+func (app *Application) SetStorage(s fileprocessor.StorageSummary) {
+	app.storage = &s
+}
+
+// SetAlbumCovers records the covers set on the created albums, given in the summary
+func (app *Application) SetAlbumCovers(s fileprocessor.AlbumCoversSummary) {
+	app.albumCovers = &s
+}
+
+// SetStacks records the stacks created during the upload, given in the summary
+func (app *Application) SetStacks(s fileprocessor.StacksSummary) {
+	app.stacks = &s
+}
+
+// SetBlockedRequests records the number of requests refused by --read-only, given in the summary
+func (app *Application) SetBlockedRequests(n int64) {
+	app.blocked = &n
+}
+
+// SetInvalidMedia records the number of files rejected by --validate-media, given in the summary
+func (app *Application) SetInvalidMedia(n int64) {
+	app.invalidMedia = n
+}
+
+// SetMergedAlbums records the album names merged by --album-name-match, given in the summary
+func (app *Application) SetMergedAlbums(albums []fileprocessor.MergedAlbumSummary) {
+	app.mergedAlbums = albums
+}
+
+// SetManifestFile records the path of the checksum manifest written by the run
+func (app *Application) SetManifestFile(name string) {
+	app.manifestFile = name
+}
+
+// SetLimit records the --limit cap on the number of assets read from the input
+func (app *Application) SetLimit(n int) {
+	app.limit = n
+}
+
+// Limit gives the --limit cap on the number of assets read from the input, 0 without
+func (app *Application) Limit() int {
+	return app.limit
+}
+
 // FileProcessor returns the file processor for coordinated asset tracking and event logging
 func (app *Application) FileProcessor() *fileprocessor.FileProcessor {
 	return app.processor
 }
 
-// SetFileProcessor sets the file processor
+// SetFileProcessor sets the file processor, and starts the --status-file updates and the checkpoints
 func (app *Application) SetFileProcessor(processor *fileprocessor.FileProcessor) {
 	app.processor = processor
+	app.startStatusFile(processor)
+	app.startCheckpoints()
 }
 
 func (app *Application) SetLog(log *Log) {
@@ -99,6 +220,34 @@ func (app *Application) SetSupportedMedia(sm filetypes.SupportedMedia) {
 	app.sm = sm
 }
 
+// ErrAuthenticationFailed stops the run when the server rejects the API key after the connection,
+// like a key revoked or rotated during a long run
+var ErrAuthenticationFailed = errors.New("authentication failed mid-run: the server rejected the API key, it may have been revoked or rotated. Use --api-key-file to pick up a rotated key without restarting")
+
+// ErrMaxErrors is the cause of the cancellation when the --max-errors limit is exceeded
+var ErrMaxErrors = errors.New("too many errors")
+
+// ErrNotEnoughSpace is the cause of the cancellation when the upload doesn't fit in the available space of the server
+var ErrNotEnoughSpace = errors.New("not enough space on the server")
+
+// Exit codes of immich-go
+const (
+	ExitError          = 1 // the run has failed
+	ExitAuthentication = 3 // the server has rejected the API key
+)
+
+// ExitCode gives the exit code of the run from its error
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrAuthenticationFailed), immich.IsUnauthorized(err):
+		return ExitAuthentication
+	default:
+		return ExitError
+	}
+}
+
 func (app *Application) ProcessError(err error) error {
 	if err == nil {
 		return nil
@@ -109,6 +258,14 @@ func (app *Application) ProcessError(err error) error {
 		return err
 	}
 
+	// the next calls would fail the same way, the run stops whatever --on-errors
+	if immich.IsUnauthorized(err) && !immich.KeyReloaded(err) {
+		if app.authFailed.CompareAndSwap(false, true) {
+			app.Log().Error("Authentication failed mid-run", "err", err.Error())
+		}
+		return ErrAuthenticationFailed
+	}
+
 	nErr := app.numErrors.Add(1)
 	if app.OnErrors == cliflags.OnErrorsStop {
 		app.Log().Error("Error", "err", err.Error())
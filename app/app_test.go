@@ -2,11 +2,17 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
+	"github.com/simulot/immich-go/immich"
 	"github.com/simulot/immich-go/internal/assettracker"
+	cliflags "github.com/simulot/immich-go/internal/cliFlags"
 	"github.com/simulot/immich-go/internal/fileevent"
 	"github.com/simulot/immich-go/internal/fileprocessor"
 	"github.com/spf13/cobra"
@@ -43,3 +49,60 @@ func TestApplicationFileProcessor(t *testing.T) {
 		t.Error("FileProcessor.Logger should return the recorder")
 	}
 }
+
+func TestProcessErrorUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	ic, err := immich.NewImmichClient(server.URL, "revoked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, callErr := ic.GetMyAPIKey(context.Background())
+
+	app := New(context.Background(), &cobra.Command{})
+	app.log.Logger = slog.New(slog.DiscardHandler)
+	app.OnErrors = cliflags.OnErrorsNeverStop
+
+	// the run stops even with --on-errors=continue
+	for range 2 {
+		if err := app.ProcessError(callErr); !errors.Is(err, ErrAuthenticationFailed) {
+			t.Fatalf("ProcessError() = %v, want ErrAuthenticationFailed", err)
+		}
+	}
+	if n := app.numErrors.Load(); n != 0 {
+		t.Errorf("numErrors = %d, want 0", n)
+	}
+	if got := ExitCode(ErrAuthenticationFailed); got != ExitAuthentication {
+		t.Errorf("ExitCode(ErrAuthenticationFailed) = %d, want %d", got, ExitAuthentication)
+	}
+	if got := ExitCode(callErr); got != ExitAuthentication {
+		t.Errorf("ExitCode(401) = %d, want %d", got, ExitAuthentication)
+	}
+	if got := ExitCode(errors.New("other")); got != ExitError {
+		t.Errorf("ExitCode(other) = %d, want %d", got, ExitError)
+	}
+	if got := RunStatus(ErrAuthenticationFailed); got != "authentication failed" {
+		t.Errorf("RunStatus() = %q", got)
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, "completed"},
+		{context.Canceled, "interrupted"},
+		{fmt.Errorf("upload aborted: %w, 11 errors exceeded the limit of 10 set by --max-errors", ErrMaxErrors), "aborted, too many errors"},
+		{fmt.Errorf("upload aborted: %w, 2 GB required, 1 GB available", ErrNotEnoughSpace), "aborted, not enough space on the server"},
+		{ErrAuthenticationFailed, "authentication failed"},
+		{errors.New("other"), "failed"},
+	}
+	for _, tt := range tests {
+		if got := RunStatus(tt.err); got != tt.want {
+			t.Errorf("RunStatus(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package archive
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/simulot/immich-go/adapters/folder"
 	"github.com/simulot/immich-go/adapters/fromimmich"
@@ -15,12 +16,27 @@ import (
 )
 
 type ArchiveCmd struct {
-	ArchivePath string
+	ArchivePath         string
+	IncludeTrashed      bool   // Archive trashed assets in the _trashed folder
+	Estimate            bool   // Only compute the disk usage of the archive
+	Format              string // Output format of the estimate: text or json
+	JSONPretty          bool   // Indent the JSON estimate
+	Resume              bool   // Skip the assets already in the archive
+	ResumeChecksum      bool   // Compare the checksum of the archived files when resuming
+	ConcurrentDownloads int    // Number of assets written in parallel, 0 for --concurrent-tasks
+	NoAlbumOnly         bool   // Archive only the assets that aren't in any album
+	InAlbumOnly         bool   // Archive only the assets that are in at least one album
+	SetMtime            bool   // Set the times of the archived files to the capture date
+	Manifest            bool   // Write the SHA-256 of the archived files in manifest.sha256
+	Limit               int    // Stop the browsing after this number of assets, 0 for no limit
 
 	app  *app.Application
 	dest *folder.LocalAssetWriter
 }
 
+// archiveOnErrors is the number of errors tolerated by the archive when --on-errors isn't given
+const archiveOnErrors = 5
+
 func NewArchiveCommand(ctx context.Context, app *app.Application) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "archive",
@@ -32,6 +48,19 @@ func NewArchiveCommand(ctx context.Context, app *app.Application) *cobra.Command
 
 	cmd.PersistentFlags().StringVarP(&ac.ArchivePath, "write-to-folder", "w", "", "Path where to write the archive")
 	_ = cmd.MarkPersistentFlagRequired("write-to-folder")
+	cmd.PersistentFlags().BoolVar(&ac.IncludeTrashed, "include-trashed", false, "Archive trashed assets into the _trashed folder")
+	cmd.PersistentFlags().BoolVar(&ac.Estimate, "estimate", false, "Print the disk space needed by the archive, per folder, without writing anything")
+	cmd.PersistentFlags().StringVar(&ac.Format, "format", "text", "Output format of the estimate (text|json)")
+	app.SetMachineOutput(cmd, func() bool { return ac.Format == "json" })
+	cmd.PersistentFlags().BoolVar(&ac.JSONPretty, "json-pretty", false, "Indent the JSON estimate on several lines, for reading by hand")
+	cmd.PersistentFlags().BoolVar(&ac.Resume, "resume", false, "Skip the assets already present in the archive with the same size")
+	cmd.PersistentFlags().BoolVar(&ac.NoAlbumOnly, "no-album-only", false, "Archive only the assets that don't belong to any album")
+	cmd.PersistentFlags().BoolVar(&ac.InAlbumOnly, "in-album-only", false, "Archive only the assets that belong to at least one album")
+	cmd.PersistentFlags().IntVar(&ac.ConcurrentDownloads, "concurrent-downloads", 0, "Number of assets downloaded in parallel (default: the value of --concurrent-tasks)")
+	cmd.PersistentFlags().BoolVar(&ac.SetMtime, "set-mtime", false, "Set the modification and access times of the archived files to the capture date, and the creation time when the file system supports it")
+	cmd.PersistentFlags().BoolVar(&ac.Manifest, "manifest", false, "Write the SHA-256 of the archived files in manifest.sha256 at the root of the archive, to be checked later with sha256sum -c. The files of the previous runs are kept in it")
+	cmd.PersistentFlags().IntVar(&ac.Limit, "limit", 0, "Stop reading the source after this number of assets, and finish the ones in progress, for a quick check of the settings on a large source (0 for no limit)")
+	cmd.PersistentFlags().BoolVar(&ac.ResumeChecksum, "resume-checksum", false, "When resuming, compare the checksum of the archived files too (slower)")
 
 	cmd.AddCommand(folder.NewFromFolderCommand(ctx, cmd, app, ac))
 	cmd.AddCommand(folder.NewFromICloudCommand(ctx, cmd, app, ac))
@@ -48,6 +77,19 @@ func NewArchiveCommand(ctx context.Context, app *app.Application) *cobra.Command
 			app.SetFileProcessor(processor)
 		}
 
+		if !cmd.Flags().Changed("on-errors") {
+			app.OnErrors = archiveOnErrors
+		}
+		if ac.NoAlbumOnly && ac.InAlbumOnly {
+			return errors.New("--no-album-only and --in-album-only can't be used together")
+		}
+		if ac.ConcurrentDownloads < 0 {
+			return fmt.Errorf("invalid value for --concurrent-downloads: %d, expected a positive number", ac.ConcurrentDownloads)
+		}
+		if ac.Format != "text" && ac.Format != "json" {
+			return fmt.Errorf("invalid value for --format: %q, expected text or json", ac.Format)
+		}
+
 		// app.SetTZ(time.Local)
 		// if tz, err := cmd.Flags().GetString("time-zone"); err == nil && tz != "" {
 		// 	if loc, err := time.LoadLocation(tz); err == nil {
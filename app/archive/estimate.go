@@ -0,0 +1,88 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/simulot/immich-go/adapters"
+	"github.com/simulot/immich-go/adapters/folder"
+	"github.com/simulot/immich-go/internal/ui"
+)
+
+// estimateBucket gives the number of assets and their size for an archive folder
+type estimateBucket struct {
+	AssetCount int64 `json:"asset_count"`
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+// estimate is the disk usage of the archive, per folder of the layout
+type estimate struct {
+	Type       string                     `json:"type"`
+	TotalBytes int64                      `json:"total_bytes"`
+	AssetCount int64                      `json:"asset_count"`
+	Buckets    map[string]*estimateBucket `json:"buckets"`
+}
+
+// estimate sums the asset sizes without writing anything
+func (ac *ArchiveCmd) estimate(ctx context.Context, adapter adapters.Reader) (*estimate, error) {
+	e := &estimate{
+		Type:    "estimate",
+		Buckets: map[string]*estimateBucket{},
+	}
+	gChan := adapter.Browse(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case g, ok := <-gChan:
+			if !ok {
+				return e, nil
+			}
+			for _, a := range g.Assets {
+				if (!a.Trashed || ac.IncludeTrashed) && ac.albumFilter(a) == "" {
+					dir := folder.ArchiveFolder(a)
+					b, ok := e.Buckets[dir]
+					if !ok {
+						b = &estimateBucket{}
+						e.Buckets[dir] = b
+					}
+					b.AssetCount++
+					b.TotalBytes += int64(a.FileSize)
+					e.AssetCount++
+					e.TotalBytes += int64(a.FileSize)
+				}
+				a.Close()
+			}
+		}
+	}
+}
+
+// writeJSON writes the estimate on a single line, or indented when pretty is set
+func (e *estimate) writeJSON(w io.Writer, pretty bool) error {
+	enc := json.NewEncoder(w)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(e)
+}
+
+func (e *estimate) writeText(w io.Writer) error {
+	dirs := make([]string, 0, len(e.Buckets))
+	for d := range e.Buckets {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "FOLDER\tASSETS\tSIZE\t")
+	for _, d := range dirs {
+		b := e.Buckets[d]
+		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", d, b.AssetCount, ui.FormatBytes(b.TotalBytes))
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%s\t\n", e.AssetCount, ui.FormatBytes(e.TotalBytes))
+	return tw.Flush()
+}
@@ -0,0 +1,104 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/simulot/immich-go/app"
+	"github.com/simulot/immich-go/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// TestArchiveEstimate runs archive --estimate from-folder on the folder adapter's fixture
+func TestArchiveEstimate(t *testing.T) {
+	const source = "../../adapters/folder/DATA/date-range"
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wantCount, wantBytes int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantCount++
+		wantBytes += info.Size()
+	}
+
+	run := func(t *testing.T, flags ...string) string {
+		dest := filepath.Join(t.TempDir(), "archive")
+		root := &cobra.Command{Use: "immich-go"}
+		a := app.New(context.Background(), root)
+		a.Log().Logger = slog.New(slog.DiscardHandler)
+		a.RegisterFlags(root.PersistentFlags())
+		a.ConcurrentTask = 1
+		root.AddCommand(NewArchiveCommand(context.Background(), a))
+
+		out := bytes.NewBuffer(nil)
+		root.SetOut(out)
+		root.SetErr(bytes.NewBuffer(nil))
+		root.SetArgs(append(append([]string{"archive", "--estimate", "--write-to-folder", dest}, flags...), "from-folder", source))
+		if err := root.ExecuteContext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(dest); !os.IsNotExist(err) {
+			t.Errorf("the estimate has created the archive folder: %v", err)
+		}
+		return out.String()
+	}
+
+	t.Run("json", func(t *testing.T) {
+		out := run(t, "--format", "json")
+		var e estimate
+		if err := json.Unmarshal([]byte(out), &e); err != nil {
+			t.Fatalf("the output isn't JSON: %v\n%s", err, out)
+		}
+		if e.Type != "estimate" || e.AssetCount != wantCount || e.TotalBytes != wantBytes {
+			t.Errorf("estimate %q: %d assets, %d bytes, want %d assets, %d bytes", e.Type, e.AssetCount, e.TotalBytes, wantCount, wantBytes)
+		}
+		var count, size int64
+		for _, b := range e.Buckets {
+			count += b.AssetCount
+			size += b.TotalBytes
+		}
+		if count != wantCount || size != wantBytes {
+			t.Errorf("the buckets sum to %d assets, %d bytes, want %d assets, %d bytes", count, size, wantCount, wantBytes)
+		}
+	})
+
+	t.Run("json-pretty", func(t *testing.T) {
+		out := run(t, "--format", "json", "--json-pretty")
+		if !strings.Contains(out, "\n  \"") {
+			t.Errorf("the JSON isn't indented:\n%s", out)
+		}
+		if !json.Valid([]byte(out)) {
+			t.Errorf("the output isn't JSON:\n%s", out)
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		out := run(t)
+		total := regexp.MustCompile(`TOTAL\s+(\d+)\s+(.+?)\s*$`)
+		var m []string
+		for _, l := range strings.Split(out, "\n") {
+			if m = total.FindStringSubmatch(l); m != nil {
+				break
+			}
+		}
+		if m == nil {
+			t.Fatalf("no TOTAL line in:\n%s", out)
+		}
+		if m[1] != strconv.FormatInt(wantCount, 10) || m[2] != ui.FormatBytes(wantBytes) {
+			t.Errorf("TOTAL %s assets, %s, want %d assets, %s", m[1], m[2], wantCount, ui.FormatBytes(wantBytes))
+		}
+	})
+}
@@ -1,16 +1,21 @@
 package archive
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/simulot/immich-go/adapters"
 	"github.com/simulot/immich-go/adapters/folder"
+	"github.com/simulot/immich-go/internal/assets"
 	"github.com/simulot/immich-go/internal/assettracker"
 	"github.com/simulot/immich-go/internal/fileevent"
 	"github.com/simulot/immich-go/internal/fileprocessor"
 	"github.com/simulot/immich-go/internal/fshelper/osfs"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
 func (ac *ArchiveCmd) Run(cmd *cobra.Command, adapter adapters.Reader) error {
@@ -27,6 +32,29 @@ func (ac *ArchiveCmd) Run(cmd *cobra.Command, adapter adapters.Reader) error {
 		ac.app.SetFileProcessor(processor)
 	}
 
+	if !ac.IncludeTrashed && trashedOnly(adapter) {
+		// the trashed assets are the ones asked for
+		ac.IncludeTrashed = true
+		log.Info("the source gives the trashed assets only, they are archived without --include-trashed")
+	}
+
+	if ac.Limit > 0 {
+		ac.app.SetLimit(ac.Limit)
+		adapter = adapters.NewLimitedReader(adapter, ac.Limit)
+	}
+
+	if ac.Estimate {
+		e, err := ac.estimate(ctx, adapter)
+		if err != nil {
+			return err
+		}
+		log.Info("archive estimate", "assets", e.AssetCount, "bytes", e.TotalBytes)
+		if ac.Format == "json" {
+			return e.writeJSON(cmd.OutOrStdout(), ac.JSONPretty)
+		}
+		return e.writeText(cmd.OutOrStdout())
+	}
+
 	p := ac.ArchivePath
 	err := os.MkdirAll(p, 0o755)
 	if err != nil {
@@ -38,35 +66,137 @@ func (ac *ArchiveCmd) Run(cmd *cobra.Command, adapter adapters.Reader) error {
 	if err != nil {
 		return err
 	}
+	ac.dest.Resume = ac.Resume
+	ac.dest.VerifyChecksum = ac.ResumeChecksum
+	ac.dest.SetMtime = ac.SetMtime
+	if ac.Manifest {
+		ac.dest.Manifest = true
+		if err := ac.dest.LoadManifest(folder.ManifestName); err != nil {
+			return fmt.Errorf("can't read the manifest of the archive: %w", err)
+		}
+	}
 
-	gChan := adapter.Browse(ctx)
-	errCount := 0
+	workers := ac.ConcurrentDownloads
+	if workers <= 0 {
+		workers = ac.app.ConcurrentTask
+	}
+	grp, gCtx := errgroup.WithContext(ctx)
+	grp.SetLimit(workers)
+
+	albumFiltered := 0
+	// the browse stops with the downloads, its pending groups are closed
+	browseCtx, cancelBrowse := context.WithCancel(gCtx)
+	defer cancelBrowse()
+	gChan := adapter.Browse(browseCtx)
+browse:
 	for {
 		select {
-		case <-ctx.Done():
-			return ctx.Err()
+		case <-gCtx.Done():
+			break browse
 		case g, ok := <-gChan:
 			if !ok {
-				return nil
+				break browse
 			}
 			for _, a := range g.Assets {
-				err := ac.dest.WriteAsset(ctx, a)
-				if err == nil {
-					err = a.Close()
+				if a.Trashed && !ac.IncludeTrashed {
+					ac.app.FileProcessor().RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedFiltered, "discarding trashed asset")
+					a.Close()
+					continue
 				}
-				if err != nil {
-					ac.app.FileProcessor().RecordAssetError(ctx, a.File, int64(a.FileSize), fileevent.ErrorFileAccess, err)
-					errCount++
-					if errCount > 5 {
-						err := errors.New("too many errors, aborting")
-						log.Error(err.Error())
-						return err
-					}
-				} else {
-					// Asset successfully archived
-					ac.app.FileProcessor().RecordAssetProcessed(ctx, a.File, int64(a.FileSize), fileevent.ProcessedFileArchived)
+				if reason := ac.albumFilter(a); reason != "" {
+					ac.app.FileProcessor().RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedFiltered, reason)
+					albumFiltered++
+					a.Close()
+					continue
 				}
+				grp.Go(func() error {
+					return ac.archiveAsset(gCtx, a)
+				})
 			}
 		}
 	}
+	cancelBrowse()
+	for g := range gChan {
+		for _, a := range g.Assets {
+			a.Close()
+		}
+	}
+	err = grp.Wait()
+	if lr, ok := adapter.(*adapters.LimitedReader); ok && lr.Reached() {
+		n := ac.app.FileProcessor().DiscardPending(ctx, fileevent.DiscardedFiltered, "--limit reached")
+		log.Info("archive limited by --limit", "limit", ac.Limit, "not-archived", n)
+	}
+	if ac.Manifest {
+		// the manifest lists the files written before an interruption or an error too
+		err = errors.Join(err, ac.writeManifest())
+	}
+	if err != nil {
+		return err
+	}
+	if ac.NoAlbumOnly || ac.InAlbumOnly {
+		log.Info("assets filtered out by album membership", "count", albumFiltered)
+	}
+	if ac.Resume {
+		counts := ac.app.FileProcessor().Logger().GetEventCounts()
+		log.Info("archive resumed", "downloaded", counts[fileevent.ProcessedFileArchived], "skipped", counts[fileevent.DiscardedAlreadyArchived])
+	}
+	return ctx.Err()
+}
+
+// writeManifest writes the checksums of the archived files, and reports the manifest's path
+func (ac *ArchiveCmd) writeManifest() error {
+	n, err := ac.dest.WriteManifest(folder.ManifestName)
+	if err != nil {
+		return fmt.Errorf("can't write the manifest of the archive: %w", err)
+	}
+	name := filepath.Join(ac.ArchivePath, folder.ManifestName)
+	ac.app.SetManifestFile(name)
+	ac.app.Log().Message("Manifest of %d files: %s", n, name)
+	return nil
+}
+
+// albumFilter gives the reason to discard the asset according to the album membership filters,
+// or an empty string to keep it
+func (ac *ArchiveCmd) albumFilter(a *assets.Asset) string {
+	switch {
+	case ac.NoAlbumOnly && len(a.Albums) > 0:
+		return "asset in an album"
+	case ac.InAlbumOnly && len(a.Albums) == 0:
+		return "asset not in any album"
+	}
+	return ""
+}
+
+// archiveAsset writes one asset in the archive and records the outcome.
+// The returned error stops the other downloads, depending on the --on-errors setting.
+func (ac *ArchiveCmd) archiveAsset(ctx context.Context, a *assets.Asset) error {
+	err := ac.dest.WriteAsset(ctx, a)
+	if errors.Is(err, folder.ErrAlreadyArchived) {
+		ac.app.FileProcessor().RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedAlreadyArchived, "already in the archive")
+		a.Close()
+		return nil
+	}
+	if err == nil {
+		err = a.Close()
+	}
+	if err != nil {
+		a.Close()
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		ac.app.FileProcessor().RecordAssetError(ctx, a.File, int64(a.FileSize), fileevent.ErrorFileAccess, err)
+		return ac.app.ProcessError(err)
+	}
+	// Asset successfully archived
+	ac.app.FileProcessor().RecordAssetProcessed(ctx, a.File, int64(a.FileSize), fileevent.ProcessedFileArchived)
+	if a.Trashed {
+		ac.app.FileProcessor().Logger().RecordWithSize(ctx, fileevent.ProcessedTrashed, a.File, int64(a.FileSize))
+	}
+	return nil
+}
+
+// trashedOnly tells if the source has been asked for the trashed assets only, like from-immich --from-trash
+func trashedOnly(adapter adapters.Reader) bool {
+	tr, ok := adapter.(adapters.TrashReader)
+	return ok && tr.TrashedOnly()
 }
@@ -0,0 +1,341 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/simulot/immich-go/adapters"
+	"github.com/simulot/immich-go/adapters/fromimmich"
+	"github.com/simulot/immich-go/app"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+	"github.com/spf13/cobra"
+)
+
+// groupsReader gives its groups, one asset per group
+type groupsReader struct {
+	assets []*assets.Asset
+}
+
+func (r groupsReader) Browse(ctx context.Context) chan *assets.Group {
+	c := make(chan *assets.Group)
+	go func() {
+		defer close(c)
+		for _, a := range r.assets {
+			select {
+			case c <- assets.NewGroup(assets.GroupByNone, a):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
+
+func TestTrashedOnly(t *testing.T) {
+	for _, tst := range []struct {
+		name    string
+		adapter adapters.Reader
+		want    bool
+	}{
+		{name: "from-immich --from-trash", adapter: &fromimmich.FromImmichCmd{OnlyTrashed: true}, want: true},
+		{name: "from-immich", adapter: &fromimmich.FromImmichCmd{}, want: false},
+		{name: "other source", adapter: groupsReader{}, want: false},
+	} {
+		t.Run(tst.name, func(t *testing.T) {
+			if got := trashedOnly(tst.adapter); got != tst.want {
+				t.Errorf("trashedOnly() = %v, want %v", got, tst.want)
+			}
+		})
+	}
+}
+
+func TestArchiveOnErrors(t *testing.T) {
+	// the files are missing from the source, each download fails
+	missing := func() []*assets.Asset {
+		fsys := fstest.MapFS{}
+		list := []*assets.Asset{}
+		for i := range 10 {
+			name := fmt.Sprintf("IMG_%03d.jpg", i)
+			a := &assets.Asset{
+				File:        fshelper.FSName(fsys, name),
+				CaptureDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			}
+			a.Base = name
+			list = append(list, a)
+		}
+		return list
+	}
+
+	for _, tst := range []struct {
+		name     string
+		onErrors string // the --on-errors flag, empty when not given
+		wantErr  bool
+		errors   int64 // the recorded errors
+	}{
+		{name: "default", wantErr: true, errors: archiveOnErrors + 1},
+		{name: "continue", onErrors: "continue", errors: 10},
+		{name: "stop", onErrors: "stop", wantErr: true, errors: 1},
+	} {
+		t.Run(tst.name, func(t *testing.T) {
+			root := &cobra.Command{Use: "immich-go"}
+			a := app.New(context.Background(), root)
+			a.Log().Logger = slog.New(slog.DiscardHandler)
+			a.RegisterFlags(root.PersistentFlags())
+			archive := NewArchiveCommand(context.Background(), a)
+			root.AddCommand(archive)
+			args := []string{"--write-to-folder", t.TempDir()}
+			if tst.onErrors != "" {
+				args = append(args, "--on-errors", tst.onErrors)
+			}
+			if err := archive.ParseFlags(args); err != nil {
+				t.Fatal(err)
+			}
+			if err := archive.PersistentPreRunE(archive, nil); err != nil {
+				t.Fatal(err)
+			}
+			if tst.onErrors == "" && a.OnErrors != archiveOnErrors {
+				t.Errorf("OnErrors = %v, want %d", a.OnErrors, archiveOnErrors)
+			}
+
+			ac := &ArchiveCmd{app: a, ArchivePath: t.TempDir(), ConcurrentDownloads: 1}
+			archive.SetContext(context.Background())
+			err := ac.Run(archive, groupsReader{assets: missing()})
+			if (err != nil) != tst.wantErr {
+				t.Fatalf("Run() error = %v, want an error: %v", err, tst.wantErr)
+			}
+			n := a.FileProcessor().Logger().TotalErrors()
+			if n != tst.errors {
+				t.Errorf("recorded errors = %d, want %d", n, tst.errors)
+			}
+		})
+	}
+}
+
+// sendAllReader sends all its groups without watching the context, like a source blocked on its send.
+// done is closed when its browse ends.
+type sendAllReader struct {
+	groups []*assets.Group
+	done   chan struct{}
+}
+
+func (r sendAllReader) Browse(ctx context.Context) chan *assets.Group {
+	c := make(chan *assets.Group)
+	go func() {
+		defer close(r.done)
+		defer close(c)
+		for _, g := range r.groups {
+			c <- g
+		}
+	}()
+	return c
+}
+
+func TestArchiveStopsBrowse(t *testing.T) {
+	t.Setenv("IMMICHGO_TEMPDIR", t.TempDir())
+	temp := filepath.Join(os.Getenv("IMMICHGO_TEMPDIR"), "immich-go", "temp")
+	fsys := fstest.MapFS{}
+	r := sendAllReader{done: make(chan struct{})}
+	for i := range 10 {
+		name := fmt.Sprintf("IMG_%03d.jpg", i)
+		a := &assets.Asset{File: fshelper.FSName(fsys, name), CaptureDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+		a.Base = name
+		if i > 0 {
+			// the first file is missing, its download fails. The others are read already
+			fsys[name] = &fstest.MapFile{Data: []byte(name)}
+			f, err := a.OpenFile()
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+		}
+		r.groups = append(r.groups, assets.NewGroup(assets.GroupByNone, a))
+	}
+
+	root := &cobra.Command{Use: "immich-go"}
+	a := app.New(context.Background(), root)
+	a.Log().Logger = slog.New(slog.DiscardHandler)
+	a.RegisterFlags(root.PersistentFlags())
+	archive := NewArchiveCommand(context.Background(), a)
+	root.AddCommand(archive)
+	if err := archive.ParseFlags([]string{"--write-to-folder", t.TempDir(), "--on-errors", "stop"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.PersistentPreRunE(archive, nil); err != nil {
+		t.Fatal(err)
+	}
+	ac := &ArchiveCmd{app: a, ArchivePath: t.TempDir(), ConcurrentDownloads: 1}
+	archive.SetContext(context.Background())
+
+	if err := ac.Run(archive, r); err == nil {
+		t.Fatal("Run() should fail on the missing file")
+	}
+	select {
+	case <-r.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the source is left blocked on its send")
+	}
+	if e, err := os.ReadDir(temp); err != nil || len(e) != 0 {
+		t.Errorf("temporary files left: %d, %v", len(e), err)
+	}
+}
+
+func TestArchiveAlbumFilters(t *testing.T) {
+	newAssets := func() []*assets.Asset {
+		fsys := fstest.MapFS{}
+		list := []*assets.Asset{}
+		for i := range 5 {
+			name := fmt.Sprintf("IMG_%03d.jpg", i)
+			fsys[name] = &fstest.MapFile{Data: []byte(name)}
+			a := &assets.Asset{File: fshelper.FSName(fsys, name), FileSize: len(name), CaptureDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			a.Base = name
+			if i < 2 {
+				a.Albums = []assets.Album{{Title: "Trip"}}
+			}
+			list = append(list, a)
+		}
+		return list
+	}
+
+	for _, tst := range []struct {
+		name                     string
+		flags                    []string
+		wantArchived, wantFilter int64
+		wantErr                  bool
+	}{
+		{name: "all", wantArchived: 5},
+		{name: "--no-album-only", flags: []string{"--no-album-only"}, wantArchived: 3, wantFilter: 2},
+		{name: "--in-album-only", flags: []string{"--in-album-only"}, wantArchived: 2, wantFilter: 3},
+		{name: "both", flags: []string{"--no-album-only", "--in-album-only"}, wantErr: true},
+	} {
+		t.Run(tst.name, func(t *testing.T) {
+			root := &cobra.Command{Use: "immich-go"}
+			a := app.New(context.Background(), root)
+			a.Log().Logger = slog.New(slog.DiscardHandler)
+			a.RegisterFlags(root.PersistentFlags())
+			archive := NewArchiveCommand(context.Background(), a)
+			root.AddCommand(archive)
+			if err := archive.ParseFlags(append([]string{"--write-to-folder", t.TempDir()}, tst.flags...)); err != nil {
+				t.Fatal(err)
+			}
+			err := archive.PersistentPreRunE(archive, nil)
+			if tst.wantErr {
+				if err == nil {
+					t.Fatal("the combination should be refused")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ac := &ArchiveCmd{app: a, ArchivePath: t.TempDir(), ConcurrentDownloads: 1}
+			ac.NoAlbumOnly, _ = archive.PersistentFlags().GetBool("no-album-only")
+			ac.InAlbumOnly, _ = archive.PersistentFlags().GetBool("in-album-only")
+			archive.SetContext(context.Background())
+			if err := ac.Run(archive, groupsReader{assets: newAssets()}); err != nil {
+				t.Fatal(err)
+			}
+			counts := a.FileProcessor().Logger().GetEventCounts()
+			if counts[fileevent.ProcessedFileArchived] != tst.wantArchived || counts[fileevent.DiscardedFiltered] != tst.wantFilter {
+				t.Errorf("archived %d, filtered %d, want %d and %d", counts[fileevent.ProcessedFileArchived], counts[fileevent.DiscardedFiltered], tst.wantArchived, tst.wantFilter)
+			}
+		})
+	}
+}
+
+// TestArchiveResume archives the assets, then resumes the archive after one file is deleted and one is corrupted
+func TestArchiveResume(t *testing.T) {
+	dest := t.TempDir()
+	newAssets := func() []*assets.Asset {
+		fsys := fstest.MapFS{}
+		list := []*assets.Asset{}
+		for i := range 3 {
+			name := fmt.Sprintf("IMG_%03d.jpg", i)
+			data := []byte("content of " + name)
+			sum := sha1.Sum(data)
+			fsys[name] = &fstest.MapFile{Data: data}
+			a := &assets.Asset{
+				File:        fshelper.FSName(fsys, name),
+				FileSize:    len(data),
+				Checksum:    base64.StdEncoding.EncodeToString(sum[:]),
+				CaptureDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			}
+			a.Base = name
+			list = append(list, a)
+		}
+		return list
+	}
+	archived := func(name string) string {
+		return filepath.Join(dest, "2024", "2024-01", name)
+	}
+	run := func(t *testing.T, resume, checksum bool) map[fileevent.Code]int64 {
+		root := &cobra.Command{Use: "immich-go"}
+		a := app.New(context.Background(), root)
+		a.Log().Logger = slog.New(slog.DiscardHandler)
+		a.RegisterFlags(root.PersistentFlags())
+		archive := NewArchiveCommand(context.Background(), a)
+		root.AddCommand(archive)
+		if err := archive.ParseFlags([]string{"--write-to-folder", dest}); err != nil {
+			t.Fatal(err)
+		}
+		if err := archive.PersistentPreRunE(archive, nil); err != nil {
+			t.Fatal(err)
+		}
+		ac := &ArchiveCmd{app: a, ArchivePath: dest, ConcurrentDownloads: 1, Resume: resume, ResumeChecksum: checksum}
+		archive.SetContext(context.Background())
+		if err := ac.Run(archive, groupsReader{assets: newAssets()}); err != nil {
+			t.Fatal(err)
+		}
+		return a.FileProcessor().Logger().GetEventCounts()
+	}
+	check := func(t *testing.T, counts map[fileevent.Code]int64, downloaded, skipped int64) {
+		t.Helper()
+		if counts[fileevent.ProcessedFileArchived] != downloaded || counts[fileevent.DiscardedAlreadyArchived] != skipped {
+			t.Errorf("downloaded %d, skipped %d, want %d and %d", counts[fileevent.ProcessedFileArchived], counts[fileevent.DiscardedAlreadyArchived], downloaded, skipped)
+		}
+	}
+
+	check(t, run(t, false, false), 3, 0)
+
+	// the same size, another content
+	if err := os.WriteFile(archived("IMG_001.jpg"), []byte("garbage of IMG_001.jpg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(archived("IMG_002.jpg")); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("size only", func(t *testing.T) {
+		// the deleted file is downloaded again, the corrupted one has the expected size and is kept
+		check(t, run(t, true, false), 1, 2)
+	})
+	t.Run("checksum", func(t *testing.T) {
+		// only the corrupted file is downloaded again
+		check(t, run(t, true, true), 1, 2)
+		b, err := os.ReadFile(archived("IMG_001.jpg"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "content of IMG_001.jpg" {
+			t.Errorf("the corrupted file isn't downloaded again: %q", b)
+		}
+	})
+
+	entries, err := os.ReadDir(filepath.Join(dest, "2024", "2024-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("the archive has %d files, want 3 without duplicates", len(entries))
+	}
+}
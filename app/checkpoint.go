@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+)
+
+// StatusInProgress is the status of the summaries written during the run, by the checkpoints and the status file
+const StatusInProgress = "in_progress"
+
+// checkpoints writes the summary of the run at each --checkpoint-interval
+type checkpoints struct {
+	command string
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// PrepareCheckpoints records the command for the --checkpoint-interval.
+// The checkpoints start with the file processor.
+func (app *Application) PrepareCheckpoints(cmd *cobra.Command) error {
+	if app.CheckpointInterval < 0 {
+		return fmt.Errorf("invalid value for --checkpoint-interval: %s, expected a positive duration", app.CheckpointInterval)
+	}
+	if app.CheckpointInterval == 0 {
+		return nil
+	}
+	app.checkpoints = &checkpoints{
+		command: cmd.CommandPath(),
+	}
+	return nil
+}
+
+// startCheckpoints writes a checkpoint at each tick, until CloseCheckpoints is called
+func (app *Application) startCheckpoints() {
+	if app.checkpoints == nil || app.checkpoints.stop != nil {
+		return
+	}
+	cp := app.checkpoints
+	cp.stop = make(chan struct{})
+	cp.done = make(chan struct{})
+	go func() {
+		defer close(cp.done)
+		ticker := time.NewTicker(app.CheckpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-cp.stop:
+				return
+			case <-ticker.C:
+				summary := app.countersSummary(cp.command, StatusInProgress, nil)
+				summary.Type = fileprocessor.SummaryCheckpoint
+				app.writeCheckpoint(summary)
+			}
+		}
+	}()
+}
+
+// writeCheckpoint gives the summary to the JSON log and the --summary-file.
+// The counters are cumulative: a checkpoint replaces the previous one, and the final summary replaces the last one.
+func (app *Application) writeCheckpoint(summary fileprocessor.RunSummary) {
+	log := app.Log()
+	if log.JSONFormat() {
+		log.Info(summary.Type, "summary", summary)
+	} else {
+		a := summary.Assets
+		log.Info(summary.Type, "status", summary.Status, "uploaded", summary.Uploaded, "processed", a.Processed, "discarded", a.Discarded, "errors", a.Errors, "pending", a.Pending)
+	}
+	if app.SummaryFile != "" {
+		if err := writeSummaryFile(app.SummaryFile, summary); err != nil {
+			log.Warn("can't write the checkpoint into the summary file", "file", app.SummaryFile, "error", err)
+		}
+	}
+}
+
+// CloseCheckpoints stops the checkpoints, and gives the final summary to the JSON log to end the series
+func (app *Application) CloseCheckpoints(cmd *cobra.Command, runErr error) {
+	cp := app.checkpoints
+	if cp == nil || cp.stop == nil {
+		return
+	}
+	close(cp.stop)
+	<-cp.done
+	if app.Log().JSONFormat() {
+		app.Log().Info(fileprocessor.SummaryFinal, "summary", app.RunSummary(cmd, runErr))
+	}
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assettracker"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/simulot/immich-go/internal/fshelper"
+	"github.com/spf13/cobra"
+)
+
+func TestCheckpoints(t *testing.T) {
+	cmd := &cobra.Command{Use: "upload"}
+	app := New(context.Background(), cmd)
+	buf := bytes.NewBuffer(nil)
+	app.log.format = "json"
+	app.log.setHandlers(buf, nil)
+	app.SummaryFile = filepath.Join(t.TempDir(), "summary.json")
+
+	read := func() fileprocessor.RunSummary {
+		t.Helper()
+		b, err := os.ReadFile(app.SummaryFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s fileprocessor.RunSummary
+		if err := json.Unmarshal(b, &s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	app.CheckpointInterval = -time.Second
+	if err := app.PrepareCheckpoints(cmd); err == nil {
+		t.Fatal("a negative interval should be rejected")
+	}
+	app.CheckpointInterval = 10 * time.Millisecond
+	if err := app.PrepareCheckpoints(cmd); err != nil {
+		t.Fatal(err)
+	}
+	processor := fileprocessor.New(assettracker.New(), fileevent.NewRecorder(app.log.Logger))
+	app.SetFileProcessor(processor)
+	processor.RecordNonAsset(context.Background(), fshelper.FSName(os.DirFS(t.TempDir()), "notes.txt"), 10, fileevent.DiscoveredUnsupported)
+
+	var s fileprocessor.RunSummary
+	for range 100 {
+		if _, err := os.Stat(app.SummaryFile); err == nil {
+			s = read()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s.Type != fileprocessor.SummaryCheckpoint || s.Status != StatusInProgress || s.Command != "upload" {
+		t.Errorf("unexpected checkpoint: %+v", s)
+	}
+
+	// the final summary replaces the last checkpoint, the counters aren't added twice
+	app.CloseCheckpoints(cmd, nil)
+	app.WriteSummaryFile(cmd, nil)
+	s = read()
+	if s.Type != fileprocessor.SummaryFinal || s.Status != "completed" {
+		t.Errorf("unexpected final summary: %+v", s)
+	}
+	if n := s.Events[fileevent.DiscoveredUnsupported.ID()].Count; n != 1 {
+		t.Errorf("unsupported files = %d, want 1", n)
+	}
+
+	// the JSON log gets the checkpoints, then the final summary
+	var types []string
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var r struct {
+			Msg     string                   `json:"msg"`
+			Summary fileprocessor.RunSummary `json:"summary"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Msg == r.Summary.Type {
+			types = append(types, r.Summary.Type)
+		}
+	}
+	if len(types) < 2 || types[0] != fileprocessor.SummaryCheckpoint || types[len(types)-1] != fileprocessor.SummaryFinal {
+		t.Errorf("unexpected records in the JSON log: %v", types)
+	}
+}
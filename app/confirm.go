@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// ErrNotConfirmed is returned when the user declines the changes
+var ErrNotConfirmed = errors.New("aborted, the changes are not confirmed")
+
+// Confirmation asks the user to confirm the changes before modifying the server.
+// Scripts skip the question with --yes.
+type Confirmation struct {
+	Yes bool // Don't ask for confirmation
+}
+
+func (c *Confirmation) RegisterFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(&c.Yes, "yes", "y", false, "Apply the changes to the server without asking for confirmation, required when the command isn't run in a terminal")
+}
+
+// Check tells if the confirmation can be asked. Call it before a long process, to fail early.
+// Without a terminal to ask, or when the output is JSON, the changes are refused unless --yes is given.
+func (c *Confirmation) Check(app *Application, cmd *cobra.Command) error {
+	if c.Yes {
+		return nil
+	}
+	if app.MachineReadable(cmd) {
+		return errors.New("can't ask for confirmation with a JSON output, use --yes to apply the changes")
+	}
+	if f, ok := cmd.InOrStdin().(*os.File); ok && !IsTerminal(f) {
+		return errors.New("can't ask for confirmation, the input isn't a terminal: use --yes to apply the changes")
+	}
+	return nil
+}
+
+// Confirm prints the summary of the changes, and asks the user to confirm them.
+func (c *Confirmation) Confirm(app *Application, cmd *cobra.Command, summary string) error {
+	if c.Yes {
+		return nil
+	}
+	if err := c.Check(app, cmd); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, summary)
+	fmt.Fprint(out, "Apply the changes? [y/N] ")
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return ErrNotConfirmed
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return ErrNotConfirmed
+}
+
+// IsTerminal tells if the file is a terminal. /dev/null is a character device, but not a terminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
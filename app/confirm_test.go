@@ -0,0 +1,53 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		yes     bool
+		format  string
+		input   string
+		wantErr bool
+		wantOut string
+	}{
+		{name: "yes flag", yes: true},
+		{name: "answer y", input: "y\n", wantOut: "2 stacks will be created"},
+		{name: "answer yes", input: "YES\n"},
+		{name: "answer n", input: "n\n", wantErr: true},
+		{name: "empty answer", input: "\n", wantErr: true},
+		{name: "no input", input: "", wantErr: true},
+		{name: "json mode", format: "json", input: "y\n", wantErr: true},
+		{name: "json mode with yes flag", format: "json", yes: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			app := New(context.Background(), cmd)
+			app.SetMachineOutput(cmd, func() bool { return tt.format == "json" })
+			out := bytes.NewBuffer(nil)
+			cmd.SetOut(out)
+			cmd.SetIn(strings.NewReader(tt.input))
+
+			c := Confirmation{Yes: tt.yes}
+			err := c.Confirm(app, cmd, "2 stacks will be created")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && tt.format == "" && !errors.Is(err, ErrNotConfirmed) {
+				t.Errorf("expected ErrNotConfirmed, got %v", err)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("the summary should be printed: %q", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/simulot/immich-go/app"
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fshelper"
+	"github.com/simulot/immich-go/internal/fshelper/osfs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// check results
+const (
+	statusPass = "pass"
+	statusWarn = "warn"
+	statusFail = "fail"
+	statusSkip = "skip"
+)
+
+// DoctorCmd runs non-destructive checks of the connection to the server
+type DoctorCmd struct {
+	// CLI flags
+	Format     string // text or json
+	WriteProbe bool   // Upload and delete a tiny asset to check the write permission
+
+	client app.Client
+}
+
+func (dc *DoctorCmd) RegisterFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&dc.Format, "format", "text", "Output format (text|json)")
+	flags.BoolVar(&dc.WriteProbe, "write-probe", false, "Check the write permission by uploading a tiny image, deleted right after")
+}
+
+// checkResult is the result of one check
+type checkResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// NewDoctorCommand adds the doctor command
+func NewDoctorCommand(ctx context.Context, a *app.Application) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor [flags]",
+		Short: "Check the connection to the server",
+		Long:  `Run non-destructive checks: server reachability, API key validity, server version, clock skew between the client and the server, and optionally the write permission.`,
+		Args:  cobra.NoArgs,
+	}
+
+	dc := &DoctorCmd{}
+	dc.RegisterFlags(cmd.Flags())
+	dc.client.RegisterFlags(cmd.Flags(), "")
+	a.SetMachineOutput(cmd, func() bool { return strings.EqualFold(dc.Format, "json") })
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error { //nolint:contextcheck
+		format := strings.ToLower(dc.Format)
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid value for --format: %q, expected text or json", dc.Format)
+		}
+
+		// a failed check is not a usage error
+		cmd.SilenceUsage = true
+		results := dc.run(cmd.Context(), a)
+		var err error
+		if format == "json" {
+			err = writeJSON(cmd.OutOrStdout(), results)
+		} else {
+			err = writeText(cmd.OutOrStdout(), results)
+		}
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			if r.Status == statusFail {
+				return errors.New("some checks have failed")
+			}
+		}
+		return nil
+	}
+	return cmd
+}
+
+// run runs the checks in sequence. The checks depending on a failed one are skipped.
+func (dc *DoctorCmd) run(ctx context.Context, a *app.Application) []checkResult {
+	results := []checkResult{}
+	add := func(name, status, detail string) {
+		results = append(results, checkResult{Name: name, Status: status, Detail: detail})
+	}
+	skipRest := func(names ...string) []checkResult {
+		for _, n := range names {
+			add(n, statusSkip, "previous check failed")
+		}
+		return results
+	}
+
+	if err := dc.client.Prepare(ctx, a); err != nil {
+		add("configuration", statusFail, err.Error())
+		return skipRest("server reachable", "API key", "server version", "clock skew", "write permission")
+	}
+
+	if err := dc.client.Immich.PingServer(ctx); err != nil {
+		add("server reachable", statusFail, err.Error())
+		return skipRest("API key", "server version", "clock skew", "write permission")
+	}
+	add("server reachable", statusPass, dc.client.Server)
+
+	user, err := dc.client.Immich.ValidateConnection(ctx)
+	if err != nil {
+		add("API key", statusFail, err.Error())
+		return skipRest("server version", "clock skew", "write permission")
+	}
+	add("API key", statusPass, "user "+user.Email)
+
+	if about, err := dc.client.Immich.GetAboutInfo(ctx); err != nil {
+		add("server version", statusFail, err.Error())
+	} else {
+		add("server version", statusPass, fmt.Sprintf("server %s, immich-go %s", about.Version, app.Version))
+	}
+
+	add(dc.clockSkew(ctx))
+
+	switch {
+	case !dc.WriteProbe:
+		add("write permission", statusSkip, "use --write-probe to check it")
+	case dc.client.DryRun:
+		add("write permission", statusSkip, "dry-run mode")
+	default:
+		add(dc.writeProbe(ctx))
+	}
+	return results
+}
+
+func (dc *DoctorCmd) clockSkew(ctx context.Context) (string, string, string) {
+	const name = "clock skew"
+	c, ok := dc.client.Immich.(immich.ImmichClockInterface)
+	if !ok {
+		return name, statusSkip, "not supported by the client"
+	}
+	skew, err := c.ClockSkew(ctx)
+	if err != nil {
+		return name, statusFail, err.Error()
+	}
+	if t := dc.client.ClockSkewThreshold; t > 0 && skew.Abs() > t {
+		return name, statusWarn, fmt.Sprintf("the server's clock differs by %s", skew)
+	}
+	return name, statusPass, skew.String()
+}
+
+// writeProbe uploads a tiny image, and deletes it right after.
+// An image already on the server is left untouched.
+func (dc *DoctorCmd) writeProbe(ctx context.Context) (string, string, string) {
+	const name = "write permission"
+
+	dir, err := os.MkdirTemp("", "immich-go-doctor")
+	if err != nil {
+		return name, statusFail, err.Error()
+	}
+	defer os.RemoveAll(dir)
+
+	// the pixel's color makes the image unique enough to not be a duplicate
+	now := time.Now()
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.RGBA{R: uint8(now.Nanosecond()), G: uint8(now.Nanosecond() >> 8), B: uint8(now.Nanosecond() >> 16), A: 255})
+	b := bytes.NewBuffer(nil)
+	if err := png.Encode(b, img); err != nil {
+		return name, statusFail, err.Error()
+	}
+	const fileName = "immich-go-doctor.png"
+	if err := os.WriteFile(filepath.Join(dir, fileName), b.Bytes(), 0o600); err != nil {
+		return name, statusFail, err.Error()
+	}
+
+	a := &assets.Asset{
+		File:             fshelper.FSName(osfs.DirFS(dir), fileName),
+		OriginalFileName: fileName,
+		FileSize:         b.Len(),
+		FileDate:         now,
+		CaptureDate:      now,
+	}
+	ar, err := dc.client.Immich.AssetUpload(ctx, a)
+	if err != nil {
+		return name, statusFail, err.Error()
+	}
+	if ar.Status == immich.UploadDuplicate {
+		return name, statusPass, "the probe image is already on the server, it is left untouched"
+	}
+	err = dc.client.Immich.DeleteAssets(ctx, []string{ar.ID}, true)
+	if err != nil {
+		return name, statusWarn, fmt.Sprintf("the probe image %s is uploaded, but can't be deleted: %s", ar.ID, err)
+	}
+	return name, statusPass, "probe image uploaded and deleted"
+}
+
+func writeJSON(w io.Writer, results []checkResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+func writeText(w io.Writer, results []checkResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		fmt.Fprintf(tw, "[%s]\t%s\t%s\n", strings.ToUpper(r.Status), r.Name, r.Detail)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// --dump-config values
+const (
+	DumpConfigText = "text"
+	DumpConfigJSON = "json"
+)
+
+// configValue is a flag of the --dump-config report
+type configValue struct {
+	Flag   string `json:"flag"`
+	Value  string `json:"value"`
+	Origin string `json:"origin"` // cli, environment, config file or default
+}
+
+// configDump is the --dump-config report
+type configDump struct {
+	Type       string        `json:"type"`
+	RunID      string        `json:"run_id,omitempty"`
+	Command    string        `json:"command"`
+	ConfigFile string        `json:"config_file,omitempty"`
+	Values     []configValue `json:"values"`
+}
+
+// maskedFlagValue gives the value of the flag safe to be displayed:
+// the API keys are masked, and the credentials of the URLs are redacted.
+func maskedFlagValue(flag *pflag.Flag) string {
+	val := flag.Value.String()
+	if isSecretKey(flag.Name) && len(val) > 4 {
+		return strings.Repeat("*", len(val)-4) + val[len(val)-4:]
+	}
+	return RedactURL(val)
+}
+
+// DumpConfiguration writes the effective configuration of the command when --dump-config is given,
+// with the origin of each value. The command is then replaced by a no-op to exit without doing any work.
+func (app *Application) DumpConfiguration(cmd *cobra.Command, w io.Writer) error {
+	switch app.DumpConfig {
+	case "":
+		return nil
+	case DumpConfigText, DumpConfigJSON:
+	default:
+		return fmt.Errorf("invalid value for --dump-config: %q, expected text or json", app.DumpConfig)
+	}
+
+	d := configDump{
+		Type:       "config",
+		RunID:      app.RunID,
+		Command:    cmd.CommandPath(),
+		ConfigFile: app.Config.GetConfigFile(),
+	}
+	visitFlags := func(flag *pflag.Flag) {
+		if flag.Name == "dump-config" {
+			return
+		}
+		d.Values = append(d.Values, configValue{
+			Flag:   flag.Name,
+			Value:  maskedFlagValue(flag),
+			Origin: app.Config.GetFlagOrigin(cmd, flag),
+		})
+	}
+	cmd.Flags().VisitAll(visitFlags)
+	cmd.PersistentFlags().VisitAll(visitFlags)
+
+	var err error
+	if app.DumpConfig == DumpConfigJSON {
+		err = json.NewEncoder(w).Encode(d)
+	} else {
+		err = writeConfigDump(w, d)
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd.RunE = nil
+	cmd.Run = func(cmd *cobra.Command, args []string) {}
+	return nil
+}
+
+// writeConfigDump writes the report as text
+func writeConfigDump(w io.Writer, d configDump) error {
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	printf("Command: %s\n", d.Command)
+	if d.ConfigFile != "" {
+		printf("Configuration file: %s\n", d.ConfigFile)
+	} else {
+		printf("Configuration file: none\n")
+	}
+	width := 0
+	for _, v := range d.Values {
+		width = max(width, len(v.Flag)+2)
+	}
+	for _, v := range d.Values {
+		printf("  %-*s %-14s %s\n", width, "--"+v.Flag, "("+v.Origin+")", v.Value)
+	}
+	return err
+}
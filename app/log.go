@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/phsym/console-slog"
@@ -23,24 +24,48 @@ import (
 )
 
 type Log struct {
-	Type  string `mapstructure:"type" json:"type" toml:"type" yaml:"type"`     // Log format : text|json
-	File  string `mapstructure:"file" json:"file" toml:"file" yaml:"file"`     // Log file name
-	Level string `mapstructure:"level" json:"level" toml:"level" yaml:"level"` // Indicate the log level (string)
+	Type   string `mapstructure:"type" json:"type" toml:"type" yaml:"type"`         // Log format : text|json
+	Format string `mapstructure:"format" json:"format" toml:"format" yaml:"format"` // Log format : text|json, follows the command output when not set
+	File   string `mapstructure:"file" json:"file" toml:"file" yaml:"file"`         // Log file name
+	Level  string `mapstructure:"level" json:"level" toml:"level" yaml:"level"`     // Indicate the log level (string)
 
-	*slog.Logger             // Logger
-	sLevel        slog.Level // the log level value
-	mainWriter    io.Writer  // the log writer to file
+	JSONLevel string `mapstructure:"json_level" json:"json_level" toml:"json_level" yaml:"json_level"` // Log level of the JSON log, follows Level when not set
+
+	NoBanner bool `mapstructure:"no_banner" json:"no_banner" toml:"no_banner" yaml:"no_banner"` // Don't display the banner
+
+	Syslog        string `mapstructure:"syslog" json:"syslog" toml:"syslog" yaml:"syslog"`                                 // Syslog facility receiving the run summary
+	SyslogAddress string `mapstructure:"syslog_address" json:"syslog_address" toml:"syslog_address" yaml:"syslog_address"` // Remote syslog server, the local daemon when not set
+	SyslogAll     bool   `mapstructure:"syslog_all" json:"syslog_all" toml:"syslog_all" yaml:"syslog_all"`                 // Send all the log to syslog, not only the summary
+
+	*slog.Logger              // Logger
+	sLevel        slog.Level  // the log level value
+	jsonLevel     *slog.Level // the level of the JSON log, nil to follow sLevel
+	mainWriter    io.Writer   // the log writer to file
+	stopRotation  func()      // stops reopening the log file on SIGHUP, nil when not watched
 	consoleWriter io.Writer
+	msgWriter     io.Writer // where the messages are printed, os.Stderr when the output is machine readable
+	syslog        syslogWriter
 
 	apiTracer      *httptrace.Tracer
 	apiTraceWriter *os.File
 	apiTraceName   string
+
+	suppressed atomic.Int64 // number of log records dropped by the FilteredHandler
+
+	format string // the format of the log, resolved from --log-format, the command output and --log-type
+	runID  string // added to each record of the JSON log
 }
 
 func (log *Log) RegisterFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&log.Level, "log-level", "INFO", "Log level (DEBUG|INFO|WARN|ERROR), default INFO")
 	flags.StringVarP(&log.File, "log-file", "l", "", "Write log messages into the file")
 	flags.StringVar(&log.Type, "log-type", "text", "Log formatted  as text of JSON file")
+	flags.StringVar(&log.Format, "log-format", "", "Format of the log (text|json). By default, json when the command output is JSON, --log-type otherwise")
+	flags.StringVar(&log.JSONLevel, "json-log-level", "", "Log level of the JSON log (DEBUG|INFO|WARN|ERROR), default --log-level. The console messages aren't affected")
+	flags.BoolVar(&log.NoBanner, "no-banner", false, "Don't display the banner")
+	flags.StringVar(&log.Syslog, "syslog", "", "Send the run summary to syslog with this facility (user|daemon|local0..local7)")
+	flags.StringVar(&log.SyslogAddress, "syslog-address", "", "Syslog server as host:port, udp by default, or tcp://host:port. The local syslog daemon when not set")
+	flags.BoolVar(&log.SyslogAll, "syslog-all", false, "Send all the log to syslog, not only the run summary")
 }
 
 // DefaultLogFile returns the default log file path
@@ -65,14 +90,16 @@ func (log *Log) OpenLogFile() error {
 			if err != nil {
 				return err
 			}
-			w, err = os.OpenFile(log.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o664)
+			f, err := openLogFile(log.File)
 			if err != nil {
 				return err
 			}
+			w = f
 			err = log.sLevel.UnmarshalText([]byte(strings.ToUpper(log.Level)))
 			if err != nil {
 				return err
 			}
+			log.stopRotation = log.watchLogRotation()
 			log.Message("Log file: %s", log.File)
 		}
 	} else {
@@ -87,7 +114,13 @@ func (log *Log) Open(ctx context.Context, cmd *cobra.Command, app *Application)
 	for c := cmd; c != nil; c = c.Parent() {
 		// no log, nor banner for those commands
 		switch c.Name() {
-		case "version", "completion":
+		case "version", "completion", "status":
+			return nil
+		case "doctor":
+			// no banner nor flags dump, the log goes only into the --log-file when given
+			if log.File == "" {
+				log.setHandlers(io.Discard, nil)
+			}
 			return nil
 		}
 		if cmd.Flags().Changed("--help") {
@@ -95,8 +128,31 @@ func (log *Log) Open(ctx context.Context, cmd *cobra.Command, app *Application)
 		}
 	}
 
-	fmt.Println(Banner())
-	err := log.OpenLogFile()
+	machineOutput := app.MachineReadable(cmd)
+	format, err := log.logFormat(machineOutput)
+	if err != nil {
+		return err
+	}
+	log.format = format
+	log.runID = app.RunID
+	if log.JSONLevel != "" {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(strings.ToUpper(log.JSONLevel))); err != nil {
+			return fmt.Errorf("invalid value for --json-log-level: %q, expected DEBUG, INFO, WARN or ERROR", log.JSONLevel)
+		}
+		log.jsonLevel = &l
+	}
+	if err := log.openSyslog(); err != nil {
+		return err
+	}
+
+	// no banner when not wanted, and the messages go to stderr when the command output is machine readable
+	if machineOutput {
+		log.msgWriter = os.Stderr
+	} else if !log.NoBanner {
+		fmt.Println(Banner())
+	}
+	err = log.OpenLogFile()
 	if err != nil {
 		return err
 	}
@@ -111,6 +167,7 @@ func (log *Log) Open(ctx context.Context, cmd *cobra.Command, app *Application)
 	}
 
 	log.Info("Running environment:", "architecture", runtime.GOARCH, "os", runtime.GOOS)
+	log.Info("", "Run ID", app.RunID)
 
 	cmdStack := []string{cmd.Name()}
 	for c := cmd.Parent(); c != nil; c = c.Parent() {
@@ -121,11 +178,7 @@ func (log *Log) Open(ctx context.Context, cmd *cobra.Command, app *Application)
 	log.Info("Flags:")
 	visitFlags := func(flag *pflag.Flag) {
 		origin := app.Config.GetFlagOrigin(cmd, flag)
-		val := flag.Value.String()
-		if strings.Contains(flag.Name, "api-key") && len(val) > 4 {
-			val = strings.Repeat("*", len(val)-4) + val[len(val)-4:]
-		}
-		log.Info("", "--"+flag.Name, val, "origin", origin)
+		log.Info("", "--"+flag.Name, maskedFlagValue(flag), "origin", origin)
 	}
 	cmd.Flags().VisitAll(visitFlags)
 	cmd.PersistentFlags().VisitAll(visitFlags)
@@ -142,6 +195,45 @@ func (log *Log) Open(ctx context.Context, cmd *cobra.Command, app *Application)
 	return nil
 }
 
+// SetMachineOutput declares how the command tells if its standard output is read by a program, like a JSON output.
+// The subcommands share the declaration of their parent.
+func (app *Application) SetMachineOutput(cmd *cobra.Command, isMachine func() bool) {
+	if app.machineOutputs == nil {
+		app.machineOutputs = map[*cobra.Command]func() bool{}
+	}
+	app.machineOutputs[cmd] = isMachine
+}
+
+// MachineReadable tells if the command writes on the standard output for a program:
+// the log is then JSON, and the messages go to the error output.
+func (app *Application) MachineReadable(cmd *cobra.Command) bool {
+	if app.DumpConfig == DumpConfigJSON {
+		return true
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if isMachine, ok := app.machineOutputs[c]; ok {
+			return isMachine()
+		}
+	}
+	return false
+}
+
+// logFormat gives the format of the log: --log-format when given,
+// json when the command output is JSON, and --log-type otherwise.
+func (log *Log) logFormat(machineOutput bool) (string, error) {
+	switch strings.ToLower(log.Format) {
+	case "text", "json":
+		return strings.ToLower(log.Format), nil
+	case "":
+	default:
+		return "", fmt.Errorf("invalid value for --log-format: %q, expected text or json", log.Format)
+	}
+	if machineOutput {
+		return "json", nil
+	}
+	return strings.ToLower(log.Type), nil
+}
+
 /*
 func replaceAttr(groups []string, a slog.Attr) slog.Attr {
 	if a.Key == slog.LevelKey {
@@ -152,14 +244,31 @@ func replaceAttr(groups []string, a slog.Attr) slog.Attr {
 }
 */
 
+// JSONFormat tells if the log is written as JSON records
+func (log *Log) JSONFormat() bool {
+	format := log.format
+	if format == "" {
+		format = log.Type
+	}
+	return strings.EqualFold(format, "json")
+}
+
 func (log *Log) setHandlers(file, con io.Writer) {
 	handlers := []slog.Handler{}
 
 	log.mainWriter = file
-	if log.Type == "JSON" {
-		handlers = append(handlers, slog.NewJSONHandler(log.mainWriter, &slog.HandlerOptions{
-			Level: log.sLevel,
-		}))
+	if log.JSONFormat() {
+		var level slog.Leveler = log.sLevel
+		if log.jsonLevel != nil {
+			level = *log.jsonLevel
+		}
+		var h slog.Handler = slog.NewJSONHandler(log.mainWriter, &slog.HandlerOptions{
+			Level: level,
+		})
+		if log.runID != "" {
+			h = h.WithAttrs([]slog.Attr{slog.String("run_id", log.runID)})
+		}
+		handlers = append(handlers, h)
 	} else {
 		handlers = append(handlers, console.NewHandler(log.mainWriter, &console.HandlerOptions{
 			// ReplaceAttr: replaceAttr,
@@ -181,7 +290,16 @@ func (log *Log) setHandlers(file, con io.Writer) {
 		}))
 	}
 
-	log.Logger = slog.New(NewFilteredHandler(slogmulti.Fanout(handlers...)))
+	if log.syslog != nil && log.SyslogAll {
+		handlers = append(handlers, newSyslogHandler(log.syslog, log.sLevel))
+	}
+
+	log.Logger = slog.New(NewFilteredHandler(slogmulti.Fanout(handlers...), &log.suppressed))
+}
+
+// SuppressedRecords returns the number of context canceled error records dropped so far
+func (log *Log) SuppressedRecords() int64 {
+	return log.suppressed.Load()
 }
 
 func (log *Log) SetLogWriter(w io.Writer) *slog.Logger {
@@ -189,9 +307,21 @@ func (log *Log) SetLogWriter(w io.Writer) *slog.Logger {
 	return log.Logger
 }
 
+// MessageWriter gives where the messages are printed
+func (log *Log) MessageWriter() io.Writer {
+	if log.msgWriter != nil {
+		return log.msgWriter
+	}
+	return os.Stdout
+}
+
 func (log *Log) Message(msg string, values ...any) {
 	s := fmt.Sprintf(msg, values...)
-	fmt.Println(s)
+	if log.msgWriter != nil {
+		fmt.Fprintln(log.msgWriter, s)
+	} else {
+		fmt.Println(s)
+	}
 	if log.Logger != nil {
 		log.Info(s)
 	}
@@ -203,6 +333,9 @@ func (log *Log) Close(ctx context.Context, cmd *cobra.Command, app *Application)
 		return nil
 	}
 	debugfiles.ReportTrackedFiles()
+	if n := log.SuppressedRecords(); n > 0 && log.Logger != nil {
+		log.Info("context canceled errors dropped from the log", "suppressed_log_records", n)
+	}
 	if log.File != "" {
 		log.Message("Check the log file: %s", log.File)
 	}
@@ -212,6 +345,10 @@ func (log *Log) Close(ctx context.Context, cmd *cobra.Command, app *Application)
 		log.apiTraceWriter.Close()
 	}
 
+	if log.stopRotation != nil {
+		log.stopRotation()
+		log.stopRotation = nil
+	}
 	if closer, ok := log.mainWriter.(io.Closer); ok {
 		return closer.Close()
 	}
@@ -243,15 +380,18 @@ func (log *Log) APITracer() *httptrace.Tracer {
 // FilteredHandler filterslog messages and filters out context canceled errors
 // if err, ok := a.Value.Any().(error); ok {
 // if errors.Is(err, context.Canceled) {
+// The dropped records are counted in suppressed, when not nil.
 type FilteredHandler struct {
-	handler slog.Handler
+	handler    slog.Handler
+	suppressed *atomic.Int64
 }
 
 var _ slog.Handler = (*FilteredHandler)(nil)
 
-func NewFilteredHandler(handler slog.Handler) slog.Handler {
+func NewFilteredHandler(handler slog.Handler, suppressed *atomic.Int64) slog.Handler {
 	return &FilteredHandler{
-		handler: handler,
+		handler:    handler,
+		suppressed: suppressed,
 	}
 }
 
@@ -274,6 +414,9 @@ func (h *FilteredHandler) Handle(ctx context.Context, r slog.Record) error {
 			return true
 		})
 		if !keepMe {
+			if h.suppressed != nil {
+				h.suppressed.Add(1)
+			}
 			return nil
 		}
 	}
@@ -282,9 +425,9 @@ func (h *FilteredHandler) Handle(ctx context.Context, r slog.Record) error {
 }
 
 func (h *FilteredHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &FilteredHandler{handler: h.handler.WithAttrs(attrs)}
+	return &FilteredHandler{handler: h.handler.WithAttrs(attrs), suppressed: h.suppressed}
 }
 
 func (h *FilteredHandler) WithGroup(name string) slog.Handler {
-	return &FilteredHandler{handler: h.handler.WithGroup(name)}
+	return &FilteredHandler{handler: h.handler.WithGroup(name), suppressed: h.suppressed}
 }
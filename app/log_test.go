@@ -0,0 +1,121 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestFilteredHandlerSuppressed(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	log := &Log{}
+	log.setHandlers(buf, nil)
+
+	ctx := context.Background()
+	log.With("key", "value").Error("canceled", "err", fmt.Errorf("upload: %w", context.Canceled))
+	log.Error("canceled", "err", context.Canceled)
+	log.Error("real error", "err", errors.New("boom"))
+	log.Log(ctx, slog.LevelWarn, "warning", "err", context.Canceled)
+
+	if got := log.SuppressedRecords(); got != 2 {
+		t.Errorf("SuppressedRecords() = %d, want 2", got)
+	}
+	if strings.Contains(buf.String(), "ERR canceled") {
+		t.Errorf("canceled errors should not be logged: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "real error") {
+		t.Errorf("real errors should be logged: %s", buf.String())
+	}
+}
+
+func TestLogFormat(t *testing.T) {
+	tests := []struct {
+		format, logType, output string
+		want                    string
+		wantErr                 bool
+	}{
+		{format: "", logType: "text", output: "text", want: "text"},
+		{format: "", logType: "text", output: "json", want: "json"},
+		{format: "", logType: "JSON", output: "text", want: "json"},
+		{format: "text", logType: "text", output: "json", want: "text"},
+		{format: "JSON", logType: "text", output: "text", want: "json"},
+		{format: "xml", logType: "text", output: "text", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.logType+"/"+tt.output, func(t *testing.T) {
+			log := &Log{Format: tt.format, Type: tt.logType}
+			got, err := log.logFormat(tt.output == "json")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	buf := bytes.NewBuffer(nil)
+	log := &Log{format: "json"}
+	log.setHandlers(buf, nil)
+	log.Info("hello")
+	if !strings.HasPrefix(buf.String(), "{") {
+		t.Errorf("the log isn't JSON: %s", buf.String())
+	}
+}
+
+func TestMachineReadable(t *testing.T) {
+	root := &cobra.Command{Use: "immich-go"}
+	parent := &cobra.Command{Use: "archive"}
+	child := &cobra.Command{Use: "from-folder"}
+	other := &cobra.Command{Use: "stack"}
+	root.AddCommand(parent, other)
+	parent.AddCommand(child)
+
+	app := New(context.Background(), root)
+	format := "text"
+	app.SetMachineOutput(parent, func() bool { return format == "json" })
+
+	if app.MachineReadable(child) {
+		t.Error("the text output isn't machine readable")
+	}
+	format = "json"
+	if !app.MachineReadable(child) {
+		t.Error("the subcommand should share the declaration of its parent")
+	}
+	if app.MachineReadable(other) {
+		t.Error("a command without declaration isn't machine readable")
+	}
+	app.DumpConfig = DumpConfigJSON
+	if !app.MachineReadable(other) {
+		t.Error("--dump-config=json is machine readable")
+	}
+}
+
+func TestJSONLogLevel(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	warn := slog.LevelWarn
+	log := &Log{format: "json", sLevel: slog.LevelInfo, jsonLevel: &warn}
+	log.setHandlers(buf, nil)
+	log.Info("flag dump")
+	log.Warn("something wrong")
+	if strings.Contains(buf.String(), "flag dump") {
+		t.Errorf("the INFO records should be filtered out: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "something wrong") {
+		t.Errorf("the WARN records should be logged: %s", buf.String())
+	}
+
+	buf.Reset()
+	log = &Log{format: "json", sLevel: slog.LevelInfo}
+	log.setHandlers(buf, nil)
+	log.Info("flag dump")
+	if !strings.Contains(buf.String(), "flag dump") {
+		t.Errorf("the JSON log should follow --log-level by default: %s", buf.String())
+	}
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// logFile is the log file, reopened by its name on demand, to follow a rotation by logrotate
+type logFile struct {
+	mu   sync.Mutex
+	name string
+	f    *os.File
+}
+
+func openLogFile(name string) (*logFile, error) {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o664)
+	if err != nil {
+		return nil, err
+	}
+	return &logFile{name: name, f: f}, nil
+}
+
+func (l *logFile) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return 0, os.ErrClosed
+	}
+	return l.f.Write(b)
+}
+
+// Reopen opens the file by its name again, and closes the previous one.
+// The previous file is kept when the new one can't be opened. A closed log isn't reopened.
+func (l *logFile) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return os.ErrClosed
+	}
+	f, err := os.OpenFile(l.name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o664)
+	if err != nil {
+		return err
+	}
+	old := l.f
+	l.f = f
+	return old.Close()
+}
+
+func (l *logFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// reopenLogFile reopens the log file after its rotation, and logs it in the new file
+func (log *Log) reopenLogFile() {
+	f, ok := log.mainWriter.(*logFile)
+	if !ok {
+		return
+	}
+	err := f.Reopen()
+	switch {
+	case errors.Is(err, os.ErrClosed):
+		// the run is over
+	case err != nil:
+		log.Error("can't reopen the log file", "file", f.name, "error", err)
+	default:
+		// at WARN, to be kept with --log-level=WARN too
+		log.Warn("log file reopened", "file", f.name)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows || plan9
+
+package app
+
+// watchLogRotation does nothing: SIGHUP isn't available on this platform
+func (log *Log) watchLogRotation() func() {
+	return func() {}
+}
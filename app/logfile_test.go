@@ -0,0 +1,69 @@
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "immich-go.log")
+	f, err := openLogFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := &Log{sLevel: slog.LevelWarn}
+	log.setHandlers(f, nil)
+	defer f.Close()
+
+	log.Warn("before rotation")
+	// logrotate renames the file, then sends SIGHUP
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	log.Warn("rotated")
+	log.reopenLogFile()
+	log.Warn("after rotation")
+
+	old, err := os.ReadFile(name + ".1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(old), "before rotation") || !strings.Contains(string(old), "rotated") || strings.Contains(string(old), "after rotation") {
+		t.Errorf("unexpected content of the rotated file:\n%s", old)
+	}
+	current, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(current), "log file reopened") || !strings.Contains(string(current), "after rotation") {
+		t.Errorf("unexpected content of the new file:\n%s", current)
+	}
+}
+
+func TestLogFileReopenAfterClose(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "immich-go.log")
+	f, err := openLogFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reopen(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Reopen after Close: %v, want os.ErrClosed", err)
+	}
+	if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the closed log file is opened again: %v", err)
+	}
+	if _, err := f.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write after Close: %v, want os.ErrClosed", err)
+	}
+}
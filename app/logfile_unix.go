@@ -0,0 +1,25 @@
+//go:build !windows && !plan9
+
+package app
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchLogRotation reopens the log file when the process receives SIGHUP, like logrotate sends after renaming it.
+// It returns the function stopping the watch.
+func (log *Log) watchLogRotation() func() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	go func() {
+		for range c {
+			log.reopenLogFile()
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(c)
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	memorySampling  = 500 * time.Millisecond // interval between the samples of the memory used
+	memoryResumePct = 90                     // WaitForMemory returns when the memory drops below this percentage of the limit
+)
+
+// memoryMonitor samples the memory used by the process, to report its peak
+// and to throttle the work near the --max-memory limit.
+type memoryMonitor struct {
+	peak      atomic.Uint64
+	throttled atomic.Int64 // number of times the work has waited for the memory
+	warned    atomic.Bool
+	alone     atomic.Bool // the memory has stayed over the limit without any running task
+}
+
+// memoryInUse gives the memory obtained from the system by the Go runtime, minus the memory given back to it.
+// It's close to the resident size of the process.
+func memoryInUse() uint64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	total, released := samples[0].Value.Uint64(), samples[1].Value.Uint64()
+	if released > total {
+		return 0
+	}
+	return total - released
+}
+
+// sample records the memory in use, and gives it
+func (m *memoryMonitor) sample() uint64 {
+	used := memoryInUse()
+	for {
+		peak := m.peak.Load()
+		if used <= peak || m.peak.CompareAndSwap(peak, used) {
+			return used
+		}
+	}
+}
+
+// StartMemoryMonitor applies the --max-memory limit to the Go runtime, and samples the memory
+// used by the process until the context is done.
+// With the limit, the garbage collector runs more often when the memory in use approaches it.
+func (app *Application) StartMemoryMonitor(ctx context.Context) {
+	if app.MaxMemory > 0 {
+		debug.SetMemoryLimit(int64(app.MaxMemory))
+	}
+	app.memory.sample()
+	go func() {
+		ticker := time.NewTicker(memorySampling)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				app.memory.sample()
+			}
+		}
+	}()
+}
+
+// PeakMemory gives the highest memory used by the process during the run
+func (app *Application) PeakMemory() uint64 {
+	app.memory.sample()
+	return app.memory.peak.Load()
+}
+
+// MemoryThrottled gives the number of times the work has waited for the memory to drop under --max-memory
+func (app *Application) MemoryThrottled() int64 {
+	return app.memory.throttled.Load()
+}
+
+// WaitForMemory holds the caller while the memory used is over the --max-memory limit,
+// to let the running tasks finish and release their memory before starting new ones.
+// It returns when the memory drops under 90% of the limit, or when running gives 0: the memory
+// is held by something else than the tasks, and they continue one at a time.
+func (app *Application) WaitForMemory(ctx context.Context, running func() int) {
+	if app.MaxMemory <= 0 {
+		return
+	}
+	limit := uint64(app.MaxMemory)
+	if app.memory.sample() <= limit {
+		return
+	}
+	app.memory.throttled.Add(1)
+	if !app.memory.warned.Swap(true) {
+		app.Log().Warn("the memory used is over --max-memory, the new tasks wait for the running ones to finish", "max-memory", app.MaxMemory.String())
+	}
+	runtime.GC()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if app.memory.sample() <= limit*memoryResumePct/100 {
+				return
+			}
+			if running() == 0 {
+				if !app.memory.alone.Swap(true) {
+					app.Log().Warn("the memory used stays over --max-memory without any running task, the tasks continue one at a time", "max-memory", app.MaxMemory.String())
+				}
+				return
+			}
+		}
+	}
+}
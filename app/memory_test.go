@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryMonitor(t *testing.T) {
+	log := &Log{}
+	log.setHandlers(io.Discard, nil)
+	a := &Application{log: log}
+	if a.PeakMemory() == 0 {
+		t.Fatal("PeakMemory() should give the memory used")
+	}
+
+	// no limit, no wait
+	start := time.Now()
+	a.WaitForMemory(context.Background(), func() int { return 1 })
+	if time.Since(start) > time.Second || a.MemoryThrottled() != 0 {
+		t.Error("WaitForMemory() should not wait without --max-memory")
+	}
+
+	// a canceled context stops the wait
+	a.MaxMemory = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.WaitForMemory(ctx, func() int { return 1 })
+	if a.MemoryThrottled() != 1 {
+		t.Errorf("MemoryThrottled() = %d, want 1", a.MemoryThrottled())
+	}
+
+	// over the limit, the wait lasts until no task is running
+	var running atomic.Int64
+	running.Store(2)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		running.Store(0)
+	}()
+	start = time.Now()
+	a.WaitForMemory(context.Background(), func() int { return int(running.Load()) })
+	if d := time.Since(start); d < 300*time.Millisecond || d > 10*time.Second {
+		t.Errorf("WaitForMemory() waited %s, want until the tasks end", d)
+	}
+	if a.MemoryThrottled() != 2 {
+		t.Errorf("MemoryThrottled() = %d, want 2", a.MemoryThrottled())
+	}
+}
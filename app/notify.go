@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// Notify holds the settings of the completion notification
+type Notify struct {
+	Webhook string // URL receiving the run summary
+	On      string // failure or always
+}
+
+func (n *Notify) RegisterFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&n.Webhook, "notify-webhook", "", "POST the run summary as JSON to this URL when the run completes")
+	flags.StringVar(&n.On, "notify-on", "always", "When to call the notification webhook (failure|always)")
+}
+
+// Validate checks the notification settings
+func (n *Notify) Validate() error {
+	switch n.On {
+	case "failure", "always":
+		return nil
+	}
+	return fmt.Errorf("invalid value for --notify-on: %q, expected failure or always", n.On)
+}
+
+// RunStatus gives the final status of a run from its error.
+// The log, the report, the summary, the status file and the webhook all use it.
+func RunStatus(err error) string {
+	switch {
+	case err == nil:
+		return "completed"
+	case errors.Is(err, ErrMaxErrors):
+		return "aborted, too many errors"
+	case errors.Is(err, ErrNotEnoughSpace):
+		return "aborted, not enough space on the server"
+	case errors.Is(err, context.Canceled):
+		return "interrupted"
+	case errors.Is(err, ErrAuthenticationFailed):
+		return "authentication failed"
+	default:
+		return "failed"
+	}
+}
+
+// RunSummary builds the summary of the run, completed with the application's counters.
+// The JSON outputs and the text report of the upload are built from it.
+func (app *Application) RunSummary(cmd *cobra.Command, runErr error) fileprocessor.RunSummary {
+	summary := app.countersSummary(cmd.CommandPath(), RunStatus(runErr), runErr)
+	summary.Type = fileprocessor.SummaryFinal
+	summary.ManifestFile = app.manifestFile
+	summary.CreatedAlbums = app.albums
+	summary.Visibility = app.visibility
+	summary.Limit = app.limit
+	summary.DuplicateSetsFound = app.dupSets
+	summary.Storage = app.storage
+	summary.AlbumCovers = app.albumCovers
+	summary.Stacks = app.stacks
+	summary.BlockedRequests = app.blocked
+	summary.InvalidMedia = app.invalidMedia
+	summary.MergedAlbums = app.mergedAlbums
+	if skew, ok := app.ClockSkew(); ok {
+		summary.ClockSkew = skew.String()
+	}
+	return summary
+}
+
+// countersSummary builds the summary from the counters that can be read while the run goes on
+func (app *Application) countersSummary(command string, status string, runErr error) fileprocessor.RunSummary {
+	summary := app.processor.RunSummary(command, status, runErr)
+	summary.RunID = app.RunID
+	summary.ConfigFile = app.Config.GetConfigFile()
+	summary.SuppressedLogRecords = app.Log().SuppressedRecords()
+	summary.PeakMemory = app.PeakMemory()
+	summary.MemoryThrottled = app.MemoryThrottled()
+	return summary
+}
+
+// NotifyCompletion posts the run summary to the notification webhook.
+// A failing notification is logged but doesn't change the result of the run.
+func (app *Application) NotifyCompletion(cmd *cobra.Command, runErr error) {
+	if app.Notify.Webhook == "" || app.processor == nil {
+		return
+	}
+	if app.Notify.On == "failure" && runErr == nil {
+		return
+	}
+
+	body, err := json.Marshal(app.RunSummary(cmd, runErr))
+	if err != nil {
+		app.Log().Warn("can't encode the run summary", "error", err)
+		return
+	}
+
+	// The run's context may be canceled already
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, app.Notify.Webhook, bytes.NewReader(body))
+	if err != nil {
+		app.Log().Warn("can't call the notification webhook", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		app.Log().Warn("can't call the notification webhook", "error", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		app.Log().Warn("the notification webhook returned an error", "status", resp.Status)
+		return
+	}
+	app.Log().Info("notification sent", "webhook", app.Notify.Webhook)
+}
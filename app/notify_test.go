@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/simulot/immich-go/internal/assettracker"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+)
+
+func TestNotifyCompletion(t *testing.T) {
+	var got []fileprocessor.RunSummary
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var s fileprocessor.RunSummary
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			t.Errorf("can't decode the payload: %v", err)
+		}
+		got = append(got, s)
+	}))
+	defer server.Close()
+
+	cmd := &cobra.Command{Use: "upload"}
+	app := New(context.Background(), cmd)
+	app.log.Logger = slog.New(slog.DiscardHandler)
+	app.SetFileProcessor(fileprocessor.New(assettracker.New(), fileevent.NewRecorder(app.log.Logger)))
+	app.Notify = Notify{Webhook: server.URL, On: "failure"}
+
+	app.NotifyCompletion(cmd, nil)
+	if len(got) != 0 {
+		t.Fatalf("--notify-on=failure: a completed run is notified")
+	}
+	app.NotifyCompletion(cmd, errors.New("failed"))
+	app.Notify.On = "always"
+	app.NotifyCompletion(cmd, nil)
+	if len(got) != 2 {
+		t.Fatalf("%d notifications, want 2", len(got))
+	}
+	// the payload is the final summary, with the vocabulary of the summary file
+	for i, want := range []string{"failed", "completed"} {
+		if got[i].Type != fileprocessor.SummaryFinal || got[i].Status != want || got[i].Command != "upload" {
+			t.Errorf("notification %d: type %q, status %q, command %q", i, got[i].Type, got[i].Status, got[i].Command)
+		}
+	}
+}
@@ -2,12 +2,14 @@ package root
 
 import (
 	"context"
-	"fmt"
-	"os"
 
+	"github.com/google/uuid"
 	"github.com/simulot/immich-go/app"
+	"github.com/simulot/immich-go/app/albums"
 	"github.com/simulot/immich-go/app/archive"
+	"github.com/simulot/immich-go/app/doctor"
 	"github.com/simulot/immich-go/app/stack"
+	"github.com/simulot/immich-go/app/status"
 	"github.com/simulot/immich-go/app/upload"
 	"github.com/simulot/immich-go/app/version"
 	"github.com/spf13/cobra"
@@ -38,10 +40,13 @@ func RootImmichGoCommand(ctx context.Context) (*cobra.Command, *app.Application)
 
 	// Add all subcommands to the root command
 	cmd.AddCommand(
-		version.NewVersionCommand(ctx, a), // Version command to display app version
-		upload.NewUploadCommand(ctx, a),   // Upload command for uploading assets
-		archive.NewArchiveCommand(ctx, a), // Archive command for archiving assets
-		stack.NewStackCommand(ctx, a),     // Stack command for managing stacks
+		version.NewVersionCommand(ctx, a),   // Version command to display app version
+		upload.NewUploadCommand(ctx, a),     // Upload command for uploading assets
+		archive.NewArchiveCommand(ctx, a),   // Archive command for archiving assets
+		stack.NewStackCommand(ctx, a),       // Stack command for managing stacks
+		albums.NewListAlbumsCommand(ctx, a), // List the server's albums
+		doctor.NewDoctorCommand(ctx, a),     // Check the connection to the server
+		status.NewStatusCommand(ctx, a),     // Show the progress of a run started with --status-file
 	)
 
 	// PersistentPreRunE is executed before any command runs, used for initialization
@@ -58,21 +63,44 @@ func RootImmichGoCommand(ctx context.Context) (*cobra.Command, *app.Application)
 			return err
 		}
 
+		// the banner can be disabled with the no_banner key too
+		if !cmd.Flags().Changed("no-banner") && a.Config.GetBool("no_banner") {
+			a.Log().NoBanner = true
+		}
+
+		if a.RunID == "" {
+			a.RunID = uuid.NewString()
+		}
+
+		a.StartMemoryMonitor(cmd.Context())
+
 		// clip the number of concurrent tasks
 		a.ConcurrentTask = min(max(a.ConcurrentTask, 1), 20)
 
-		// Save configuration if the --save-config flag is set
-		if save, _ := cmd.Flags().GetBool("save-config"); save {
-			if err := a.Config.Save("immich-go.yaml"); err != nil {
-				fmt.Fprintln(os.Stderr, "Can't save the configuration: ", err.Error())
-				return err
-			}
+		err = a.Notify.Validate()
+		if err != nil {
+			return err
 		}
 
 		// Start the log
 		err = a.Log().Open(cmd.Context(), cmd, a)
+		if err != nil {
+			return err
+		}
+
+		// Print the configuration and skip the command if the --dump-config flag is set.
+		// Nothing is written, not even the file of --save-config
+		if a.DumpConfig != "" {
+			return a.DumpConfiguration(cmd, cmd.OutOrStdout())
+		}
 
-		return err
+		// Save configuration if the --save-config flag is set
+		err = a.SaveConfiguration()
+		if err != nil {
+			return err
+		}
+		a.PrepareStatusFile(cmd)
+		return a.PrepareCheckpoints(cmd)
 	}
 
 	return cmd, a
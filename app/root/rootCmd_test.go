@@ -0,0 +1,30 @@
+package root
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDumpConfigDoesntSave(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	saved := filepath.Join(dir, "saved.yaml")
+
+	c, _ := RootImmichGoCommand(t.Context())
+	out := bytes.NewBuffer(nil)
+	c.SetOut(out)
+	c.SetErr(bytes.NewBuffer(nil))
+	c.SetArgs([]string{"version", "--dump-config=json", "--save-config=" + saved, "--log-file=" + filepath.Join(dir, "run.log")})
+	if err := c.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"type":"config"`) {
+		t.Errorf("the configuration isn't dumped: %s", out)
+	}
+	if _, err := os.Stat(saved); !os.IsNotExist(err) {
+		t.Errorf("the configuration is saved by a dump-only run: %v", err)
+	}
+}
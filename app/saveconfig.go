@@ -0,0 +1,61 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// DefaultConfigFile is the file written by --save-config without path
+const DefaultConfigFile = "immich-go.yaml"
+
+// saveConfigKeys are the flags controlling the save, they aren't saved to not save again at each run
+var saveConfigKeys = []string{"save-config", "save-secrets", "force"}
+
+// configFileToSave gives the file given to --save-config, empty when the configuration isn't saved.
+// The values true and false of the previous boolean flag are still accepted.
+func (app *Application) configFileToSave() string {
+	switch strings.ToLower(app.SaveConfig) {
+	case "", "false":
+		return ""
+	case "true":
+		return DefaultConfigFile
+	}
+	return app.SaveConfig
+}
+
+// isSecretKey tells if the configuration key holds an API key
+func isSecretKey(key string) bool {
+	return strings.HasSuffix(key, "api-key")
+}
+
+// SaveConfiguration writes the configuration to the file given by --save-config.
+// An existing file is kept unless --force is given, and the API keys are saved only with --save-secrets.
+func (app *Application) SaveConfiguration() error {
+	name := app.configFileToSave()
+	if name == "" {
+		return nil
+	}
+	skip := func(key string) bool {
+		for _, k := range saveConfigKeys {
+			if key == k {
+				return true
+			}
+		}
+		return !app.SaveSecrets && isSecretKey(key)
+	}
+	err := app.Config.SaveAs(name, app.ForceSave, skip)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("can't save the configuration, %s already exists: use --force to overwrite it", name)
+	}
+	if err != nil {
+		return fmt.Errorf("can't save the configuration: %w", err)
+	}
+	if app.SaveSecrets {
+		app.log.Message("Configuration saved to %s, with the API keys", name)
+	} else {
+		app.log.Message("Configuration saved to %s, without the API keys", name)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package stack
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"time"
 
@@ -9,8 +10,11 @@ import (
 	"github.com/simulot/immich-go/app"
 	"github.com/simulot/immich-go/immich"
 	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/assettracker"
 	cliflags "github.com/simulot/immich-go/internal/cliFlags"
+	"github.com/simulot/immich-go/internal/fileevent"
 	"github.com/simulot/immich-go/internal/filenames"
+	"github.com/simulot/immich-go/internal/fileprocessor"
 	"github.com/simulot/immich-go/internal/filetypes"
 	"github.com/simulot/immich-go/internal/filters"
 	"github.com/simulot/immich-go/internal/groups"
@@ -31,6 +35,7 @@ type StackCmd struct {
 	// CLI flags
 	StackOptions shared.StackOptions
 	DateRange    cliflags.DateRange
+	Confirmation app.Confirmation
 
 	// internal state
 	SupportedMedia filetypes.SupportedMedia
@@ -45,6 +50,7 @@ type StackCmd struct {
 func (sc *StackCmd) RegisterFlags(flags *pflag.FlagSet) {
 	sc.StackOptions.RegisterFlags(flags)
 	flags.Var(&sc.DateRange, "date-range", "photos must be taken in the date range")
+	sc.Confirmation.RegisterFlags(flags)
 }
 
 // const timeFormat = "2006-01-02T15:04:05.000Z"
@@ -64,6 +70,19 @@ func NewStackCommand(ctx context.Context, a *app.Application) *cobra.Command {
 	cmd.RunE = func(cmd *cobra.Command, args []string) error { //nolint:contextcheck
 		// ready to run
 		ctx := cmd.Context()
+		if !o.client.DryRun {
+			if err := o.Confirmation.Check(a, cmd); err != nil {
+				return err
+			}
+		}
+		// a server error or a declined confirmation is not a usage error
+		cmd.SilenceUsage = true
+
+		// the processor gives the run summary
+		if a.FileProcessor() == nil {
+			recorder := fileevent.NewRecorder(a.Log().Logger)
+			a.SetFileProcessor(fileprocessor.New(assettracker.New(), recorder))
+		}
 		err := o.client.Open(ctx, a)
 		if err != nil {
 			return err
@@ -114,13 +133,14 @@ func NewStackCommand(ctx context.Context, a *app.Application) *cobra.Command {
 		if err != nil {
 			return err
 		}
-		err = o.ProcessAssets(ctx, a)
+		err = o.ProcessAssets(ctx, cmd, a)
 		return err
 	}
 	return cmd
 }
 
-func (s *StackCmd) ProcessAssets(ctx context.Context, app *app.Application) error {
+// ProcessAssets groups the assets, and applies the changes once confirmed by the user
+func (s *StackCmd) ProcessAssets(ctx context.Context, cmd *cobra.Command, app *app.Application) error {
 	log := app.Log()
 
 	in := make(chan *assets.Asset)
@@ -147,8 +167,34 @@ func (s *StackCmd) ProcessAssets(ctx context.Context, app *app.Application) erro
 	// Group assets
 	gChan := groups.NewGrouperPipeline(ctx, s.groupers...).PipeGrouper(ctx, in)
 
+	// Count the changes before applying them
+	groupList := []*assets.Group{}
+	stacks, stacked, deleted := 0, 0, 0
 	for g := range gChan {
 		g = filters.ApplyFilters(g, s.filters...)
+		deleted += len(g.Removed)
+		if len(g.Assets) > 1 && g.Grouping != assets.GroupByNone {
+			stacks++
+			stacked += len(g.Assets)
+		}
+		groupList = append(groupList, g)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if stacks == 0 && deleted == 0 {
+		log.Message("No stack to create")
+		return nil
+	}
+	if !s.client.DryRun {
+		summary := fmt.Sprintf("%d stacks will be created with %d assets, %d assets will be moved to the trash", stacks, stacked, deleted)
+		log.Info(summary)
+		if err := s.Confirmation.Confirm(app, cmd, summary); err != nil {
+			return err
+		}
+	}
+
+	for _, g := range groupList {
 		// Delete filtered assets
 		if len(g.Removed) > 0 {
 			for _, r := range g.Removed {
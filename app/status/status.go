@@ -0,0 +1,82 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/simulot/immich-go/app"
+	"github.com/spf13/cobra"
+)
+
+// staleAfter is the age of a running status file that tells its process is stopped
+const staleAfter = 5 * app.StatusInterval
+
+// NewStatusCommand adds the status command
+func NewStatusCommand(ctx context.Context, a *app.Application) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status <status-file>",
+		Short: "Show the progress of a run started with --status-file",
+		Long:  `Read the status file written by a command started with --status-file, and show its progress. The file is updated every few seconds during the run, and gives the final summary once the run is over.`,
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		b, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		var p app.ProgressUpdate
+		if err := json.Unmarshal(b, &p); err != nil {
+			return fmt.Errorf("%s isn't a status file: %w", args[0], err)
+		}
+		return writeText(cmd.OutOrStdout(), p, time.Now())
+	}
+	return cmd
+}
+
+func writeText(w io.Writer, p app.ProgressUpdate, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	state := p.Status
+	if p.Running && now.Sub(p.UpdatedAt) > staleAfter {
+		state = "running, but not updated for " + now.Sub(p.UpdatedAt).Round(time.Second).String() + ", the process may be stopped"
+	}
+	fmt.Fprintf(tw, "Command:\t%s\n", p.Command)
+	if p.RunID != "" {
+		fmt.Fprintf(tw, "Run ID:\t%s\n", p.RunID)
+	}
+	fmt.Fprintf(tw, "Process:\t%d\n", p.PID)
+	fmt.Fprintf(tw, "Status:\t%s\n", state)
+	if p.Running && p.Phase != "" {
+		fmt.Fprintf(tw, "Phase:\t%s\n", p.Phase)
+	}
+	if p.Error != "" {
+		fmt.Fprintf(tw, "Error:\t%s\n", p.Error)
+	}
+	fmt.Fprintf(tw, "Started:\t%s\n", p.StartedAt.Format(time.DateTime))
+	fmt.Fprintf(tw, "Updated:\t%s (elapsed %s)\n", p.UpdatedAt.Format(time.DateTime), p.UpdatedAt.Sub(p.StartedAt).Round(time.Second))
+
+	fmt.Fprintf(tw, "\nAssets:\n")
+	fmt.Fprintf(tw, "  pending\t%d\n", p.Assets.Pending)
+	fmt.Fprintf(tw, "  processed\t%d\n", p.Assets.Processed)
+	fmt.Fprintf(tw, "  discarded\t%d\n", p.Assets.Discarded)
+	fmt.Fprintf(tw, "  errors\t%d\n", p.Assets.Errors)
+
+	if len(p.Events) > 0 {
+		fmt.Fprintf(tw, "\nEvents:\n")
+		names := make([]string, 0, len(p.Events))
+		for n := range p.Events {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			fmt.Fprintf(tw, "  %s\t%d\n", n, p.Events[n].Count)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+)
+
+// StatusInterval is the period of the --status-file updates
+const StatusInterval = 2 * time.Second
+
+// ProgressUpdate is the content of the --status-file, read by the status command
+type ProgressUpdate struct {
+	PID       int       `json:"pid"`
+	Running   bool      `json:"running"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Phase     string    `json:"phase,omitempty"` // the stage of the running command, when it reports one
+	fileprocessor.RunSummary
+}
+
+// statusFile writes the progress of the run into the --status-file
+type statusFile struct {
+	command string
+	started time.Time
+	stop    chan struct{}
+	done    chan struct{}
+	phase   atomic.Pointer[func() string]
+}
+
+// PrepareStatusFile records the command for the --status-file.
+// The updates start with the file processor.
+func (app *Application) PrepareStatusFile(cmd *cobra.Command) {
+	if app.StatusFile == "" {
+		return
+	}
+	app.status = &statusFile{
+		command: cmd.CommandPath(),
+		started: time.Now(),
+	}
+}
+
+// SetStatusPhase gives the function reporting the current stage of the command in the status file
+func (app *Application) SetStatusPhase(phase func() string) {
+	if app.status == nil {
+		return
+	}
+	app.status.phase.Store(&phase)
+}
+
+// startStatusFile writes the status file at each tick, until CloseStatusFile is called
+func (app *Application) startStatusFile(processor *fileprocessor.FileProcessor) {
+	if app.status == nil || app.status.stop != nil {
+		return
+	}
+	sf := app.status
+	sf.stop = make(chan struct{})
+	sf.done = make(chan struct{})
+	go func() {
+		defer close(sf.done)
+		ticker := time.NewTicker(StatusInterval)
+		defer ticker.Stop()
+		for {
+			summary := processor.RunSummary(sf.command, StatusInProgress, nil)
+			summary.RunID = app.RunID
+			summary.Type = fileprocessor.SummaryCheckpoint
+			p := ProgressUpdate{
+				PID:        os.Getpid(),
+				Running:    true,
+				StartedAt:  sf.started,
+				UpdatedAt:  time.Now(),
+				RunSummary: summary,
+			}
+			if phase := sf.phase.Load(); phase != nil {
+				p.Phase = (*phase)()
+			}
+			app.writeStatusFile(p)
+			select {
+			case <-sf.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// CloseStatusFile stops the updates, and marks the status file as complete with the final summary
+func (app *Application) CloseStatusFile(cmd *cobra.Command, runErr error) {
+	sf := app.status
+	if sf == nil || sf.stop == nil {
+		return
+	}
+	close(sf.stop)
+	<-sf.done
+	app.writeStatusFile(ProgressUpdate{
+		PID:        os.Getpid(),
+		StartedAt:  sf.started,
+		UpdatedAt:  time.Now(),
+		RunSummary: app.RunSummary(cmd, runErr),
+	})
+}
+
+// writeStatusFile replaces the status file atomically, so the reader never gets a partial file.
+// A failing write is logged, and the run continues.
+func (app *Application) writeStatusFile(p ProgressUpdate) {
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err == nil {
+		tmp := app.StatusFile + ".tmp"
+		err = os.WriteFile(tmp, append(b, '\n'), 0o644)
+		if err == nil {
+			err = os.Rename(tmp, app.StatusFile)
+		}
+	}
+	if err != nil {
+		app.Log().Warn("can't write the status file", "file", app.StatusFile, "error", err)
+	}
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assettracker"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+)
+
+func TestStatusFile(t *testing.T) {
+	cmd := &cobra.Command{Use: "upload"}
+	app := New(context.Background(), cmd)
+	app.log.Logger = slog.New(slog.DiscardHandler)
+	app.StatusFile = filepath.Join(t.TempDir(), "status.json")
+
+	read := func() ProgressUpdate {
+		t.Helper()
+		b, err := os.ReadFile(app.StatusFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var p ProgressUpdate
+		if err := json.Unmarshal(b, &p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	app.PrepareStatusFile(cmd)
+	app.SetStatusPhase(func() string { return "scanning" })
+	app.SetFileProcessor(fileprocessor.New(assettracker.New(), fileevent.NewRecorder(app.log.Logger)))
+
+	// the first update is written right away
+	var p ProgressUpdate
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(app.StatusFile); err == nil {
+			p = read()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !p.Running || p.Status != StatusInProgress || p.Type != fileprocessor.SummaryCheckpoint || p.Command != "upload" || p.PID != os.Getpid() || p.Phase != "scanning" {
+		t.Errorf("unexpected running status: %+v", p)
+	}
+
+	app.CloseStatusFile(cmd, nil)
+	p = read()
+	if p.Running || p.Status != "completed" || p.Type != fileprocessor.SummaryFinal || p.Phase != "" {
+		t.Errorf("unexpected final status: %+v", p)
+	}
+	if _, err := os.Stat(app.StatusFile + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("the temporary file is left: %v", err)
+	}
+}
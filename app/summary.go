@@ -0,0 +1,37 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// WriteSummaryFile writes the run summary as JSON into the --summary-file.
+// It is called when the run ends, even on error, to give the summary of a partial run.
+// A failing write is reported but doesn't change the result of the run.
+func (app *Application) WriteSummaryFile(cmd *cobra.Command, runErr error) {
+	if app.SummaryFile == "" || app.processor == nil {
+		return
+	}
+	err := writeSummaryFile(app.SummaryFile, app.RunSummary(cmd, runErr))
+	if err != nil {
+		app.Log().Warn("can't write the summary file", "file", app.SummaryFile, "error", err)
+		return
+	}
+	app.Log().Info("summary written", "file", app.SummaryFile)
+}
+
+// writeSummaryFile replaces the file atomically, the checkpoints are read while the run goes on
+func writeSummaryFile(name string, summary any) error {
+	b, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't encode the run summary: %w", err)
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
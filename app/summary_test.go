@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/simulot/immich-go/internal/assettracker"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+)
+
+func TestWriteSummaryFile(t *testing.T) {
+	cmd := &cobra.Command{Use: "upload"}
+	app := New(context.Background(), cmd)
+	app.log.Logger = slog.New(slog.DiscardHandler)
+	app.SummaryFile = filepath.Join(t.TempDir(), "summary.json")
+
+	// no processor, no summary
+	app.WriteSummaryFile(cmd, nil)
+	if _, err := os.Stat(app.SummaryFile); !os.IsNotExist(err) {
+		t.Fatalf("the summary file is written without processor: %v", err)
+	}
+
+	app.SetFileProcessor(fileprocessor.New(assettracker.New(), fileevent.NewRecorder(app.log.Logger)))
+	app.RunID = "run-1"
+	app.SetManifestFile("archive/manifest.sha256")
+	app.SetAlbums([]fileprocessor.AlbumSummary{{Name: "Trip", ID: "a1", AssetCount: 3}})
+	app.WriteSummaryFile(cmd, context.Canceled)
+
+	b, err := os.ReadFile(app.SummaryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s fileprocessor.RunSummary
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.RunID != "run-1" || s.Command != "upload" || s.Status != "interrupted" || s.Error != context.Canceled.Error() || s.ManifestFile != "archive/manifest.sha256" {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if len(s.CreatedAlbums) != 1 || s.CreatedAlbums[0] != (fileprocessor.AlbumSummary{Name: "Trip", ID: "a1", AssetCount: 3}) {
+		t.Errorf("unexpected created albums: %+v", s.CreatedAlbums)
+	}
+
+	// a failing write doesn't panic
+	app.SummaryFile = filepath.Join(t.TempDir(), "missing", "summary.json")
+	app.WriteSummaryFile(cmd, errors.New("boom"))
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// syslogWriter sends the messages to syslog with their severity
+type syslogWriter interface {
+	Err(m string) error
+	Warning(m string) error
+	Info(m string) error
+	Debug(m string) error
+	Close() error
+}
+
+// syslogNetwork splits the --syslog-address into the network and the address.
+// The address is host:port, udp by default, or prefixed by udp:// or tcp://.
+// An empty address is the local syslog daemon.
+func syslogNetwork(address string) (string, string) {
+	if address == "" {
+		return "", ""
+	}
+	if network, addr, ok := strings.Cut(address, "://"); ok {
+		return strings.ToLower(network), addr
+	}
+	return "udp", address
+}
+
+// openSyslog connects to the syslog daemon when --syslog is given
+func (log *Log) openSyslog() error {
+	if log.Syslog == "" || log.syslog != nil {
+		return nil
+	}
+	network, addr := syslogNetwork(log.SyslogAddress)
+	w, err := dialSyslog(network, addr, strings.ToLower(log.Syslog))
+	if err != nil {
+		return err
+	}
+	log.syslog = w
+	return nil
+}
+
+// syslogHandler is a slog handler writing the records to syslog.
+// The records are formatted as text, without the time added by syslog, and sent with the
+// severity matching their level.
+type syslogHandler struct {
+	w   syslogWriter
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+	h   slog.Handler // text handler writing into buf
+}
+
+var _ slog.Handler = (*syslogHandler)(nil)
+
+func newSyslogHandler(w syslogWriter, level slog.Leveler) *syslogHandler {
+	buf := &bytes.Buffer{}
+	return &syslogHandler{
+		w:   w,
+		mu:  &sync.Mutex{},
+		buf: buf,
+		h: slog.NewTextHandler(buf, &slog.HandlerOptions{
+			Level: level,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}),
+	}
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buf.Reset()
+	if err := h.h.Handle(ctx, r); err != nil {
+		return err
+	}
+	msg := strings.TrimSuffix(h.buf.String(), "\n")
+	switch {
+	case r.Level >= slog.LevelError:
+		return h.w.Err(msg)
+	case r.Level >= slog.LevelWarn:
+		return h.w.Warning(msg)
+	case r.Level >= slog.LevelInfo:
+		return h.w.Info(msg)
+	default:
+		return h.w.Debug(msg)
+	}
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{w: h.w, mu: h.mu, buf: h.buf, h: h.h.WithAttrs(attrs)}
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{w: h.w, mu: h.mu, buf: h.buf, h: h.h.WithGroup(name)}
+}
+
+// SyslogSummary sends the run summary as JSON to syslog when --syslog is given,
+// with the error severity when the run has failed. The connection is closed afterward.
+func (app *Application) SyslogSummary(cmd *cobra.Command, runErr error) {
+	log := app.Log()
+	if log.syslog == nil {
+		return
+	}
+	defer func() {
+		log.syslog.Close()
+		log.syslog = nil
+	}()
+	if app.processor == nil {
+		return
+	}
+	b, err := json.Marshal(app.RunSummary(cmd, runErr))
+	if err != nil {
+		log.Warn("can't encode the run summary", "error", err)
+		return
+	}
+	if runErr != nil {
+		err = log.syslog.Err(string(b))
+	} else {
+		err = log.syslog.Info(string(b))
+	}
+	if err != nil {
+		log.Warn("can't send the summary to syslog", "error", err)
+	}
+}
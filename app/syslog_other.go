@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package app
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// dialSyslog fails: syslog isn't available on this platform
+func dialSyslog(network, addr, facility string) (syslogWriter, error) {
+	return nil, fmt.Errorf("--syslog isn't supported on %s", runtime.GOOS)
+}
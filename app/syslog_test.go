@@ -0,0 +1,61 @@
+package app
+
+import (
+	"log/slog"
+	"testing"
+)
+
+type fakeSyslog struct {
+	messages []string
+}
+
+func (f *fakeSyslog) record(severity, m string) error {
+	f.messages = append(f.messages, severity+" "+m)
+	return nil
+}
+
+func (f *fakeSyslog) Err(m string) error     { return f.record("err", m) }
+func (f *fakeSyslog) Warning(m string) error { return f.record("warning", m) }
+func (f *fakeSyslog) Info(m string) error    { return f.record("info", m) }
+func (f *fakeSyslog) Debug(m string) error   { return f.record("debug", m) }
+func (f *fakeSyslog) Close() error           { return nil }
+
+func TestSyslogHandler(t *testing.T) {
+	w := &fakeSyslog{}
+	l := slog.New(newSyslogHandler(w, slog.LevelInfo)).With("run_id", "r1")
+	l.Debug("hidden")
+	l.Info("started", "n", 1)
+	l.Warn("slow")
+	l.Error("failed", "error", "boom")
+
+	expected := []string{
+		"info msg=started run_id=r1 n=1",
+		"warning msg=slow run_id=r1",
+		"err msg=failed run_id=r1 error=boom",
+	}
+	if len(w.messages) != len(expected) {
+		t.Fatalf("expected %d messages, got %q", len(expected), w.messages)
+	}
+	for i := range expected {
+		if w.messages[i] != expected[i] {
+			t.Errorf("message %d: expected %q, got %q", i, expected[i], w.messages[i])
+		}
+	}
+}
+
+func TestSyslogNetwork(t *testing.T) {
+	tests := []struct {
+		address, network, addr string
+	}{
+		{"", "", ""},
+		{"nas:514", "udp", "nas:514"},
+		{"tcp://nas:601", "tcp", "nas:601"},
+		{"UDP://nas:514", "udp", "nas:514"},
+	}
+	for _, tt := range tests {
+		network, addr := syslogNetwork(tt.address)
+		if network != tt.network || addr != tt.addr {
+			t.Errorf("syslogNetwork(%q) = %q, %q, expected %q, %q", tt.address, network, addr, tt.network, tt.addr)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+//go:build !windows && !plan9
+
+package app
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+var syslogFacilities = map[string]syslog.Priority{
+	"user":   syslog.LOG_USER,
+	"daemon": syslog.LOG_DAEMON,
+	"local0": syslog.LOG_LOCAL0,
+	"local1": syslog.LOG_LOCAL1,
+	"local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4,
+	"local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6,
+	"local7": syslog.LOG_LOCAL7,
+}
+
+// dialSyslog connects to the syslog daemon, local when the network is empty
+func dialSyslog(network, addr, facility string) (syslogWriter, error) {
+	p, ok := syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("invalid value for --syslog: %q, expected user, daemon or local0 to local7", facility)
+	}
+	w, err := syslog.Dial(network, addr, p|syslog.LOG_INFO, "immich-go")
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to syslog: %w", err)
+	}
+	return w, nil
+}
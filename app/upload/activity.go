@@ -0,0 +1,188 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptrace"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/simulot/immich-go/app"
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/simulot/immich-go/internal/ui"
+)
+
+// User interface modes
+const (
+	uiModeTUI  = "tui"  // full screen interface
+	uiModeLine = "line" // single progress line
+)
+
+// isInteractive tells if the standard output is a terminal
+func isInteractive() bool {
+	return app.IsTerminal(os.Stdout)
+}
+
+const recentAlbumsSize = 5
+
+// albumActivity keeps the last album updates for the progress display.
+// It is safe for concurrent use.
+type albumActivity struct {
+	lock   sync.Mutex
+	recent []string
+}
+
+func (aa *albumActivity) Add(title string, count int) {
+	aa.lock.Lock()
+	defer aa.lock.Unlock()
+	aa.recent = append(aa.recent, fmt.Sprintf("%s %s (+%d)", time.Now().Format(time.TimeOnly), title, count))
+	if len(aa.recent) > recentAlbumsSize {
+		aa.recent = aa.recent[len(aa.recent)-recentAlbumsSize:]
+	}
+}
+
+// Recent returns the last album updates, the most recent first
+func (aa *albumActivity) Recent() []string {
+	aa.lock.Lock()
+	defer aa.lock.Unlock()
+	r := make([]string, 0, len(aa.recent))
+	for i := len(aa.recent) - 1; i >= 0; i-- {
+		r = append(r, aa.recent[i])
+	}
+	return r
+}
+
+// throughput gives the upload rate since the start of the upload phase
+func (uc *UpCmd) throughput(fp *fileprocessor.FileProcessor) string {
+	if uc.phase.Get() != phaseUploading {
+		return "-"
+	}
+	elapsed := time.Since(uc.phase.Since()).Seconds()
+	if elapsed < 1 {
+		return "-"
+	}
+	files, bytes := uploadedTotals(fp)
+	return fmt.Sprintf("%s/s, %.1f assets/s", ui.FormatBytes(int64(float64(bytes)/elapsed)), float64(files)/elapsed)
+}
+
+// uploadProgress tracks the bytes sent for the files being uploaded, for the progress display.
+// It is safe for concurrent use.
+type uploadProgress struct {
+	lock  sync.Mutex
+	files map[*assets.Asset]*fileProgress
+}
+
+type fileProgress struct {
+	name       string
+	sent, size int64
+	lastSent   time.Time // when the last bytes were sent, zero while waiting for a connection
+}
+
+// errUploadStalled cancels the upload of a file not sending any byte during --stall-timeout
+var errUploadStalled = errors.New("upload stalled")
+
+// track registers the upload of the asset, and gives the context reporting its progress.
+// When stallTimeout isn't 0, the context is canceled with errUploadStalled when no byte of the file
+// is sent during this duration. The clock starts when the request gets its connection: the wait for
+// a connection, limited by --max-conns-per-host, and the wait for the server's response, once the file
+// is sent, aren't stalls.
+// done must be called at the end of the upload.
+func (up *uploadProgress) track(ctx context.Context, a *assets.Asset, stallTimeout time.Duration) (context.Context, func()) {
+	fp := &fileProgress{name: path.Base(a.File.Name()), size: int64(a.FileSize)}
+	up.lock.Lock()
+	if up.files == nil {
+		up.files = map[*assets.Asset]*fileProgress{}
+	}
+	up.files[a] = fp
+	up.lock.Unlock()
+
+	ctx = immich.WithUploadProgress(ctx, func(sent, size int64) {
+		up.lock.Lock()
+		fp.sent, fp.size, fp.lastSent = sent, size, time.Now()
+		up.lock.Unlock()
+	})
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			up.lock.Lock()
+			fp.lastSent = time.Now()
+			up.lock.Unlock()
+		},
+	})
+
+	stop := func() {}
+	if stallTimeout > 0 {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		finished := make(chan struct{})
+		go up.watchStall(fp, stallTimeout, cancel, finished)
+		stop = func() {
+			close(finished)
+			cancel(nil)
+		}
+	}
+	return ctx, func() {
+		stop()
+		up.lock.Lock()
+		delete(up.files, a)
+		up.lock.Unlock()
+	}
+}
+
+// watchStall cancels the upload when no byte is sent during the timeout, until the upload is finished.
+// The upload waiting for its connection isn't watched.
+func (up *uploadProgress) watchStall(fp *fileProgress, timeout time.Duration, cancel context.CancelCauseFunc, finished chan struct{}) {
+	ticker := time.NewTicker(max(timeout/10, 10*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-finished:
+			return
+		case <-ticker.C:
+			up.lock.Lock()
+			sent := fp.size > 0 && fp.sent >= fp.size
+			started := !fp.lastSent.IsZero()
+			idle := time.Since(fp.lastSent)
+			up.lock.Unlock()
+			if sent {
+				return
+			}
+			if started && idle >= timeout {
+				cancel(fmt.Errorf("%w: no byte sent for %s", errUploadStalled, timeout))
+				return
+			}
+		}
+	}
+}
+
+// Sent gives the bytes already sent of the files being uploaded
+func (up *uploadProgress) Sent() int64 {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	var sent int64
+	for _, fp := range up.files {
+		sent += fp.sent
+	}
+	return sent
+}
+
+// String gives the progress of the biggest file being uploaded, like "uploading bigvideo.mov 43%".
+// It is empty when no file is being uploaded.
+func (up *uploadProgress) String() string {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	var biggest *fileProgress
+	for _, fp := range up.files {
+		if biggest == nil || fp.size > biggest.size || (fp.size == biggest.size && fp.name < biggest.name) {
+			biggest = fp
+		}
+	}
+	if biggest == nil || biggest.size <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("uploading %s %d%%", biggest.name, 100*biggest.sent/biggest.size)
+}
@@ -0,0 +1,99 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+func TestWatchStall(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	t.Run("waiting for a connection", func(t *testing.T) {
+		up := &uploadProgress{}
+		fp := &fileProgress{name: "IMG_0001.jpg", size: 10}
+		ctx, cancel := context.WithCancelCause(context.Background())
+		finished := make(chan struct{})
+		go up.watchStall(fp, timeout, cancel, finished)
+
+		time.Sleep(4 * timeout)
+		if err := context.Cause(ctx); err != nil {
+			t.Fatalf("the upload waiting for its connection is canceled: %v", err)
+		}
+
+		// the upload starts, and stalls
+		up.lock.Lock()
+		fp.lastSent = time.Now()
+		up.lock.Unlock()
+		select {
+		case <-ctx.Done():
+		case <-time.After(10 * timeout):
+			t.Fatal("the stalled upload isn't canceled")
+		}
+		if err := context.Cause(ctx); !errors.Is(err, errUploadStalled) {
+			t.Errorf("cause = %v, want errUploadStalled", err)
+		}
+	})
+
+	t.Run("file sent", func(t *testing.T) {
+		up := &uploadProgress{}
+		fp := &fileProgress{name: "IMG_0001.jpg", size: 10, sent: 10, lastSent: time.Now()}
+		ctx, cancel := context.WithCancelCause(context.Background())
+		finished := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			up.watchStall(fp, timeout, cancel, finished)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * timeout):
+			t.Fatal("watchStall doesn't return once the file is sent")
+		}
+		if err := context.Cause(ctx); err != nil {
+			t.Errorf("the sent upload is canceled: %v", err)
+		}
+	})
+}
+
+func TestTrackStartsOnConnection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	fsys := fstest.MapFS{"IMG_0001.jpg": &fstest.MapFile{Data: []byte("abc")}}
+	a := &assets.Asset{File: fshelper.FSName(fsys, "IMG_0001.jpg"), FileSize: 3}
+	up := &uploadProgress{}
+	ctx, done := up.track(context.Background(), a, time.Hour)
+	defer done()
+
+	up.lock.Lock()
+	started := !up.files[a].lastSent.IsZero()
+	up.lock.Unlock()
+	if started {
+		t.Fatal("the clock runs before the connection")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	up.lock.Lock()
+	started = !up.files[a].lastSent.IsZero()
+	up.lock.Unlock()
+	if !started {
+		t.Error("the clock doesn't start with the connection")
+	}
+}
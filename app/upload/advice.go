@@ -10,6 +10,7 @@ import (
 
 	"github.com/simulot/immich-go/immich"
 	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fshelper/hash"
 	"github.com/simulot/immich-go/internal/gen/syncmap"
 	"github.com/simulot/immich-go/internal/gen/syncset"
 )
@@ -35,6 +36,8 @@ func (a AdviceCode) String() string {
 		return "AlreadyProcessed"
 	case ForceUpload:
 		return "ForceUpload"
+	case ReplaceExisting:
+		return "ReplaceExisting"
 	}
 	return fmt.Sprintf("advice(%d)", a)
 }
@@ -47,6 +50,7 @@ const (
 	NotOnServer
 	AlreadyProcessed
 	ForceUpload
+	ReplaceExisting
 )
 
 type immichIndex struct {
@@ -64,6 +68,9 @@ type immichIndex struct {
 	// map of SHA1 to assetID
 	byChecksum *syncmap.SyncMap[string, *assets.Asset]
 
+	// map of local checksums to processed local assets, when the algorithm isn't SHA1
+	byLocalChecksum *syncmap.SyncMap[string, *assets.Asset]
+
 	assetNumber int64
 }
 
@@ -71,6 +78,7 @@ func newAssetIndex() *immichIndex {
 	return &immichIndex{
 		immichAssets:    syncmap.New[string, *assets.Asset](),
 		byChecksum:      syncmap.New[string, *assets.Asset](),
+		byLocalChecksum: syncmap.New[string, *assets.Asset](),
 		byName:          syncmap.New[string, []string](),
 		uploadsChecksum: syncset.New[string](),
 	}
@@ -91,7 +99,7 @@ func (ii *immichIndex) addImmichAsset(ia *immich.Asset) (*assets.Asset, bool) {
 		return existing, false
 	}
 	a := ia.AsAsset()
-	return ii.add(a, false), true
+	return ii.add(a, a.ID, false), true
 }
 
 func (ii *immichIndex) addLocalAsset(ia *assets.Asset) (*assets.Asset, bool) {
@@ -104,7 +112,22 @@ func (ii *immichIndex) addLocalAsset(ia *assets.Asset) (*assets.Asset, bool) {
 	if existing, ok := ii.byChecksum.Load(ia.Checksum); ok {
 		return existing, false
 	}
-	return ii.add(ia, true), true
+	return ii.add(ia, ia.ID, true), true
+}
+
+// addPlannedAsset records an asset that --plan would upload. The asset has no server ID,
+// the key identifies it in the index only.
+func (ii *immichIndex) addPlannedAsset(a *assets.Asset, replaced *assets.Asset, key string) {
+	if replaced != nil {
+		ii.replace(a, replaced, key)
+		return
+	}
+	ii.lock.Lock()
+	defer ii.lock.Unlock()
+	if _, ok := ii.byChecksum.Load(a.Checksum); ok {
+		return
+	}
+	ii.add(a, key, true)
 }
 
 func (ii *immichIndex) getByID(id string) *assets.Asset {
@@ -116,8 +139,9 @@ func (ii *immichIndex) len() int {
 	return int(atomic.LoadInt64(&ii.assetNumber))
 }
 
-func (ii *immichIndex) add(a *assets.Asset, local bool) *assets.Asset {
-	if a.ID == "" {
+// add indexes the asset under the key, its ID for the server's and the uploaded assets
+func (ii *immichIndex) add(a *assets.Asset, key string, local bool) *assets.Asset {
+	if key == "" {
 		panic("asset ID is empty")
 	}
 	if a.Checksum == "" {
@@ -133,22 +157,30 @@ func (ii *immichIndex) add(a *assets.Asset, local bool) *assets.Asset {
 	}
 
 	atomic.AddInt64(&ii.assetNumber, 1)
-	ii.immichAssets.Store(a.ID, a)
+	ii.immichAssets.Store(key, a)
 	ii.byChecksum.Store(a.Checksum, a)
 	filename := a.OriginalFileName
 
 	if local {
 		ii.uploadsChecksum.Add(a.Checksum)
+		if a.LocalChecksum != "" {
+			ii.byLocalChecksum.Store(a.LocalChecksum, a)
+		}
 	}
 
 	l, _ := ii.byName.Load(filename)
-	l = append(l, a.ID)
+	l = append(l, key)
 	ii.byName.Store(filename, l)
 	return a
 }
 
 func (ii *immichIndex) replaceAsset(newA *assets.Asset, oldA *assets.Asset) *assets.Asset {
-	if newA.ID == "" {
+	return ii.replace(newA, oldA, newA.ID)
+}
+
+// replace indexes the new asset under the key, and marks the old one as trashed
+func (ii *immichIndex) replace(newA *assets.Asset, oldA *assets.Asset, key string) *assets.Asset {
+	if key == "" {
 		panic("asset ID is empty")
 	}
 	if newA.Checksum == "" {
@@ -157,13 +189,13 @@ func (ii *immichIndex) replaceAsset(newA *assets.Asset, oldA *assets.Asset) *ass
 	ii.lock.Lock()
 	defer ii.lock.Unlock()
 	oldA.Trashed = true
-	ii.immichAssets.Store(newA.ID, newA)     // Store the new asset
+	ii.immichAssets.Store(key, newA)         // Store the new asset
 	ii.byChecksum.Store(newA.Checksum, newA) // Store the new SHA1
 	ii.uploadsChecksum.Add(newA.Checksum)
 
 	filename := newA.OriginalFileName
 	l, _ := ii.byName.Load(filename)
-	l = append(l, newA.ID)
+	l = append(l, key)
 	ii.byName.Store(filename, l)
 	return newA
 }
@@ -242,6 +274,14 @@ func (ii *immichIndex) adviceForceUpload(sa *assets.Asset) *Advice {
 	}
 }
 
+func (ii *immichIndex) adviceReplaceExisting(sa *assets.Asset) *Advice {
+	return &Advice{
+		Advice:      ReplaceExisting,
+		Message:     fmt.Sprintf("An asset with the same name:%q and date:%q but a different content exists on the server. Replace it.", sa.OriginalFileName, sa.CaptureDate.Format(time.DateTime)),
+		ServerAsset: sa,
+	}
+}
+
 // ShouldUpload check if the server has this asset
 //
 // The server may have different assets with the same name. This happens with photos produced by digital cameras.
@@ -252,6 +292,18 @@ func (ii *immichIndex) adviceForceUpload(sa *assets.Asset) *Advice {
 // la.OriginalFileName is the name of the file as it was on the device before it was uploaded to the server
 
 func (ii *immichIndex) ShouldUpload(la *assets.Asset, upCmd *UpCmd) (*Advice, error) {
+	// Local duplicates are detected with the local algorithm, the SHA1 checksum is computed in the same read of the file
+	if upCmd.ChecksumAlgo != hash.AlgoSHA1 {
+		localChecksum, err := la.GetLocalChecksum(upCmd.ChecksumAlgo)
+		if err != nil {
+			return nil, err
+		}
+		if sa, ok := ii.byLocalChecksum.Load(localChecksum); ok {
+			return ii.adviceAlreadyProcessed(sa), nil
+		}
+	}
+
+	// The server knows only SHA1 checksums
 	checksum, err := la.GetChecksum()
 	if err != nil {
 		return nil, err
@@ -288,6 +340,14 @@ func (ii *immichIndex) ShouldUpload(la *assets.Asset, upCmd *UpCmd) (*Advice, er
 			switch {
 			case compareDate == 0 && upCmd.Overwrite:
 				return ii.adviceForceUpload(sa), nil
+			case compareDate == 0 && upCmd.ReplaceExisting && !ii.isAlreadyProcessed(sa.Checksum):
+				// the checksums differ, the file has changed since its upload.
+				// An asset uploaded by this run is never replaced by another file of the input.
+				return ii.adviceReplaceExisting(sa), nil
+			case compareDate == 0 && compareSize == 0 && ii.isAlreadyProcessed(sa.Checksum):
+				// another file of the input with the same name, date and size, but a different content.
+				// Not a duplicate, both are uploaded.
+				continue
 			case compareDate == 0 && compareSize == 0:
 				return ii.adviceSameOnServer(sa), nil
 			case compareDate == 0 && compareSize > 0:
@@ -0,0 +1,156 @@
+package upload
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+	"github.com/simulot/immich-go/internal/fshelper/hash"
+)
+
+func TestShouldUpload(t *testing.T) {
+	date := time.Date(2023, 7, 14, 10, 30, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"IMG_0001.jpg":         &fstest.MapFile{Data: []byte("abc")},
+		"changed/IMG_0001.jpg": &fstest.MapFile{Data: []byte("xyz")},
+		"bigger/IMG_0001.jpg":  &fstest.MapFile{Data: []byte("abcdef")},
+		"smaller/IMG_0001.jpg": &fstest.MapFile{Data: []byte("a")},
+		"IMG_0002.jpg":         &fstest.MapFile{Data: []byte("new")},
+		"copy/IMG_0002.jpg":    &fstest.MapFile{Data: []byte("new")},
+		"other/IMG_0002.jpg":   &fstest.MapFile{Data: []byte("old")},
+	}
+	serverAsset := func() *immich.Asset {
+		return &immich.Asset{
+			ID:               "s1",
+			OriginalFileName: "IMG_0001.jpg",
+			Checksum:         "qZk+NkcGgWq6PiVxeFDCbJzQ2J0=", // SHA1 of "abc"
+			ExifInfo:         immich.ExifInfo{FileSizeInByte: 3, DateTimeOriginal: immich.ImmichExifTime{Time: date}},
+		}
+	}
+	localAsset := func(name string, date time.Time) *assets.Asset {
+		return &assets.Asset{
+			File:             fshelper.FSName(fsys, name),
+			OriginalFileName: "IMG_0001.jpg",
+			FileSize:         len(fsys[name].Data),
+			CaptureDate:      date,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		upCmd   *UpCmd
+		local   string
+		date    time.Time
+		uploads []string // files uploaded before by the run
+		want    AdviceCode
+	}{
+		{name: "same checksum", local: "IMG_0001.jpg", date: date, want: SameOnServer},
+		{name: "not on server", local: "IMG_0002.jpg", date: date, want: NotOnServer},
+		{name: "same name, other date", local: "bigger/IMG_0001.jpg", date: date.Add(time.Hour), want: NotOnServer},
+		{name: "bigger than the server's", local: "bigger/IMG_0001.jpg", date: date, want: SmallerOnServer},
+		{name: "smaller than the server's", local: "smaller/IMG_0001.jpg", date: date, want: BetterOnServer},
+		{name: "same name, date and size", local: "changed/IMG_0001.jpg", date: date, want: SameOnServer},
+		{name: "changed, --replace-existing", upCmd: &UpCmd{ReplaceExisting: true}, local: "changed/IMG_0001.jpg", date: date, want: ReplaceExisting},
+		{name: "bigger, --overwrite", upCmd: &UpCmd{Overwrite: true}, local: "bigger/IMG_0001.jpg", date: date, want: ForceUpload},
+		{name: "already uploaded by the run", local: "copy/IMG_0002.jpg", date: date, uploads: []string{"IMG_0002.jpg"}, want: AlreadyProcessed},
+		{name: "already uploaded, local checksum", upCmd: &UpCmd{ChecksumAlgo: hash.AlgoXXHash}, local: "copy/IMG_0002.jpg", date: date, uploads: []string{"IMG_0002.jpg"}, want: AlreadyProcessed},
+		// another file of the run, with the same name, date and size: both are uploaded
+		{name: "same name as an upload of the run", local: "other/IMG_0002.jpg", date: date, uploads: []string{"IMG_0002.jpg"}, want: NotOnServer},
+		// an asset uploaded by the run is never replaced by another file of the input
+		{name: "--replace-existing, uploaded by the run", upCmd: &UpCmd{ReplaceExisting: true}, local: "other/IMG_0002.jpg", date: date, uploads: []string{"IMG_0002.jpg"}, want: NotOnServer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.upCmd == nil {
+				tt.upCmd = &UpCmd{}
+			}
+			ii := newAssetIndex()
+			ii.addImmichAsset(serverAsset())
+			for _, name := range tt.uploads {
+				a := localAsset(name, date)
+				a.OriginalFileName = "IMG_0002.jpg"
+				if _, err := a.GetLocalChecksum(tt.upCmd.ChecksumAlgo); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := a.GetChecksum(); err != nil {
+					t.Fatal(err)
+				}
+				a.ID = "uploaded " + name
+				ii.addLocalAsset(a)
+			}
+
+			la := localAsset(tt.local, tt.date)
+			advice, err := ii.ShouldUpload(la, tt.upCmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if advice.Advice != tt.want {
+				t.Errorf("ShouldUpload() = %s, want %s: %s", advice.Advice, tt.want, advice.Message)
+			}
+			if tt.want != NotOnServer && advice.ServerAsset == nil {
+				t.Error("the advice doesn't give the matching asset")
+			}
+		})
+	}
+}
+
+func TestHandleAssetBetterOnServer(t *testing.T) {
+	date := time.Date(2023, 7, 14, 10, 30, 0, 0, time.UTC)
+	fsys := fstest.MapFS{"IMG_0001.jpg": &fstest.MapFile{Data: []byte("a")}}
+	uc := newTestUpCmd(t)
+	uc.assetIndex = newAssetIndex()
+	uc.assetIndex.addImmichAsset(&immich.Asset{
+		ID:               "s1",
+		OriginalFileName: "IMG_0001.jpg",
+		Checksum:         "qZk+NkcGgWq6PiVxeFDCbJzQ2J0=",
+		ExifInfo:         immich.ExifInfo{FileSizeInByte: 3, DateTimeOriginal: immich.ImmichExifTime{Time: date}},
+	})
+	a := &assets.Asset{File: fshelper.FSName(fsys, "IMG_0001.jpg"), OriginalFileName: "IMG_0001.jpg", FileSize: 1, CaptureDate: date}
+	fp := uc.app.FileProcessor()
+	fp.RecordAssetDiscovered(context.Background(), a.File, 1, fileevent.DiscoveredImage)
+
+	if err := uc.handleAsset(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file gives a single outcome
+	counts := fp.Logger().GetCounts()
+	if counts[fileevent.DiscardedServerBetter] != 1 || counts[fileevent.ProcessedMetadataUpdated] != 0 {
+		t.Errorf("server better: %d, metadata updated: %d, want 1 and 0", counts[fileevent.DiscardedServerBetter], counts[fileevent.ProcessedMetadataUpdated])
+	}
+	if c := fp.GetAssetCounters(); c.Discarded != 1 || c.Processed != 0 || c.Pending != 0 {
+		t.Errorf("unexpected counters: %+v", c)
+	}
+	if n, size := fp.Logger().DuplicateTotals(); n != 1 || size != 1 {
+		t.Errorf("duplicates: %d of %d bytes, want 1 of 1 byte", n, size)
+	}
+}
+
+// TestHandleAssetAlreadyProcessed sends two files with the same content: the second one isn't uploaded
+func TestHandleAssetAlreadyProcessed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/IMG_0001.jpg": &fstest.MapFile{Data: []byte("abc")},
+		"b/IMG_0001.jpg": &fstest.MapFile{Data: []byte("abc")},
+	}
+	uc := newTestUpCmd(t)
+	uc.client.Immich = collisionServer(t)
+	uc.assetIndex = newAssetIndex()
+	ctx := context.Background()
+	fp := uc.app.FileProcessor()
+	for _, name := range []string{"a/IMG_0001.jpg", "b/IMG_0001.jpg"} {
+		a := &assets.Asset{File: fshelper.FSName(fsys, name), OriginalFileName: "IMG_0001.jpg", FileSize: 3}
+		fp.RecordAssetDiscovered(ctx, a.File, 3, fileevent.DiscoveredImage)
+		if err := uc.handleAsset(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	counts := fp.Logger().GetCounts()
+	if counts[fileevent.ProcessedUploadSuccess] != 1 || counts[fileevent.DiscardedLocalDuplicate] != 1 {
+		t.Errorf("uploaded %d, local duplicates %d, want 1 and 1", counts[fileevent.ProcessedUploadSuccess], counts[fileevent.DiscardedLocalDuplicate])
+	}
+}
@@ -0,0 +1,49 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+// albumRequests counts the assets added to albums and the requests sent for them.
+// Without batching, each asset would need its own request.
+type albumRequests struct {
+	assets   atomic.Int64
+	requests atomic.Int64
+}
+
+// addAlbumAssetsOneByOne adds the assets to the album one request per asset.
+// It isolates the assets making a batch fail. The other assets are added.
+func (uc *UpCmd) addAlbumAssetsOneByOne(ctx context.Context, album assets.Album, ids []string) error {
+	var errs error
+	added := 0
+	for _, id := range ids {
+		uc.albumRequests.requests.Add(1)
+		_, err := uc.client.Immich.AddAssetToAlbum(ctx, album.ID, []string{id})
+		if err != nil {
+			uc.app.Log().Error("failed to add asset to album", "err", err, "album", album.Title, "asset", id)
+			errs = errors.Join(errs, err)
+			continue
+		}
+		added++
+	}
+	if added > 0 {
+		uc.albumRequests.assets.Add(int64(added))
+		uc.app.Log().Info("updated album", "album", album.Title, "assets", added)
+		uc.albumActivity.Add(album.Title, added)
+		uc.createdAlbums.added(album, added)
+	}
+	return errs
+}
+
+// albumRequestsReport logs the number of album requests, with and without batching
+func (uc *UpCmd) albumRequestsReport() {
+	n := uc.albumRequests.assets.Load()
+	if n == 0 {
+		return
+	}
+	uc.app.Log().Debug("album requests", "without batching", n, "with batching", uc.albumRequests.requests.Load(), "batch size", uc.AlbumBatchSize)
+}
@@ -0,0 +1,68 @@
+package upload
+
+import (
+	"context"
+	"sort"
+)
+
+// maxCoverAttempts is the number of album members tried as cover before giving up
+const maxCoverAttempts = 3
+
+// albumCovers counts the covers set on the albums created by the upload, with --set-album-cover.
+// The takeouts don't name the album's key photo, so the cover is the album's oldest member,
+// as Google Photos does by default. The albums already on the server keep their cover.
+type albumCovers struct {
+	set, fallback, failed int
+}
+
+// candidates gives the members of the album, the oldest first. The members without date come last.
+func (c *createdAlbum) candidates() []albumMember {
+	members := c.members
+	sort.SliceStable(members, func(i, j int) bool {
+		di, dj := members[i].date, members[j].date
+		if di.IsZero() || dj.IsZero() {
+			return !di.IsZero() && dj.IsZero()
+		}
+		return di.Before(dj)
+	})
+	return members
+}
+
+// setAlbumCovers sets the cover of the albums created by the upload, once all their members are added.
+// When the first member can't be the cover, the next ones are tried.
+func (uc *UpCmd) setAlbumCovers(ctx context.Context) {
+	members := &uc.albumMembers
+	members.lock.Lock()
+	defer members.lock.Unlock()
+
+	covers := &uc.albumCovers
+	for _, c := range members.albums {
+		if c.albumID == "" || len(c.members) == 0 {
+			continue
+		}
+		done := false
+		for i, m := range c.candidates() {
+			if i == maxCoverAttempts || ctx.Err() != nil {
+				break
+			}
+			err := uc.client.Immich.UpdateAlbumCover(ctx, c.albumID, m.id)
+			if err != nil {
+				uc.app.Log().Warn("can't set the album cover, trying the next member", "album", c.title, "asset", m.id, "err", err)
+				continue
+			}
+			if i > 0 {
+				covers.fallback++
+				uc.app.Log().Info("album cover set with a fallback member", "album", c.title, "asset", m.id)
+			} else {
+				uc.app.Log().Debug("album cover set", "album", c.title, "asset", m.id)
+			}
+			covers.set++
+			done = true
+			break
+		}
+		if !done {
+			covers.failed++
+			uc.app.Log().Error("can't set the album cover", "album", c.title)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+)
+
+func TestAlbumCoverCandidates(t *testing.T) {
+	date := time.Date(2023, 7, 14, 10, 30, 0, 0, time.UTC)
+	c := createdAlbum{members: []albumMember{
+		{id: "no date"},
+		{id: "newer", date: date.Add(time.Hour)},
+		{id: "older", date: date},
+	}}
+	var got []string
+	for _, m := range c.candidates() {
+		got = append(got, m.id)
+	}
+	if want := []string{"older", "newer", "no date"}; !slices.Equal(got, want) {
+		t.Errorf("candidates() = %v, want %v", got, want)
+	}
+}
+
+func TestSetAlbumCovers(t *testing.T) {
+	var covers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || !strings.HasPrefix(r.URL.Path, "/api/albums/") {
+			http.NotFound(w, r)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		var body struct {
+			AlbumThumbnailAssetID string `json:"albumThumbnailAssetId"`
+		}
+		_ = json.Unmarshal(b, &body)
+		if strings.HasPrefix(body.AlbumThumbnailAssetID, "bad") {
+			http.Error(w, "not an asset of the album", http.StatusBadRequest)
+			return
+		}
+		covers = append(covers, strings.TrimPrefix(r.URL.Path, "/api/albums/")+":"+body.AlbumThumbnailAssetID)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ic, err := immich.NewImmichClient(server.URL, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := newTestUpCmd(t)
+	uc.client.Immich = ic
+
+	date := time.Date(2023, 7, 14, 10, 30, 0, 0, time.UTC)
+	am := &uc.albumMembers
+	am.created("first", assets.NewAlbum("a1", "First", ""))
+	am.addMember("first", "First", "good1", date)
+	am.created("fallback", assets.NewAlbum("a2", "Fallback", ""))
+	am.addMember("fallback", "Fallback", "bad1", date)
+	am.addMember("fallback", "Fallback", "good2", date.Add(time.Hour))
+	am.created("failed", assets.NewAlbum("a3", "Failed", ""))
+	for _, id := range []string{"bad2", "bad3", "bad4", "good3"} {
+		am.addMember("failed", "Failed", id, date) // the 4th member is never tried
+	}
+	am.addMember("existing", "Existing", "good4", date) // not created by the upload
+
+	uc.setAlbumCovers(context.Background())
+	ac := &uc.albumCovers
+
+	slices.Sort(covers)
+	if want := []string{"a1:good1", "a2:good2"}; !slices.Equal(covers, want) {
+		t.Errorf("covers %v, want %v", covers, want)
+	}
+	if ac.set != 2 || ac.fallback != 1 || ac.failed != 1 {
+		t.Errorf("set %d, fallback %d, failed %d, want 2, 1, 1", ac.set, ac.fallback, ac.failed)
+	}
+
+	// the summary gives the covers
+	uc.app.SetAlbumCovers(fileprocessor.AlbumCoversSummary{Set: ac.set, Fallback: ac.fallback, Failed: ac.failed})
+	uc.app.SummaryFile = filepath.Join(t.TempDir(), "summary.json")
+	uc.app.WriteSummaryFile(&cobra.Command{Use: "upload"}, nil)
+	b, err := os.ReadFile(uc.app.SummaryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary fileprocessor.RunSummary
+	if err := json.Unmarshal(b, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if c := summary.AlbumCovers; c == nil || *c != (fileprocessor.AlbumCoversSummary{Set: 2, Fallback: 1, Failed: 1}) {
+		t.Errorf("unexpected album_covers in %s", b)
+	}
+}
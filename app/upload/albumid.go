@@ -0,0 +1,27 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+// targetAlbumKey is the key of the album given by --album-id in the album cache.
+// It can't collide with the album names.
+const targetAlbumKey = "\x00album-id"
+
+// loadTargetAlbum gets the album given by --album-id from the server, with its assets.
+// The upload fails when the album can't be read, before anything is sent.
+func (uc *UpCmd) loadTargetAlbum(ctx context.Context) (assets.Album, []string, error) {
+	r, err := uc.client.Immich.GetAlbumInfo(ctx, uc.AlbumID, false)
+	if err != nil {
+		return assets.Album{}, nil, fmt.Errorf("can't get the album %q given by --album-id: %w", uc.AlbumID, err)
+	}
+	ids := make([]string, 0, len(r.Assets))
+	for _, a := range r.Assets {
+		ids = append(ids, a.ID)
+	}
+	uc.app.Log().Info("got the album given by --album-id", "album", r.AlbumName, "id", r.ID, "assets", len(ids))
+	return assets.NewAlbum(r.ID, r.AlbumName, r.Description), ids, nil
+}
@@ -0,0 +1,80 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/assets/cache"
+)
+
+func TestGetImmichAlbumsWithAlbumID(t *testing.T) {
+	var lock sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests = append(requests, r.URL.Path)
+		lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/albums":
+			_ = json.NewEncoder(w).Encode([]immich.AlbumSimplified{
+				{ID: "a1", AlbumName: "Holidays", AssetCount: 1},
+				{ID: "a2", AlbumName: "Done", AssetCount: 1},
+			})
+		case "/api/albums/a2":
+			_ = json.NewEncoder(w).Encode(immich.AlbumContent{ID: "a2", AlbumName: "Done", Assets: []*immich.Asset{{ID: "s1"}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		skipIfInAlbum string
+		want          []string
+	}{
+		{name: "album id", want: nil},
+		{name: "album id, skip if in album", skipIfInAlbum: "Done", want: []string{"/api/albums", "/api/albums", "/api/albums/a2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			ic, err := immich.NewImmichClient(server.URL, "1234")
+			if err != nil {
+				t.Fatal(err)
+			}
+			uc := newTestUpCmd(t)
+			uc.client.Immich = ic
+			uc.AlbumID = "target"
+			uc.SkipIfInAlbum = tt.skipIfInAlbum
+			uc.assetIndex = newAssetIndex()
+			uc.assetIndex.addImmichAsset(&immich.Asset{ID: "s1", Checksum: "c1"})
+			uc.immichAssetsReady = make(chan struct{})
+			close(uc.immichAssetsReady)
+			uc.albumsCache = cache.NewCollectionCache(10, func(album assets.Album, ids []string) (assets.Album, error) {
+				return album, nil
+			})
+
+			if err := uc.getImmichAlbums(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			// GetAllAlbums lists the owned albums and the shared ones
+			if !slices.Equal(requests, tt.want) {
+				t.Errorf("requests %v, want %v", requests, tt.want)
+			}
+			if tt.skipIfInAlbum != "" {
+				if _, ok := uc.excludeAlbum.checksums["c1"]; !ok {
+					t.Error("the album of --skip-if-in-album isn't read")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package upload
+
+import (
+	"sync"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+// albumMembers records the members of the albums created by the upload,
+// to set their cover and their settings once the upload ends
+type albumMembers struct {
+	lock   sync.Mutex
+	albums map[string]*createdAlbum // by album key
+}
+
+type createdAlbum struct {
+	title       string
+	description string // description given by the input
+	albumID     string // set when the album is created by the upload
+	members     []albumMember
+}
+
+type albumMember struct {
+	id   string
+	date time.Time
+}
+
+func (am *albumMembers) album(key, title string) *createdAlbum {
+	if am.albums == nil {
+		am.albums = map[string]*createdAlbum{}
+	}
+	c, ok := am.albums[key]
+	if !ok {
+		c = &createdAlbum{title: title}
+		am.albums[key] = c
+	}
+	return c
+}
+
+// addMember records an asset added to the album
+func (am *albumMembers) addMember(key, title, id string, date time.Time) {
+	am.lock.Lock()
+	defer am.lock.Unlock()
+	c := am.album(key, title)
+	c.members = append(c.members, albumMember{id: id, date: date})
+}
+
+// created records the ID of an album created by the upload
+func (am *albumMembers) created(key string, album assets.Album) {
+	am.lock.Lock()
+	defer am.lock.Unlock()
+	c := am.album(key, album.Title)
+	c.albumID = album.ID
+	c.description = album.Description
+}
+
+// albumCreated records the album created by the upload, to set its cover and its settings at the end,
+// and to list it in the summary
+func (uc *UpCmd) albumCreated(album assets.Album) {
+	uc.createdAlbums.created(album)
+	if uc.trackCreatedAlbums() {
+		uc.albumMembers.created(uc.albumNames.key(album.Title), album)
+	}
+}
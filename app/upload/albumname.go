@@ -0,0 +1,72 @@
+package upload
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/simulot/immich-go/internal/fileprocessor"
+)
+
+// --album-name-match values
+const (
+	AlbumMatchExact = "exact" // the names must be identical
+	AlbumMatchTrim  = "trim"  // the leading and trailing spaces are ignored
+	AlbumMatchCI    = "ci"    // the spaces and the case are ignored
+)
+
+// albumNames gives the key of an album in the cache, so the albums with names differing
+// only by spaces or case are merged. The merged names are kept for the report.
+type albumNames struct {
+	mode   string
+	lock   sync.Mutex
+	first  map[string]string              // first name seen by key, the one used for the album
+	merged map[string]map[string]struct{} // other names merged into the first one
+}
+
+// key gives the normalized album name, and records the name merged with another one.
+func (an *albumNames) key(title string) string {
+	var k string
+	switch an.mode {
+	case AlbumMatchTrim:
+		k = strings.TrimSpace(title)
+	case AlbumMatchCI:
+		k = strings.ToLower(strings.TrimSpace(title))
+	default:
+		return title
+	}
+
+	an.lock.Lock()
+	defer an.lock.Unlock()
+	if an.first == nil {
+		an.first = map[string]string{}
+		an.merged = map[string]map[string]struct{}{}
+	}
+	first, ok := an.first[k]
+	if !ok {
+		an.first[k] = title
+		return k
+	}
+	if first != title {
+		names, ok := an.merged[k]
+		if !ok {
+			names = map[string]struct{}{}
+			an.merged[k] = names
+		}
+		names[title] = struct{}{}
+	}
+	return k
+}
+
+// merges lists the merged album names, sorted by the name kept for the album
+func (an *albumNames) merges() []fileprocessor.MergedAlbumSummary {
+	an.lock.Lock()
+	defer an.lock.Unlock()
+	list := []fileprocessor.MergedAlbumSummary{}
+	for k, names := range an.merged {
+		list = append(list, fileprocessor.MergedAlbumSummary{Name: an.first[k], Merged: slices.Sorted(maps.Keys(names))})
+	}
+	slices.SortFunc(list, func(a, b fileprocessor.MergedAlbumSummary) int { return strings.Compare(a.Name, b.Name) })
+	return list
+}
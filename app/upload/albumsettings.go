@@ -0,0 +1,101 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/simulot/immich-go/immich"
+)
+
+// --album-activity values
+const (
+	AlbumActivityOn  = "on"
+	AlbumActivityOff = "off"
+)
+
+// albumDescriptionData is given to the --album-description-template template
+type albumDescriptionData struct {
+	Name        string    // Album title
+	Description string    // Description given by the input, like the takeout's album JSON
+	Start       time.Time // Capture date of the oldest member, zero when unknown
+	End         time.Time // Capture date of the newest member, zero when unknown
+	Count       int       // Number of assets added to the album by the upload
+}
+
+// newAlbumDescriptionTemplate parses the template, and checks it against a sample album
+func newAlbumDescriptionTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("album-description").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for --album-description-template: %w", err)
+	}
+	sample := albumDescriptionData{Name: "album", Start: time.Now(), End: time.Now(), Count: 1}
+	if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return nil, fmt.Errorf("invalid value for --album-description-template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// trackCreatedAlbums tells if the members of the albums created by the upload are recorded,
+// for their cover or their settings
+func (uc *UpCmd) trackCreatedAlbums() bool {
+	return uc.SetAlbumCover || uc.albumDescription != nil || uc.AlbumActivity != ""
+}
+
+// albumSettings gives the settings of the created album, from --album-description-template and --album-activity
+func (uc *UpCmd) albumSettings(c *createdAlbum) (immich.AlbumSettings, error) {
+	var settings immich.AlbumSettings
+	if uc.albumDescription != nil {
+		d := albumDescriptionData{Name: c.title, Description: c.description, Count: len(c.members)}
+		for _, m := range c.members {
+			if m.date.IsZero() {
+				continue
+			}
+			if d.Start.IsZero() || m.date.Before(d.Start) {
+				d.Start = m.date
+			}
+			if m.date.After(d.End) {
+				d.End = m.date
+			}
+		}
+		b := bytes.Buffer{}
+		if err := uc.albumDescription.Execute(&b, d); err != nil {
+			return settings, err
+		}
+		description := strings.TrimSpace(b.String())
+		settings.Description = &description
+	}
+	if uc.AlbumActivity != "" {
+		enabled := uc.AlbumActivity == AlbumActivityOn
+		settings.IsActivityEnabled = &enabled
+	}
+	return settings, nil
+}
+
+// setAlbumSettings applies --album-description-template and --album-activity to the albums created by the upload,
+// once all their members are added. The albums already on the server keep their settings.
+func (uc *UpCmd) setAlbumSettings(ctx context.Context) {
+	members := &uc.albumMembers
+	members.lock.Lock()
+	defer members.lock.Unlock()
+
+	for _, c := range members.albums {
+		if c.albumID == "" || ctx.Err() != nil {
+			continue
+		}
+		settings, err := uc.albumSettings(c)
+		if err != nil {
+			uc.app.Log().Error("can't apply the --album-description-template", "album", c.title, "err", err)
+			continue
+		}
+		err = uc.client.Immich.UpdateAlbumSettings(ctx, c.albumID, settings)
+		if err != nil {
+			uc.app.Log().Error("can't set the album settings", "album", c.title, "err", err)
+			continue
+		}
+		uc.app.Log().Info("album settings set", "album", c.title, "activity", uc.AlbumActivity)
+	}
+}
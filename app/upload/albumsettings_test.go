@@ -0,0 +1,77 @@
+package upload
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlbumSettings(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2023, 7, d, 10, 30, 0, 0, time.UTC) }
+	const tmpl = `{{.Name}}: {{if .Start.IsZero}}no date{{else}}{{.Start.Format "2006-01-02"}}..{{.End.Format "2006-01-02"}}{{end}}, {{.Count}} {{.Description}}`
+	tests := []struct {
+		name    string
+		members []albumMember
+		want    string
+	}{
+		{
+			name:    "members in any order",
+			members: []albumMember{{id: "1", date: day(14)}, {id: "2", date: day(3)}, {id: "3", date: day(21)}},
+			want:    "Holidays: 2023-07-03..2023-07-21, 3 from the takeout",
+		},
+		{
+			name:    "members without date ignored",
+			members: []albumMember{{id: "1"}, {id: "2", date: day(14)}, {id: "3"}},
+			want:    "Holidays: 2023-07-14..2023-07-14, 3 from the takeout",
+		},
+		{
+			name:    "no member with a date",
+			members: []albumMember{{id: "1"}},
+			want:    "Holidays: no date, 1 from the takeout",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := newTestUpCmd(t)
+			var err error
+			uc.albumDescription, err = newAlbumDescriptionTemplate(tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := uc.albumSettings(&createdAlbum{title: "Holidays", description: "from the takeout", members: tt.members})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Description == nil || *s.Description != tt.want {
+				t.Errorf("description = %v, want %q", s.Description, tt.want)
+			}
+			if s.IsActivityEnabled != nil {
+				t.Errorf("activity set without --album-activity")
+			}
+		})
+	}
+}
+
+func TestAlbumSettingsActivity(t *testing.T) {
+	for _, activity := range []string{AlbumActivityOn, AlbumActivityOff} {
+		uc := newTestUpCmd(t)
+		uc.AlbumActivity = activity
+		s, err := uc.albumSettings(&createdAlbum{title: "Holidays"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Description != nil {
+			t.Errorf("%s: description set without --album-description-template", activity)
+		}
+		if s.IsActivityEnabled == nil || *s.IsActivityEnabled != (activity == AlbumActivityOn) {
+			t.Errorf("%s: activity = %v", activity, s.IsActivityEnabled)
+		}
+	}
+}
+
+func TestAlbumDescriptionTemplateInvalid(t *testing.T) {
+	for _, tmpl := range []string{"{{.Name", "{{.Unknown}}"} {
+		if _, err := newAlbumDescriptionTemplate(tmpl); err == nil {
+			t.Errorf("%q: want an error", tmpl)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package upload
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+)
+
+// albumSummary collects the albums updated by the upload, for the created_albums of the summary
+type albumSummary struct {
+	lock   sync.Mutex
+	albums map[string]*fileprocessor.AlbumSummary // by album ID
+}
+
+func (as *albumSummary) album(album assets.Album) *fileprocessor.AlbumSummary {
+	if as.albums == nil {
+		as.albums = map[string]*fileprocessor.AlbumSummary{}
+	}
+	s, ok := as.albums[album.ID]
+	if !ok {
+		s = &fileprocessor.AlbumSummary{Name: album.Title, ID: album.ID, Existing: true}
+		as.albums[album.ID] = s
+	}
+	return s
+}
+
+// created records an album created by the upload
+func (as *albumSummary) created(album assets.Album) {
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	as.album(album).Existing = false
+}
+
+// added counts the assets added to the album
+func (as *albumSummary) added(album assets.Album, n int) {
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	as.album(album).AssetCount += n
+}
+
+// list gives the created albums sorted by name, and the existing ones too when asked
+func (as *albumSummary) list(withExisting bool) []fileprocessor.AlbumSummary {
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	var l []fileprocessor.AlbumSummary
+	for _, s := range as.albums {
+		if !s.Existing || withExisting {
+			l = append(l, *s)
+		}
+	}
+	slices.SortFunc(l, func(a, b fileprocessor.AlbumSummary) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return l
+}
@@ -0,0 +1,36 @@
+package upload
+
+import (
+	"context"
+
+	"github.com/simulot/immich-go/immich"
+)
+
+// runDedup starts the server's duplicate detection job and reports the duplicate sets known by the server.
+// The job runs in the background: the newly uploaded assets may not be counted yet.
+func (uc *UpCmd) runDedup(ctx context.Context) {
+	if uc.app.DryRun {
+		uc.app.Log().Info("dry-run: the duplicate detection job is not started")
+	} else {
+		_, err := uc.client.AdminImmich.SendJobCommand(ctx, immich.DuplicateDetection, immich.Start, false)
+		if err != nil {
+			uc.app.Log().Warn("can't start the server's duplicate detection job, the existing duplicate sets are reported. Pass an administrator key with the flag --admin-api-key", "err", err)
+		} else {
+			uc.app.Log().Info("Immich Job command sent", "start", immich.DuplicateDetection)
+		}
+	}
+
+	dc, ok := uc.client.Immich.(immich.ImmichDuplicateInterface)
+	if !ok {
+		uc.app.Log().Warn("the client can't query the duplicate sets")
+		return
+	}
+	sets, err := dc.GetDuplicates(ctx)
+	if err != nil {
+		uc.app.Log().Warn("can't get the duplicate sets from the server", "err", err)
+		return
+	}
+	uc.duplicateSets = len(sets)
+	uc.app.SetDuplicateSets(uc.duplicateSets)
+	uc.app.Log().Info("server's duplicate detection", "duplicate_sets_found", uc.duplicateSets)
+}
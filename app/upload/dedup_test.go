@@ -0,0 +1,56 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+)
+
+func TestRunDedup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/duplicates" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"duplicateId":"d1","assets":[]},{"duplicateId":"d2","assets":[]}]`))
+	}))
+	defer server.Close()
+
+	ic, err := immich.NewImmichClient(server.URL, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc := newTestUpCmd(t)
+	uc.app.DryRun = true // the job isn't started
+	uc.client.Immich = ic
+	uc.duplicateSets = -1
+
+	uc.runDedup(context.Background())
+	if uc.duplicateSets != 2 {
+		t.Errorf("duplicateSets = %d, want 2", uc.duplicateSets)
+	}
+
+	// the summary gives the duplicate sets
+	uc.app.SummaryFile = filepath.Join(t.TempDir(), "summary.json")
+	uc.app.WriteSummaryFile(&cobra.Command{Use: "upload"}, nil)
+	b, err := os.ReadFile(uc.app.SummaryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary fileprocessor.RunSummary
+	if err := json.Unmarshal(b, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.DuplicateSetsFound == nil || *summary.DuplicateSetsFound != 2 {
+		t.Errorf("unexpected duplicate_sets_found in %s", b)
+	}
+}
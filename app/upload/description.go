@@ -0,0 +1,66 @@
+package upload
+
+import (
+	"context"
+	"strings"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+// Description modes, used when the server's asset already has a description
+const (
+	DescriptionSkip      = "skip"      // keep the server's description
+	DescriptionOverwrite = "overwrite" // replace the server's description
+	DescriptionAppend    = "append"    // add the local description after the server's one
+)
+
+// mergeDescription gives the description to set on the server.
+// It returns an empty string when there is nothing to change.
+func mergeDescription(mode, server, local string) string {
+	local = strings.TrimSpace(local)
+	if local == "" || local == server {
+		return ""
+	}
+	if server == "" {
+		return local
+	}
+	switch mode {
+	case DescriptionOverwrite:
+		return local
+	case DescriptionAppend:
+		if strings.Contains(server, local) {
+			return ""
+		}
+		return server + "\n" + local
+	}
+	return ""
+}
+
+// localDescription returns the description given by the sidecar, if any
+func localDescription(a *assets.Asset) string {
+	if a.FromApplication != nil && a.FromApplication.Description != "" {
+		return a.FromApplication.Description
+	}
+	return a.Description
+}
+
+// manageAssetDescription sets the description of an asset already present on the server, with --import-descriptions.
+// The assets sent by the upload pass of --two-pass get their description like the uploaded ones.
+func (uc *UpCmd) manageAssetDescription(ctx context.Context, a *assets.Asset, serverAsset *assets.Asset, uploaded bool) {
+	if (!uc.ImportDescriptions && !uploaded) || serverAsset == nil || uc.uploadOnly() {
+		return
+	}
+	d := mergeDescription(uc.DescriptionMode, serverAsset.Description, localDescription(a))
+	if d == "" {
+		return
+	}
+	_, err := uc.client.Immich.UpdateAsset(ctx, serverAsset.ID, immich.UpdAssetField{Description: d})
+	if err != nil {
+		uc.app.Log().Error("can't set the asset description", "file", a.File, "error", err)
+		return
+	}
+	serverAsset.Description = d
+	uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedDescriptionSet, a.File)
+}
@@ -0,0 +1,97 @@
+package upload
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+func TestMergeDescription(t *testing.T) {
+	tests := []struct {
+		name          string
+		server, local string
+		want          map[string]string // by mode
+	}{
+		{
+			name: "empty server", server: "", local: "At the beach",
+			want: map[string]string{DescriptionSkip: "At the beach", DescriptionOverwrite: "At the beach", DescriptionAppend: "At the beach"},
+		},
+		{
+			name: "empty local", server: "Paris", local: "  ",
+			want: map[string]string{DescriptionSkip: "", DescriptionOverwrite: "", DescriptionAppend: ""},
+		},
+		{
+			name: "equal", server: "Paris", local: "Paris\n",
+			want: map[string]string{DescriptionSkip: "", DescriptionOverwrite: "", DescriptionAppend: ""},
+		},
+		{
+			name: "different", server: "Paris", local: "At the beach",
+			want: map[string]string{DescriptionSkip: "", DescriptionOverwrite: "At the beach", DescriptionAppend: "Paris\nAt the beach"},
+		},
+		{
+			name: "already appended", server: "Paris\nAt the beach", local: "At the beach",
+			want: map[string]string{DescriptionSkip: "", DescriptionOverwrite: "At the beach", DescriptionAppend: ""},
+		},
+	}
+	for _, tt := range tests {
+		for _, mode := range []string{DescriptionSkip, DescriptionOverwrite, DescriptionAppend} {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				if got := mergeDescription(mode, tt.server, tt.local); got != tt.want[mode] {
+					t.Errorf("mergeDescription(%q, %q, %q) = %q, want %q", mode, tt.server, tt.local, got, tt.want[mode])
+				}
+			})
+		}
+	}
+}
+
+func TestManageAssetDescription(t *testing.T) {
+	var puts atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPut && r.URL.Path == "/api/assets/s1" {
+			puts.Add(1)
+			_, _ = w.Write([]byte(`{"id":"s1"}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	ic, err := immich.NewImmichClient(server.URL, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		imports  bool
+		uploaded bool
+		want     string
+	}{
+		{name: "server asset, by default", want: ""},
+		{name: "server asset, --import-descriptions", imports: true, want: "At the beach"},
+		{name: "sent by the upload pass", uploaded: true, want: "At the beach"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puts.Store(0)
+			uc := newTestUpCmd(t)
+			uc.client.Immich = ic
+			uc.ImportDescriptions = tt.imports
+			uc.DescriptionMode = DescriptionSkip
+			a := &assets.Asset{FromApplication: &assets.Metadata{Description: "At the beach"}}
+			sa := &assets.Asset{ID: "s1"}
+			uc.manageAssetDescription(context.Background(), a, sa, tt.uploaded)
+			if sa.Description != tt.want {
+				t.Errorf("server description = %q, want %q", sa.Description, tt.want)
+			}
+			if sent := puts.Load() > 0; sent != (tt.want != "") {
+				t.Errorf("update sent: %v, want %v", sent, tt.want != "")
+			}
+		})
+	}
+}
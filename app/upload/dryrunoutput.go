@@ -0,0 +1,64 @@
+package upload
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/simulot/immich-go/adapters"
+)
+
+// dryRunItem is a line of the --dry-run-output file.
+// The fields are in the alphabetical order, and nothing depends on the run, so two files can be compared with diff.
+type dryRunItem struct {
+	Action string   `json:"action"`
+	Album  []string `json:"album,omitempty"`
+	Path   string   `json:"path"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+// dryRunOutput writes the decision taken for each asset of the input into the file, sorted by path
+func (uc *UpCmd) dryRunOutput(ctx context.Context, adapter adapters.Reader, name string, w io.Writer) error {
+	var items []dryRunItem
+	err := uc.planItems(ctx, adapter, func(item planItem) error {
+		albums := slices.Clone(item.Album)
+		slices.Sort(albums)
+		items = append(items, dryRunItem{
+			Action: item.Action,
+			Album:  albums,
+			Path:   item.Path,
+			Reason: item.Reason,
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(items, func(a, b dryRunItem) int {
+		return cmp.Or(
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(a.Action, b.Action),
+			cmp.Compare(a.Reason, b.Reason),
+		)
+	})
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("can't write the dry run output: %w", err)
+	}
+	uc.app.Log().Info("dry run output written", "file", name, "assets", len(items))
+	fmt.Fprintf(w, "Decisions for %d assets written to %s\n", len(items), name)
+	return nil
+}
@@ -0,0 +1,61 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+// the paths sorted, the keys in the alphabetical order and the albums sorted
+const dryRunGolden = `{"action":"skip","path":"a/movie.avi","reason":"unsupported"}
+{"action":"upload","album":["Holidays","Zoo"],"path":"b/new.jpg","reason":"This a new asset, upload it."}
+{"action":"duplicate","path":"c/same.jpg","reason":"An asset with the same name:\"same.jpg\", date:\"0001-01-01 00:00:00\" and size:3 B exists on the server. No need to upload."}
+`
+
+func TestDryRunOutput(t *testing.T) {
+	fsys := fstest.MapFS{
+		"c/same.jpg":  &fstest.MapFile{Data: []byte("abc")},
+		"b/new.jpg":   &fstest.MapFile{Data: []byte("new")},
+		"a/movie.avi": &fstest.MapFile{Data: []byte("avi")},
+	}
+	asset := func(name string, albums ...string) *assets.Asset {
+		a := &assets.Asset{File: fshelper.FSName(fsys, name), OriginalFileName: filepath.Base(name), FileSize: len(fsys[name].Data)}
+		for _, al := range albums {
+			a.Albums = append(a.Albums, assets.NewAlbum("", al, ""))
+		}
+		return a
+	}
+	g := assets.NewGroup(assets.GroupByNone, asset("c/same.jpg"), asset("b/new.jpg", "Zoo", "Holidays"))
+	removed := asset("a/movie.avi")
+	g2 := assets.NewGroup(assets.GroupByOther, removed)
+	g2.RemoveAsset(removed, "unsupported")
+
+	uc := newTestUpCmd(t)
+	uc.immichAssetsReady = make(chan struct{})
+	uc.client.Immich = newInventoryServer(t,
+		immich.Asset{ID: "s1", OriginalFileName: "same.jpg", Checksum: "qZk+NkcGgWq6PiVxeFDCbJzQ2J0=", ExifInfo: immich.ExifInfo{FileSizeInByte: 3}},
+	)
+
+	name := filepath.Join(t.TempDir(), "dry-run.jsonl")
+	out := bytes.NewBuffer(nil)
+	if err := uc.dryRunOutput(context.Background(), groupsReader{groups: []*assets.Group{g, g2}}, name, out); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != dryRunGolden {
+		t.Errorf("got:\n%s\nwant:\n%s", got, dryRunGolden)
+	}
+	if want := "Decisions for 3 assets written to " + name + "\n"; out.String() != want {
+		t.Errorf("message = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,85 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+// excludeAlbum gives the checksums of the members of the server's album given by --skip-if-in-album
+type excludeAlbum struct {
+	checksums map[string]struct{} // nil until the album is found on the server
+	sizes     map[int]struct{}    // sizes of the members, only the input files of these sizes are hashed
+	unsized   bool                // a member has no size, all the input files are hashed
+}
+
+// setExcludeAlbum keeps the checksums of the album's members, once the server's assets are read
+func (uc *UpCmd) setExcludeAlbum(ids []string) {
+	uc.excludeAlbum.checksums = make(map[string]struct{}, len(ids))
+	uc.excludeAlbum.sizes = make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		if a := uc.assetIndex.getByID(id); a != nil && a.Checksum != "" {
+			uc.excludeAlbum.checksums[a.Checksum] = struct{}{}
+			if a.FileSize > 0 {
+				uc.excludeAlbum.sizes[a.FileSize] = struct{}{}
+			} else {
+				uc.excludeAlbum.unsized = true
+			}
+		}
+	}
+	uc.app.Log().Info("album of --skip-if-in-album", "album", uc.SkipIfInAlbum, "assets", len(uc.excludeAlbum.checksums))
+}
+
+// loadExcludeAlbum reads the album given by --skip-if-in-album without the other albums, for --plan
+func (uc *UpCmd) loadExcludeAlbum(ctx context.Context) error {
+	serverAlbums, err := uc.client.Immich.GetAllAlbums(ctx)
+	if err != nil {
+		return fmt.Errorf("can't get the album list from the server: %w", err)
+	}
+	key := uc.albumNames.key(uc.SkipIfInAlbum)
+	for _, a := range serverAlbums {
+		if uc.albumNames.key(a.AlbumName) != key {
+			continue
+		}
+		r, err := uc.client.Immich.GetAlbumInfo(ctx, a.ID, false)
+		if err != nil {
+			return fmt.Errorf("can't get the album %q given by --skip-if-in-album: %w", uc.SkipIfInAlbum, err)
+		}
+		ids := make([]string, 0, len(r.Assets))
+		for _, aa := range r.Assets {
+			ids = append(ids, aa.ID)
+		}
+		uc.setExcludeAlbum(ids)
+		break
+	}
+	return uc.checkExcludeAlbum()
+}
+
+// checkExcludeAlbum fails when the album given by --skip-if-in-album isn't on the server,
+// rather than uploading the assets it should exclude
+func (uc *UpCmd) checkExcludeAlbum() error {
+	if uc.SkipIfInAlbum != "" && uc.excludeAlbum.checksums == nil {
+		return fmt.Errorf("the album %q given by --skip-if-in-album isn't on the server", uc.SkipIfInAlbum)
+	}
+	return nil
+}
+
+// inExcludeAlbum tells if the asset's checksum matches a member of the album given by --skip-if-in-album.
+// The asset is hashed only when a member has its size.
+func (uc *UpCmd) inExcludeAlbum(a *assets.Asset) (bool, error) {
+	if len(uc.excludeAlbum.checksums) == 0 {
+		return false, nil
+	}
+	if a.FileSize > 0 && !uc.excludeAlbum.unsized {
+		if _, ok := uc.excludeAlbum.sizes[a.FileSize]; !ok {
+			return false, nil
+		}
+	}
+	checksum, err := a.GetChecksum()
+	if err != nil {
+		return false, err
+	}
+	_, ok := uc.excludeAlbum.checksums[checksum]
+	return ok, nil
+}
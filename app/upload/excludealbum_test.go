@@ -0,0 +1,111 @@
+package upload
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+func TestInExcludeAlbum(t *testing.T) {
+	fsys := fstest.MapFS{
+		"member.jpg":    &fstest.MapFile{Data: []byte("abc")},
+		"same-size.jpg": &fstest.MapFile{Data: []byte("xyz")},
+		"other.jpg":     &fstest.MapFile{Data: []byte("abcd")},
+	}
+	abc := "qZk+NkcGgWq6PiVxeFDCbJzQ2J0=" // SHA1 of abc
+	tests := []struct {
+		name       string
+		members    []immich.Asset // nil for no album
+		file       string
+		wantIn     bool
+		wantHashed bool
+	}{
+		{name: "no album", file: "member.jpg"},
+		{
+			name:    "member",
+			members: []immich.Asset{{ID: "s1", Checksum: abc, ExifInfo: immich.ExifInfo{FileSizeInByte: 3}}},
+			file:    "member.jpg", wantIn: true, wantHashed: true,
+		},
+		{
+			name:    "same size, other content",
+			members: []immich.Asset{{ID: "s1", Checksum: abc, ExifInfo: immich.ExifInfo{FileSizeInByte: 3}}},
+			file:    "same-size.jpg", wantHashed: true,
+		},
+		{
+			name:    "no member of this size",
+			members: []immich.Asset{{ID: "s1", Checksum: abc, ExifInfo: immich.ExifInfo{FileSizeInByte: 3}}},
+			file:    "other.jpg",
+		},
+		{
+			name:    "member without size",
+			members: []immich.Asset{{ID: "s1", Checksum: abc}},
+			file:    "other.jpg", wantHashed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := newTestUpCmd(t)
+			uc.SkipIfInAlbum = "Done"
+			uc.assetIndex = newAssetIndex()
+			if tt.members != nil {
+				var ids []string
+				for i := range tt.members {
+					uc.assetIndex.addImmichAsset(&tt.members[i])
+					ids = append(ids, tt.members[i].ID)
+				}
+				uc.setExcludeAlbum(ids)
+			}
+			a := &assets.Asset{File: fshelper.FSName(fsys, tt.file), OriginalFileName: tt.file, FileSize: len(fsys[tt.file].Data)}
+			in, err := uc.inExcludeAlbum(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if in != tt.wantIn {
+				t.Errorf("inExcludeAlbum = %v, want %v", in, tt.wantIn)
+			}
+			if hashed := a.Checksum != ""; hashed != tt.wantHashed {
+				t.Errorf("hashed = %v, want %v", hashed, tt.wantHashed)
+			}
+		})
+	}
+}
+
+func TestCheckExcludeAlbum(t *testing.T) {
+	uc := newTestUpCmd(t)
+	if err := uc.checkExcludeAlbum(); err != nil {
+		t.Errorf("without --skip-if-in-album: %v", err)
+	}
+	uc.SkipIfInAlbum = "Done"
+	if err := uc.checkExcludeAlbum(); err == nil {
+		t.Error("the album isn't on the server: want an error")
+	}
+	uc.assetIndex = newAssetIndex()
+	uc.setExcludeAlbum(nil)
+	if err := uc.checkExcludeAlbum(); err != nil {
+		t.Errorf("empty album on the server: %v", err)
+	}
+}
+
+func TestHandleAssetInExcludeAlbum(t *testing.T) {
+	fsys := fstest.MapFS{"IMG_0001.jpg": &fstest.MapFile{Data: []byte("abc")}}
+	uc := newTestUpCmd(t)
+	uc.SkipIfInAlbum = "Done"
+	uc.assetIndex = newAssetIndex()
+	uc.assetIndex.addImmichAsset(&immich.Asset{ID: "s1", OriginalFileName: "other.jpg", Checksum: "qZk+NkcGgWq6PiVxeFDCbJzQ2J0=", ExifInfo: immich.ExifInfo{FileSizeInByte: 3}})
+	uc.setExcludeAlbum([]string{"s1"})
+	a := &assets.Asset{File: fshelper.FSName(fsys, "IMG_0001.jpg"), OriginalFileName: "IMG_0001.jpg", FileSize: 3}
+	fp := uc.app.FileProcessor()
+	fp.RecordAssetDiscovered(context.Background(), a.File, 3, fileevent.DiscoveredImage)
+
+	if err := uc.handleAsset(context.Background(), a); err != nil {
+		t.Fatal(err)
+	}
+	if n := fp.Logger().GetCounts()[fileevent.DiscardedInExcludeAlbum]; n != 1 {
+		t.Errorf("discarded in the album: %d, want 1", n)
+	}
+}
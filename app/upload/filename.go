@@ -0,0 +1,111 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+// filenameData is given to the --filename-template template
+type filenameData struct {
+	Name  string    // Original file name, without extension
+	Ext   string    // Original extension, with the dot
+	Date  time.Time // Capture date, or the file date when unknown
+	Album string    // Title of the first album of the asset
+	Index int64     // Rank of the asset in the input, starting at 1
+}
+
+// filenameTemplate renames the assets before their upload.
+// The assets are numbered when their group is read, the workers' order doesn't change the index.
+type filenameTemplate struct {
+	tmpl  *template.Template
+	next  int64    // last given rank, used by the group reader only
+	ranks sync.Map // *assets.Asset -> rank
+}
+
+// newFilenameTemplate parses the template, and checks it against a sample asset
+func newFilenameTemplate(text string) (*filenameTemplate, error) {
+	tmpl, err := template.New("filename").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for --filename-template: %w", err)
+	}
+	sample := filenameData{Name: "IMG_0001", Ext: ".jpg", Date: time.Now(), Album: "album", Index: 1}
+	if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return nil, fmt.Errorf("invalid value for --filename-template: %w", err)
+	}
+	return &filenameTemplate{tmpl: tmpl}, nil
+}
+
+// name gives the new name of the asset. The original extension is kept.
+func (ft *filenameTemplate) name(a *assets.Asset) (string, error) {
+	ext := path.Ext(a.OriginalFileName)
+	d := filenameData{
+		Name: strings.TrimSuffix(a.OriginalFileName, ext),
+		Ext:  ext,
+		Date: a.CaptureDate,
+	}
+	if rank, ok := ft.ranks.Load(a); ok {
+		d.Index = rank.(int64)
+	}
+	if d.Date.IsZero() {
+		d.Date = a.FileDate
+	}
+	if len(a.Albums) > 0 {
+		d.Album = a.Albums[0].Title
+	}
+
+	b := bytes.Buffer{}
+	if err := ft.tmpl.Execute(&b, d); err != nil {
+		return "", err
+	}
+	// the name can't contain a path
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(strings.TrimSpace(b.String()))
+	if name == "" {
+		return "", fmt.Errorf("the template gives an empty name")
+	}
+	if !strings.EqualFold(path.Ext(name), ext) {
+		name += ext
+	}
+	return name, nil
+}
+
+// number gives their rank to the assets of the group, in the input order.
+// The returned function forgets them once the group is handled.
+func (ft *filenameTemplate) number(g *assets.Group) func() {
+	if ft == nil {
+		return func() {}
+	}
+	members := slices.Clone(g.Assets)
+	for _, a := range members {
+		ft.next++
+		ft.ranks.Store(a, ft.next)
+	}
+	return func() {
+		for _, a := range members {
+			ft.ranks.Delete(a)
+		}
+	}
+}
+
+// renameAsset applies the --filename-template to the asset.
+// The asset keeps its original name when the template fails.
+func (uc *UpCmd) renameAsset(ctx context.Context, a *assets.Asset) {
+	if uc.filenameTemplate == nil {
+		return
+	}
+	name, err := uc.filenameTemplate.name(a)
+	if err != nil {
+		uc.app.Log().Warn("can't apply the filename template, the original name is kept", "file", a.File, "err", err)
+		return
+	}
+	uc.app.Log().DebugContext(ctx, "asset renamed", "file", a.File, "name", name)
+	a.OriginalFileName = name
+}
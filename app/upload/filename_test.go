@@ -0,0 +1,97 @@
+package upload
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+func TestFilenameTemplate(t *testing.T) {
+	date := time.Date(2023, 7, 14, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		template string
+		asset    assets.Asset
+		want     string
+		wantErr  bool
+	}{
+		{name: "date and name", template: `{{.Date.Format "2006-01-02"}}_{{.Name}}`, asset: assets.Asset{OriginalFileName: "IMG_0001.jpg", CaptureDate: date}, want: "2023-07-14_IMG_0001.jpg"},
+		{name: "file date when no capture date", template: `{{.Date.Format "2006"}}_{{.Name}}`, asset: assets.Asset{OriginalFileName: "IMG_0001.jpg", FileDate: date}, want: "2023_IMG_0001.jpg"},
+		{name: "album", template: `{{.Album}}-{{.Name}}`, asset: assets.Asset{OriginalFileName: "IMG_0001.jpg", Albums: []assets.Album{assets.NewAlbum("", "Holidays", "")}}, want: "Holidays-IMG_0001.jpg"},
+		{name: "extension given by the template", template: `{{.Name}}{{.Ext}}`, asset: assets.Asset{OriginalFileName: "IMG_0001.JPG"}, want: "IMG_0001.JPG"},
+		{name: "no path", template: `{{.Album}}/{{.Name}}`, asset: assets.Asset{OriginalFileName: "IMG_0001.jpg", Albums: []assets.Album{assets.NewAlbum("", `a\b`, "")}}, want: "a_b_IMG_0001.jpg"},
+		{name: "empty name", template: `{{.Album}}`, asset: assets.Asset{OriginalFileName: "IMG_0001.jpg"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft, err := newFilenameTemplate(tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ft.name(&tt.asset)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("name() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("name() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilenameTemplateInvalid(t *testing.T) {
+	for _, text := range []string{`{{.Name`, `{{.Unknown}}`} {
+		if _, err := newFilenameTemplate(text); err == nil {
+			t.Errorf("the template %q should be rejected", text)
+		}
+	}
+}
+
+func TestFilenameTemplateIndex(t *testing.T) {
+	ft, err := newFilenameTemplate(`{{.Index}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := make([]*assets.Group, 5)
+	for i := range groups {
+		groups[i] = &assets.Group{Assets: []*assets.Asset{{OriginalFileName: "a.jpg"}, {OriginalFileName: "b.jpg"}}}
+	}
+
+	// the groups are numbered when read, and renamed in any order by the workers
+	forgets := make([]func(), len(groups))
+	for i, g := range groups {
+		forgets[i] = ft.number(g)
+	}
+	names := make([][]string, len(groups))
+	var wg sync.WaitGroup
+	for i := len(groups) - 1; i >= 0; i-- {
+		wg.Go(func() {
+			defer forgets[i]()
+			for _, a := range groups[i].Assets {
+				name, err := ft.name(a)
+				if err != nil {
+					t.Error(err)
+				}
+				names[i] = append(names[i], name)
+			}
+		})
+	}
+	wg.Wait()
+
+	for i := range groups {
+		want := []string{strconv.Itoa(2*i+1) + ".jpg", strconv.Itoa(2*i+2) + ".jpg"}
+		if names[i][0] != want[0] || names[i][1] != want[1] {
+			t.Errorf("group %d: names %v, want %v", i, names[i], want)
+		}
+	}
+
+	// the ranks are forgotten once the groups are handled
+	n := 0
+	ft.ranks.Range(func(_, _ any) bool { n++; return true })
+	if n != 0 {
+		t.Errorf("%d ranks are kept", n)
+	}
+}
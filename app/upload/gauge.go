@@ -0,0 +1,60 @@
+package upload
+
+import (
+	"sync/atomic"
+)
+
+// --progress-format values
+const (
+	ProgressText  = "text"  // the progress line of the line mode
+	ProgressGauge = "gauge" // an integer percentage per line, for dialog --gauge or whiptail --gauge
+)
+
+// gaugeFetchShare is the part of the overall progress given to the server's inventory fetch
+const gaugeFetchShare = 10
+
+// progressGauge gives the overall progress of the upload, from 0 to 100.
+// The server's inventory fetch counts for the first 10%, the assets of the input for the rest.
+// The value never goes back, even when the discovery finds new assets.
+type progressGauge struct {
+	last atomic.Int64
+}
+
+// value computes the progress. It stays below 100 until the upload is finished.
+func (pg *progressGauge) value(uc *UpCmd, finished bool) int64 {
+	var v int64
+	switch uc.phase.Get() {
+	case phaseFetchingServerAssets:
+		if total := uc.phase.total.Load(); total > 0 {
+			v = min(gaugeFetchShare, gaugeFetchShare*uc.phase.fetched.Load()/total)
+		}
+	case phaseFetchingAlbums:
+		v = gaugeFetchShare
+	default:
+		c := uc.app.FileProcessor().Tracker().GetCounters()
+		done := c.Processed + c.Discarded + c.Errors
+		total := done + c.Pending
+		if uc.Limit > 0 {
+			total = min(total, int64(uc.Limit))
+			done = min(done, total)
+		}
+		v = gaugeFetchShare
+		if total > 0 {
+			v += (100 - gaugeFetchShare) * done / total
+		}
+	}
+	if finished {
+		v = 100
+	} else {
+		v = min(v, 99)
+	}
+	for {
+		last := pg.last.Load()
+		if v <= last {
+			return last
+		}
+		if pg.last.CompareAndSwap(last, v) {
+			return v
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+func TestProgressGauge(t *testing.T) {
+	uc := newTestUpCmd(t)
+	fp := uc.app.FileProcessor()
+	ctx := context.Background()
+	fsys := fstest.MapFS{}
+	file := func(i int) fshelper.FSAndName { return fshelper.FSName(fsys, fmt.Sprintf("%d.jpg", i)) }
+	discover := func(from, to int) {
+		for i := from; i < to; i++ {
+			fp.RecordAssetDiscovered(ctx, file(i), 1, fileevent.DiscoveredImage)
+		}
+	}
+	process := func(from, to int) {
+		for i := from; i < to; i++ {
+			fp.RecordAssetProcessed(ctx, file(i), 1, fileevent.ProcessedUploadSuccess)
+		}
+	}
+
+	steps := []struct {
+		name     string
+		do       func()
+		finished bool
+		want     int64
+	}{
+		{name: "inventory not started", do: func() { uc.phase.Set(phaseFetchingServerAssets) }, want: 0},
+		{name: "half of the inventory", do: func() { uc.phase.SetInventory(50, 100) }, want: 5},
+		{name: "albums", do: func() { uc.phase.Set(phaseFetchingAlbums) }, want: 10},
+		{name: "nothing uploaded", do: func() { uc.phase.Set(phaseUploading); discover(0, 4) }, want: 10},
+		{name: "half uploaded", do: func() { process(0, 2) }, want: 55},
+		{name: "new assets found", do: func() { discover(4, 8) }, want: 55}, // 10+90*2/8, the value never goes back
+		{name: "all done, not finished", do: func() { process(2, 8) }, want: 99},
+		{name: "finished", finished: true, do: func() {}, want: 100},
+	}
+	var g progressGauge
+	for _, s := range steps {
+		s.do()
+		if got := g.value(uc, s.finished); got != s.want {
+			t.Errorf("%s: value() = %d, want %d", s.name, got, s.want)
+		}
+	}
+}
+
+func TestProgressGaugeLimit(t *testing.T) {
+	uc := newTestUpCmd(t)
+	uc.Limit = 2
+	uc.phase.Set(phaseUploading)
+	fp := uc.app.FileProcessor()
+	ctx := context.Background()
+	fsys := fstest.MapFS{}
+	for i := range 4 {
+		fp.RecordAssetDiscovered(ctx, fshelper.FSName(fsys, fmt.Sprintf("%d.jpg", i)), 1, fileevent.DiscoveredImage)
+	}
+	fp.RecordAssetError(ctx, fshelper.FSName(fsys, "0.jpg"), 1, fileevent.ErrorServerError, errors.New("failed"))
+	var g progressGauge
+	// the errors count as done, the total is the limit
+	if got := g.value(uc, false); got != 55 {
+		t.Errorf("value() = %d, want 55", got)
+	}
+}
@@ -0,0 +1,25 @@
+package upload
+
+import (
+	"github.com/simulot/immich-go/adapters/shared"
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+// sidecarGPS gives the coordinates to set on the server from the sidecar.
+// The embedded GPS coordinates win, unless --prefer-sidecar-gps is set.
+// The embedded metadata are the ones read by the adapter, or read once and kept with the asset.
+func (uc *UpCmd) sidecarGPS(a *assets.Asset) (float64, float64, bool) {
+	if !uc.ImportGPS || a.FromApplication == nil {
+		return 0, 0, false
+	}
+	lat, lon := a.FromApplication.Latitude, a.FromApplication.Longitude
+	if lat == 0 && lon == 0 {
+		return 0, 0, false
+	}
+	if !uc.PreferSidecarGPS {
+		if md := shared.EmbeddedMetadata(a, uc.tz); md != nil && (md.Latitude != 0 || md.Longitude != 0) {
+			return 0, 0, false
+		}
+	}
+	return lat, lon, true
+}
@@ -0,0 +1,85 @@
+package upload
+
+import (
+	"io/fs"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+// openCounter counts the files opened
+type openCounter struct {
+	fs.FS
+	opens atomic.Int64
+}
+
+func (c *openCounter) Open(name string) (fs.File, error) {
+	c.opens.Add(1)
+	return c.FS.Open(name)
+}
+
+func TestSidecarGPS(t *testing.T) {
+	const withGPS = "PXL_20231006_063000139.jpg" // 48.858, 2.291 in its EXIF
+	sidecar := &assets.Metadata{Latitude: 45.5, Longitude: 6.5}
+	tests := []struct {
+		name     string
+		upCmd    *UpCmd
+		sidecar  *assets.Metadata
+		embedded *assets.Metadata // the metadata read by the adapter, the file is read when nil
+		wantGPS  bool
+	}{
+		{name: "no --import-gps", upCmd: &UpCmd{}, sidecar: sidecar, embedded: &assets.Metadata{}},
+		{name: "no sidecar", upCmd: &UpCmd{ImportGPS: true}, embedded: &assets.Metadata{}},
+		{name: "no coordinates in the sidecar", upCmd: &UpCmd{ImportGPS: true}, sidecar: &assets.Metadata{Description: "x"}, embedded: &assets.Metadata{}},
+		{name: "no embedded coordinates", upCmd: &UpCmd{ImportGPS: true}, sidecar: sidecar, embedded: &assets.Metadata{}, wantGPS: true},
+		{name: "embedded coordinates read by the adapter win", upCmd: &UpCmd{ImportGPS: true}, sidecar: sidecar, embedded: &assets.Metadata{Latitude: 1, Longitude: 2}},
+		{name: "embedded coordinates of the file win", upCmd: &UpCmd{ImportGPS: true}, sidecar: sidecar},
+		{name: "--prefer-sidecar-gps", upCmd: &UpCmd{ImportGPS: true, PreferSidecarGPS: true}, sidecar: sidecar, wantGPS: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IMMICHGO_TEMPDIR", t.TempDir())
+			uc := tt.upCmd
+			uc.tz = time.UTC
+			a := &assets.Asset{
+				File:            fshelper.FSName(os.DirFS("TEST_DATA/folder/low"), withGPS),
+				FromApplication: tt.sidecar,
+				FromSourceFile:  tt.embedded,
+			}
+			a.Ext = ".jpg"
+			lat, lon, ok := uc.sidecarGPS(a)
+			if ok != tt.wantGPS {
+				t.Fatalf("sidecarGPS() = %v, want %v", ok, tt.wantGPS)
+			}
+			if ok && (lat != sidecar.Latitude || lon != sidecar.Longitude) {
+				t.Errorf("sidecarGPS() = %v, %v, want the sidecar's coordinates", lat, lon)
+			}
+		})
+	}
+}
+
+func TestSidecarGPSReadsOnce(t *testing.T) {
+	t.Setenv("IMMICHGO_TEMPDIR", t.TempDir())
+	fsys := &openCounter{FS: os.DirFS("TEST_DATA/folder/low")}
+	uc := &UpCmd{ImportGPS: true, tz: time.UTC}
+	a := &assets.Asset{
+		File:            fshelper.FSName(fsys, "PXL_20231006_063000139.jpg"),
+		FromApplication: &assets.Metadata{Latitude: 45.5, Longitude: 6.5},
+	}
+	a.Ext = ".jpg"
+	for range 2 {
+		if _, _, ok := uc.sidecarGPS(a); ok {
+			t.Error("the embedded coordinates should win")
+		}
+	}
+	if n := fsys.opens.Load(); n != 1 {
+		t.Errorf("the file is opened %d times, want 1", n)
+	}
+	if a.FromSourceFile == nil {
+		t.Error("the embedded metadata aren't kept with the asset")
+	}
+}
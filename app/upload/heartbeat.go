@@ -0,0 +1,56 @@
+package upload
+
+import (
+	"time"
+
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+// heartbeatInterval is the period of the heartbeat records of the JSON log
+const heartbeatInterval = 10 * time.Second
+
+// heartbeatAttrs gives the attributes of the heartbeat record: the phase, and the progress made in it.
+// The monitors tell a stuck run from a long server's inventory read.
+func (uc *UpCmd) heartbeatAttrs() []any {
+	phase := uc.phase.Get()
+	attrs := []any{"phase", string(phase)}
+	if phase == phaseFetchingServerAssets {
+		attrs = append(attrs, "inventory_fetched", uc.phase.fetched.Load(), "inventory_total", uc.phase.total.Load())
+	}
+	if fp := uc.app.FileProcessor(); fp != nil {
+		counts := fp.Logger().GetCounts()
+		attrs = append(attrs,
+			"assets_found", fp.Logger().TotalAssets(),
+			"uploaded", counts[fileevent.ProcessedUploadSuccess],
+			"upload_errors", counts[fileevent.ErrorServerError],
+		)
+	}
+	return attrs
+}
+
+// startHeartbeat writes a heartbeat record into the JSON log at each interval, even when nothing progresses,
+// until the returned function is called. The text log doesn't get them, the progress line shows the phase.
+func (uc *UpCmd) startHeartbeat() func() {
+	if !uc.app.Log().JSONFormat() {
+		return func() {}
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			uc.app.Log().Info("heartbeat", uc.heartbeatAttrs()...)
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
@@ -0,0 +1,139 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/simulot/immich-go/adapters"
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/filters"
+)
+
+// --list-duplicates values
+const (
+	ListDuplicatesText = "text"
+	ListDuplicatesJSON = "json"
+)
+
+// duplicateGroup is a group of the --list-duplicates report.
+// The input groups are the files with the same checksum, with the server's asset having it.
+// The server groups are the duplicate sets found by the server's duplicate detection.
+type duplicateGroup struct {
+	Type         string           `json:"type"`
+	RunID        string           `json:"run_id,omitempty"`
+	Source       string           `json:"source"` // input or server
+	Key          string           `json:"key"`    // the checksum, or the server's duplicate ID
+	Files        []string         `json:"files,omitempty"`
+	ServerAssets []duplicateAsset `json:"server_assets,omitempty"`
+}
+
+type duplicateAsset struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// listDuplicates reads the server's assets and the input, and reports the input files already on the server
+// or present several times in the input, grouped by checksum, then the server's duplicate sets.
+// Nothing is changed on the server.
+func (uc *UpCmd) listDuplicates(ctx context.Context, adapter adapters.Reader, w io.Writer) error {
+	uc.assetIndex = newAssetIndex()
+	if err := uc.getImmichAssets(ctx, nil); err != nil {
+		return err
+	}
+
+	var groups []*duplicateGroup
+	byChecksum := map[string]*duplicateGroup{}
+	for g := range adapter.Browse(ctx) {
+		g = filters.ApplyFilters(g, uc.Filters...)
+		for _, r := range g.Removed {
+			r.Asset.Close()
+		}
+		for _, a := range g.Assets {
+			checksum, err := a.GetChecksum()
+			a.Close()
+			if err != nil {
+				uc.app.Log().Error("can't compute the checksum", "file", a.File, "err", err)
+				continue
+			}
+			dg, ok := byChecksum[checksum]
+			if !ok {
+				dg = &duplicateGroup{Type: "duplicate_group", Source: "input", Key: checksum}
+				if sa, ok := uc.assetIndex.byChecksum.Load(checksum); ok {
+					dg.ServerAssets = []duplicateAsset{{ID: sa.ID, Name: sa.OriginalFileName}}
+				}
+				byChecksum[checksum] = dg
+				groups = append(groups, dg)
+			}
+			dg.Files = append(dg.Files, a.File.FullName())
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// keep the groups with duplicates, in the input order
+	n := 0
+	for _, dg := range groups {
+		if len(dg.Files)+len(dg.ServerAssets) > 1 {
+			groups[n] = dg
+			n++
+		}
+	}
+	groups = groups[:n]
+	inputGroups := n
+
+	if dc, ok := uc.client.Immich.(immich.ImmichDuplicateInterface); ok {
+		sets, err := dc.GetDuplicates(ctx)
+		if err != nil {
+			uc.app.Log().Warn("can't get the duplicate sets from the server", "err", err)
+		}
+		for _, s := range sets {
+			dg := &duplicateGroup{Type: "duplicate_group", Source: "server", Key: s.DuplicateID}
+			for _, a := range s.Assets {
+				dg.ServerAssets = append(dg.ServerAssets, duplicateAsset{ID: a.ID, Name: a.OriginalFileName})
+			}
+			groups = append(groups, dg)
+		}
+	}
+
+	if uc.ListDuplicates == ListDuplicatesJSON {
+		enc := json.NewEncoder(w)
+		for _, dg := range groups {
+			dg.RunID = uc.app.RunID
+			if err := enc.Encode(dg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return writeDuplicateGroups(w, groups, inputGroups)
+}
+
+// writeDuplicateGroups writes the report as text
+func writeDuplicateGroups(w io.Writer, groups []*duplicateGroup, inputGroups int) error {
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	printf("Input files already on the server or duplicated in the input: %d groups\n", inputGroups)
+	for i, dg := range groups {
+		if i == inputGroups {
+			printf("\nServer's duplicate sets: %d\n", len(groups)-inputGroups)
+		}
+		printf("  %s\n", dg.Key)
+		for _, sa := range dg.ServerAssets {
+			printf("    server: %s (%s)\n", sa.Name, sa.ID)
+		}
+		for _, f := range dg.Files {
+			printf("    input:  %s\n", f)
+		}
+	}
+	if inputGroups == len(groups) {
+		printf("\nServer's duplicate sets: 0\n")
+	}
+	return err
+}
@@ -0,0 +1,29 @@
+package upload
+
+import (
+	"context"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+// linkLivePhoto links the image of a live photo with its video on the server.
+// The server then shows the video as the motion part of the image.
+func (uc *UpCmd) linkLivePhoto(ctx context.Context, g *assets.Group) {
+	// one part was filtered out
+	if len(g.Assets) != 2 {
+		return
+	}
+	image, video := g.Assets[0], g.Assets[1]
+	// one part isn't on the server
+	if image.ID == "" || video.ID == "" {
+		return
+	}
+	_, err := uc.client.Immich.UpdateAsset(ctx, image.ID, immich.UpdAssetField{LivePhotoVideoID: video.ID})
+	if err != nil {
+		uc.app.Log().Error("can't link the live photo with its video", "file", image.File, "video", video.File, "error", err)
+		return
+	}
+	uc.app.FileProcessor().RecordNonAsset(ctx, image.File, 0, fileevent.ProcessedLivePhoto, "video", video.File)
+}
@@ -0,0 +1,44 @@
+package upload
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/simulot/immich-go/app"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/assettracker"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+)
+
+// newTestUpCmd gives an upload command with an application and a file processor, without server
+func newTestUpCmd(t *testing.T) *UpCmd {
+	t.Helper()
+	a := app.New(context.Background(), &cobra.Command{Use: "upload"})
+	a.Log().Logger = slog.New(slog.DiscardHandler)
+	logger := a.Log().Logger
+	a.SetFileProcessor(fileprocessor.New(assettracker.New(), fileevent.NewRecorder(logger)))
+	return &UpCmd{app: a}
+}
+
+// groupsReader is a source giving its groups
+type groupsReader struct {
+	groups []*assets.Group
+}
+
+func (r groupsReader) Browse(ctx context.Context) chan *assets.Group {
+	c := make(chan *assets.Group)
+	go func() {
+		defer close(c)
+		for _, g := range r.groups {
+			select {
+			case c <- g:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return c
+}
@@ -0,0 +1,59 @@
+package upload
+
+import (
+	"context"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+// updateMetadataOnly applies the local metadata to the matching server asset, without uploading the file.
+// Used with --metadata-only and --two-pass=enrich. The assets without match on the server are skipped.
+func (uc *UpCmd) updateMetadataOnly(ctx context.Context, a *assets.Asset, advice *Advice) error {
+	sa := advice.ServerAsset
+	ta := uc.twoPassServerAsset(a)
+	if ta != nil {
+		sa = ta // the asset sent by the upload pass of --two-pass
+	}
+	if sa == nil {
+		uc.app.FileProcessor().RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedNoServerMatch, "no matching asset on the server")
+		return nil
+	}
+	a.ID = sa.ID
+	// the metadata given by the application, like the takeout's JSON, are forced as by the upload
+	fromApp := a.UseMetadata(a.FromApplication) != nil
+
+	upd := immich.UpdAssetField{}
+	changed := false
+	if fromApp && !a.CaptureDate.IsZero() && !a.CaptureDate.Equal(sa.CaptureDate) {
+		upd.DateTimeOriginal = a.CaptureDate
+		changed = true
+	}
+	if a.Favorite && !sa.Favorite {
+		upd.IsFavorite = true
+		changed = true
+	}
+	if a.Rating > 0 && a.Rating != sa.Rating {
+		upd.Rating = a.Rating
+		changed = true
+	}
+	if lat, lon, ok := uc.sidecarGPS(a); ok && (uc.PreferSidecarGPS || (sa.Latitude == 0 && sa.Longitude == 0)) {
+		upd.Latitude, upd.Longitude = lat, lon
+		changed = true
+		uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedGPSFromSidecar, a.File)
+	}
+	if changed {
+		_, err := uc.client.Immich.UpdateAsset(ctx, sa.ID, upd)
+		if err != nil {
+			uc.app.FileProcessor().RecordAssetError(ctx, a.File, int64(a.FileSize), fileevent.ErrorServerError, err)
+			return err
+		}
+	}
+
+	uc.manageAssetDescription(ctx, a, sa, ta != nil)
+	uc.manageAssetAlbums(ctx, a)
+	uc.manageAssetTags(ctx, a)
+	uc.app.FileProcessor().RecordAssetProcessed(ctx, a.File, int64(a.FileSize), fileevent.ProcessedMetadataUpdated)
+	return nil
+}
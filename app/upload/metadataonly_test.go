@@ -0,0 +1,105 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+// TestMetadataOnly updates the server asset matching a local file, skips the file without match,
+// and never uploads
+func TestMetadataOnly(t *testing.T) {
+	var lock sync.Mutex
+	var uploads int
+	updates := map[string]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/users/me":
+			_, _ = w.Write([]byte(`{"id":"user1"}`))
+		case r.URL.Path == "/api/server/media-types":
+			_, _ = w.Write([]byte(`{"image":[".jpg"]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/assets":
+			_, _ = io.Copy(io.Discard, r.Body)
+			uploads++
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(immich.AssetResponse{ID: "new", Status: immich.UploadCreated})
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/api/assets/"):
+			id := strings.TrimPrefix(r.URL.Path, "/api/assets/")
+			body := map[string]any{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			updates[id] = body
+			_, _ = w.Write([]byte(`{"id":"` + id + `"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	ic, err := immich.NewImmichClient(server.URL, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ic.ValidateConnection(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{
+		"IMG_0001.jpg": &fstest.MapFile{Data: []byte("on the server")},
+		"IMG_0002.jpg": &fstest.MapFile{Data: []byte("local only")},
+	}
+	sum := sha1.Sum(fsys["IMG_0001.jpg"].Data)
+
+	uc := newTestUpCmd(t)
+	uc.client.Immich = ic
+	uc.client.Server = server.URL
+	uc.client.User.ID = "user1"
+	uc.MetadataOnly = true
+	uc.assetIndex = newAssetIndex()
+	uc.assetIndex.addImmichAsset(&immich.Asset{ID: "s1", OriginalFileName: "IMG_0001.jpg", Checksum: base64.StdEncoding.EncodeToString(sum[:]), ExifInfo: immich.ExifInfo{FileSizeInByte: 13}})
+
+	ctx := context.Background()
+	for _, name := range []string{"IMG_0001.jpg", "IMG_0002.jpg"} {
+		a := &assets.Asset{
+			File:             fshelper.FSName(fsys, name),
+			OriginalFileName: name,
+			FileSize:         len(fsys[name].Data),
+			Favorite:         true,
+		}
+		uc.app.FileProcessor().RecordAssetDiscovered(ctx, a.File, int64(a.FileSize), fileevent.DiscoveredImage)
+		if err := uc.handleAsset(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if uploads != 0 {
+		t.Errorf("%d uploads, want none", uploads)
+	}
+	if len(updates) != 1 || updates["s1"]["isFavorite"] != true {
+		t.Errorf("updates %v, want the favorite flag on s1 only", updates)
+	}
+	counts := uc.app.FileProcessor().Logger().GetCounts()
+	if counts[fileevent.ProcessedMetadataUpdated] != 1 {
+		t.Errorf("%d metadata updated, want 1", counts[fileevent.ProcessedMetadataUpdated])
+	}
+	if counts[fileevent.DiscardedNoServerMatch] != 1 {
+		t.Errorf("%d skipped without server match, want 1", counts[fileevent.DiscardedNoServerMatch])
+	}
+	if counts[fileevent.ProcessedUploadSuccess] != 0 {
+		t.Errorf("%d uploaded, want none", counts[fileevent.ProcessedUploadSuccess])
+	}
+}
@@ -0,0 +1,122 @@
+package upload
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+)
+
+const (
+	metricsInterval   = 500 * time.Millisecond // same as the progress line
+	metricsFlushTicks = 10                     // the rows are flushed every 10 ticks
+)
+
+var metricsHeader = []string{"timestamp", "phase", "uploaded", "bytes", "bytes_per_second"}
+
+// uploadedTotals gives the number and the size of the files uploaded so far
+func uploadedTotals(fp *fileprocessor.FileProcessor) (files, bytes int64) {
+	sizes := fp.Logger().GetEventSizes()
+	counts := fp.Logger().GetCounts()
+	for _, c := range []fileevent.Code{fileevent.ProcessedUploadSuccess, fileevent.ProcessedUploadUpgraded, fileevent.ProcessedAssetReplaced} {
+		files += counts[c]
+		bytes += sizes[c]
+	}
+	return files, bytes
+}
+
+// metricsFile appends a CSV row per progress tick to the --metrics-file, to plot the throughput over time.
+// The bytes include the part already sent of the files being uploaded.
+type metricsFile struct {
+	f         *os.File
+	w         *csv.Writer
+	ticks     int
+	lastTime  time.Time
+	lastBytes int64
+}
+
+// openMetricsFile opens the file in append mode, and writes the header when the file is empty
+func openMetricsFile(name string) (*metricsFile, error) {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("can't open the metrics file: %w", err)
+	}
+	mf := &metricsFile{f: f, w: csv.NewWriter(f)}
+	if st, err := f.Stat(); err == nil && st.Size() == 0 {
+		_ = mf.w.Write(metricsHeader)
+	}
+	return mf, nil
+}
+
+// sample writes the row of the tick
+func (mf *metricsFile) sample(now time.Time, phase uploadPhase, files, bytes int64) {
+	rate := 0.0
+	if !mf.lastTime.IsZero() {
+		if d := now.Sub(mf.lastTime).Seconds(); d > 0 {
+			rate = float64(bytes-mf.lastBytes) / d
+		}
+	}
+	mf.lastTime, mf.lastBytes = now, bytes
+	_ = mf.w.Write([]string{
+		now.Format(time.RFC3339Nano),
+		string(phase),
+		strconv.FormatInt(files, 10),
+		strconv.FormatInt(bytes, 10),
+		strconv.FormatFloat(rate, 'f', 0, 64),
+	})
+	mf.ticks++
+	if mf.ticks%metricsFlushTicks == 0 {
+		mf.w.Flush()
+	}
+}
+
+func (mf *metricsFile) Close() error {
+	mf.w.Flush()
+	err := mf.w.Error()
+	if cerr := mf.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// startMetrics samples the upload progress into the --metrics-file until the returned function is called
+func (uc *UpCmd) startMetrics() (func(), error) {
+	if uc.MetricsFile == "" {
+		return func() {}, nil
+	}
+	mf, err := openMetricsFile(uc.MetricsFile)
+	if err != nil {
+		return nil, err
+	}
+	sample := func() {
+		files, bytes := uploadedTotals(uc.app.FileProcessor())
+		mf.sample(time.Now(), uc.phase.Get(), files, bytes+uc.uploads.Sent())
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(metricsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				sample()
+				return
+			case <-ticker.C:
+				sample()
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+		if err := mf.Close(); err != nil {
+			uc.app.Log().Warn("can't write the metrics file", "file", uc.MetricsFile, "error", err)
+		}
+	}, nil
+}
@@ -0,0 +1,131 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+// --on-name-collision values
+const (
+	NameCollisionKeep   = "keep"   // both files keep their name
+	NameCollisionSuffix = "suffix" // the name of the second file gets a _1, _2... suffix
+)
+
+// nameCollisions keeps the names of the files sent to each album by the upload, to detect
+// the different files with the same name in an album, like the IMG_0001.jpg of two cameras.
+type nameCollisions struct {
+	lock  sync.Mutex
+	names map[string]map[string]string // checksum by lower case name, by album key
+}
+
+// check records the name of the file in its albums. It returns the index of the albums already having
+// another file with this name, the name to use, and the albums where the name is reserved for the file.
+// With suffix, the name is free in all the albums. The name stays to the first file sent with it.
+func (nc *nameCollisions) check(keys []string, name, checksum string, suffix bool) ([]int, string, []string) {
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	if nc.names == nil {
+		nc.names = map[string]map[string]string{}
+	}
+
+	// collisions gives the albums where the name is taken by another file
+	collisions := func(name string) []int {
+		var l []int
+		for i, k := range keys {
+			if c, ok := nc.names[k][strings.ToLower(name)]; ok && c != checksum {
+				l = append(l, i)
+			}
+		}
+		return l
+	}
+
+	albums := collisions(name)
+	if len(albums) > 0 && suffix {
+		ext := path.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		for i := 1; ; i++ {
+			n := fmt.Sprintf("%s_%d%s", base, i, ext)
+			if len(collisions(n)) == 0 {
+				name = n
+				break
+			}
+		}
+	}
+
+	var reserved []string
+	for _, k := range keys {
+		if nc.names[k] == nil {
+			nc.names[k] = map[string]string{}
+		}
+		if _, ok := nc.names[k][strings.ToLower(name)]; !ok {
+			nc.names[k][strings.ToLower(name)] = checksum
+			reserved = append(reserved, k)
+		}
+	}
+	return albums, name, reserved
+}
+
+// release frees the name reserved in the albums for a file that wasn't sent
+func (nc *nameCollisions) release(keys []string, name string) {
+	nc.lock.Lock()
+	defer nc.lock.Unlock()
+	for _, k := range keys {
+		delete(nc.names[k], strings.ToLower(name))
+	}
+}
+
+// albumKeys gives the keys of the albums receiving the asset
+func (uc *UpCmd) albumKeys(a *assets.Asset) []string {
+	if uc.targetAlbum != nil {
+		return []string{targetAlbumKey}
+	}
+	if uc.IgnoreAlbums {
+		return nil
+	}
+	keys := make([]string, 0, len(a.Albums))
+	for _, album := range a.Albums {
+		keys = append(keys, uc.albumNames.key(album.Title))
+	}
+	return keys
+}
+
+// checkNameCollision detects a different file already sent with the same name to one of the asset's albums.
+// With --on-name-collision=suffix, the asset is renamed to keep the names unique in the album.
+// The returned function frees the name when the upload fails.
+func (uc *UpCmd) checkNameCollision(ctx context.Context, a *assets.Asset) (release func()) {
+	release = func() {}
+	keys := uc.albumKeys(a)
+	if len(keys) == 0 {
+		return release
+	}
+	checksum, err := a.GetChecksum()
+	if err != nil {
+		return release // the upload reports the file's error
+	}
+	albums, name, reserved := uc.nameCollisions.check(keys, a.OriginalFileName, checksum, uc.OnNameCollision == NameCollisionSuffix)
+	if len(reserved) > 0 {
+		release = func() { uc.nameCollisions.release(reserved, name) }
+	}
+	if len(albums) == 0 {
+		return release
+	}
+	var title string
+	if uc.targetAlbum != nil {
+		title = uc.targetAlbum.Title
+	} else {
+		title = a.Albums[albums[0]].Title
+	}
+	if name != a.OriginalFileName {
+		uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedNameCollision, a.File, "album", title, "name", a.OriginalFileName, "renamed", name)
+		a.OriginalFileName = name
+		return release
+	}
+	uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedNameCollision, a.File, "album", title, "name", a.OriginalFileName)
+	return release
+}
@@ -0,0 +1,208 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+func TestNameCollisionsCheck(t *testing.T) {
+	type step struct {
+		keys       []string
+		name       string
+		checksum   string
+		wantAlbums []int
+		wantName   string
+	}
+	tests := []struct {
+		name   string
+		suffix bool
+		steps  []step
+	}{
+		{
+			name: "keep",
+			steps: []step{
+				{keys: []string{"k1"}, name: "IMG_0001.jpg", checksum: "c1", wantName: "IMG_0001.jpg"},
+				{keys: []string{"k1"}, name: "img_0001.JPG", checksum: "c2", wantAlbums: []int{0}, wantName: "img_0001.JPG"},
+				{keys: []string{"k1"}, name: "IMG_0001.jpg", checksum: "c1", wantName: "IMG_0001.jpg"}, // the same file
+				{keys: []string{"k2"}, name: "IMG_0001.jpg", checksum: "c3", wantName: "IMG_0001.jpg"}, // another album
+			},
+		},
+		{
+			name:   "suffix",
+			suffix: true,
+			steps: []step{
+				{keys: []string{"k1"}, name: "IMG_0001.jpg", checksum: "c1", wantName: "IMG_0001.jpg"},
+				{keys: []string{"k1"}, name: "IMG_0001.jpg", checksum: "c2", wantAlbums: []int{0}, wantName: "IMG_0001_1.jpg"},
+				{keys: []string{"k1"}, name: "IMG_0001.jpg", checksum: "c3", wantAlbums: []int{0}, wantName: "IMG_0001_2.jpg"},
+				{keys: []string{"k1"}, name: "IMG_0001.jpg", checksum: "c2", wantAlbums: []int{0}, wantName: "IMG_0001_1.jpg"}, // keeps its suffix
+			},
+		},
+		{
+			name:   "several albums",
+			suffix: true,
+			steps: []step{
+				{keys: []string{"k2"}, name: "IMG_0001.jpg", checksum: "c1", wantName: "IMG_0001.jpg"},
+				{keys: []string{"k1", "k2"}, name: "IMG_0001.jpg", checksum: "c2", wantAlbums: []int{1}, wantName: "IMG_0001_1.jpg"},
+				{keys: []string{"k1"}, name: "IMG_0001_1.jpg", checksum: "c3", wantAlbums: []int{0}, wantName: "IMG_0001_1_1.jpg"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var nc nameCollisions
+			for i, s := range tt.steps {
+				albums, name, _ := nc.check(s.keys, s.name, s.checksum, tt.suffix)
+				if !slices.Equal(albums, s.wantAlbums) || name != s.wantName {
+					t.Errorf("step %d: got %v %q, want %v %q", i, albums, name, s.wantAlbums, s.wantName)
+				}
+			}
+		})
+	}
+}
+
+func TestNameCollisionsRelease(t *testing.T) {
+	var nc nameCollisions
+	_, _, reserved := nc.check([]string{"k1", "k2"}, "IMG_0001.jpg", "c1", false)
+	if !slices.Equal(reserved, []string{"k1", "k2"}) {
+		t.Fatalf("reserved %v", reserved)
+	}
+	// a file with a name already taken reserves nothing, its release doesn't free the name of the first one
+	_, _, reserved = nc.check([]string{"k1"}, "IMG_0001.jpg", "c2", false)
+	if len(reserved) != 0 {
+		t.Fatalf("reserved %v for a taken name", reserved)
+	}
+	nc.release(reserved, "IMG_0001.jpg")
+	if albums, _, _ := nc.check([]string{"k1"}, "IMG_0001.jpg", "c3", false); len(albums) != 1 {
+		t.Errorf("the name of the first file is freed")
+	}
+
+	// the name of a file not sent is free for the next ones
+	_, _, reserved = nc.check([]string{"k3"}, "IMG_0002.jpg", "c4", false)
+	nc.release(reserved, "IMG_0002.jpg")
+	if albums, _, _ := nc.check([]string{"k3"}, "IMG_0002.jpg", "c5", false); len(albums) != 0 {
+		t.Errorf("the released name is still taken")
+	}
+}
+
+// collisionServer accepts the uploads, but the one of the files listed in fail, and the copies and deletes of a replacement
+func collisionServer(t *testing.T, fail ...string) *immich.ImmichClient {
+	t.Helper()
+	var lock sync.Mutex
+	ids := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/users/me":
+			_, _ = w.Write([]byte(`{"id":"user1"}`))
+		case r.URL.Path == "/api/server/media-types":
+			_, _ = w.Write([]byte(`{"image":[".jpg"]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/assets":
+			b, _ := io.ReadAll(r.Body)
+			for _, f := range fail {
+				if bytes.Contains(b, []byte(f)) {
+					http.Error(w, `{"message":"internal error"}`, http.StatusInternalServerError)
+					return
+				}
+			}
+			ids++
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(immich.AssetResponse{ID: fmt.Sprintf("new%d", ids), Status: immich.UploadCreated})
+		case r.URL.Path == "/api/assets/copy" || (r.Method == http.MethodDelete && r.URL.Path == "/api/assets"):
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	ic, err := immich.NewImmichClient(server.URL, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ic.ValidateConnection(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return ic
+}
+
+func TestFailedUploadReleasesName(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/IMG_0001.jpg": &fstest.MapFile{Data: []byte("rejected content")},
+		"b/IMG_0001.jpg": &fstest.MapFile{Data: []byte("accepted content")},
+	}
+	uc := newTestUpCmd(t)
+	uc.client.Immich = collisionServer(t, "rejected content")
+	uc.assetIndex = newAssetIndex()
+	uc.OnNameCollision = NameCollisionSuffix
+	ctx := context.Background()
+	asset := func(name string) *assets.Asset {
+		a := &assets.Asset{File: fshelper.FSName(fsys, name), OriginalFileName: "IMG_0001.jpg", FileSize: len(fsys[name].Data)}
+		a.Albums = []assets.Album{assets.NewAlbum("", "Holidays", "")}
+		uc.app.FileProcessor().RecordAssetDiscovered(ctx, a.File, int64(a.FileSize), fileevent.DiscoveredImage)
+		return a
+	}
+
+	if _, err := uc.uploadAsset(ctx, asset("a/IMG_0001.jpg")); err == nil {
+		t.Fatal("want an upload error")
+	}
+	a := asset("b/IMG_0001.jpg")
+	if _, err := uc.uploadAsset(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	// the failed file doesn't hold the name
+	if a.OriginalFileName != "IMG_0001.jpg" {
+		t.Errorf("the file is renamed %q", a.OriginalFileName)
+	}
+	if n := uc.app.FileProcessor().Logger().GetCounts()[fileevent.ProcessedNameCollision]; n != 0 {
+		t.Errorf("%d name collisions, want 0", n)
+	}
+}
+
+func TestReplaceAssetNameCollision(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/IMG_0001.jpg": &fstest.MapFile{Data: []byte("first")},
+		"b/IMG_0001.jpg": &fstest.MapFile{Data: []byte("bigger second")},
+	}
+	uc := newTestUpCmd(t)
+	uc.client.Immich = collisionServer(t)
+	uc.assetIndex = newAssetIndex()
+	uc.OnNameCollision = NameCollisionSuffix
+	ctx := context.Background()
+	asset := func(name string) *assets.Asset {
+		a := &assets.Asset{File: fshelper.FSName(fsys, name), OriginalFileName: "IMG_0001.jpg", FileSize: len(fsys[name].Data)}
+		a.Albums = []assets.Album{assets.NewAlbum("", "Holidays", "")}
+		uc.app.FileProcessor().RecordAssetDiscovered(ctx, a.File, int64(a.FileSize), fileevent.DiscoveredImage)
+		return a
+	}
+
+	if _, err := uc.uploadAsset(ctx, asset("a/IMG_0001.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	// the replacement of a server asset by a file of the album is checked too
+	a := asset("b/IMG_0001.jpg")
+	old := &assets.Asset{ID: "old", OriginalFileName: "IMG_0001.jpg", FileSize: 1}
+	if _, err := uc.replaceAsset(ctx, a, old); err != nil {
+		t.Fatal(err)
+	}
+	if a.OriginalFileName != "IMG_0001_1.jpg" {
+		t.Errorf("the replacement is named %q, want IMG_0001_1.jpg", a.OriginalFileName)
+	}
+	if n := uc.app.FileProcessor().Logger().GetCounts()[fileevent.ProcessedNameCollision]; n != 1 {
+		t.Errorf("%d name collisions, want 1", n)
+	}
+}
@@ -5,52 +5,44 @@ import (
 	"errors"
 	"fmt"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/simulot/immich-go/app"
-	"github.com/simulot/immich-go/internal/assets"
+	cliflags "github.com/simulot/immich-go/internal/cliFlags"
 	"github.com/simulot/immich-go/internal/fileevent"
 	"golang.org/x/sync/errgroup"
 )
 
+// progressWidth is the room given to the progress of the file being uploaded
+const progressWidth = 40
+
 func (uc *UpCmd) runNoUI(ctx context.Context, app *app.Application) error {
 	ctx, cancel := context.WithCancelCause(ctx)
-	lock := sync.RWMutex{}
 	defer cancel(nil)
 
 	var preparationDone atomic.Bool
+	var succeeded atomic.Bool // the run ended without error, the gauge can reach 100
 
 	stopProgress := make(chan any)
-	var maxImmich, currImmich int
 	spinner := []rune{' ', ' ', '.', ' ', ' '}
 	spinIdx := 0
 
-	immichUpdate := func(value, total int) {
-		lock.Lock()
-		currImmich, maxImmich = value, total
-		lock.Unlock()
-	}
-
 	progressString := func() string {
-		counts := app.FileProcessor().Logger().GetCounts()
 		defer func() {
 			spinIdx++
 			if spinIdx == len(spinner) {
 				spinIdx = 0
 			}
 		}()
-		lock.Lock()
-		immichPct := 0
-		if maxImmich > 0 {
-			immichPct = 100 * currImmich / maxImmich
-		} else {
-			immichPct = 100
-		}
-		lock.Unlock()
-
-		return fmt.Sprintf("\rImmich read %d%%, Assets found: %d, Upload errors: %d, Uploaded %d %s", immichPct, app.FileProcessor().Logger().TotalAssets(), counts[fileevent.ErrorServerError], counts[fileevent.ProcessedUploadSuccess], string(spinner[spinIdx]))
+		return uc.progressLine(spinner[spinIdx])
+	}
+	printProgress := func(finished bool) { fmt.Print(progressString()) }
+	endProgress := func() { fmt.Println(progressString()) }
+	if uc.ProgressFormat == ProgressGauge {
+		// only the percentage goes to the standard output, the messages go to stderr
+		printProgress = func(finished bool) { fmt.Println(uc.gauge.value(uc, finished)) }
+		endProgress = func() {}
 	}
 	uiGrp := errgroup.Group{}
 
@@ -58,50 +50,29 @@ func (uc *UpCmd) runNoUI(ctx context.Context, app *app.Application) error {
 		ticker := time.NewTicker(500 * time.Millisecond)
 		defer func() {
 			ticker.Stop()
-			fmt.Println(progressString())
+			endProgress()
 		}()
 		for {
 			select {
 			case <-stopProgress:
-				fmt.Print(progressString())
+				printProgress(succeeded.Load())
 				return nil
 			case <-ctx.Done():
-				fmt.Print(progressString())
+				printProgress(false)
 				return ctx.Err()
 			case <-ticker.C:
-				fmt.Print(progressString())
+				printProgress(false)
 			}
 		}
 	})
 
 	uiGrp.Go(func() error {
-		processGrp := errgroup.Group{}
-		var groupChan chan *assets.Group
-		var err error
-
-		processGrp.Go(func() error {
-			// Get immich asset
-			err := uc.getImmichAssets(ctx, immichUpdate)
-			if err != nil {
-				cancel(err)
-			}
-			return err
-		})
-		processGrp.Go(func() error {
-			return uc.getImmichAlbums(ctx)
-		})
-		processGrp.Go(func() error {
-			// Run Prepare
-			groupChan = uc.adapter.Browse(ctx)
-			return err
-		})
-		err = processGrp.Wait()
+		groupChan, err := uc.prepare(ctx, nil, func(err error) { cancel(err) })
 		if err != nil {
-			err := context.Cause(ctx)
-			if err != nil {
-				cancel(err)
-				return err
+			if cause := context.Cause(ctx); cause != nil {
+				return cause
 			}
+			return err
 		}
 		preparationDone.Store(true)
 		err = uc.uploadLoop(ctx, groupChan)
@@ -109,16 +80,21 @@ func (uc *UpCmd) runNoUI(ctx context.Context, app *app.Application) error {
 			cancel(err)
 		}
 
-		counts := app.FileProcessor().Logger().GetCounts()
 		messages := strings.Builder{}
-		if counts[fileevent.ErrorUploadFailed]+counts[fileevent.ErrorServerError]+counts[fileevent.ErrorFileAccess]+counts[fileevent.ErrorIncomplete] > 0 {
+		if app.FileProcessor().Logger().TotalErrors() > 0 {
 			messages.WriteString("Some errors have occurred. Look at the log file for details\n")
 		}
 
 		if messages.Len() > 0 {
-			cancel(errors.New(messages.String()))
+			if app.OnErrors == cliflags.OnErrorsNeverStop {
+				// in continue mode, errors are reported but the run is not failed
+				app.Log().Message("%s", strings.TrimSpace(messages.String()))
+			} else {
+				cancel(errors.New(messages.String()))
+			}
 		}
 		err = errors.Join(err, uc.finishing(ctx))
+		succeeded.Store(err == nil && context.Cause(ctx) == nil)
 		close(stopProgress)
 		return err
 	})
@@ -129,3 +105,22 @@ func (uc *UpCmd) runNoUI(ctx context.Context, app *app.Application) error {
 	}
 	return err
 }
+
+// progressLine gives the progress line of the run without UI.
+// The browsing runs during the server's inventory read, so the assets found are counted meanwhile.
+func (uc *UpCmd) progressLine(spin rune) string {
+	fp := uc.app.FileProcessor()
+	counts := fp.Logger().GetCounts()
+	phase := uc.phase.Get()
+	inventory := ""
+	if phase == phaseFetchingServerAssets {
+		inventory = uc.phase.Inventory() + ", "
+	}
+	found := fmt.Sprint(fp.Logger().TotalAssets())
+	if uc.Limit > 0 {
+		found += fmt.Sprintf(" (limited to %d)", uc.Limit)
+	}
+	line := fmt.Sprintf("\r[%s] %sAssets found: %s, Upload errors: %d, Uploaded %d %s", phase, inventory, found, counts[fileevent.ErrorServerError], counts[fileevent.ProcessedUploadSuccess], string(spin))
+	// the padding erases the end of a longer previous line
+	return fmt.Sprintf("%s %-*s", line, progressWidth, uc.uploads.String())
+}
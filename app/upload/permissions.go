@@ -0,0 +1,77 @@
+package upload
+
+import "github.com/simulot/immich-go/internal/filters"
+
+// requiredPermissions gives the permissions of the scoped API keys needed by the upload with its options.
+// A dry run, a plan or a duplicate report only reads the server.
+func (uc *UpCmd) requiredPermissions() []string {
+	perms := []string{"asset.read", "asset.statistics"}
+	if !uc.IgnoreAlbums || uc.AlbumID != "" || uc.SkipIfInAlbum != "" {
+		perms = append(perms, "album.read")
+	}
+	if uc.client.User.QuotaSizeInBytes <= 0 {
+		// without quota, the space available is the server's one
+		perms = append(perms, "server.storage")
+	}
+	if uc.ListDuplicates != "" || uc.RunDedup {
+		perms = append(perms, "duplicate.read")
+	}
+	if uc.Plan || uc.ListDuplicates != "" || uc.client.DryRun {
+		return perms
+	}
+
+	if uc.MetadataOnly {
+		perms = append(perms, "asset.update")
+	} else {
+		// a smaller asset on the server is replaced by the input's one: the new one gets its metadata, the old one is deleted
+		perms = append(perms, "asset.upload", "asset.copy", "asset.delete")
+		if !uc.uploadOnly() {
+			// the metadata of the sidecars, the descriptions and the live photos are set after the upload
+			perms = append(perms, "asset.update")
+		}
+	}
+	if uc.uploadOnly() {
+		// the albums, tags and stacks are left to the enrich pass
+		return append(perms, uc.jobPermissions()...)
+	}
+
+	switch {
+	case uc.AlbumID != "":
+		perms = append(perms, "albumAsset.create")
+	case !uc.IgnoreAlbums:
+		perms = append(perms, "album.create", "albumAsset.create")
+	}
+	if uc.trackCreatedAlbums() {
+		perms = append(perms, "album.update")
+	}
+	if len(uc.Tags) > 0 || uc.SessionTag {
+		perms = append(perms, "tag.create", "tag.asset")
+	}
+	if uc.stacksRequested() {
+		perms = append(perms, "stack.create")
+	}
+	if uc.RestoreTrashed {
+		perms = append(perms, "asset.delete")
+	}
+	return append(perms, uc.jobPermissions()...)
+}
+
+// stacksRequested tells if the options ask for stacks
+func (uc *UpCmd) stacksRequested() bool {
+	return uc.StackOnUpload || uc.ManageEpsonFastFoto ||
+		uc.ManageRawJPG == filters.RawJPGStackRaw || uc.ManageRawJPG == filters.RawJPGStackJPG ||
+		uc.ManageHEICJPG == filters.HeicJpgStackHeic || uc.ManageHEICJPG == filters.HeicJpgStackJPG ||
+		uc.ManageBurst != filters.BurstNothing
+}
+
+// jobPermissions gives the permissions needed to pause and start the server's jobs, when they are sent
+// with the key of the upload, without --admin-api-key
+func (uc *UpCmd) jobPermissions() []string {
+	if uc.client.AdminAPIKey != uc.client.APIKey {
+		return nil
+	}
+	if uc.client.PauseImmichBackgroundJobs || uc.RunDedup {
+		return []string{"job.create"}
+	}
+	return nil
+}
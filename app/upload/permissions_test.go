@@ -0,0 +1,118 @@
+package upload
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/simulot/immich-go/internal/filters"
+)
+
+func TestRequiredPermissions(t *testing.T) {
+	upload := []string{"asset.read", "asset.statistics", "server.storage", "asset.upload", "asset.copy", "asset.delete", "asset.update"}
+	tests := []struct {
+		name   string
+		set    func(uc *UpCmd)
+		want   []string
+		absent []string
+	}{
+		{
+			name:   "default",
+			want:   append(upload, "album.read", "album.create", "albumAsset.create", "job.create"),
+			absent: []string{"album.update", "tag.create", "stack.create", "duplicate.read"},
+		},
+		{
+			name:   "user quota",
+			set:    func(uc *UpCmd) { uc.client.User.QuotaSizeInBytes = 1000 },
+			absent: []string{"server.storage"},
+		},
+		{
+			name:   "admin key",
+			set:    func(uc *UpCmd) { uc.client.AdminAPIKey = "admin" },
+			absent: []string{"job.create"},
+		},
+		{
+			name:   "jobs not paused",
+			set:    func(uc *UpCmd) { uc.client.PauseImmichBackgroundJobs = false },
+			absent: []string{"job.create"},
+		},
+		{
+			name:   "ignore albums",
+			set:    func(uc *UpCmd) { uc.IgnoreAlbums = true },
+			absent: []string{"album.read", "album.create", "albumAsset.create"},
+		},
+		{
+			name:   "album id",
+			set:    func(uc *UpCmd) { uc.IgnoreAlbums = true; uc.AlbumID = "a1" },
+			want:   []string{"album.read", "albumAsset.create"},
+			absent: []string{"album.create"},
+		},
+		{
+			name: "skip if in album",
+			set:  func(uc *UpCmd) { uc.IgnoreAlbums = true; uc.SkipIfInAlbum = "Done" },
+			want: []string{"album.read"},
+		},
+		{name: "album cover", set: func(uc *UpCmd) { uc.SetAlbumCover = true }, want: []string{"album.update"}},
+		{name: "album activity", set: func(uc *UpCmd) { uc.AlbumActivity = AlbumActivityOn }, want: []string{"album.update"}},
+		{name: "tags", set: func(uc *UpCmd) { uc.Tags = []string{"t"} }, want: []string{"tag.create", "tag.asset"}},
+		{name: "session tag", set: func(uc *UpCmd) { uc.SessionTag = true }, want: []string{"tag.create", "tag.asset"}},
+		{name: "stack on upload", set: func(uc *UpCmd) { uc.StackOnUpload = true }, want: []string{"stack.create"}},
+		{name: "stack raw jpeg", set: func(uc *UpCmd) { uc.ManageRawJPG = filters.RawJPGStackJPG }, want: []string{"stack.create"}},
+		{name: "keep raw", set: func(uc *UpCmd) { uc.ManageRawJPG = filters.RawJPGKeepRaw }, absent: []string{"stack.create"}},
+		{name: "stack heic jpeg", set: func(uc *UpCmd) { uc.ManageHEICJPG = filters.HeicJpgStackHeic }, want: []string{"stack.create"}},
+		{name: "stack bursts", set: func(uc *UpCmd) { uc.ManageBurst = filters.BurstStack }, want: []string{"stack.create"}},
+		{name: "epson fastfoto", set: func(uc *UpCmd) { uc.ManageEpsonFastFoto = true }, want: []string{"stack.create"}},
+		{name: "restore trashed", set: func(uc *UpCmd) { uc.RestoreTrashed = true }, want: []string{"asset.delete"}},
+		{name: "run dedup", set: func(uc *UpCmd) { uc.RunDedup = true }, want: []string{"duplicate.read", "job.create"}},
+		{
+			name:   "metadata only",
+			set:    func(uc *UpCmd) { uc.MetadataOnly = true },
+			want:   []string{"asset.update", "album.create"},
+			absent: []string{"asset.upload", "asset.copy", "asset.delete"},
+		},
+		{
+			name:   "upload pass",
+			set:    func(uc *UpCmd) { uc.TwoPass = TwoPassUpload; uc.Tags = []string{"t"}; uc.StackOnUpload = true },
+			want:   []string{"asset.upload", "job.create"},
+			absent: []string{"asset.update", "album.create", "albumAsset.create", "tag.create", "stack.create"},
+		},
+		{
+			name:   "dry run",
+			set:    func(uc *UpCmd) { uc.client.DryRun = true; uc.Tags = []string{"t"} },
+			want:   []string{"asset.read", "asset.statistics", "album.read"},
+			absent: []string{"asset.upload", "asset.update", "album.create", "tag.create", "job.create"},
+		},
+		{
+			name:   "plan",
+			set:    func(uc *UpCmd) { uc.Plan = true },
+			absent: []string{"asset.upload", "album.create", "job.create"},
+		},
+		{
+			name:   "list duplicates",
+			set:    func(uc *UpCmd) { uc.ListDuplicates = ListDuplicatesJSON },
+			want:   []string{"duplicate.read"},
+			absent: []string{"asset.upload"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &UpCmd{}
+			uc.client.APIKey = "key"
+			uc.client.AdminAPIKey = "key" // the key of the upload, when --admin-api-key isn't given
+			uc.client.PauseImmichBackgroundJobs = true
+			if tt.set != nil {
+				tt.set(uc)
+			}
+			got := uc.requiredPermissions()
+			for _, p := range tt.want {
+				if !slices.Contains(got, p) {
+					t.Errorf("%s is missing from %v", p, got)
+				}
+			}
+			for _, p := range tt.absent {
+				if slices.Contains(got, p) {
+					t.Errorf("%s isn't needed: %v", p, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"golang.org/x/sync/errgroup"
+)
+
+// uploadPhase names the stage the upload process is currently in.
+// It lets the progress display tell a slow stage from a stuck one.
+type uploadPhase string
+
+const (
+	phaseFetchingServerAssets uploadPhase = "fetching_server_assets"
+	phaseFetchingAlbums       uploadPhase = "fetching_albums"
+	phaseScanning             uploadPhase = "scanning"
+	phaseUploading            uploadPhase = "uploading"
+)
+
+type phaseState struct {
+	phase uploadPhase
+	since time.Time
+}
+
+// phaseTracker holds the current phase, and the progress of the server's inventory fetch.
+// It is safe for concurrent use.
+type phaseTracker struct {
+	v atomic.Value
+
+	fetched, total atomic.Int64 // the server's assets read, and their number given by the statistics
+
+	assetsDone, albumsDone atomic.Bool // the server's assets and albums are read
+	uploadStarted          atomic.Bool // the input has given its first group
+}
+
+func (p *phaseTracker) Set(ph uploadPhase) {
+	p.v.Store(phaseState{phase: ph, since: time.Now()})
+}
+
+func (p *phaseTracker) Get() uploadPhase {
+	if s, ok := p.v.Load().(phaseState); ok {
+		return s.phase
+	}
+	return phaseFetchingServerAssets
+}
+
+// AssetsFetched moves to the next phase once the server's assets are read
+func (p *phaseTracker) AssetsFetched() {
+	p.assetsDone.Store(true)
+	if p.albumsDone.Load() {
+		p.Set(phaseScanning)
+	} else {
+		p.Set(phaseFetchingAlbums)
+	}
+}
+
+// AlbumsFetched moves to the scanning phase once the server's albums are read, if the assets are read too
+func (p *phaseTracker) AlbumsFetched() {
+	p.albumsDone.Store(true)
+	if p.assetsDone.Load() {
+		p.Set(phaseScanning)
+	}
+}
+
+// GroupReceived moves to the uploading phase when the input gives its first group.
+// Until then, the input is still scanned, like a takeout matching its JSON files.
+func (p *phaseTracker) GroupReceived() {
+	if p.uploadStarted.CompareAndSwap(false, true) {
+		p.Set(phaseUploading)
+	}
+}
+
+// Since returns when the current phase has started
+func (p *phaseTracker) Since() time.Time {
+	if s, ok := p.v.Load().(phaseState); ok {
+		return s.since
+	}
+	return time.Time{}
+}
+
+// SetInventory records the progress of the server's inventory fetch
+func (p *phaseTracker) SetInventory(fetched, total int) {
+	p.fetched.Store(int64(fetched))
+	p.total.Store(int64(total))
+}
+
+// Inventory gives the progress of the server's inventory fetch, as "Fetching server inventory: X/Y (NN%)"
+func (p *phaseTracker) Inventory() string {
+	fetched, total := p.fetched.Load(), p.total.Load()
+	pct := int64(0)
+	if total > 0 {
+		pct = min(100, 100*fetched/total)
+	}
+	return fmt.Sprintf("Fetching server inventory: %d/%d (%d%%)", fetched, total, pct)
+}
+
+// prepare reads the server's assets and albums while the adapter starts browsing the input,
+// and follows the phases until the scanning one. It's shared by the line mode and the TUI, fail is called with the first error.
+func (uc *UpCmd) prepare(ctx context.Context, updateFn progressUpdate, fail func(error)) (chan *assets.Group, error) {
+	var groupChan chan *assets.Group
+
+	uc.phase.Set(phaseFetchingServerAssets)
+	grp := errgroup.Group{}
+	grp.Go(func() error {
+		err := uc.getImmichAssets(ctx, updateFn)
+		if err != nil {
+			fail(err)
+		}
+		uc.phase.AssetsFetched()
+		return err
+	})
+	grp.Go(func() error {
+		err := uc.getImmichAlbums(ctx)
+		if err != nil {
+			fail(err)
+		}
+		uc.phase.AlbumsFetched()
+		return err
+	})
+	grp.Go(func() error {
+		groupChan = uc.adapter.Browse(ctx)
+		return nil
+	})
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+	return groupChan, nil
+}
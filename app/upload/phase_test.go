@@ -0,0 +1,140 @@
+package upload
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+func TestPhaseTracker(t *testing.T) {
+	for _, tst := range []struct {
+		name  string
+		steps []func(p *phaseTracker)
+		want  []uploadPhase
+	}{
+		{
+			name:  "assets first",
+			steps: []func(p *phaseTracker){(*phaseTracker).AssetsFetched, (*phaseTracker).AlbumsFetched},
+			want:  []uploadPhase{phaseFetchingAlbums, phaseScanning},
+		},
+		{
+			name:  "albums first",
+			steps: []func(p *phaseTracker){(*phaseTracker).AlbumsFetched, (*phaseTracker).AssetsFetched},
+			want:  []uploadPhase{phaseFetchingServerAssets, phaseScanning},
+		},
+		{
+			name:  "first group",
+			steps: []func(p *phaseTracker){(*phaseTracker).AssetsFetched, (*phaseTracker).AlbumsFetched, (*phaseTracker).GroupReceived, (*phaseTracker).GroupReceived},
+			want:  []uploadPhase{phaseFetchingAlbums, phaseScanning, phaseUploading, phaseUploading},
+		},
+	} {
+		t.Run(tst.name, func(t *testing.T) {
+			var p phaseTracker
+			p.Set(phaseFetchingServerAssets)
+			var got []uploadPhase
+			for _, step := range tst.steps {
+				step(&p)
+				got = append(got, p.Get())
+			}
+			if !slices.Equal(got, tst.want) {
+				t.Errorf("phases = %v, want %v", got, tst.want)
+			}
+		})
+	}
+}
+
+// TestPreparePhases checks that the scanning phase lasts until the input gives its first group
+func TestPreparePhases(t *testing.T) {
+	uc := newTestUpCmd(t)
+	uc.client.Immich = newInventoryServer(t)
+	uc.IgnoreAlbums = true
+	uc.assetIndex = newAssetIndex()
+	uc.immichAssetsReady = make(chan struct{})
+	uc.app.ConcurrentTask = 1
+	uc.adapter = groupsReader{groups: []*assets.Group{assets.NewGroup(assets.GroupByNone)}}
+	ctx := context.Background()
+
+	groupChan, err := uc.prepare(ctx, nil, func(err error) { t.Error(err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := uc.phase.Get(); p != phaseScanning {
+		t.Errorf("phase after the server's inventory = %s, want %s", p, phaseScanning)
+	}
+	if err := uc.uploadLoop(ctx, groupChan); err != nil {
+		t.Fatal(err)
+	}
+	if p := uc.phase.Get(); p != phaseUploading {
+		t.Errorf("phase after the first group = %s, want %s", p, phaseUploading)
+	}
+}
+
+func TestPhaseInventory(t *testing.T) {
+	var p phaseTracker
+	p.SetInventory(250, 1000)
+	if got, want := p.Inventory(), "Fetching server inventory: 250/1000 (25%)"; got != want {
+		t.Errorf("Inventory() = %q, want %q", got, want)
+	}
+}
+
+func TestHeartbeatAttrs(t *testing.T) {
+	uc := newTestUpCmd(t)
+	uc.phase.Set(phaseFetchingServerAssets)
+	uc.phase.SetInventory(10, 40)
+
+	attrs := func() map[string]any {
+		m := map[string]any{}
+		l := uc.heartbeatAttrs()
+		for i := 0; i < len(l); i += 2 {
+			m[l[i].(string)] = l[i+1]
+		}
+		return m
+	}
+	m := attrs()
+	if m["phase"] != string(phaseFetchingServerAssets) || m["inventory_fetched"] != int64(10) || m["inventory_total"] != int64(40) {
+		t.Errorf("unexpected heartbeat during the inventory: %v", m)
+	}
+
+	ctx := context.Background()
+	file := fshelper.FSName(os.DirFS(t.TempDir()), "a.jpg")
+	uc.app.FileProcessor().Logger().Record(ctx, fileevent.DiscoveredImage, file)
+	uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedUploadSuccess, file)
+	uc.phase.Set(phaseUploading)
+	m = attrs()
+	if m["phase"] != string(phaseUploading) || m["assets_found"] != int64(1) || m["uploaded"] != int64(1) {
+		t.Errorf("unexpected heartbeat during the upload: %v", m)
+	}
+	if _, ok := m["inventory_fetched"]; ok {
+		t.Errorf("the inventory is given after its phase: %v", m)
+	}
+
+	// the text log gets no heartbeat
+	uc.startHeartbeat()()
+}
+
+func TestProgressLine(t *testing.T) {
+	uc := newTestUpCmd(t)
+	uc.phase.Set(phaseFetchingServerAssets)
+	uc.phase.SetInventory(10, 40)
+	// the browsing finds assets during the inventory read
+	file := fshelper.FSName(os.DirFS(t.TempDir()), "a.jpg")
+	uc.app.FileProcessor().Logger().Record(context.Background(), fileevent.DiscoveredImage, file)
+
+	got := strings.TrimSpace(uc.progressLine('.'))
+	if want := "[fetching_server_assets] Fetching server inventory: 10/40 (25%), Assets found: 1, Upload errors: 0, Uploaded 0 ."; got != want {
+		t.Errorf("progressLine() = %q, want %q", got, want)
+	}
+
+	uc.phase.Set(phaseUploading)
+	uc.Limit = 5
+	got = strings.TrimSpace(uc.progressLine('.'))
+	if want := "[uploading] Assets found: 1 (limited to 5), Upload errors: 0, Uploaded 0 ."; got != want {
+		t.Errorf("progressLine() = %q, want %q", got, want)
+	}
+}
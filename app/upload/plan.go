@@ -0,0 +1,133 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/simulot/immich-go/adapters"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/filters"
+)
+
+// plan actions
+const (
+	planUpload    = "upload"
+	planSkip      = "skip"
+	planDuplicate = "duplicate"
+	planUpdate    = "update" // --metadata-only: the server's asset metadata is updated
+)
+
+// planItem is a line of the --plan output
+type planItem struct {
+	Type   string   `json:"type"`
+	RunID  string   `json:"run_id,omitempty"`
+	Action string   `json:"action"`
+	Path   string   `json:"path"`
+	Album  []string `json:"album,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+// plan reads the server's assets and the input, and writes the decision taken for each asset
+// as JSON lines, without changing anything on the server.
+// The groups are processed in sequence to give the same output at each run.
+func (uc *UpCmd) plan(ctx context.Context, adapter adapters.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return uc.planItems(ctx, adapter, func(item planItem) error {
+		item.RunID = uc.app.RunID
+		return enc.Encode(item)
+	})
+}
+
+// planItems gives the decision taken for each asset of the input to the emit function
+func (uc *UpCmd) planItems(ctx context.Context, adapter adapters.Reader, emit func(planItem) error) error {
+	uc.assetIndex = newAssetIndex()
+	if err := uc.getImmichAssets(ctx, nil); err != nil {
+		return err
+	}
+	if uc.SkipIfInAlbum != "" {
+		if err := uc.loadExcludeAlbum(ctx); err != nil {
+			return err
+		}
+	}
+
+	planned := 0
+	for g := range adapter.Browse(ctx) {
+		g = filters.ApplyFilters(g, uc.Filters...)
+		for _, r := range g.Removed {
+			r.Asset.Close()
+			if err := uc.emitPlanItem(emit, newPlanItem(r.Asset, planSkip, r.Reason)); err != nil {
+				return err
+			}
+		}
+		for _, a := range g.Assets {
+			item, err := uc.planAsset(a, &planned)
+			a.Close()
+			if err != nil {
+				return err
+			}
+			if err := uc.emitPlanItem(emit, item); err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// emitPlanItem gives the line of the plan to the emit function
+func (uc *UpCmd) emitPlanItem(emit func(planItem) error, item planItem) error {
+	if uc.IgnoreAlbums {
+		item.Album = nil
+	}
+	return emit(item)
+}
+
+// planAsset gives the decision for the asset, and updates the index like the upload would do,
+// so the next assets of the input are compared with it.
+func (uc *UpCmd) planAsset(a *assets.Asset, planned *int) (planItem, error) {
+	excluded, err := uc.inExcludeAlbum(a)
+	if err != nil {
+		return planItem{}, err
+	}
+	if excluded {
+		return newPlanItem(a, planSkip, "in the album "+uc.SkipIfInAlbum), nil
+	}
+
+	advice, err := uc.assetIndex.ShouldUpload(a, uc)
+	if err != nil {
+		return planItem{}, err
+	}
+
+	if uc.MetadataOnly && advice.Advice != AlreadyProcessed {
+		if advice.ServerAsset == nil {
+			return newPlanItem(a, planSkip, "no matching asset on the server"), nil
+		}
+		return newPlanItem(a, planUpdate, advice.Message), nil
+	}
+
+	switch advice.Advice {
+	case NotOnServer, SmallerOnServer, ForceUpload, ReplaceExisting:
+		// the asset isn't uploaded, it has no server ID.
+		*planned++
+		uc.assetIndex.addPlannedAsset(a, advice.ServerAsset, fmt.Sprintf("plan-%d", *planned))
+		return newPlanItem(a, planUpload, advice.Message), nil
+	case SameOnServer, AlreadyProcessed:
+		return newPlanItem(a, planDuplicate, advice.Message), nil
+	default:
+		return newPlanItem(a, planSkip, advice.Message), nil
+	}
+}
+
+func newPlanItem(a *assets.Asset, action, reason string) planItem {
+	item := planItem{
+		Type:   "plan_item",
+		Action: action,
+		Path:   a.File.FullName(),
+		Reason: reason,
+	}
+	for _, al := range a.Albums {
+		item.Album = append(item.Album, al.Title)
+	}
+	return item
+}
@@ -0,0 +1,127 @@
+package upload
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+// newInventoryServer gives a server whose inventory is the given assets
+func newInventoryServer(t *testing.T, serverAssets ...immich.Asset) *immich.ImmichClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/assets/statistics":
+			_ = json.NewEncoder(w).Encode(immich.UserStatistics{Images: len(serverAssets), Total: len(serverAssets)})
+		case "/api/search/metadata":
+			var resp searchResponse
+			resp.Assets.Items = serverAssets
+			resp.Assets.Total = len(serverAssets)
+			resp.Assets.Count = len(serverAssets)
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	ic, err := immich.NewImmichClient(server.URL, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ic
+}
+
+type searchResponse struct {
+	Assets struct {
+		Total int            `json:"total"`
+		Count int            `json:"count"`
+		Items []immich.Asset `json:"items"`
+	} `json:"assets"`
+}
+
+func TestPlan(t *testing.T) {
+	fsys := fstest.MapFS{
+		"same.jpg":   &fstest.MapFile{Data: []byte("abc")},
+		"new.jpg":    &fstest.MapFile{Data: []byte("new")},
+		"copy.jpg":   &fstest.MapFile{Data: []byte("new")},
+		"bigger.jpg": &fstest.MapFile{Data: []byte("bigger")},
+		"movie.avi":  &fstest.MapFile{Data: []byte("avi")},
+	}
+	asset := func(name string, albums ...string) *assets.Asset {
+		a := &assets.Asset{File: fshelper.FSName(fsys, name), OriginalFileName: name, FileSize: len(fsys[name].Data)}
+		for _, al := range albums {
+			a.Albums = append(a.Albums, assets.NewAlbum("", al, ""))
+		}
+		return a
+	}
+	input := []*assets.Asset{asset("same.jpg"), asset("new.jpg", "Holidays"), asset("copy.jpg"), asset("bigger.jpg")}
+	g := assets.NewGroup(assets.GroupByNone, input...)
+	removed := asset("movie.avi")
+	g2 := assets.NewGroup(assets.GroupByOther, removed)
+	g2.RemoveAsset(removed, "unsupported")
+
+	uc := newTestUpCmd(t)
+	uc.immichAssetsReady = make(chan struct{})
+	uc.client.Immich = newInventoryServer(t,
+		immich.Asset{ID: "s1", OriginalFileName: "same.jpg", Checksum: "qZk+NkcGgWq6PiVxeFDCbJzQ2J0=", ExifInfo: immich.ExifInfo{FileSizeInByte: 3}},
+		immich.Asset{ID: "s2", OriginalFileName: "bigger.jpg", Checksum: "small", ExifInfo: immich.ExifInfo{FileSizeInByte: 1}},
+	)
+
+	out := bytes.NewBuffer(nil)
+	if err := uc.plan(context.Background(), groupsReader{groups: []*assets.Group{g, g2}}, out); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []planItem
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var item planItem
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			t.Fatalf("%s: %v", scanner.Bytes(), err)
+		}
+		got = append(got, item)
+	}
+	want := []struct {
+		path   string
+		action string
+		album  string
+	}{
+		{"same.jpg", planDuplicate, ""},
+		{"new.jpg", planUpload, "Holidays"},
+		{"copy.jpg", planDuplicate, ""}, // the same content as new.jpg, planned for upload
+		{"bigger.jpg", planUpload, ""},
+		{"movie.avi", planSkip, ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		item := got[i]
+		if item.Type != "plan_item" || item.Path != fshelper.FSName(fsys, w.path).FullName() || item.Action != w.action {
+			t.Errorf("item %d: %+v, want %s %s", i, item, w.action, w.path)
+		}
+		if (w.album == "" && len(item.Album) != 0) || (w.album != "" && (len(item.Album) != 1 || item.Album[0] != w.album)) {
+			t.Errorf("item %d: albums %v, want %q", i, item.Album, w.album)
+		}
+	}
+	if got[4].Reason != "unsupported" {
+		t.Errorf("skip reason = %q", got[4].Reason)
+	}
+
+	// the planned assets don't get a server ID
+	for _, a := range input {
+		if a.ID != "" {
+			t.Errorf("%s has the ID %q", a.File.Name(), a.ID)
+		}
+	}
+}
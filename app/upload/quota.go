@@ -0,0 +1,101 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/simulot/immich-go/adapters"
+	"github.com/simulot/immich-go/app"
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+)
+
+// storageCheck follows the space needed by the upload against the space available on the server
+type storageCheck struct {
+	available int64  // available bytes, -1 when unknown
+	source    string // user quota or server disk
+	input     int64  // size of the input files, -1 when unknown
+	required  atomic.Int64
+	warned    atomic.Bool
+}
+
+// loadStorage gets the space available for the user: the remaining quota when the user has one,
+// the server's free disk space otherwise
+func (uc *UpCmd) loadStorage(ctx context.Context) {
+	uc.storage.available = -1
+	user := uc.client.User
+	if user.QuotaSizeInBytes > 0 {
+		uc.storage.available = max(user.QuotaSizeInBytes-user.QuotaUsageInBytes, 0)
+		uc.storage.source = "user quota"
+	} else if si, ok := uc.client.Immich.(immich.ImmichStorageInterface); ok {
+		s, err := si.GetServerStorage(ctx)
+		if err != nil {
+			uc.app.Log().Warn("can't get the server's storage information", "error", err)
+			return
+		}
+		uc.storage.available = s.DiskAvailableRaw
+		uc.storage.source = "server disk"
+	}
+	if uc.storage.available >= 0 {
+		uc.app.Log().Info("storage available", "source", uc.storage.source, "available", formatBytes(uc.storage.available))
+	}
+}
+
+// checkInputSize compares the size of the input files with the available space, before the upload.
+// The files already on the server aren't uploaded, so a bigger input only gives a warning,
+// unless the input can't fit even when all the files of the user on the server are in it: --strict-quota refuses it.
+func (uc *UpCmd) checkInputSize(ctx context.Context, adapter adapters.Reader) error {
+	uc.storage.input = -1
+	if o, ok := adapter.(*adapters.OrderedReader); ok {
+		adapter = o.Reader // the order doesn't change the input, unlike --limit
+	}
+	sizer, ok := adapter.(adapters.InputSizer)
+	if !ok || uc.storage.available < 0 {
+		return nil
+	}
+	input, err := sizer.InputSize(ctx)
+	if err != nil {
+		uc.app.Log().Warn("can't get the size of the input", "error", err)
+		return nil
+	}
+	uc.storage.input = input
+	uc.app.Log().Info("pre-flight storage check", "input", formatBytes(input), "available", formatBytes(uc.storage.available), "source", uc.storage.source)
+	if input <= uc.storage.available {
+		return nil
+	}
+	if uc.StrictQuota && input-uc.client.User.QuotaUsageInBytes > uc.storage.available {
+		return fmt.Errorf("upload aborted: %w, the input has %s, %s available (%s)", app.ErrNotEnoughSpace, formatBytes(input), formatBytes(uc.storage.available), uc.storage.source)
+	}
+	uc.app.Log().Warn("the input may not fit in the available space, the files already on the server aren't uploaded", "input", formatBytes(input), "available", formatBytes(uc.storage.available), "source", uc.storage.source)
+	return nil
+}
+
+// reserveStorage adds the size of an asset to upload to the required space.
+// It warns once when the available space is exceeded, or fails with --strict-quota.
+func (uc *UpCmd) reserveStorage(size int64) error {
+	required := uc.storage.required.Add(size)
+	if uc.storage.available < 0 || required <= uc.storage.available {
+		return nil
+	}
+	if uc.StrictQuota {
+		return fmt.Errorf("upload aborted: %w, %s required, %s available (%s)", app.ErrNotEnoughSpace, formatBytes(required), formatBytes(uc.storage.available), uc.storage.source)
+	}
+	if !uc.storage.warned.Swap(true) {
+		uc.app.Log().Warn("the upload exceeds the available space", "required", formatBytes(required), "available", formatBytes(uc.storage.available), "source", uc.storage.source)
+	}
+	return nil
+}
+
+// storageSummary gives the available and the required space for the run summary
+func (uc *UpCmd) storageSummary() (fileprocessor.StorageSummary, bool) {
+	if uc.storage.available < 0 {
+		return fileprocessor.StorageSummary{}, false
+	}
+	return fileprocessor.StorageSummary{
+		Source:    uc.storage.source,
+		Available: uc.storage.available,
+		Required:  uc.storage.required.Load(),
+		Input:     max(uc.storage.input, 0),
+	}, true
+}
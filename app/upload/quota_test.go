@@ -0,0 +1,141 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/simulot/immich-go/adapters"
+	"github.com/simulot/immich-go/app"
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+)
+
+// sizedReader is a source giving the size of its input
+type sizedReader struct {
+	groupsReader
+	size int64
+}
+
+func (r sizedReader) InputSize(ctx context.Context) (int64, error) {
+	return r.size, nil
+}
+
+func TestCheckInputSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		available int64
+		input     int64
+		usage     int64 // the bytes used by the user on the server
+		strict    bool
+		wantErr   bool
+		wantInput int64
+	}{
+		{name: "fits", available: 1000, input: 800, wantInput: 800},
+		{name: "exceeds, warning", available: 1000, input: 1500, wantInput: 1500},
+		{name: "exceeds, strict", available: 1000, input: 1500, strict: true, wantErr: true, wantInput: 1500},
+		{name: "exceeds, strict, may be on the server", available: 1000, input: 1500, usage: 600, strict: true, wantInput: 1500},
+		{name: "unknown space", available: -1, input: 1500, strict: true, wantInput: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := newTestUpCmd(t)
+			uc.storage.available = tt.available
+			uc.storage.source = "server disk"
+			uc.client.User.QuotaUsageInBytes = tt.usage
+			uc.StrictQuota = tt.strict
+
+			err := uc.checkInputSize(context.Background(), sizedReader{size: tt.input})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkInputSize() error = %v", err)
+			}
+			if err != nil && !errors.Is(err, app.ErrNotEnoughSpace) {
+				t.Errorf("the error should be ErrNotEnoughSpace: %v", err)
+			}
+			if uc.storage.input != tt.wantInput {
+				t.Errorf("input = %d, want %d", uc.storage.input, tt.wantInput)
+			}
+		})
+	}
+
+	// the sorted source has the same input, the limited one is smaller
+	uc := newTestUpCmd(t)
+	uc.storage.available = 1000
+	uc.StrictQuota = true
+	if err := uc.checkInputSize(context.Background(), adapters.NewOrderedReader(sizedReader{size: 1500}, adapters.OrderPath)); !errors.Is(err, app.ErrNotEnoughSpace) {
+		t.Errorf("checkInputSize(--order) = %v, want ErrNotEnoughSpace", err)
+	}
+	if err := uc.checkInputSize(context.Background(), adapters.NewLimitedReader(sizedReader{size: 1500}, 10)); err != nil {
+		t.Errorf("checkInputSize(--limit) = %v", err)
+	}
+
+	// a source that can't give its size isn't checked
+	uc = newTestUpCmd(t)
+	uc.storage.available = 10
+	uc.StrictQuota = true
+	if err := uc.checkInputSize(context.Background(), groupsReader{}); err != nil || uc.storage.input != -1 {
+		t.Errorf("checkInputSize() = %v, input = %d", err, uc.storage.input)
+	}
+}
+
+func TestReserveStorage(t *testing.T) {
+	uc := newTestUpCmd(t)
+	uc.storage.available = 1000
+	if err := uc.reserveStorage(600); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.reserveStorage(600); err != nil || !uc.storage.warned.Load() {
+		t.Errorf("reserveStorage() = %v, the excess should give a warning", err)
+	}
+
+	uc = newTestUpCmd(t)
+	uc.storage.available = 1000
+	uc.StrictQuota = true
+	if err := uc.reserveStorage(600); err != nil {
+		t.Fatal(err)
+	}
+	if err := uc.reserveStorage(600); !errors.Is(err, app.ErrNotEnoughSpace) {
+		t.Errorf("reserveStorage() = %v, want ErrNotEnoughSpace", err)
+	}
+}
+
+func TestStorageSummary(t *testing.T) {
+	uc := newTestUpCmd(t)
+	uc.storage.available = -1
+	if _, ok := uc.storageSummary(); ok {
+		t.Error("no summary without the available space")
+	}
+
+	uc.storage.available = 1000
+	uc.storage.source = "user quota"
+	uc.storage.input = 1500
+	uc.StrictQuota = true
+	_ = uc.reserveStorage(700)
+	err := uc.reserveStorage(700)
+	s, ok := uc.storageSummary()
+	if !ok {
+		t.Fatal("the summary should be given")
+	}
+	uc.app.SetStorage(s)
+
+	uc.app.SummaryFile = filepath.Join(t.TempDir(), "summary.json")
+	uc.app.WriteSummaryFile(&cobra.Command{Use: "upload"}, err)
+	b, rErr := os.ReadFile(uc.app.SummaryFile)
+	if rErr != nil {
+		t.Fatal(rErr)
+	}
+	var summary fileprocessor.RunSummary
+	if err := json.Unmarshal(b, &summary); err != nil {
+		t.Fatal(err)
+	}
+	want := fileprocessor.StorageSummary{Source: "user quota", Available: 1000, Required: 1400, Input: 1500}
+	if summary.Storage == nil || *summary.Storage != want {
+		t.Errorf("storage = %+v, want %+v", summary.Storage, want)
+	}
+	if summary.Status != "aborted, not enough space on the server" {
+		t.Errorf("status = %q", summary.Status)
+	}
+}
@@ -0,0 +1,70 @@
+package upload
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/simulot/immich-go/internal/ui"
+)
+
+// recordSummary gives the upload's own counters to the application, for the run summary
+func (uc *UpCmd) recordSummary() {
+	if s, ok := uc.storageSummary(); ok {
+		uc.app.SetStorage(s)
+	}
+	if c := &uc.albumCovers; c.set+c.failed > 0 {
+		uc.app.SetAlbumCovers(fileprocessor.AlbumCoversSummary{Set: c.set, Fallback: c.fallback, Failed: c.failed})
+	}
+	if s, ok := uc.stacksSummary(); ok {
+		uc.app.SetStacks(s)
+	}
+	if uc.app.ReadOnly {
+		uc.app.SetBlockedRequests(uc.client.BlockedRequests())
+	}
+	uc.app.SetInvalidMedia(uc.invalidMedia.Load())
+	uc.app.SetMergedAlbums(uc.albumNames.merges())
+}
+
+// summaryReport gives the lines ending the text report of the upload.
+// They are built from the run summary, so the text report and the JSON outputs give the same values.
+func summaryReport(s fileprocessor.RunSummary) []string {
+	var lines []string
+	if st := s.Storage; st != nil {
+		lines = append(lines, fmt.Sprintf("Storage: %s available (%s), %s required by the upload", formatBytes(st.Available), st.Source, formatBytes(st.Required)))
+	}
+	lines = append(lines, fmt.Sprintf("Peak memory: %s", ui.FormatBytes(int64(s.PeakMemory))))
+	if s.MemoryThrottled > 0 {
+		lines = append(lines, fmt.Sprintf("Uploads delayed by --max-memory: %d", s.MemoryThrottled))
+	}
+	if s.ClockSkew != "" {
+		lines = append(lines, fmt.Sprintf("Clock skew with the server: %s", s.ClockSkew))
+	}
+	if s.Limit > 0 {
+		lines = append(lines, fmt.Sprintf("Input limited to %d assets by --limit", s.Limit))
+	}
+	if s.BlockedRequests != nil {
+		lines = append(lines, fmt.Sprintf("Requests blocked by --read-only: %d", *s.BlockedRequests))
+	}
+	for _, m := range s.MergedAlbums {
+		merged := make([]string, len(m.Merged))
+		for i, n := range m.Merged {
+			merged[i] = fmt.Sprintf("%q", n)
+		}
+		lines = append(lines, fmt.Sprintf("Album %q merged with %s (--album-name-match)", m.Name, strings.Join(merged, ", ")))
+	}
+	if s.InvalidMedia > 0 {
+		lines = append(lines, fmt.Sprintf("Truncated or corrupt files not uploaded: %d (--validate-media)", s.InvalidMedia))
+	}
+	if c := s.AlbumCovers; c != nil {
+		lines = append(lines, fmt.Sprintf("Album covers set: %d, with a fallback member: %d, failed: %d", c.Set, c.Fallback, c.Failed))
+	}
+	if st := s.Stacks; st != nil {
+		lines = append(lines, fmt.Sprintf("Stacks created during the upload: %d, failed: %d", st.Created, st.Failed))
+	}
+	if n := s.DuplicateSetsFound; n != nil {
+		lines = append(lines, fmt.Sprintf("Duplicate sets found by the server: %d. The detection job may still be running, check the server's duplicates utility for the final result", *n))
+	}
+	lines = append(lines, fmt.Sprintf("Upload status: %s", s.Status))
+	return lines
+}
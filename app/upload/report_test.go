@@ -0,0 +1,106 @@
+package upload
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/spf13/cobra"
+)
+
+func TestSummaryReport(t *testing.T) {
+	four := 4
+	blocked := int64(0)
+	tests := []struct {
+		name    string
+		summary fileprocessor.RunSummary
+		want    []string
+	}{
+		{
+			name:    "minimal",
+			summary: fileprocessor.RunSummary{Status: "completed", PeakMemory: 1 << 20},
+			want:    []string{"Peak memory: 1.0 MB", "Upload status: completed"},
+		},
+		{
+			name: "all",
+			summary: fileprocessor.RunSummary{
+				Status:             "failed",
+				Storage:            &fileprocessor.StorageSummary{Source: "user quota", Available: 1 << 30, Required: 1 << 20},
+				MemoryThrottled:    2,
+				ClockSkew:          "3s",
+				Limit:              10,
+				BlockedRequests:    &blocked,
+				MergedAlbums:       []fileprocessor.MergedAlbumSummary{{Name: "Trip", Merged: []string{" Trip", "trip"}}},
+				InvalidMedia:       1,
+				AlbumCovers:        &fileprocessor.AlbumCoversSummary{Set: 2, Fallback: 1},
+				Stacks:             &fileprocessor.StacksSummary{Created: 3},
+				DuplicateSetsFound: &four,
+			},
+			want: []string{
+				"Storage: 1.0 GB available (user quota), 1.0 MB required by the upload",
+				"Peak memory: 0 B",
+				"Uploads delayed by --max-memory: 2",
+				"Clock skew with the server: 3s",
+				"Input limited to 10 assets by --limit",
+				"Requests blocked by --read-only: 0",
+				`Album "Trip" merged with " Trip", "trip" (--album-name-match)`,
+				"Truncated or corrupt files not uploaded: 1 (--validate-media)",
+				"Album covers set: 2, with a fallback member: 1, failed: 0",
+				"Stacks created during the upload: 3, failed: 0",
+				"Duplicate sets found by the server: 4. The detection job may still be running, check the server's duplicates utility for the final result",
+				"Upload status: failed",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summaryReport(tt.summary); !slices.Equal(got, tt.want) {
+				t.Errorf("summaryReport() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+// TestRecordSummary checks that the upload's counters reach the JSON summary and the text report alike
+func TestRecordSummary(t *testing.T) {
+	uc := newTestUpCmd(t)
+	uc.app.ReadOnly = true
+	uc.storage.available, uc.storage.source = 2048, "server disk"
+	uc.storage.required.Store(1024)
+	uc.albumCovers.set = 1
+	uc.StackOnUpload = true
+	uc.stacks.created.Store(2)
+	uc.invalidMedia.Store(3)
+	uc.albumNames.mode = AlbumMatchCI
+	uc.albumNames.key("Trip")
+	uc.albumNames.key("TRIP")
+
+	uc.recordSummary()
+	s := uc.app.RunSummary(&cobra.Command{Use: "upload"}, nil)
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{`"storage":`, `"album_covers":`, `"stacks":`, `"invalid_media":3`, `"blocked_requests":0`, `"merged_albums":[{"name":"Trip","merged":["TRIP"]}]`} {
+		if !strings.Contains(string(b), k) {
+			t.Errorf("the summary has no %s: %s", k, b)
+		}
+	}
+	report := strings.Join(summaryReport(s), "\n")
+	for _, l := range []string{
+		"Storage: 2.0 KB available (server disk), 1.0 KB required by the upload",
+		"Album covers set: 1, with a fallback member: 0, failed: 0",
+		"Stacks created during the upload: 2, failed: 0",
+		"Truncated or corrupt files not uploaded: 3 (--validate-media)",
+		"Requests blocked by --read-only: 0",
+		`Album "Trip" merged with "TRIP" (--album-name-match)`,
+		"Upload status: completed",
+	} {
+		if !strings.Contains(report, l) {
+			t.Errorf("the report has no line %q:\n%s", l, report)
+		}
+	}
+}
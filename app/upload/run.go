@@ -4,18 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/simulot/immich-go/adapters"
+	"github.com/simulot/immich-go/app"
 	"github.com/simulot/immich-go/immich"
 	"github.com/simulot/immich-go/internal/assets"
 	"github.com/simulot/immich-go/internal/assets/cache"
 	"github.com/simulot/immich-go/internal/fileevent"
 	"github.com/simulot/immich-go/internal/filters"
-	"github.com/simulot/immich-go/internal/fshelper"
+	"github.com/simulot/immich-go/internal/servercache"
 	"github.com/simulot/immich-go/internal/worker"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
 func (uc *UpCmd) saveAlbum(ctx context.Context, album assets.Album, ids []string) (assets.Album, error) {
@@ -23,21 +28,48 @@ func (uc *UpCmd) saveAlbum(ctx context.Context, album assets.Album, ids []string
 		return album, nil
 	}
 	if album.ID == "" {
+		uc.albumRequests.requests.Add(1)
 		r, err := uc.client.Immich.CreateAlbum(ctx, album.Title, album.Description, ids)
 		if err != nil {
-			uc.app.Log().Error("failed to create album", "err", err, "album", album.Title)
-			return album, err
+			if len(ids) == 1 {
+				uc.app.Log().Error("failed to create album", "err", err, "album", album.Title)
+				return album, err
+			}
+			// create the album without assets, then add them one by one to isolate the failing ones
+			uc.app.Log().Warn("failed to create album with its assets, retrying asset by asset", "err", err, "album", album.Title, "assets", len(ids))
+			uc.albumRequests.requests.Add(1)
+			r, err = uc.client.Immich.CreateAlbum(ctx, album.Title, album.Description, nil)
+			if err != nil {
+				uc.app.Log().Error("failed to create album", "err", err, "album", album.Title)
+				return album, err
+			}
+			uc.app.Log().Info("created album", "album", album.Title, "assets", 0)
+			album.ID = r.ID
+			uc.albumCreated(album)
+			return album, uc.addAlbumAssetsOneByOne(ctx, album, ids)
 		}
 		uc.app.Log().Info("created album", "album", album.Title, "assets", len(ids))
+		uc.albumRequests.assets.Add(int64(len(ids)))
+		uc.albumActivity.Add(album.Title, len(ids))
 		album.ID = r.ID
+		uc.albumCreated(album)
+		uc.createdAlbums.added(album, len(ids))
 		return album, nil
 	}
+	uc.albumRequests.requests.Add(1)
 	_, err := uc.client.Immich.AddAssetToAlbum(ctx, album.ID, ids)
 	if err != nil {
-		uc.app.Log().Error("failed to add assets to album", "err", err, "album", album.Title, "assets", len(ids))
-		return album, err
+		if len(ids) == 1 {
+			uc.app.Log().Error("failed to add assets to album", "err", err, "album", album.Title, "assets", len(ids))
+			return album, err
+		}
+		uc.app.Log().Warn("failed to add a batch of assets to album, retrying asset by asset", "err", err, "album", album.Title, "assets", len(ids))
+		return album, uc.addAlbumAssetsOneByOne(ctx, album, ids)
 	}
 	uc.app.Log().Info("updated album", "album", album.Title, "assets", len(ids))
+	uc.albumRequests.assets.Add(int64(len(ids)))
+	uc.albumActivity.Add(album.Title, len(ids))
+	uc.createdAlbums.added(album, len(ids))
 	return album, err
 }
 
@@ -99,12 +131,35 @@ func (uc *UpCmd) finishing(ctx context.Context) error {
 	defer func() { uc.finished = true }()
 	// do waiting operations
 	uc.albumsCache.Close()
+	uc.albumRequestsReport()
+	uc.app.SetAlbums(uc.createdAlbums.list(uc.SummaryAllAlbums))
+	uc.app.SetVisibility(uc.visibility.get())
 	uc.tagsCache.Close()
+	if uc.SetAlbumCover {
+		uc.setAlbumCovers(ctx)
+	}
+	if uc.albumDescription != nil || uc.AlbumActivity != "" {
+		uc.setAlbumSettings(ctx)
+	}
+
+	// Restore the trash state once albums and tags are set
+	if uc.trashedAssets.Len() > 0 {
+		err := uc.client.Immich.DeleteAssets(ctx, uc.trashedAssets.Items(), false)
+		if err != nil {
+			uc.app.Log().Error("can't move assets to the trash", "error", err)
+		}
+	}
 
 	// Resume immich background jobs if requested
-	err := uc.resumeJobs(ctx)
-	if err != nil {
-		return err
+	if uc.client.PauseImmichBackgroundJobs {
+		err := uc.resumeJobs(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if uc.RunDedup {
+		uc.runDedup(ctx)
 	}
 
 	// Generate FileProcessor report
@@ -121,9 +176,25 @@ func (uc *UpCmd) finishing(ctx context.Context) error {
 	return nil
 }
 
-func (uc *UpCmd) upload(ctx context.Context, adapter adapters.Reader) error {
+func (uc *UpCmd) upload(ctx context.Context, cmd *cobra.Command, adapter adapters.Reader) (err error) {
 	ctx, cancel := context.WithCancelCause(ctx)
 	defer cancel(nil)
+	stopMetrics, err := uc.startMetrics()
+	if err != nil {
+		return err
+	}
+	defer stopMetrics()
+	if uc.IgnoreAlbums {
+		uc.app.Log().Message("--ignore-albums: the albums of the input are ignored, no album is created and no asset is added to an album")
+	}
+	var targetIDs []string
+	if uc.AlbumID != "" {
+		album, ids, err := uc.loadTargetAlbum(ctx)
+		if err != nil {
+			return err
+		}
+		uc.targetAlbum, targetIDs = &album, ids
+	}
 	// Stop immich background jobs if requested
 	// will be resumed with a call to finishing()
 	if uc.client.PauseImmichBackgroundJobs {
@@ -132,27 +203,53 @@ func (uc *UpCmd) upload(ctx context.Context, adapter adapters.Reader) error {
 			return fmt.Errorf("can't pause immich background jobs: pass an administrator key with the flag --admin-api-key or disable the jobs pausing with the flag --pause-immich-jobs=FALSE\n%w", err)
 		}
 	}
+	if err := uc.openTwoPass(); err != nil {
+		return err
+	}
+	uc.logVisibility()
 	defer func() { _ = uc.finishing(ctx) }()
 	defer func() {
+		if lr, ok := uc.adapter.(*adapters.LimitedReader); ok && lr.Reached() {
+			// the assets found after the limit are never processed
+			uc.app.FileProcessor().DiscardPending(ctx, fileevent.DiscardedFiltered, "--limit reached")
+		}
+		uc.recordSummary()
 		if uc.app.FileProcessor() != nil {
-			fmt.Println(uc.app.FileProcessor().GenerateReport())
+			fmt.Fprintln(uc.app.Log().MessageWriter(), uc.app.FileProcessor().GenerateReport())
+			for _, l := range summaryReport(uc.app.RunSummary(cmd, err)) {
+				uc.app.Log().Message("%s", l)
+			}
 		}
+		uc.saveTwoPass()
 	}()
-	uc.albumsCache = cache.NewCollectionCache(50, func(album assets.Album, ids []string) (assets.Album, error) {
+	uc.albumsCache = cache.NewCollectionCache(uc.AlbumBatchSize, func(album assets.Album, ids []string) (assets.Album, error) {
 		return uc.saveAlbum(ctx, album, ids)
 	})
+	if uc.targetAlbum != nil {
+		uc.albumsCache.NewCollection(targetAlbumKey, *uc.targetAlbum, targetIDs)
+	}
 	uc.tagsCache = cache.NewCollectionCache(50, func(tag assets.Tag, ids []string) (assets.Tag, error) {
 		return uc.saveTags(ctx, tag, ids)
 	})
 
 	uc.adapter = adapter
+	uc.loadStorage(ctx)
+	if err := uc.checkInputSize(ctx, adapter); err != nil {
+		return err
+	}
+
+	uc.app.SetStatusPhase(func() string { return string(uc.phase.Get()) })
 
 	runner := uc.runUI
 	uc.assetIndex = newAssetIndex()
 
-	if uc.NoUI {
+	switch {
+	case uc.NoUI || uc.UI == uiModeLine:
 		runner = uc.runNoUI
-	} else {
+	case !isInteractive():
+		uc.app.Log().Info("the output isn't an interactive terminal. Using the line mode")
+		runner = uc.runNoUI
+	default:
 		_, err := tcell.NewScreen()
 		if err != nil {
 			uc.app.Log().Warn("can't initialize the screen for the UI mode. Falling back to no-gui mode", "err", err)
@@ -160,55 +257,141 @@ func (uc *UpCmd) upload(ctx context.Context, adapter adapters.Reader) error {
 			runner = uc.runNoUI
 		}
 	}
-	err := runner(ctx, uc.app)
+	stopHeartbeat := uc.startHeartbeat()
+	defer stopHeartbeat()
+	err = runner(ctx, uc.app)
 	return err
 }
 
 func (uc *UpCmd) getImmichAlbums(ctx context.Context) error {
+	if uc.IgnoreAlbums || (uc.AlbumID != "" && uc.SkipIfInAlbum == "") {
+		// the album given by --album-id is read by its ID, the input's albums are ignored
+		return nil
+	}
 	// Get the album list from the server, but without assets.
 	serverAlbums, err := uc.client.Immich.GetAllAlbums(ctx)
 	if err != nil {
 		return fmt.Errorf("can't get the album list from the server: %w", err)
 	}
 
+	// the owned albums first, they win over the shared ones with the same name
+	userID := uc.client.User.ID
+	slices.SortStableFunc(serverAlbums, func(a, b immich.AlbumSimplified) int {
+		switch sa, sb := isShared(a, userID), isShared(b, userID); {
+		case sa == sb:
+			return 0
+		case sb:
+			return -1
+		default:
+			return 1
+		}
+	})
+	owned := map[string]struct{}{}
+	inventoryAlbums := map[string]servercache.Album{}
+	albumsChanged := false
+
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	case <-uc.immichAssetsReady:
 		// Wait for the server's assets to be ready.
-		for _, a := range serverAlbums {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-				// Get the album info from the server, with assets.
-				r, err := uc.client.Immich.GetAlbumInfo(ctx, a.ID, false)
-				if err != nil {
-					uc.app.Log().Error("can't get the album info from the server", "album", a.AlbumName, "err", err)
-					continue
-				}
-				ids := make([]string, 0, len(r.Assets))
-				for _, aa := range r.Assets {
-					ids = append(ids, aa.ID)
-				}
+	}
 
-				album := assets.NewAlbum(a.ID, a.AlbumName, a.Description)
-				uc.albumsCache.NewCollection(a.AlbumName, album, ids)
-				uc.app.Log().Info("got album from the server", "album", a.AlbumName, "assets", len(r.Assets))
-				uc.app.Log().Debug("got album from the server", "album", a.AlbumName, "assets", ids)
-				// assign the album to the assets
-				for _, id := range ids {
-					a := uc.assetIndex.getByID(id)
-					if a == nil {
-						uc.app.Log().Debug("processing the immich albums: asset not found in index", "id", id)
-						continue
-					}
-					a.Albums = append(a.Albums, album)
-				}
+	// select the albums used by the upload: with --album-id, only the album of --skip-if-in-album
+	excludeKey := uc.albumNames.key(uc.SkipIfInAlbum)
+	type serverAlbum struct {
+		immich.AlbumSimplified
+		key    string
+		ids    []string
+		cached bool
+		err    error
+	}
+	var used []*serverAlbum
+	for _, a := range serverAlbums {
+		key := uc.albumNames.key(a.AlbumName)
+		if uc.AlbumID != "" && key != excludeKey {
+			continue
+		}
+		if !isShared(a, userID) {
+			owned[key] = struct{}{}
+		} else {
+			if _, ok := owned[key]; ok {
+				uc.app.Log().Info("album shared by another user, the owned album with the same name is used", "album", a.AlbumName)
+				continue
+			}
+			if !uc.sharedAlbums.add(key) {
+				uc.app.Log().Info("album shared by another user, not used", "album", a.AlbumName, "shared-album-mode", uc.SharedAlbumMode)
+				continue
 			}
+			uc.app.Log().Info("album shared by another user, the assets are added to it", "album", a.AlbumName, "shared-album-mode", uc.SharedAlbumMode)
 		}
+		used = append(used, &serverAlbum{AlbumSimplified: a, key: key})
 	}
-	return nil
+
+	// Get the album info from the server, with assets, unless the album is unchanged in the server's cache.
+	// --fetch-concurrency albums are requested at once.
+	grp := errgroup.Group{}
+	grp.SetLimit(max(uc.client.FetchConcurrency, 1))
+	for _, a := range used {
+		a.ids, a.cached = uc.cachedAlbumAssets(a.AlbumSimplified)
+		if a.cached {
+			continue
+		}
+		grp.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				a.err = err
+				return nil
+			}
+			r, err := uc.client.Immich.GetAlbumInfo(ctx, a.ID, false)
+			if err != nil {
+				a.err = err
+				return nil
+			}
+			a.ids = make([]string, 0, len(r.Assets))
+			for _, aa := range r.Assets {
+				a.ids = append(a.ids, aa.ID)
+			}
+			return nil
+		})
+	}
+	_ = grp.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, a := range used {
+		if a.err != nil {
+			uc.app.Log().Error("can't get the album info from the server", "album", a.AlbumName, "err", a.err)
+			continue
+		}
+		if uc.SkipIfInAlbum != "" && a.key == excludeKey && uc.excludeAlbum.checksums == nil {
+			uc.setExcludeAlbum(a.ids)
+		}
+		if !a.cached {
+			albumsChanged = true
+		}
+		inventoryAlbums[a.ID] = servercache.Album{AssetCount: a.AssetCount, AssetIDs: a.ids}
+
+		album := assets.NewAlbum(a.ID, a.AlbumName, a.Description)
+		uc.albumsCache.NewCollection(a.key, album, a.ids)
+		uc.app.Log().Info("got album from the server", "album", a.AlbumName, "assets", len(a.ids), "cached", a.cached)
+		uc.app.Log().Debug("got album from the server", "album", a.AlbumName, "assets", a.ids)
+		// assign the album to the assets
+		for _, id := range a.ids {
+			ia := uc.assetIndex.getByID(id)
+			if ia == nil {
+				uc.app.Log().Debug("processing the immich albums: asset not found in index", "id", id)
+				continue
+			}
+			ia.Albums = append(ia.Albums, album)
+		}
+	}
+	// the inventory isn't updated from the partial album list of --album-id
+	if uc.inventory != nil && uc.AlbumID == "" && (albumsChanged || len(inventoryAlbums) != len(uc.inventory.Albums)) {
+		uc.inventory.Albums = inventoryAlbums
+		uc.saveServerInventory()
+	}
+	return uc.checkExcludeAlbum()
 }
 
 func (uc *UpCmd) getImmichAssets(ctx context.Context, updateFn progressUpdate) error {
@@ -219,13 +402,18 @@ func (uc *UpCmd) getImmichAssets(ctx context.Context, updateFn progressUpdate) e
 	}
 	totalOnImmich := statistics.Total
 	received := 0
-
-	err = uc.client.Immich.GetAllAssets(ctx, func(a *immich.Asset) error {
-		if updateFn != nil {
-			defer func() {
+	uc.phase.SetInventory(received, totalOnImmich)
+	var lock sync.Mutex // the searches and their pages are fetched concurrently
+
+	addAsset := func(a *immich.Asset) error {
+		lock.Lock()
+		defer lock.Unlock()
+		defer func() {
+			uc.phase.SetInventory(received, totalOnImmich)
+			if updateFn != nil {
 				updateFn(received, totalOnImmich)
-			}()
-		}
+			}
+		}()
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -243,9 +431,28 @@ func (uc *UpCmd) getImmichAssets(ctx context.Context, updateFn progressUpdate) e
 			uc.app.Log().Debug("Immich asset:", "ID", a.ID, "FileName", a.OriginalFileName, "Capture date", a.ExifInfo.DateTimeOriginal, "CheckSum", a.Checksum, "FileSize", a.ExifInfo.FileSizeInByte, "DeviceAssetID", a.DeviceAssetID, "OwnerID", a.OwnerID, "IsTrashed", a.IsTrashed, "IsArchived", a.IsArchived)
 			return nil
 		}
-	})
-	if err != nil {
-		return err
+	}
+
+	if cached, ok := uc.cachedServerAssets(ctx, statistics); ok {
+		for _, a := range cached {
+			if err = addAsset(a); err != nil {
+				return err
+			}
+		}
+	} else {
+		err = uc.client.Immich.GetAllAssets(ctx, func(a *immich.Asset) error {
+			if uc.inventory != nil {
+				lock.Lock()
+				uc.inventory.Assets = append(uc.inventory.Assets, a)
+				lock.Unlock()
+			}
+			return addAsset(a)
+		})
+		if err != nil {
+			uc.inventory = nil // don't save a partial inventory
+			return err
+		}
+		uc.saveServerInventory()
 	}
 	if updateFn != nil {
 		updateFn(totalOnImmich, totalOnImmich)
@@ -262,6 +469,8 @@ func (uc *UpCmd) uploadLoop(ctx context.Context, groupChan chan *assets.Group) e
 	wg.Go(func() {
 		workers := worker.NewPool(uc.app.ConcurrentTask)
 		defer workers.Stop()
+		var inFlight atomic.Int64 // groups submitted and not finished, for --max-memory
+		running := func() int { return int(inFlight.Load()) }
 		for {
 			select {
 			case <-ctx.Done():
@@ -271,14 +480,27 @@ func (uc *UpCmd) uploadLoop(ctx context.Context, groupChan chan *assets.Group) e
 				if !ok {
 					return
 				}
+				uc.phase.GroupReceived()
+				uc.app.WaitForMemory(ctx, running)
+				forget := uc.filenameTemplate.number(g)
+				inFlight.Add(1)
 				workers.Submit(func() {
+					defer inFlight.Add(-1)
+					defer forget()
 					err := uc.handleGroup(ctx, g)
+					if errors.Is(err, app.ErrNotEnoughSpace) {
+						cancel(err)
+						return
+					}
 					if err != nil {
 						err = uc.app.ProcessError(err)
 						if err != nil {
 							cancel(err)
 						}
 					}
+					if err := uc.checkMaxErrors(); err != nil {
+						cancel(err)
+					}
 				})
 			}
 		}
@@ -302,6 +524,18 @@ func (uc *UpCmd) uploadLoop(ctx context.Context, groupChan chan *assets.Group) e
 	return err
 }
 
+// checkMaxErrors returns an error when the number of recorded errors exceeds the --max-errors limit
+func (uc *UpCmd) checkMaxErrors() error {
+	if uc.MaxErrors <= 0 {
+		return nil
+	}
+	n := uc.app.FileProcessor().Logger().TotalErrors()
+	if n > int64(uc.MaxErrors) {
+		return fmt.Errorf("upload aborted: %w, %d errors exceeded the limit of %d set by --max-errors", app.ErrMaxErrors, n, uc.MaxErrors)
+	}
+	return nil
+}
+
 func (uc *UpCmd) handleGroup(ctx context.Context, g *assets.Group) error {
 	var errGroup error
 
@@ -317,13 +551,19 @@ func (uc *UpCmd) handleGroup(ctx context.Context, g *assets.Group) error {
 	// Upload assets from the group
 	for _, a := range g.Assets {
 		err := uc.handleAsset(ctx, a)
-		errGroup = errors.Join(err)
+		errGroup = errors.Join(errGroup, err)
+		uc.recordTwoPass(a)
 	}
 
 	// Manage groups
 	// after the filtering and the upload, we can stack the assets
 
-	if len(g.Assets) > 1 && g.Grouping != assets.GroupByNone {
+	switch {
+	case uc.uploadOnly():
+		// the stacks and the live photos are left to the enrich pass
+	case g.Grouping == assets.GroupByLivePhoto:
+		uc.linkLivePhoto(ctx, g)
+	case len(g.Assets) > 1 && g.Grouping != assets.GroupByNone:
 		client := uc.client.Immich.(immich.ImmichStackInterface)
 		ids := []string{g.Assets[g.CoverIndex].ID}
 		for i, a := range g.Assets {
@@ -336,7 +576,11 @@ func (uc *UpCmd) handleGroup(ctx context.Context, g *assets.Group) error {
 		if len(ids) > 1 {
 			_, err := client.CreateStack(ctx, ids)
 			if err != nil {
+				uc.stacks.failed.Add(1)
 				uc.app.Log().Error("Can't create stack", "error", err)
+			} else {
+				uc.stacks.created.Add(1)
+				uc.app.FileProcessor().RecordNonAsset(ctx, g.Assets[g.CoverIndex].File, 0, fileevent.ProcessedStackCreated)
 			}
 		}
 	}
@@ -344,17 +588,33 @@ func (uc *UpCmd) handleGroup(ctx context.Context, g *assets.Group) error {
 	return errGroup
 }
 
-func (uc *UpCmd) handleAsset(ctx context.Context, a *assets.Asset) error {
+func (uc *UpCmd) handleAsset(ctx context.Context, a *assets.Asset) (err error) {
 	defer func() {
 		a.Close() // Close and clean resources linked to the local asset
+		if errors.Is(err, errUnsupportedFormat) {
+			err = nil // the asset is discarded, not an error
+		}
 	}()
 
+	excluded, err := uc.inExcludeAlbum(a)
+	if err != nil {
+		return err
+	}
+	if excluded {
+		uc.app.FileProcessor().RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedInExcludeAlbum, "in the album "+uc.SkipIfInAlbum)
+		return nil
+	}
+
 	// var status stri g
 	advice, err := uc.assetIndex.ShouldUpload(a, uc)
 	if err != nil {
 		return err
 	}
 
+	if uc.MetadataOnly && advice.Advice != AlreadyProcessed {
+		return uc.updateMetadataOnly(ctx, a, advice)
+	}
+
 	switch advice.Advice {
 	case NotOnServer: // Upload and manage albums
 		serverStatus, err := uc.uploadAsset(ctx, a)
@@ -365,27 +625,17 @@ func (uc *UpCmd) handleAsset(ctx context.Context, a *assets.Asset) error {
 		uc.processUploadedAsset(ctx, a, serverStatus)
 		return nil
 
-	case SmallerOnServer: // Upload, manage albums and delete the server's asset
-
-		// Remember existing asset's albums, if any
-		a.Albums = append(a.Albums, advice.ServerAsset.Albums...)
-
-		// Upload the superior asset
-		serverStatus, err := uc.replaceAsset(ctx, a, advice.ServerAsset)
-		if err != nil {
-			return err
-		}
+	case SmallerOnServer: // Upload the superior asset, manage albums and delete the server's asset
+		return uc.uploadReplacement(ctx, a, advice.ServerAsset, fileevent.ProcessedUploadUpgraded)
 
-		uc.processUploadedAsset(ctx, a, serverStatus)
-		uc.app.FileProcessor().RecordAssetProcessed(ctx, a.File, int64(a.FileSize), fileevent.ProcessedUploadUpgraded)
-
-		return nil
+	case ReplaceExisting: // Replace the changed asset, and manage albums
+		return uc.uploadReplacement(ctx, a, advice.ServerAsset, fileevent.ProcessedAssetReplaced)
 
 	case AlreadyProcessed: // SHA1 already processed
 		// Record as discarded - duplicate in input
 		uc.app.FileProcessor().RecordNonAsset(ctx, a.File, int64(a.FileSize), fileevent.DiscardedLocalDuplicate)
 		uc.app.FileProcessor().RecordAssetProcessed(ctx, a.File, int64(a.FileSize), fileevent.ProcessedMetadataUpdated)
-		uc.manageAssetAlbums(ctx, a.File, a.ID, a.Albums)
+		uc.manageAssetAlbums(ctx, a)
 		return nil
 
 	case SameOnServer:
@@ -394,27 +644,21 @@ func (uc *UpCmd) handleAsset(ctx context.Context, a *assets.Asset) error {
 		// Record as processed - duplicate on server
 		uc.app.FileProcessor().RecordNonAsset(ctx, a.File, int64(a.FileSize), fileevent.DiscardedServerDuplicate)
 		uc.app.FileProcessor().RecordAssetProcessed(ctx, a.File, int64(a.FileSize), fileevent.ProcessedMetadataUpdated)
-		uc.manageAssetAlbums(ctx, a.File, a.ID, a.Albums)
+		uc.manageAssetDescription(ctx, a, advice.ServerAsset, false)
+		uc.manageAssetAlbums(ctx, a)
 
 	case BetterOnServer: // and manage albums
 		a.ID = advice.ServerAsset.ID
-		// Record as discarded - server has better version
-		uc.app.FileProcessor().RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.ProcessedMetadataUpdated, advice.Message)
-		uc.manageAssetAlbums(ctx, a.File, a.ID, a.Albums)
+		// Record as discarded - server has better version, a single outcome for the file
+		uc.app.FileProcessor().RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedServerBetter, advice.Message)
+		uc.manageAssetDescription(ctx, a, advice.ServerAsset, false)
+		uc.manageAssetAlbums(ctx, a)
 
 	case ForceUpload:
-		var serverStatus string
-		var err error
-
 		if advice.ServerAsset != nil {
-			// Remember existing asset's albums, if any
-			a.Albums = append(a.Albums, advice.ServerAsset.Albums...)
-
-			// Upload the superior asset
-			serverStatus, err = uc.replaceAsset(ctx, a, advice.ServerAsset)
-		} else {
-			serverStatus, err = uc.uploadAsset(ctx, a)
+			return uc.uploadReplacement(ctx, a, advice.ServerAsset, fileevent.ProcessedUploadSuccess)
 		}
+		serverStatus, err := uc.uploadAsset(ctx, a)
 		if err != nil {
 			return err
 		}
@@ -426,6 +670,24 @@ func (uc *UpCmd) handleAsset(ctx context.Context, a *assets.Asset) error {
 	return nil
 }
 
+// uploadReplacement uploads the asset in place of the server's one, and manages the albums of both.
+// The code is recorded when the server's asset is replaced, not when the server already has the new one.
+func (uc *UpCmd) uploadReplacement(ctx context.Context, a *assets.Asset, serverAsset *assets.Asset, code fileevent.Code) error {
+	// Remember existing asset's albums, if any
+	a.Albums = append(a.Albums, serverAsset.Albums...)
+
+	serverStatus, err := uc.replaceAsset(ctx, a, serverAsset)
+	if err != nil {
+		return err
+	}
+
+	uc.processUploadedAsset(ctx, a, serverStatus)
+	if serverStatus != immich.UploadDuplicate {
+		uc.app.FileProcessor().RecordAssetProcessed(ctx, a.File, int64(a.FileSize), code)
+	}
+	return nil
+}
+
 // uploadAsset uploads the asset to the server.
 // set the server's asset ID to the asset.
 // return the duplicate condition and error.
@@ -439,10 +701,24 @@ func (uc *UpCmd) uploadAsset(ctx context.Context, a *assets.Asset) (string, erro
 		a.AddTag(tag)
 	}
 
-	ar, err := uc.client.Immich.AssetUpload(ctx, a)
+	if err := uc.validateMedia(ctx, a); err != nil {
+		return "", err
+	}
+	if err := uc.reserveStorage(int64(a.FileSize)); err != nil {
+		return "", err
+	}
+	uc.renameAsset(ctx, a)
+	release := uc.checkNameCollision(ctx, a)
+	uc.applyVisibility(a)
+	// the coordinates of the sidecar are chosen before the upload, while the file is at hand
+	var latitude, longitude float64
+	var withGPS bool
+	if a.FromApplication != nil && !uc.uploadOnly() {
+		latitude, longitude, withGPS = uc.sidecarGPS(a)
+	}
+	ar, err := uc.sendAsset(ctx, a)
 	if err != nil {
-		// Record upload error
-		uc.app.FileProcessor().RecordAssetError(ctx, a.File, int64(a.FileSize), fileevent.ErrorServerError, err)
+		release()
 		return "", err // Must signal the error to the caller
 	}
 	if ar.Status == immich.UploadDuplicate {
@@ -462,20 +738,23 @@ func (uc *UpCmd) uploadAsset(ctx context.Context, a *assets.Asset) (string, erro
 	} else {
 		// Record successful upload
 		uc.app.FileProcessor().RecordAssetProcessed(ctx, a.File, int64(a.FileSize), fileevent.ProcessedUploadSuccess)
+		uc.recordSidecar(ctx, a)
+		uc.countVisibility(a)
 	}
 	a.ID = ar.ID
 
 	// // DEBGUG
 	//  if theID, ok := uc.assetIndex.byI
 
-	if a.FromApplication != nil && ar.Status != immich.StatusDuplicate {
+	if a.FromApplication != nil && ar.Status != immich.StatusDuplicate && !uc.uploadOnly() {
 		// metadata from application (immich or google photos) are forced.
 		// if a.Description != "" || (a.Latitude != 0 && a.Longitude != 0) || a.Rating != 0 || !a.CaptureDate.IsZero() {
 		a.UseMetadata(a.FromApplication)
+		description := strings.TrimSpace(a.Description)
 		_, err := uc.client.Immich.UpdateAsset(ctx, a.ID, immich.UpdAssetField{
-			Description:      a.Description,
-			Latitude:         a.Latitude,
-			Longitude:        a.Longitude,
+			Description:      description,
+			Latitude:         latitude,
+			Longitude:        longitude,
 			Rating:           a.Rating,
 			DateTimeOriginal: a.CaptureDate,
 		})
@@ -485,20 +764,52 @@ func (uc *UpCmd) uploadAsset(ctx context.Context, a *assets.Asset) (string, erro
 			return "", err
 		}
 		// Record successful metadata update
-		uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedMetadataUpdated, a.File)
+		uc.app.FileProcessor().Logger().RecordWithSize(ctx, fileevent.ProcessedMetadataUpdated, a.File, int64(a.FileSize))
+		if description != "" {
+			uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedDescriptionSet, a.File)
+		}
+		if withGPS {
+			uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedGPSFromSidecar, a.File)
+		}
 	}
 	uc.assetIndex.addLocalAsset(a)
 	return ar.Status, nil
 }
 
+// sendAsset sends the file of the asset to the server. Each request counts as an upload attempt:
+// the file is sent again when the API key file gives a new key.
+// The error is recorded.
+func (uc *UpCmd) sendAsset(ctx context.Context, a *assets.Asset) (immich.AssetResponse, error) {
+	uc.app.FileProcessor().RecordUploadAttempt(ctx, a.File, int64(a.FileSize))
+	upCtx, done := uc.uploads.track(uc.sidecarContext(ctx), a, uc.StallTimeout)
+	ar, err := uc.client.Immich.AssetUpload(upCtx, a)
+	if immich.KeyReloaded(err) {
+		// the API key file gives a new key, the file is sent again with it
+		uc.app.FileProcessor().RecordUploadAttempt(ctx, a.File, int64(a.FileSize))
+		ar, err = uc.client.Immich.AssetUpload(upCtx, a)
+	}
+	done()
+	if err != nil {
+		return ar, uc.recordUploadError(ctx, upCtx, a, err)
+	}
+	return ar, nil
+}
+
 // replaceAsset replaces an asset on the server. It uploads the new asset, copies the metadata from the old one and deletes the old one.
 // https://github.com/immich-app/immich/pull/23172#issue-3542430029
 func (uc *UpCmd) replaceAsset(ctx context.Context, newAsset, oldAsset *assets.Asset) (string, error) {
-	// 1. Upload the new asset
-	ar, err := uc.client.Immich.AssetUpload(ctx, newAsset)
+	// 1. Upload the new asset, the old one is deleted after
+	if err := uc.validateMedia(ctx, newAsset); err != nil {
+		return "", err
+	}
+	if err := uc.reserveStorage(int64(newAsset.FileSize - oldAsset.FileSize)); err != nil {
+		return "", err
+	}
+	uc.renameAsset(ctx, newAsset)
+	release := uc.checkNameCollision(ctx, newAsset)
+	ar, err := uc.sendAsset(ctx, newAsset)
 	if err != nil {
-		// Record upload error
-		uc.app.FileProcessor().RecordAssetError(ctx, newAsset.File, int64(newAsset.FileSize), fileevent.ErrorServerError, err)
+		release()
 		return "", err // Must signal the error to the caller
 	}
 	newAsset.ID = ar.ID
@@ -524,31 +835,52 @@ func (uc *UpCmd) replaceAsset(ctx context.Context, newAsset, oldAsset *assets.As
 		return "", err // Must signal the error to the caller
 	}
 	uc.assetIndex.replaceAsset(newAsset, oldAsset)
+	uc.recordSidecar(ctx, newAsset)
 	// Record successful upgrade
 	// uc.app.FileProcessor().RecordAssetProcessed(ctx, newAsset.File, int64(newAsset.FileSize), fileevent.ProcessedUploadUpgraded)
 	return "", nil
 }
 
-// manageAssetAlbums add the assets to the albums listed.
+// manageAssetAlbums add the asset to the albums listed.
 // If an album does not exist, it is created.
 // If the album already has the asset, it is not added.
 // Errors are logged.
-func (uc *UpCmd) manageAssetAlbums(ctx context.Context, f fshelper.FSAndName, ID string, albums []assets.Album) {
-	if len(albums) == 0 {
+func (uc *UpCmd) manageAssetAlbums(ctx context.Context, a *assets.Asset) {
+	if uc.uploadOnly() {
+		return
+	}
+	if uc.targetAlbum != nil {
+		if uc.albumsCache.AddIDToCollection(targetAlbumKey, *uc.targetAlbum, a.ID) {
+			uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedAlbumAdded, a.File, "album", uc.targetAlbum.Title)
+		}
+		return
+	}
+	if len(a.Albums) == 0 || uc.IgnoreAlbums {
 		return
 	}
 
-	for _, album := range albums {
+	for _, album := range a.Albums {
 		al := assets.NewAlbum("", album.Title, album.Description)
-		if uc.albumsCache.AddIDToCollection(al.Title, album, ID) {
+		key := uc.albumNames.key(al.Title)
+		if uc.sharedAlbums.isSkipped(key) {
+			continue
+		}
+		if uc.albumsCache.AddIDToCollection(key, album, a.ID) {
 			// Record album addition event
-			uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedAlbumAdded, f, "album", al.Title)
+			code := fileevent.ProcessedAlbumAdded
+			if uc.sharedAlbums.isShared(key) {
+				code = fileevent.ProcessedSharedAlbumAdded
+			}
+			uc.app.FileProcessor().Logger().Record(ctx, code, a.File, "album", al.Title)
+			if uc.trackCreatedAlbums() {
+				uc.albumMembers.addMember(key, al.Title, a.ID, a.CaptureDate)
+			}
 		}
 	}
 }
 
 func (uc *UpCmd) manageAssetTags(ctx context.Context, a *assets.Asset) {
-	if len(a.Tags) == 0 {
+	if len(a.Tags) == 0 || uc.uploadOnly() {
 		return
 	}
 
@@ -573,26 +905,19 @@ func (uc *UpCmd) processUploadedAsset(ctx context.Context, a *assets.Asset, serv
 	if serverStatus != immich.StatusDuplicate {
 		// TODO: current version of Immich doesn't allow to add same tag to an asset already tagged.
 		//       there is no mean to go the list of tagged assets for a given tag.
-		uc.manageAssetAlbums(ctx, a.File, a.ID, a.Albums)
+		uc.manageAssetAlbums(ctx, a)
 		uc.manageAssetTags(ctx, a)
+		uc.manageAssetTrash(ctx, a)
 	}
 }
 
-/*
-func (upCmd *UpCmd) DeleteLocalAssets() error {
-	upCmd.RootImmichFlags.Message(fmt.Sprintf("%d local assets to delete.", len(upCmd.deleteLocalList)))
-
-	for _, a := range upCmd.deleteLocalList {
-		if !upCmd.DryRun {
-			upCmd.Log.Info(fmt.Sprintf("delete file %q", a.Title))
-			err := a.Remove()
-			if err != nil {
-				return err
-			}
-		} else {
-			upCmd.Log.Info(fmt.Sprintf("file %q not deleted, dry run mode.", a.Title))
-		}
+// manageAssetTrash queues the asset for the trash when its sidecar says so.
+// The asset is trashed once albums and tags are updated.
+func (uc *UpCmd) manageAssetTrash(ctx context.Context, a *assets.Asset) {
+	if !uc.RestoreTrashed || !a.Trashed || uc.uploadOnly() {
+		return
+	}
+	if uc.trashedAssets.Add(a.ID) {
+		uc.app.FileProcessor().Logger().RecordWithSize(ctx, fileevent.ProcessedTrashed, a.File, int64(a.FileSize))
 	}
-	return nil
 }
-*/
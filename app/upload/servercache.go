@@ -0,0 +1,80 @@
+package upload
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/servercache"
+)
+
+// cachedServerAssets gives the server's assets saved by a previous run with --server-cache,
+// when the inventory is still valid. The assets updated since, like the ones uploaded by the previous
+// run, are read from the server and merged into the inventory. Otherwise, a new inventory is prepared
+// to be saved once the server is read. It returns false when the assets must be read from the server.
+func (uc *UpCmd) cachedServerAssets(ctx context.Context, stats immich.UserStatistics) ([]*immich.Asset, bool) {
+	if uc.ServerCache == "" {
+		return nil, false
+	}
+	c := servercache.New(uc.ServerCache, uc.ServerCacheTTL)
+	inv, reason := c.Load(uc.client.Server, uc.client.User.ID)
+	if inv != nil {
+		var lock sync.Mutex // the searches are run concurrently
+		var updated []*immich.Asset
+		err := uc.client.Immich.GetFilteredAssetsFn(ctx, immich.SearchOptions().All().WithUpdatedAfter(inv.LastUpdate()), func(a *immich.Asset) error {
+			lock.Lock()
+			updated = append(updated, a)
+			lock.Unlock()
+			return nil
+		})
+		switch {
+		case err != nil:
+			reason = "can't read the updated assets: " + err.Error()
+		case !inv.Merge(updated, stats):
+			reason = "assets have been deleted from the server"
+		default:
+			uc.app.Log().Info("server's assets read from the cache", "assets", len(inv.Assets), "updated", len(updated), "saved", inv.SavedAt.Format(time.DateTime), "cache", uc.ServerCache)
+			uc.inventory = inv
+			if len(updated) > 0 {
+				uc.saveServerInventory()
+			}
+			return inv.Assets, true
+		}
+	}
+	uc.app.Log().Info("server cache not used, the server's assets are read", "reason", reason, "cache", uc.ServerCache)
+	uc.inventory = &servercache.Inventory{
+		Server:     uc.client.Server,
+		UserID:     uc.client.User.ID,
+		Statistics: stats,
+	}
+	return nil, false
+}
+
+// cachedAlbumAssets gives the assets of the album saved in the server's inventory,
+// when the album still has the same number of assets.
+func (uc *UpCmd) cachedAlbumAssets(a immich.AlbumSimplified) ([]string, bool) {
+	if uc.inventory == nil {
+		return nil, false
+	}
+	al, ok := uc.inventory.Albums[a.ID]
+	if !ok || al.AssetCount != a.AssetCount {
+		return nil, false
+	}
+	return al.AssetIDs, true
+}
+
+// saveServerInventory writes the server's inventory for the next runs.
+// A failure is logged, the cache is an optimization.
+func (uc *UpCmd) saveServerInventory() {
+	if uc.inventory == nil {
+		return
+	}
+	uc.inventory.SavedAt = time.Now()
+	c := servercache.New(uc.ServerCache, uc.ServerCacheTTL)
+	if err := c.Save(uc.inventory); err != nil {
+		uc.app.Log().Warn("can't save the server's inventory", "err", err, "cache", uc.ServerCache)
+		return
+	}
+	uc.app.Log().Info("server's inventory saved", "assets", len(uc.inventory.Assets), "albums", len(uc.inventory.Albums), "cache", uc.ServerCache)
+}
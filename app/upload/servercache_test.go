@@ -0,0 +1,108 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simulot/immich-go/immich"
+)
+
+func TestServerCache(t *testing.T) {
+	date := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var lock sync.Mutex
+	var serverAssets []immich.Asset
+	var fullReads, deltaReads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/assets/statistics":
+			_ = json.NewEncoder(w).Encode(immich.UserStatistics{Images: len(serverAssets), Total: len(serverAssets)})
+		case "/api/search/metadata":
+			var q immich.SearchMetadataQuery
+			_ = json.NewDecoder(r.Body).Decode(&q)
+			var resp searchResponse
+			// the assets are given once, by the query of the timeline without the trashed ones
+			if q.Visibility == "timeline" && q.TrashedAfter == "" {
+				if q.UpdatedAfter == "" {
+					fullReads++
+				} else {
+					deltaReads++
+				}
+				after, _ := time.Parse(immich.TimeFormat, q.UpdatedAfter)
+				for _, a := range serverAssets {
+					if !a.UpdatedAt.Before(after) {
+						resp.Assets.Items = append(resp.Assets.Items, a)
+					}
+				}
+			}
+			resp.Assets.Total = len(resp.Assets.Items)
+			resp.Assets.Count = len(resp.Assets.Items)
+			_ = json.NewEncoder(w).Encode(resp)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	asset := func(id string, updated time.Time) immich.Asset {
+		return immich.Asset{ID: id, Type: "IMAGE", OwnerID: "user1", OriginalFileName: id + ".jpg", Checksum: "checksum " + id, UpdatedAt: immich.ImmichTime{Time: updated}}
+	}
+
+	dir := t.TempDir()
+	run := func() int {
+		t.Helper()
+		ic, err := immich.NewImmichClient(server.URL, "1234")
+		if err != nil {
+			t.Fatal(err)
+		}
+		uc := newTestUpCmd(t)
+		uc.client.Immich = ic
+		uc.client.Server = server.URL
+		uc.client.User.ID = "user1"
+		uc.ServerCache = dir
+		uc.ServerCacheTTL = time.Hour
+		uc.assetIndex = newAssetIndex()
+		uc.immichAssetsReady = make(chan struct{})
+		if err := uc.getImmichAssets(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+		return uc.assetIndex.len()
+	}
+	reads := func(full, delta int) {
+		t.Helper()
+		if fullReads != full || deltaReads != delta {
+			t.Errorf("full reads %d, delta reads %d, want %d and %d", fullReads, deltaReads, full, delta)
+		}
+		fullReads, deltaReads = 0, 0
+	}
+
+	serverAssets = []immich.Asset{asset("s1", date), asset("s2", date)}
+	if n := run(); n != 2 {
+		t.Errorf("first run: %d assets, want 2", n)
+	}
+	reads(1, 0)
+
+	// the next run reads only the assets uploaded since
+	serverAssets = append(serverAssets, asset("s3", date.Add(time.Hour)))
+	if n := run(); n != 3 {
+		t.Errorf("after an upload: %d assets, want 3", n)
+	}
+	reads(0, 1)
+	if n := run(); n != 3 {
+		t.Errorf("unchanged: %d assets, want 3", n)
+	}
+	reads(0, 1)
+
+	// an asset deleted for good, and another one added: the server is read again
+	serverAssets = []immich.Asset{asset("s2", date), asset("s3", date.Add(time.Hour)), asset("s4", date.Add(2*time.Hour))}
+	if n := run(); n != 3 {
+		t.Errorf("after a deletion: %d assets, want 3", n)
+	}
+	reads(1, 1)
+}
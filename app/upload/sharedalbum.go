@@ -0,0 +1,62 @@
+package upload
+
+import (
+	"sync"
+
+	"github.com/simulot/immich-go/immich"
+)
+
+// --shared-album-mode values
+const (
+	SharedAlbumAdd         = "add"          // add the assets to the album shared by another user
+	SharedAlbumSkip        = "skip"         // don't add the assets to the album shared by another user
+	SharedAlbumCreateOwned = "create-owned" // create an owned album with the same name
+)
+
+// sharedAlbums keeps the albums shared by another user whose name matches a target album.
+// The owned albums with the same name win over them.
+type sharedAlbums struct {
+	mode    string
+	lock    sync.RWMutex
+	shared  map[string]struct{} // album keys of the shared albums the assets are added to
+	skipped map[string]struct{} // album keys of the shared albums skipped with --shared-album-mode=skip
+}
+
+// isShared tells if the server's album belongs to another user
+func isShared(a immich.AlbumSimplified, userID string) bool {
+	return a.OwnerID != "" && userID != "" && a.OwnerID != userID
+}
+
+// add records a shared album, and tells if it's used as target album
+func (sa *sharedAlbums) add(key string) bool {
+	sa.lock.Lock()
+	defer sa.lock.Unlock()
+	if sa.shared == nil {
+		sa.shared = map[string]struct{}{}
+		sa.skipped = map[string]struct{}{}
+	}
+	switch sa.mode {
+	case SharedAlbumSkip:
+		sa.skipped[key] = struct{}{}
+		return false
+	case SharedAlbumCreateOwned:
+		return false
+	default:
+		sa.shared[key] = struct{}{}
+		return true
+	}
+}
+
+func (sa *sharedAlbums) isShared(key string) bool {
+	sa.lock.RLock()
+	defer sa.lock.RUnlock()
+	_, ok := sa.shared[key]
+	return ok
+}
+
+func (sa *sharedAlbums) isSkipped(key string) bool {
+	sa.lock.RLock()
+	defer sa.lock.RUnlock()
+	_, ok := sa.skipped[key]
+	return ok
+}
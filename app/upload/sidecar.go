@@ -0,0 +1,25 @@
+package upload
+
+import (
+	"context"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+// sidecarContext gives the context of the upload call, without the XMP sidecar when --upload-sidecars=false
+func (uc *UpCmd) sidecarContext(ctx context.Context) context.Context {
+	if uc.UploadSidecars {
+		return ctx
+	}
+	return immich.WithoutSidecar(ctx)
+}
+
+// recordSidecar counts the XMP sidecar sent with the uploaded asset
+func (uc *UpCmd) recordSidecar(ctx context.Context, a *assets.Asset) {
+	if !uc.UploadSidecars || !immich.HasXMPSidecar(a) {
+		return
+	}
+	uc.app.FileProcessor().Logger().Record(ctx, fileevent.ProcessedSidecarAttached, a.File, "sidecar", a.FromSideCar.File)
+}
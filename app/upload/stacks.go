@@ -0,0 +1,35 @@
+package upload
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/simulot/immich-go/internal/filters"
+)
+
+// stackCounts counts the stacks created during the upload, the groups are uploaded concurrently
+type stackCounts struct {
+	created, failed atomic.Int64
+}
+
+// applyStackOnUpload turns --stack-on-upload into --manage-raw-jpeg=StackCoverRaw.
+// A stacking choice of --manage-raw-jpeg is kept, the options keeping one file of the pair are refused.
+func (uc *UpCmd) applyStackOnUpload() error {
+	if !uc.StackOnUpload {
+		return nil
+	}
+	switch uc.ManageRawJPG {
+	case filters.RawJPGNothing:
+		uc.ManageRawJPG = filters.RawJPGStackRaw
+	case filters.RawJPGKeepRaw, filters.RawJPGKeepJPG:
+		return fmt.Errorf("--stack-on-upload can't be used with --manage-raw-jpeg=%s", uc.ManageRawJPG)
+	}
+	return nil
+}
+
+// stacksSummary gives the stacks created during the upload, when --stack-on-upload is set or a stack was attempted
+func (uc *UpCmd) stacksSummary() (fileprocessor.StacksSummary, bool) {
+	s := fileprocessor.StacksSummary{Created: uc.stacks.created.Load(), Failed: uc.stacks.failed.Load()}
+	return s, uc.StackOnUpload || s.Created+s.Failed > 0
+}
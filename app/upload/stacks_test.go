@@ -0,0 +1,70 @@
+package upload
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/simulot/immich-go/internal/fileprocessor"
+	"github.com/simulot/immich-go/internal/filters"
+	"github.com/spf13/cobra"
+)
+
+func TestApplyStackOnUpload(t *testing.T) {
+	tests := []struct {
+		stackOnUpload bool
+		rawJPG        filters.RawJPGFlag
+		want          filters.RawJPGFlag
+		wantErr       bool
+	}{
+		{stackOnUpload: false, rawJPG: filters.RawJPGNothing, want: filters.RawJPGNothing},
+		{stackOnUpload: false, rawJPG: filters.RawJPGKeepRaw, want: filters.RawJPGKeepRaw},
+		{stackOnUpload: true, rawJPG: filters.RawJPGNothing, want: filters.RawJPGStackRaw},
+		{stackOnUpload: true, rawJPG: filters.RawJPGStackRaw, want: filters.RawJPGStackRaw},
+		{stackOnUpload: true, rawJPG: filters.RawJPGStackJPG, want: filters.RawJPGStackJPG},
+		{stackOnUpload: true, rawJPG: filters.RawJPGKeepRaw, wantErr: true},
+		{stackOnUpload: true, rawJPG: filters.RawJPGKeepJPG, wantErr: true},
+	}
+	for _, tt := range tests {
+		uc := &UpCmd{StackOnUpload: tt.stackOnUpload}
+		uc.ManageRawJPG = tt.rawJPG
+		err := uc.applyStackOnUpload()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("--stack-on-upload=%v --manage-raw-jpeg=%s: error %v, want an error: %v", tt.stackOnUpload, tt.rawJPG, err, tt.wantErr)
+			continue
+		}
+		if err == nil && uc.ManageRawJPG != tt.want {
+			t.Errorf("--stack-on-upload=%v --manage-raw-jpeg=%s: got %s, want %s", tt.stackOnUpload, tt.rawJPG, uc.ManageRawJPG, tt.want)
+		}
+	}
+}
+
+func TestStacksSummary(t *testing.T) {
+	uc := newTestUpCmd(t)
+	if _, ok := uc.stacksSummary(); ok {
+		t.Error("summary of the stacks without stacking")
+	}
+	uc.StackOnUpload = true
+	if s, ok := uc.stacksSummary(); !ok || s != (fileprocessor.StacksSummary{}) {
+		t.Errorf("--stack-on-upload without pair: %+v, %v, want zero counts", s, ok)
+	}
+	uc.stacks.created.Add(2)
+	uc.stacks.failed.Add(1)
+	s, _ := uc.stacksSummary()
+
+	uc.app.SetStacks(s)
+	uc.app.SummaryFile = filepath.Join(t.TempDir(), "summary.json")
+	uc.app.WriteSummaryFile(&cobra.Command{Use: "upload"}, nil)
+	b, err := os.ReadFile(uc.app.SummaryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary fileprocessor.RunSummary
+	if err := json.Unmarshal(b, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Stacks == nil || *summary.Stacks != (fileprocessor.StacksSummary{Created: 2, Failed: 1}) {
+		t.Errorf("unexpected stacks in %s", b)
+	}
+}
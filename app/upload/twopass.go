@@ -0,0 +1,85 @@
+package upload
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/twopass"
+)
+
+// --two-pass values
+const (
+	TwoPassUpload = "upload" // first pass: the files only, as fast as possible
+	TwoPassEnrich = "enrich" // second pass: the albums, tags and metadata of the files sent by the first pass
+)
+
+// uploadOnly tells if the run is the first pass of a two-pass upload. The files are sent without the albums,
+// tags, descriptions, GPS coordinates, stacks and live photo links, left to the enrich pass.
+func (uc *UpCmd) uploadOnly() bool {
+	return uc.TwoPass == TwoPassUpload
+}
+
+// openTwoPass loads the server IDs recorded by the first pass, to resume it or to enrich its assets
+func (uc *UpCmd) openTwoPass() error {
+	if uc.TwoPass == "" {
+		return nil
+	}
+	path := uc.TwoPassFile
+	if path == "" {
+		path = twopass.DefaultPath(uc.client.Server, uc.client.User.ID)
+	}
+	if uc.TwoPass == TwoPassEnrich {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("can't read the two-pass file of the upload pass, run it with --two-pass=upload first: %w", err)
+		}
+	}
+	m, err := twopass.Load(path, uc.client.Server, uc.client.User.ID)
+	if err != nil {
+		return err
+	}
+	uc.twoPass = m
+	uc.twoPassPath = path
+	if uc.TwoPass == TwoPassEnrich {
+		uc.app.Log().Info("two-pass: enriching the assets sent by the upload pass", "assets", m.Len(), "file", path)
+	}
+	return nil
+}
+
+// recordTwoPass keeps the server ID of the asset sent by the first pass
+func (uc *UpCmd) recordTwoPass(a *assets.Asset) {
+	if !uc.uploadOnly() || a.ID == "" {
+		return
+	}
+	uc.twoPass.Set(a.File.FullName(), a.ID)
+}
+
+// twoPassServerAsset gives the server asset recorded by the first pass for the file,
+// nil when the file is unknown. The enrich pass falls back to the usual matching.
+func (uc *UpCmd) twoPassServerAsset(a *assets.Asset) *assets.Asset {
+	if uc.TwoPass != TwoPassEnrich {
+		return nil
+	}
+	id, ok := uc.twoPass.Get(a.File.FullName())
+	if !ok {
+		return nil
+	}
+	return uc.assetIndex.getByID(id)
+}
+
+// saveTwoPass writes the server IDs recorded by the first pass for the enrich pass.
+// The file is kept between the runs, an interrupted upload pass is resumed with the same file.
+func (uc *UpCmd) saveTwoPass() {
+	if !uc.uploadOnly() || uc.twoPass == nil {
+		return
+	}
+	if uc.client.DryRun {
+		uc.app.Log().Message("two-pass: dry run, the server IDs aren't saved")
+		return
+	}
+	if err := uc.twoPass.Save(); err != nil {
+		uc.app.Log().Error("can't save the two-pass file", "file", uc.twoPassPath, "error", err)
+		return
+	}
+	uc.app.Log().Message("two-pass: %d files recorded in %s, run the same command with --two-pass=enrich to add their albums and metadata", uc.twoPass.Len(), uc.twoPassPath)
+}
@@ -0,0 +1,135 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+// TestTwoPass uploads a takeout file without its metadata, then enriches the same server asset
+// with the capture date and the description of the JSON
+func TestTwoPass(t *testing.T) {
+	var lock sync.Mutex
+	var uploads int
+	updates := map[string]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/users/me":
+			_, _ = w.Write([]byte(`{"id":"user1"}`))
+		case r.URL.Path == "/api/server/media-types":
+			_, _ = w.Write([]byte(`{"image":[".jpg"]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/assets":
+			_, _ = io.Copy(io.Discard, r.Body)
+			uploads++
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(immich.AssetResponse{ID: "s1", Status: immich.UploadCreated})
+		case r.Method == http.MethodPut && r.URL.Path == "/api/assets/s1":
+			body := map[string]any{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			for k, v := range body {
+				if updates["s1"] == nil {
+					updates["s1"] = map[string]any{}
+				}
+				updates["s1"][k] = v
+			}
+			_, _ = w.Write([]byte(`{"id":"s1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	ic, err := immich.NewImmichClient(server.URL, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ic.ValidateConnection(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Date(2019, 8, 4, 18, 30, 0, 0, time.UTC)
+	fsys := fstest.MapFS{"IMG_0001.jpg": &fstest.MapFile{Data: []byte("abc")}}
+	takeoutAsset := func() *assets.Asset {
+		return &assets.Asset{
+			File:             fshelper.FSName(fsys, "IMG_0001.jpg"),
+			OriginalFileName: "IMG_0001.jpg",
+			FileSize:         3,
+			FromApplication:  &assets.Metadata{DateTaken: date, Description: "At the beach"},
+		}
+	}
+	twoPassFile := filepath.Join(t.TempDir(), "two-pass.json")
+	newPass := func(pass string) *UpCmd {
+		uc := newTestUpCmd(t)
+		uc.client.Immich = ic
+		uc.client.Server = server.URL
+		uc.client.User.ID = "user1"
+		uc.TwoPass = pass
+		uc.TwoPassFile = twoPassFile
+		uc.MetadataOnly = pass == TwoPassEnrich
+		uc.assetIndex = newAssetIndex()
+		return uc
+	}
+	ctx := context.Background()
+
+	// the enrich pass needs the file of the upload pass
+	if err := newPass(TwoPassEnrich).openTwoPass(); err == nil {
+		t.Fatal("enrich pass without the two-pass file: want an error")
+	}
+
+	// upload pass: the file only
+	uc := newPass(TwoPassUpload)
+	if err := uc.openTwoPass(); err != nil {
+		t.Fatal(err)
+	}
+	a := takeoutAsset()
+	uc.app.FileProcessor().RecordAssetDiscovered(ctx, a.File, 3, fileevent.DiscoveredImage)
+	if err := uc.handleAsset(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	uc.recordTwoPass(a)
+	uc.saveTwoPass()
+	if uploads != 1 || len(updates) != 0 {
+		t.Fatalf("upload pass: %d uploads, updates %v, want 1 upload and no update", uploads, updates)
+	}
+
+	// enrich pass: the asset sent by the upload pass gets the metadata of the JSON
+	uc = newPass(TwoPassEnrich)
+	if err := uc.openTwoPass(); err != nil {
+		t.Fatal(err)
+	}
+	uc.assetIndex.addImmichAsset(&immich.Asset{ID: "s1", OriginalFileName: "IMG_0001.jpg", Checksum: "other", ExifInfo: immich.ExifInfo{FileSizeInByte: 3}})
+	a = takeoutAsset()
+	uc.app.FileProcessor().RecordAssetDiscovered(ctx, a.File, 3, fileevent.DiscoveredImage)
+	if err := uc.handleAsset(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if uploads != 1 {
+		t.Errorf("enrich pass: %d uploads, want none more", uploads)
+	}
+	u := updates["s1"]
+	if d, _ := u["dateTimeOriginal"].(string); d == "" {
+		t.Errorf("enrich pass: no capture date sent: %v", u)
+	} else if got, err := time.Parse(time.RFC3339, d); err != nil || !got.Equal(date) {
+		t.Errorf("enrich pass: capture date %q, want %s", d, date)
+	}
+	if u["description"] != "At the beach" {
+		t.Errorf("enrich pass: description %v", u["description"])
+	}
+	if n := uc.app.FileProcessor().Logger().GetCounts()[fileevent.ProcessedMetadataUpdated]; n != 1 {
+		t.Errorf("enrich pass: %d metadata updated, want 1", n)
+	}
+}
@@ -12,8 +12,8 @@ import (
 	"github.com/navidys/tvxwidgets"
 	"github.com/rivo/tview"
 	"github.com/simulot/immich-go/app"
-	"github.com/simulot/immich-go/internal/assets"
 	"github.com/simulot/immich-go/internal/assettracker"
+	cliflags "github.com/simulot/immich-go/internal/cliFlags"
 	"github.com/simulot/immich-go/internal/fileevent"
 	"github.com/simulot/immich-go/internal/fileprocessor"
 	"golang.org/x/sync/errgroup"
@@ -27,6 +27,7 @@ type uiPage struct {
 	statusZone     *tview.Grid // NEW: Asset processing status zone
 	serverJobs     *tvxwidgets.Sparkline
 	logView        *tview.TextView
+	activity       *tview.TextView // phase, throughput and recent albums
 	counts         map[fileevent.Code]*tview.TextView
 	sizes          map[fileevent.Code]*tview.TextView // Size views for discovery events
 
@@ -155,6 +156,7 @@ func (uc *UpCmd) runUI(ctx context.Context, app *app.Application) error {
 					}
 					// Update the processing status zone
 					ui.updateStatusZone()
+					ui.updateActivity(uc)
 					if uc.Mode == UpModeGoogleTakeout {
 						ui.immichPrepare.SetMaxValue(int(app.FileProcessor().Logger().TotalAssets()))
 						// Calculate processed items for Google Takeout progress
@@ -164,7 +166,11 @@ func (uc *UpCmd) runUI(ctx context.Context, app *app.Application) error {
 						ui.immichPrepare.SetValue(int(processedGP))
 
 						if preparationDone.Load() {
-							ui.immichUpload.SetMaxValue(int(app.FileProcessor().Logger().TotalAssets()))
+							total := int(app.FileProcessor().Logger().TotalAssets())
+							if uc.Limit > 0 {
+								total = min(total, uc.Limit)
+							}
+							ui.immichUpload.SetMaxValue(total)
 						}
 						// ui.immichUpload.SetValue(int(app.Jnl().TotalProcessed(uc.takeoutOptions.KeepJSONLess)))
 					}
@@ -187,33 +193,8 @@ func (uc *UpCmd) runUI(ctx context.Context, app *app.Application) error {
 
 	// start the processes
 	uiGroup.Go(func() error {
-		var groupChan chan *assets.Group
-		var err error
-
-		processGrp := errgroup.Group{}
-		processGrp.Go(func() error {
-			// Get immich asset
-			err = uc.getImmichAssets(ctx, ui.updateImmichReading)
-			if err != nil {
-				stopUI(err)
-			}
-			return err
-		})
-		processGrp.Go(func() error {
-			err = uc.getImmichAlbums(ctx)
-			if err != nil {
-				stopUI(err)
-			}
-			return err
-		})
-		processGrp.Go(func() error {
-			// Run Prepare
-			groupChan = uc.adapter.Browse(ctx)
-			return nil
-		})
-
 		// Wait the end of the preparation: immich assets, albums and first browsing
-		err = processGrp.Wait()
+		groupChan, err := uc.prepare(ctx, ui.updateImmichReading, stopUI)
 		if err != nil {
 			return context.Cause(ctx)
 		}
@@ -228,8 +209,7 @@ func (uc *UpCmd) runUI(ctx context.Context, app *app.Application) error {
 		err = errors.Join(err, uc.finishing(ctx))
 
 		uploadDone.Store(true)
-		counts := app.FileProcessor().Logger().GetCounts()
-		if counts[fileevent.ErrorUploadFailed]+counts[fileevent.ErrorServerError]+counts[fileevent.ErrorFileAccess]+counts[fileevent.ErrorIncomplete] > 0 {
+		if app.FileProcessor().Logger().TotalErrors() > 0 {
 			messages.WriteString("Some errors have occurred. Look at the log file for details\n")
 		}
 
@@ -247,8 +227,8 @@ func (uc *UpCmd) runUI(ctx context.Context, app *app.Application) error {
 		err = context.Cause(ctx)
 	}
 
-	// Time to leave
-	if messages.Len() > 0 {
+	// Time to leave, errors are reported without failing the run in continue mode
+	if messages.Len() > 0 && app.OnErrors != cliflags.OnErrorsNeverStop {
 		return (errors.New(messages.String()))
 	}
 	return err
@@ -287,6 +267,9 @@ func (uc *UpCmd) newUI(ctx context.Context, a *app.Application) *uiPage {
 	ui.screen.AddItem(tview.NewTextView().SetText(app.Banner()), 0, 0, 1, 1, 0, 0, false)
 
 	ui.discoveryZone = ui.createDiscoveryZone()
+	if uc.Limit > 0 {
+		ui.discoveryZone.SetTitle(fmt.Sprintf("Discovery (limited to %d)", uc.Limit))
+	}
 
 	// Create the processing zone (shows processing events)
 	ui.processingZone = ui.createProcessingZone()
@@ -324,12 +307,16 @@ func (uc *UpCmd) newUI(ctx context.Context, a *app.Application) *uiPage {
 
 	ui.screen.AddItem(counts, 1, 0, 1, 1, 0, 0, false)
 
+	ui.activity = tview.NewTextView()
+	ui.activity.SetBorder(true).SetTitle("Activity")
+	ui.screen.AddItem(ui.activity, 2, 0, 1, 1, 0, 0, false)
+
 	// Hijack the log
 	ui.logView = tview.NewTextView().SetMaxLines(100).ScrollToEnd()
 	ui.highJackLogger(a)
 
 	ui.logView.SetBorder(true).SetTitle("Log")
-	ui.screen.AddItem(ui.logView, 2, 0, 1, 1, 0, 0, false)
+	ui.screen.AddItem(ui.logView, 3, 0, 1, 1, 0, 0, false)
 
 	ui.immichReading = tvxwidgets.NewPercentageModeGauge()
 	ui.immichReading.SetRect(0, 0, 50, 1)
@@ -356,10 +343,10 @@ func (uc *UpCmd) newUI(ctx context.Context, a *app.Application) *uiPage {
 	} else {
 		ui.footer.SetColumns(25, 0)
 	}
-	ui.screen.AddItem(ui.footer, 3, 0, 1, 1, 0, 0, false)
+	ui.screen.AddItem(ui.footer, 4, 0, 1, 1, 0, 0, false)
 
 	// Adjust section's height
-	ui.screen.SetRows(4, 10, 0, 1)
+	ui.screen.SetRows(4, 10, 10, 0, 1)
 	return ui
 }
 
@@ -566,6 +553,28 @@ func (ui *uiPage) updateStatusZone() {
 	}
 }
 
+// updateActivity shows the current phase, the upload throughput, the biggest file being uploaded and the last album updates
+func (ui *uiPage) updateActivity(uc *UpCmd) {
+	if ui.fileProcessor == nil {
+		return
+	}
+	var sb strings.Builder
+	if phase := uc.phase.Get(); phase == phaseFetchingServerAssets {
+		fmt.Fprintf(&sb, "Phase:      %s, %s\n", phase, uc.phase.Inventory())
+	} else {
+		fmt.Fprintf(&sb, "Phase:      %s\n", phase)
+	}
+	fmt.Fprintf(&sb, "Throughput: %s\n", uc.throughput(ui.fileProcessor))
+	if p := uc.uploads.String(); p != "" {
+		fmt.Fprintf(&sb, "Current:    %s\n", p)
+	}
+	sb.WriteString("Recent albums:")
+	for _, s := range uc.albumActivity.Recent() {
+		sb.WriteString("\n  " + s)
+	}
+	ui.activity.SetText(sb.String())
+}
+
 // formatBytes formats byte count as human-readable string
 func (ui *uiPage) formatBytes(bytes int64) string {
 	const unit = 1024
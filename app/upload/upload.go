@@ -2,7 +2,10 @@ package upload
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/simulot/immich-go/adapters"
@@ -19,10 +22,13 @@ import (
 	"github.com/simulot/immich-go/internal/filenames"
 	"github.com/simulot/immich-go/internal/fileprocessor"
 	"github.com/simulot/immich-go/internal/filters"
+	"github.com/simulot/immich-go/internal/fshelper/hash"
 	"github.com/simulot/immich-go/internal/gen/syncset"
 	"github.com/simulot/immich-go/internal/groups/burst"
 	"github.com/simulot/immich-go/internal/groups/epsonfastfoto"
 	"github.com/simulot/immich-go/internal/groups/series"
+	"github.com/simulot/immich-go/internal/servercache"
+	"github.com/simulot/immich-go/internal/twopass"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -55,12 +61,56 @@ type UpCmd struct {
 	// Cli flags
 
 	shared.StackOptions
-	client     app.Client
-	NoUI       bool // Disable UI
-	Overwrite  bool // Always overwrite files on the server with local versions
-	Tags       []string
-	SessionTag bool
-	session    string // Session tag value
+	client          app.Client
+	NoUI            bool   // Disable UI
+	UI              string // User interface mode: tui or line
+	ProgressFormat  string // Progress written by the line mode: text or gauge
+	Overwrite       bool   // Always overwrite files on the server with local versions
+	ReplaceExisting bool   // Replace the server's asset with the same name and date when the file has changed
+	Tags            []string
+	SessionTag      bool
+	session         string // Session tag value
+
+	RestoreTrashed bool           // Move to the trash the assets flagged as trashed in their sidecar
+	ChecksumAlgo   hash.Algorithm // Algorithm used to detect local duplicates
+	MaxErrors      int            // Abort the upload when the number of errors exceeds this value, 0 for unlimited
+	Limit          int            // Stop the browsing after this number of assets, 0 for no limit
+	Order          string         // Order of the assets sent to the upload: date-asc, date-desc, path or none
+
+	ImportDescriptions bool          // Set the description of the assets already on the server from the sidecar
+	DescriptionMode    string        // What to do when the server's asset already has a description
+	ImportGPS          bool          // Set the GPS coordinates from the sidecar
+	PreferSidecarGPS   bool          // The sidecar's GPS coordinates win over the embedded ones
+	UploadSidecars     bool          // Send the XMP sidecar with the asset, for the server to keep it
+	RunDedup           bool          // Start the server's duplicate detection and report the duplicate sets
+	StackOnUpload      bool          // Stack the RAW+JPEG pairs once their members are uploaded, with the RAW as cover
+	MetadataOnly       bool          // Update the metadata of the server's assets without uploading the files
+	TwoPass            string        // Pass of a two-pass upload: upload or enrich, a single pass when empty
+	TwoPassFile        string        // File keeping the server IDs of the files sent by the upload pass
+	StrictQuota        bool          // Abort the upload when it doesn't fit in the available space
+	AlbumBatchSize     int           // Number of assets added to an album in one request
+	FilenameTemplate   string        // Template giving the name of the uploaded assets
+	OnNameCollision    string        // What to do with the different files of the same name in an album: keep or suffix
+	Visibility         string        // Default visibility of the uploaded assets: timeline, archive or hidden, per asset when empty
+	AlbumNameMatch     string        // How the album names are compared: exact, trim or ci
+	SetAlbumCover      bool          // Set the cover of the created albums once their members are uploaded
+	AlbumDescription   string        // Template giving the description of the created albums
+	AlbumActivity      string        // Comments and likes of the created albums: on, off, or the server's default when empty
+	SummaryAllAlbums   bool          // List the existing albums that received assets in the summary too
+	ValidateMedia      bool          // Check the structure of the files before uploading them
+	SharedAlbumMode    string        // What to do with a target album shared by another user: add, skip or create-owned
+	MetricsFile        string        // CSV file receiving a row of upload metrics per progress tick
+	IgnoreAlbums       bool          // Don't create albums, nor add the assets to albums
+	Plan               bool          // Write the decision for each asset as JSON lines, without uploading
+	DryRunOutput       string        // File receiving the decision for each asset of a dry run, sorted by path
+	CheckPermissions   bool          // Check the API key's permissions before starting
+	ListDuplicates     string        // Report the duplicates of the input and the server, without uploading: text or json
+	ServerCache        string        // Folder of the server's inventories reused by the next runs, empty to disable
+	ServerCacheTTL     time.Duration // Time to live of the server's inventory
+	FailOnUnsupported  bool          // Count the files rejected by the server because of their format as errors
+	AlbumID            string        // Add the assets to the server's album with this ID, instead of the input's albums
+	SkipIfInAlbum      string        // Skip the assets whose checksum matches a member of this server's album
+	StallTimeout       time.Duration // Cancel the upload of a file not sending any byte during this duration
 
 	// Upload command state
 	// Filters           []filters.Filter
@@ -69,6 +119,7 @@ type UpCmd struct {
 	app               *app.Application
 	assetIndex        *immichIndex                         // List of assets present on the server
 	localAssets       *syncset.Set[string]                 // List of assets present on the local input by name+size
+	trashedAssets     *syncset.Set[string]                 // List of uploaded assets to move to the trash
 	immichAssetsReady chan struct{}                        // Signal that the asset index is ready
 	deleteServerList  []*immich.Asset                      // List of server assets to remove
 	adapter           adapters.Reader                      // the source of assets
@@ -77,16 +128,83 @@ type UpCmd struct {
 	tagsCache         *cache.CollectionCache[assets.Tag]   // List of tags present on the server
 	finished          bool                                 // the finish task has been run
 	infoCollector     *filenames.InfoCollector             // Collects information about the files being processed
+	phase             phaseTracker                         // Current stage of the upload process
+	albumActivity     albumActivity                        // Last album updates, for the progress display
+	albumRequests     albumRequests                        // Number of album requests, with and without batching
+	filenameTemplate  *filenameTemplate                    // Parsed --filename-template, nil when not set
+	albumDescription  *template.Template                   // Parsed --album-description-template, nil when not set
+	duplicateSets     int                                  // Number of duplicate sets on the server, -1 when not queried
+	storage           storageCheck                         // Space available on the server and required by the upload
+	albumNames        albumNames                           // Album names normalized by --album-name-match
+	uploads           uploadProgress                       // Bytes sent for the files being uploaded
+	albumMembers      albumMembers                         // Members of the created albums, for their cover and their settings
+	albumCovers       albumCovers                          // Covers set on the created albums, for the summary
+	stacks            stackCounts                          // Stacks created during the upload, for the summary
+	invalidMedia      atomic.Int64                         // Number of files rejected by --validate-media
+	sharedAlbums      sharedAlbums                         // Target albums shared by another user
+	targetAlbum       *assets.Album                        // Album given by --album-id, nil when not set
+	excludeAlbum      excludeAlbum                         // Members of the album given by --skip-if-in-album
+	createdAlbums     albumSummary                         // Albums updated by the upload, for the summary
+	nameCollisions    nameCollisions                       // Names of the files sent to each album, by --on-name-collision
+	gauge             progressGauge                        // Overall progress written by --progress-format=gauge
+	visibility        visibilityCounts                     // Uploaded assets by visibility, for the summary
+	twoPass           *twopass.Map                         // Server IDs of the files sent by the upload pass of --two-pass
+	twoPassPath       string                               // File of twoPass
+	inventory         *servercache.Inventory               // Server's inventory to save with --server-cache, nil when not used
 }
 
 func (uc *UpCmd) RegisterFlags(flags *pflag.FlagSet) {
 	uc.client.RegisterFlags(flags, "")
-	flags.BoolVar(&uc.NoUI, "no-ui", false, "Disable the user interface")
+	flags.BoolVar(&uc.NoUI, "no-ui", false, "Disable the user interface (same as --ui line)")
+	flags.StringVar(&uc.UI, "ui", uiModeTUI, "User interface mode (tui|line). The tui mode falls back to line when the output isn't an interactive terminal")
+	flags.StringVar(&uc.ProgressFormat, "progress-format", ProgressText, "Progress written on the standard output by the line mode (text|gauge). gauge writes the overall percentage, an integer per line, for dialog --gauge or whiptail --gauge. The messages go to the error output")
 	flags.BoolVar(&uc.Overwrite, "overwrite", false, "Always overwrite files on the server with local versions")
+	flags.BoolVar(&uc.ReplaceExisting, "replace-existing", false, "Replace the server's asset with the same name and date when the file's checksum differs. The unchanged files are still skipped")
 	flags.StringSliceVar(&uc.Tags, "tag", nil, "Add tags to the imported assets. Can be specified multiple times. Hierarchy is supported using a / separator (e.g. 'tag1/subtag1')")
 	flags.BoolVar(&uc.SessionTag, "session-tag", false, "Tag uploaded photos with a tag \"{immich-go}/YYYY-MM-DD HH-MM-SS\"")
+	flags.Var(&uc.ChecksumAlgo, "checksum-algo", "Algorithm used to detect duplicates in the input (sha1|blake3|xxhash). The server comparison always uses sha1")
+	flags.IntVar(&uc.MaxErrors, "max-errors", 0, "Abort the upload when the number of errors exceeds this value (0 for unlimited)")
+	flags.StringVar(&uc.Order, "order", adapters.OrderNone, "Order of the upload (date-asc|date-desc|path|none). Sorting keeps the whole input in memory before uploading")
+	flags.IntVar(&uc.Limit, "limit", 0, "Stop reading the input after this number of assets, and finish the ones in progress, for a quick check of the settings on a large input (0 for no limit)")
+	flags.BoolVar(&uc.RestoreTrashed, "restore-trashed", false, "Move to the trash the uploaded assets that are marked as trashed in their sidecar")
+	flags.BoolVar(&uc.ImportDescriptions, "import-descriptions", false, "Set the description of the assets already on the server from the sidecar's description. The uploaded assets always get it")
+	flags.StringVar(&uc.DescriptionMode, "description-mode", DescriptionSkip, "What to do when the server's asset already has a description (skip|overwrite|append)")
+	flags.BoolVar(&uc.ImportGPS, "import-gps", true, "Set the GPS coordinates from the sidecar when the file has none")
+	flags.BoolVar(&uc.PreferSidecarGPS, "prefer-sidecar-gps", false, "Use the sidecar's GPS coordinates even when the file has embedded ones")
+	flags.BoolVar(&uc.UploadSidecars, "upload-sidecars", true, "Send the XMP file found next to a photo (photo.xmp or photo.jpg.xmp) with it, the server keeps it as the asset's sidecar. The XMP files without photo are never uploaded")
+	flags.BoolVar(&uc.MetadataOnly, "metadata-only", false, "Don't upload files, only update the metadata and the albums of the matching server assets")
+	flags.StringVar(&uc.TwoPass, "two-pass", "", "Split the upload in two runs (upload|enrich): upload sends the files only, without albums, tags, descriptions, GPS, stacks and live photos, and records their server IDs. enrich applies them later to the same assets, like --metadata-only")
+	flags.StringVar(&uc.TwoPassFile, "two-pass-file", "", "File keeping the server IDs of the files sent by --two-pass=upload (default: a file by server and user in the user's cache folder)")
+	flags.BoolVar(&uc.StrictQuota, "strict-quota", false, "Abort the upload when it exceeds the user's quota or the server's free space, instead of a warning")
+	flags.StringVar(&uc.FilenameTemplate, "filename-template", "", "Go template giving the name of the uploaded assets, e.g. '{{.Date.Format \"2006-01-02\"}}_{{.Album}}_{{.Index}}'. Fields: .Name .Ext .Date .Album .Index. The extension is kept")
+	flags.StringVar(&uc.Visibility, "visibility", "", "Visibility of the uploaded assets (timeline|archive|hidden). The archived assets and the visibility given by the sidecar are kept. By default, timeline, and archive for the archived assets")
+	flags.StringVar(&uc.OnNameCollision, "on-name-collision", NameCollisionKeep, "What to do when different files with the same name go to the same album (keep|suffix). Both files are uploaded and the collision is reported, suffix renames the second one IMG_0001_1.jpg")
+	flags.StringVar(&uc.AlbumNameMatch, "album-name-match", AlbumMatchExact, "How the album names are compared to merge the albums, with the server's ones too (exact|trim|ci). trim ignores the leading and trailing spaces, ci ignores the case too")
+	flags.BoolVar(&uc.SetAlbumCover, "set-album-cover", false, "Set the cover of the albums created by the upload to their oldest member, once all the members are uploaded")
+	flags.StringVar(&uc.AlbumDescription, "album-description-template", "", "Go template giving the description of the albums created by the upload, e.g. '{{.Name}}, {{.Start.Format \"Jan 2006\"}} - {{.End.Format \"Jan 2006\"}}'. Fields: .Name .Description .Start .End .Count")
+	flags.StringVar(&uc.AlbumActivity, "album-activity", "", "Enable the comments and likes of the albums created by the upload (on|off). The server's default when not set")
+	flags.BoolVar(&uc.SummaryAllAlbums, "summary-all-albums", false, "List in the created_albums of the summary the existing albums that received assets too, marked as existing. Only the created albums are listed by default")
+	flags.BoolVar(&uc.IgnoreAlbums, "ignore-albums", false, "Ignore the albums of the input, like the takeout's album JSONs: no album is created and the assets aren't added to albums")
+	flags.StringVar(&uc.AlbumID, "album-id", "", "Add all the uploaded assets to the server's album with this ID, instead of the albums of the input. The upload fails when the album doesn't exist")
+	flags.StringVar(&uc.SkipIfInAlbum, "skip-if-in-album", "", "Skip the files whose checksum matches an asset of this server's album, used as a list of the already processed photos")
+	flags.StringVar(&uc.SharedAlbumMode, "shared-album-mode", SharedAlbumAdd, "What to do when a target album is shared by another user (add|skip|create-owned). create-owned creates an album of the user with the same name")
+	flags.IntVar(&uc.AlbumBatchSize, "album-batch-size", 100, "Number of assets added to an album in one request. A failing batch is retried asset by asset")
+	flags.BoolVar(&uc.ValidateMedia, "validate-media", false, "Check the structure of the JPEG, PNG, MP4 and MOV files before uploading them. The truncated files are reported as errors, without being uploaded")
+	flags.BoolVar(&uc.Plan, "plan", false, "Analyze the input and the server, write the decision taken for each asset as JSON lines (upload|skip|duplicate) on the standard output, and exit without uploading")
+	flags.StringVar(&uc.DryRunOutput, "dry-run-output", "", "With --dry-run, write the decision taken for each asset (upload|skip|duplicate) into this file as JSON lines sorted by path, to compare two runs with diff")
+	flags.BoolVar(&uc.CheckPermissions, "check-permissions", true, "Check that the API key has the permissions required by the upload before starting, and abort with the list of the missing ones")
+	flags.StringVar(&uc.ListDuplicates, "list-duplicates", "", "Report the input files already on the server or duplicated in the input, grouped by checksum, and the server's duplicate sets, then exit without uploading (text|json)")
+	flags.Lookup("list-duplicates").NoOptDefVal = ListDuplicatesText
+	flags.StringVar(&uc.ServerCache, "server-cache", "", "Save the server's assets and albums in this folder, and reuse them in the next runs against the same server while they are fresh, reading only the assets updated since. The default folder is in the user's cache folder")
+	flags.Lookup("server-cache").NoOptDefVal = servercache.DefaultDir()
+	flags.DurationVar(&uc.ServerCacheTTL, "server-cache-ttl", time.Hour, "How long the server's inventory saved by --server-cache is reused")
+	flags.BoolVar(&uc.FailOnUnsupported, "fail-on-unsupported", false, "Count the files rejected by the server because of their format as errors. By default, they are discarded")
+	flags.DurationVar(&uc.StallTimeout, "stall-timeout", 5*time.Minute, "Cancel the upload of a file when no byte is sent during this duration, and report it as a failed upload. The other uploads continue (0 to disable)")
+	flags.StringVar(&uc.MetricsFile, "metrics-file", "", "Append a CSV row per progress tick to this file: timestamp, phase, uploaded assets, bytes and throughput, to plot the throughput over time")
+	flags.BoolVar(&uc.RunDedup, "run-dedup", false, "After the upload, start the server's duplicate detection job and report the number of duplicate sets")
 
 	uc.StackOptions.RegisterFlags(flags)
+	flags.BoolVar(&uc.StackOnUpload, "stack-on-upload", false, "Stack the RAW and JPEG files with the same name once both are uploaded, with the RAW as cover, like the stack command does. Same as --manage-raw-jpeg=StackCoverRaw")
 }
 
 // NewUploadCommand creates the root "upload" command and adds subcommands for each supported source.
@@ -100,11 +218,17 @@ func NewUploadCommand(ctx context.Context, app *app.Application) *cobra.Command
 	uc := &UpCmd{
 		app:               app,
 		localAssets:       syncset.New[string](),
+		trashedAssets:     syncset.New[string](),
+		duplicateSets:     -1,
+		storage:           storageCheck{available: -1},
 		immichAssetsReady: make(chan struct{}),
 	}
 
 	// Register CLI flags for the upload command
 	uc.RegisterFlags(cmd.PersistentFlags())
+	app.SetMachineOutput(cmd, func() bool {
+		return uc.Plan || uc.ListDuplicates == ListDuplicatesJSON || uc.ProgressFormat == ProgressGauge
+	})
 
 	// Add subcommands for each supported upload source
 	cmd.AddCommand(folder.NewFromFolderCommand(ctx, cmd, app, uc))
@@ -122,6 +246,128 @@ func NewUploadCommand(ctx context.Context, app *app.Application) *cobra.Command
 			app.SetFileProcessor(processor)
 		}
 
+		switch uc.UI {
+		case uiModeTUI, uiModeLine:
+		default:
+			return fmt.Errorf("invalid value for --ui: %q, expected tui or line", uc.UI)
+		}
+		switch uc.ProgressFormat {
+		case ProgressText:
+		case ProgressGauge:
+			if cmd.Flags().Changed("ui") && uc.UI == uiModeTUI {
+				return errors.New("--progress-format=gauge and --ui=tui can't be used together")
+			}
+			uc.UI = uiModeLine // the gauge replaces the progress line
+		default:
+			return fmt.Errorf("invalid value for --progress-format: %q, expected text or gauge", uc.ProgressFormat)
+		}
+		switch uc.DescriptionMode {
+		case DescriptionSkip, DescriptionOverwrite, DescriptionAppend:
+		default:
+			return fmt.Errorf("invalid value for --description-mode: %q, expected skip, overwrite or append", uc.DescriptionMode)
+		}
+
+		switch uc.AlbumNameMatch {
+		case AlbumMatchExact, AlbumMatchTrim, AlbumMatchCI:
+			uc.albumNames.mode = uc.AlbumNameMatch
+		default:
+			return fmt.Errorf("invalid value for --album-name-match: %q, expected exact, trim or ci", uc.AlbumNameMatch)
+		}
+
+		switch uc.SharedAlbumMode {
+		case SharedAlbumAdd, SharedAlbumSkip, SharedAlbumCreateOwned:
+			uc.sharedAlbums.mode = uc.SharedAlbumMode
+		default:
+			return fmt.Errorf("invalid value for --shared-album-mode: %q, expected add, skip or create-owned", uc.SharedAlbumMode)
+		}
+
+		if err := adapters.ValidateOrder(uc.Order); err != nil {
+			return err
+		}
+
+		switch uc.ListDuplicates {
+		case "", ListDuplicatesText, ListDuplicatesJSON:
+		default:
+			return fmt.Errorf("invalid value for --list-duplicates: %q, expected text or json", uc.ListDuplicates)
+		}
+		if uc.ListDuplicates != "" && uc.Plan {
+			return errors.New("--list-duplicates and --plan can't be used together")
+		}
+		if uc.DryRunOutput != "" {
+			if !uc.client.DryRun && !app.DryRun && !app.ReadOnly {
+				return errors.New("--dry-run-output needs --dry-run")
+			}
+			if uc.Plan || uc.ListDuplicates != "" {
+				return errors.New("--dry-run-output can't be used with --plan or --list-duplicates")
+			}
+		}
+
+		if uc.AlbumID != "" && uc.IgnoreAlbums {
+			return errors.New("--album-id and --ignore-albums can't be used together")
+		}
+		if uc.SkipIfInAlbum != "" && uc.IgnoreAlbums {
+			return errors.New("--skip-if-in-album and --ignore-albums can't be used together")
+		}
+
+		if err := uc.applyStackOnUpload(); err != nil {
+			return err
+		}
+
+		if uc.AlbumBatchSize < 1 {
+			return fmt.Errorf("invalid value for --album-batch-size: %d, expected a positive number", uc.AlbumBatchSize)
+		}
+
+		if uc.AlbumDescription != "" {
+			tmpl, err := newAlbumDescriptionTemplate(uc.AlbumDescription)
+			if err != nil {
+				return err
+			}
+			uc.albumDescription = tmpl
+		}
+		switch uc.AlbumActivity {
+		case "", AlbumActivityOn, AlbumActivityOff:
+		default:
+			return fmt.Errorf("invalid value for --album-activity: %q, expected on or off", uc.AlbumActivity)
+		}
+
+		switch assets.Visibility(uc.Visibility) {
+		case assets.VisibilityUnknown, assets.VisibilityTimeline, assets.VisibilityArchive, assets.VisibilityHidden:
+		default:
+			return fmt.Errorf("invalid value for --visibility: %q, expected timeline, archive or hidden", uc.Visibility)
+		}
+
+		switch uc.OnNameCollision {
+		case NameCollisionKeep, NameCollisionSuffix:
+		default:
+			return fmt.Errorf("invalid value for --on-name-collision: %q, expected keep or suffix", uc.OnNameCollision)
+		}
+
+		if uc.FilenameTemplate != "" {
+			ft, err := newFilenameTemplate(uc.FilenameTemplate)
+			if err != nil {
+				return err
+			}
+			uc.filenameTemplate = ft
+		}
+
+		switch uc.TwoPass {
+		case "":
+		case TwoPassUpload:
+			if uc.MetadataOnly {
+				return errors.New("--two-pass=upload and --metadata-only can't be used together")
+			}
+		case TwoPassEnrich:
+			uc.MetadataOnly = true // the enrich pass updates the assets sent by the upload pass
+		default:
+			return fmt.Errorf("invalid value for --two-pass: %q, expected upload or enrich", uc.TwoPass)
+		}
+		if uc.MetadataOnly && uc.Overwrite {
+			return errors.New("--metadata-only and --overwrite can't be used together")
+		}
+		if uc.MetadataOnly && uc.ReplaceExisting {
+			return errors.New("--metadata-only and --replace-existing can't be used together")
+		}
+
 		app.SetTZ(time.Local)
 		if tz, err := cmd.Flags().GetString("time-zone"); err == nil && tz != "" {
 			if loc, err := time.LoadLocation(tz); err == nil {
@@ -146,7 +392,13 @@ func (uc *UpCmd) Run(cmd *cobra.Command, adapter adapters.Reader) error {
 	if err != nil {
 		return err
 	}
+	if uc.CheckPermissions {
+		if err := uc.client.CheckPermissions(ctx, uc.requiredPermissions()...); err != nil {
+			return err
+		}
+	}
 	uc.tz = uc.app.GetTZ()
+	uc.app.Log().Info("checksum algorithm", "local", uc.ChecksumAlgo.String(), "server", hash.AlgoSHA1.String())
 	uc.app.SetSupportedMedia(uc.client.Immich.SupportedMedia())
 
 	// Initialize the FileProcessor if not already done
@@ -172,5 +424,27 @@ func (uc *UpCmd) Run(cmd *cobra.Command, adapter adapters.Reader) error {
 	uc.Filters = append(uc.Filters, uc.ManageBurst.GroupFilter(), uc.ManageRawJPG.GroupFilter(), uc.ManageHEICJPG.GroupFilter())
 	uc.infoCollector = filenames.NewInfoCollector(uc.tz, uc.app.GetSupportedMedia())
 
-	return uc.upload(ctx, adapter)
+	if uc.Order != adapters.OrderNone {
+		uc.app.Log().Info("upload order", "order", uc.Order)
+		adapter = adapters.NewOrderedReader(adapter, uc.Order)
+	}
+	if uc.Limit > 0 {
+		uc.app.SetLimit(uc.Limit)
+		adapter = adapters.NewLimitedReader(adapter, uc.Limit)
+	}
+
+	if uc.Plan {
+		return uc.plan(ctx, adapter, cmd.OutOrStdout())
+	}
+	if uc.DryRunOutput != "" {
+		return uc.dryRunOutput(ctx, adapter, uc.DryRunOutput, cmd.OutOrStdout())
+	}
+	if uc.ListDuplicates != "" {
+		return uc.listDuplicates(ctx, adapter, cmd.OutOrStdout())
+	}
+	err = uc.upload(ctx, cmd, adapter)
+	if errors.Is(err, app.ErrAuthenticationFailed) {
+		cmd.SilenceUsage = true // the key has been rejected, the usage doesn't help
+	}
+	return err
 }
@@ -0,0 +1,139 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	cliflags "github.com/simulot/immich-go/internal/cliFlags"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+// TestUploadRetriedWithReloadedKey sends a file rejected with a revoked key, and sent again with the key
+// of the API key file: the asset is uploaded once, after two attempts.
+func TestUploadRetriedWithReloadedKey(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/users/me":
+			_, _ = w.Write([]byte(`{"id":"user1"}`))
+			return
+		case "/api/server/media-types":
+			_, _ = w.Write([]byte(`{"image":[".jpg"]}`))
+			return
+		case "/api/assets":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+		requests++
+		if r.Header.Get("x-api-key") != "new key" {
+			http.Error(w, `{"message":"Invalid API key"}`, http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(immich.AssetResponse{ID: "s1", Status: immich.UploadCreated})
+	}))
+	defer server.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("new key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ic, err := immich.NewImmichClient(server.URL, "revoked key", immich.OptionAPIKeyFile(keyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the other endpoints accept the revoked key
+	if _, err := ic.ValidateConnection(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	uc := newTestUpCmd(t)
+	uc.client.Immich = ic
+	uc.assetIndex = newAssetIndex()
+
+	fsys := fstest.MapFS{"IMG_0001.jpg": &fstest.MapFile{Data: []byte("abc")}}
+	a := &assets.Asset{File: fshelper.FSName(fsys, "IMG_0001.jpg"), OriginalFileName: "IMG_0001.jpg", FileSize: 3}
+	fp := uc.app.FileProcessor()
+	fp.RecordAssetDiscovered(context.Background(), a.File, 3, fileevent.DiscoveredImage)
+
+	status, err := uc.uploadAsset(context.Background(), a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != immich.UploadCreated || a.ID != "s1" || requests != 2 {
+		t.Errorf("status %q, ID %q, %d requests", status, a.ID, requests)
+	}
+	// a late success of the same upload isn't counted again
+	fp.RecordAssetProcessed(context.Background(), a.File, 3, fileevent.ProcessedUploadSuccess)
+
+	s := fp.RunSummary("upload", "completed", nil)
+	if s.Uploaded != 1 || s.UploadAttempts != 2 {
+		t.Errorf("uploaded %d, attempts %d, want 1 and 2", s.Uploaded, s.UploadAttempts)
+	}
+	if n := fp.GetEventCounts()[fileevent.ProcessedUploadSuccess]; n != 1 {
+		t.Errorf("%d upload events, want 1", n)
+	}
+}
+
+// TestUploadOnErrorsContinue uploads two groups, the first asset is refused by the server:
+// with --on-errors continue, the other assets are uploaded and the run doesn't fail.
+func TestUploadOnErrorsContinue(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		onErrors     cliflags.OnErrorsFlag
+		wantErr      bool
+		wantUploaded int64
+	}{
+		{name: "continue", onErrors: cliflags.OnErrorsNeverStop, wantUploaded: 3},
+		{name: "stop", onErrors: cliflags.OnErrorsStop, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			uc := newTestUpCmd(t)
+			uc.client.Immich = collisionServer(t, "rejected content")
+			uc.assetIndex = newAssetIndex()
+			uc.app.ConcurrentTask = 1
+			uc.app.OnErrors = tt.onErrors
+			ctx := context.Background()
+
+			var list []*assets.Asset
+			for i, content := range []string{"rejected content", "first", "second", "third"} {
+				name := fmt.Sprintf("IMG_%04d.jpg", i)
+				fsys[name] = &fstest.MapFile{Data: []byte(content)}
+				a := &assets.Asset{File: fshelper.FSName(fsys, name), OriginalFileName: name, FileSize: len(content)}
+				uc.app.FileProcessor().RecordAssetDiscovered(ctx, a.File, int64(a.FileSize), fileevent.DiscoveredImage)
+				list = append(list, a)
+			}
+			// the failed asset's group goes on, and the next group is uploaded
+			groups := make(chan *assets.Group, 2)
+			groups <- assets.NewGroup(assets.GroupByNone, list[:2]...)
+			groups <- assets.NewGroup(assets.GroupByNone, list[2:]...)
+			close(groups)
+
+			err := uc.uploadLoop(ctx, groups)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("uploadLoop() = %v, want an error: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			counts := uc.app.FileProcessor().Logger().GetCounts()
+			if counts[fileevent.ErrorServerError] != 1 || counts[fileevent.ProcessedUploadSuccess] != tt.wantUploaded {
+				t.Errorf("%d upload errors, %d uploaded, want 1 and %d", counts[fileevent.ErrorServerError], counts[fileevent.ProcessedUploadSuccess], tt.wantUploaded)
+			}
+		})
+	}
+}
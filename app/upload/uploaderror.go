@@ -0,0 +1,33 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+// errUnsupportedFormat stops the processing of an asset discarded because the server doesn't support its format
+var errUnsupportedFormat = errors.New("format not supported by the server")
+
+// recordUploadError records the failed upload of the asset, and gives the error to return.
+// The stalled uploads are reported as failed uploads, with the reason of the cancellation.
+// The files rejected by the server because of their format are discarded, unless --fail-on-unsupported is given:
+// they will never be accepted, a new attempt is useless.
+func (uc *UpCmd) recordUploadError(ctx context.Context, upCtx context.Context, a *assets.Asset, err error) error {
+	if immich.IsUnsupportedFormat(err) && !uc.FailOnUnsupported {
+		uc.app.FileProcessor().RecordAssetDiscarded(ctx, a.File, int64(a.FileSize), fileevent.DiscardedUnsupportedFormat,
+			fmt.Sprintf("format %s not supported by the server", path.Ext(a.File.Name())))
+		return errUnsupportedFormat
+	}
+	code := fileevent.ErrorServerError
+	if cause := context.Cause(upCtx); errors.Is(cause, errUploadStalled) {
+		code, err = fileevent.ErrorUploadFailed, cause
+	}
+	uc.app.FileProcessor().RecordAssetError(ctx, a.File, int64(a.FileSize), code, err)
+	return err
+}
@@ -0,0 +1,37 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/mediacheck"
+)
+
+// validateMedia checks the structure of the file before uploading it, with --validate-media.
+// The truncated or corrupt files are recorded as incomplete, without wasting the transfer.
+func (uc *UpCmd) validateMedia(ctx context.Context, a *assets.Asset) error {
+	if !uc.ValidateMedia {
+		return nil
+	}
+	f, err := a.File.Open()
+	if err != nil {
+		uc.app.FileProcessor().RecordAssetError(ctx, a.File, int64(a.FileSize), fileevent.ErrorFileAccess, err)
+		return err
+	}
+	defer f.Close()
+
+	err = mediacheck.Check(f, int64(a.FileSize), path.Ext(a.File.Name()))
+	switch {
+	case errors.Is(err, mediacheck.ErrInvalid):
+		uc.invalidMedia.Add(1)
+		uc.app.FileProcessor().RecordAssetError(ctx, a.File, int64(a.FileSize), fileevent.ErrorIncomplete, err)
+		return fmt.Errorf("%s not uploaded: %w", a.File.FullName(), err)
+	case err != nil:
+		uc.app.FileProcessor().RecordAssetError(ctx, a.File, int64(a.FileSize), fileevent.ErrorFileAccess, err)
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package upload
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/simulot/immich-go/immich"
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+// visibilityCounts counts the uploaded assets by visibility, for the summary
+type visibilityCounts struct {
+	lock   sync.Mutex
+	counts map[string]int64
+}
+
+func (vc *visibilityCounts) add(v assets.Visibility) {
+	vc.lock.Lock()
+	defer vc.lock.Unlock()
+	if vc.counts == nil {
+		vc.counts = map[string]int64{}
+	}
+	vc.counts[string(v)]++
+}
+
+func (vc *visibilityCounts) get() map[string]int64 {
+	vc.lock.Lock()
+	defer vc.lock.Unlock()
+	return maps.Clone(vc.counts)
+}
+
+// logVisibility logs the default visibility of the uploaded assets.
+// The archived assets and the sidecar's visibility win over it.
+func (uc *UpCmd) logVisibility() {
+	v := uc.Visibility
+	if v == "" {
+		v = string(assets.VisibilityTimeline)
+	}
+	uc.app.Log().Info("default visibility of the uploaded assets", "visibility", v)
+}
+
+// applyVisibility gives the --visibility to the asset, when neither its sidecar nor its archived state decide it
+func (uc *UpCmd) applyVisibility(a *assets.Asset) {
+	if uc.Visibility == "" || a.Archived || a.Visibility != assets.VisibilityUnknown {
+		return
+	}
+	a.Visibility = assets.Visibility(uc.Visibility)
+}
+
+// countVisibility counts the uploaded asset by the visibility it got on the server
+func (uc *UpCmd) countVisibility(a *assets.Asset) {
+	uc.visibility.add(immich.UploadVisibility(a))
+}
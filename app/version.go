@@ -2,6 +2,7 @@ package app
 
 import (
 	"fmt"
+	"runtime"
 	"runtime/debug"
 	"strings"
 )
@@ -36,6 +37,11 @@ func init() {
 	}
 }
 
+// UserAgent gives the User-Agent header sent to the server: immich-go/<version> (<os>/<arch>)
+func UserAgent() string {
+	return fmt.Sprintf("immich-go/%s (%s/%s)", Version, runtime.GOOS, runtime.GOARCH)
+}
+
 // Banner Ascii art
 // Generator : http://patorjk.com/software/taag-v1/
 // Font: Three point
@@ -3,23 +3,24 @@ package immich
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/simulot/immich-go/internal/assets"
 )
 
 type AlbumSimplified struct {
-	ID          string `json:"id,omitempty"`
-	AlbumName   string `json:"albumName"`
-	Description string `json:"description,omitempty"`
-	// OwnerID                    string    `json:"ownerId"`
-	// CreatedAt                  time.Time `json:"createdAt"`
+	ID          string    `json:"id,omitempty"`
+	AlbumName   string    `json:"albumName"`
+	Description string    `json:"description,omitempty"`
+	OwnerID     string    `json:"ownerId,omitempty"`
+	CreatedAt   time.Time `json:"createdAt,omitzero"`
 	// UpdatedAt                  time.Time `json:"updatedAt"`
 	// AlbumThumbnailAssetID      string    `json:"albumThumbnailAssetId"`
 	// SharedUsers                []string  `json:"sharedUsers"`
 	// Owner                      User      `json:"owner"`
-	// Shared                     bool      `json:"shared"`
-	// AssetCount                 int       `json:"assetCount"`
+	Shared     bool `json:"shared,omitempty"` // shared with other users, or by another user
+	AssetCount int  `json:"assetCount,omitempty"`
 	// LastModifiedAssetTimestamp time.Time `json:"lastModifiedAssetTimestamp"
 	AssetIds []string `json:"assetIds,omitempty"`
 }
@@ -36,8 +37,10 @@ func AlbumsFromAlbumSimplified(albums []AlbumSimplified) []assets.Album {
 	return result
 }
 
+// GetAllAlbums returns the albums owned by the user, then the albums shared with the user.
+// The server lists them with two requests, an album owned and shared is listed once.
 func (ic *ImmichClient) GetAllAlbums(ctx context.Context) ([]AlbumSimplified, error) {
-	var albums []AlbumSimplified
+	var albums, shared []AlbumSimplified
 	err := ic.newServerCall(ctx, EndPointGetAllAlbums).
 		do(
 			getRequest("/albums", setAcceptJSON()),
@@ -46,6 +49,24 @@ func (ic *ImmichClient) GetAllAlbums(ctx context.Context) ([]AlbumSimplified, er
 	if err != nil {
 		return nil, err
 	}
+	err = ic.newServerCall(ctx, EndPointGetAllAlbums).
+		do(
+			getRequest("/albums?shared=true", setAcceptJSON()),
+			responseJSON(&shared),
+		)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]struct{}, len(albums))
+	for _, a := range albums {
+		known[a.ID] = struct{}{}
+	}
+	for _, a := range shared {
+		if _, ok := known[a.ID]; !ok {
+			known[a.ID] = struct{}{}
+			albums = append(albums, a)
+		}
+	}
 	return albums, nil
 }
 
@@ -173,6 +194,33 @@ func (ic *ImmichClient) GetAssetAlbums(ctx context.Context, assetID string) ([]A
 	return r, err
 }
 
+// UpdateAlbumCover sets the asset shown as the album's cover. The asset must be in the album.
+func (ic *ImmichClient) UpdateAlbumCover(ctx context.Context, albumID string, assetID string) error {
+	if ic.dryRun {
+		return nil
+	}
+	body := struct {
+		AlbumThumbnailAssetID string `json:"albumThumbnailAssetId"`
+	}{AlbumThumbnailAssetID: assetID}
+	return ic.newServerCall(ctx, EndPointUpdateAlbumCover).do(
+		patchRequest("/albums/"+albumID, setAcceptJSON(), setJSONBody(body)))
+}
+
+// AlbumSettings gives the album's settings to change, the nil ones are kept
+type AlbumSettings struct {
+	Description       *string `json:"description,omitempty"`
+	IsActivityEnabled *bool   `json:"isActivityEnabled,omitempty"`
+}
+
+// UpdateAlbumSettings changes the description and the activity (comments and likes) of the album
+func (ic *ImmichClient) UpdateAlbumSettings(ctx context.Context, albumID string, settings AlbumSettings) error {
+	if ic.dryRun {
+		return nil
+	}
+	return ic.newServerCall(ctx, EndPointUpdateAlbumSettings).do(
+		patchRequest("/albums/"+albumID, setAcceptJSON(), setJSONBody(settings)))
+}
+
 func (ic *ImmichClient) DeleteAlbum(ctx context.Context, id string) error {
 	if ic.dryRun {
 		return nil
@@ -0,0 +1,47 @@
+package immich_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/simulot/immich-go/immich"
+)
+
+func TestGetAllAlbums(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/albums" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("shared") == "true" {
+			// the owned album shared with others, and an album shared by another user
+			_, _ = w.Write([]byte(`[{"id":"a2","albumName":"Family","ownerId":"me","shared":true},{"id":"a3","albumName":"Party","ownerId":"friend","shared":true}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id":"a1","albumName":"Holidays","ownerId":"me"},{"id":"a2","albumName":"Family","ownerId":"me","shared":true}]`))
+	}))
+	defer server.Close()
+
+	client, err := immich.NewImmichClient(server.URL, "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	albums, err := client.GetAllAlbums(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, a := range albums {
+		ids = append(ids, a.ID)
+	}
+	if want := []string{"a1", "a2", "a3"}; !slices.Equal(ids, want) {
+		t.Errorf("GetAllAlbums() = %v, want %v", ids, want)
+	}
+	if a := albums[2]; a.AlbumName != "Party" || a.OwnerID != "friend" || !a.Shared {
+		t.Errorf("unexpected shared album: %+v", a)
+	}
+}
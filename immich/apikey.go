@@ -0,0 +1,47 @@
+package immich
+
+import (
+	"context"
+	"slices"
+)
+
+// APIKey describes the API key used by the client.
+// The scoped keys have a list of permissions, the full access keys have the permission "all".
+type APIKey struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// PermissionAll is given to the API keys with a full access
+const PermissionAll = "all"
+
+// ImmichAPIKeyInterface is not a part of the immich client interface to simplify the client mocks
+type ImmichAPIKeyInterface interface {
+	GetMyAPIKey(ctx context.Context) (APIKey, error)
+}
+
+var _ ImmichAPIKeyInterface = (*ImmichClient)(nil)
+
+// GetMyAPIKey gives the description of the API key used by the client.
+// The older servers don't have this endpoint, and answer with the status 404.
+func (ic *ImmichClient) GetMyAPIKey(ctx context.Context) (APIKey, error) {
+	var key APIKey
+	err := ic.newServerCall(ctx, EndPointGetMyAPIKey).
+		do(getRequest("/api-keys/me", setAcceptJSON()), responseJSON(&key))
+	return key, err
+}
+
+// MissingPermissions gives the required permissions not granted to the key, in the order of required
+func (k APIKey) MissingPermissions(required ...string) []string {
+	if slices.Contains(k.Permissions, PermissionAll) {
+		return nil
+	}
+	var missing []string
+	for _, p := range required {
+		if !slices.Contains(k.Permissions, p) && !slices.Contains(missing, p) {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
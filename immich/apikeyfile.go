@@ -0,0 +1,77 @@
+package immich
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// OptionAPIKeyFile gives the file of the API key. The key is read again when the server
+// rejects it, to pick up a key rotated during a long run.
+func OptionAPIKeyFile(name string) clientOption {
+	return func(ic *ImmichClient) error {
+		ic.keyFile = name
+		return nil
+	}
+}
+
+// ReadAPIKeyFile reads the API key from the file, the spaces and the line ends are ignored
+func ReadAPIKeyFile(name string) (string, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("can't read the API key file: %w", err)
+	}
+	key := strings.TrimSpace(string(b))
+	if key == "" {
+		return "", fmt.Errorf("the API key file %s is empty", name)
+	}
+	return key, nil
+}
+
+// apiKey gives the current API key
+func (ic *ImmichClient) apiKey() string {
+	ic.keyLock.RLock()
+	defer ic.keyLock.RUnlock()
+	return ic.key
+}
+
+// reloadAPIKey reads the API key file after a rejected call made with the key used.
+// It tells if the file gives another key, to try again with it.
+func (ic *ImmichClient) reloadAPIKey(used string) bool {
+	if ic.keyFile == "" {
+		return false
+	}
+	key, err := ReadAPIKeyFile(ic.keyFile)
+	if err != nil {
+		if ic.callLogger != nil {
+			ic.callLogger.Warn("can't reload the API key", "error", err)
+		}
+		return false
+	}
+	if key == used {
+		return false
+	}
+	ic.keyLock.Lock()
+	changed := key != ic.key
+	ic.key = key
+	ic.keyLock.Unlock()
+	if changed && ic.callLogger != nil {
+		ic.callLogger.Info("the server rejected the API key, the new key of the API key file is used", "file", ic.keyFile)
+	}
+	return true
+}
+
+// IsUnauthorized tells if the server rejected the call because of the API key, revoked or rotated
+func IsUnauthorized(err error) bool {
+	var ce callError
+	return errors.As(err, &ce) && ce.status == http.StatusUnauthorized
+}
+
+// KeyReloaded tells if the call was rejected with an API key that has been replaced since by the API key file.
+// The call can be made again with the new key.
+func KeyReloaded(err error) bool {
+	var ce callError
+	return errors.As(err, &ce) && ce.keyReloaded
+}
@@ -239,7 +239,8 @@ type UpdAssetField struct {
 	Longitude        float64   `json:"longitude,omitempty"`
 	Description      string    `json:"description,omitempty"`
 	Rating           int       `json:"rating,omitempty"`
-	DateTimeOriginal time.Time `json:"dateTimeOriginal,omitempty"`
+	DateTimeOriginal time.Time `json:"dateTimeOriginal,omitzero"`
+	LivePhotoVideoID string    `json:"livePhotoVideoId,omitempty"`
 }
 
 // MarshalJSON customizes the JSON marshaling for the UpdAssetField struct.
@@ -254,7 +255,8 @@ func (u UpdAssetField) MarshalJSON() ([]byte, error) {
 		Longitude        float64   `json:"longitude"`
 		Description      string    `json:"description,omitempty"`
 		Rating           int       `json:"rating,omitempty"`
-		DateTimeOriginal time.Time `json:"dateTimeOriginal,omitempty"`
+		DateTimeOriginal time.Time `json:"dateTimeOriginal,omitzero"`
+		LivePhotoVideoID string    `json:"livePhotoVideoId,omitempty"`
 	}
 
 	// alias is used to omit Latitude and Longitude when they are zero.
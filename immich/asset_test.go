@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+
+	"github.com/simulot/immich-go/internal/assets"
 )
 
 func Test_AssetJSON(t *testing.T) {
@@ -79,3 +81,24 @@ func Test_AssetJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestUploadVisibility(t *testing.T) {
+	tests := []struct {
+		name  string
+		asset assets.Asset
+		want  assets.Visibility
+	}{
+		{name: "default", asset: assets.Asset{}, want: assets.VisibilityTimeline},
+		{name: "archived", asset: assets.Asset{Archived: true}, want: assets.VisibilityArchive},
+		{name: "hidden", asset: assets.Asset{Visibility: assets.VisibilityHidden}, want: assets.VisibilityHidden},
+		{name: "visibility wins", asset: assets.Asset{Archived: true, Visibility: assets.VisibilityTimeline}, want: assets.VisibilityTimeline},
+		{name: "locked", asset: assets.Asset{Visibility: assets.VisibilityLocked}, want: assets.VisibilityTimeline},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UploadVisibility(&tt.asset); got != tt.want {
+				t.Errorf("UploadVisibility() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,12 +7,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/simulot/immich-go/internal/assets"
 	"github.com/simulot/immich-go/internal/fshelper"
 )
@@ -27,6 +30,8 @@ const (
 	EndPointCreateAlbum            = "CreateAlbum"
 	EndPointGetAssetAlbums         = "GetAssetAlbums"
 	EndPointDeleteAlbum            = "DeleteAlbum"
+	EndPointUpdateAlbumCover       = "UpdateAlbumCover"
+	EndPointUpdateAlbumSettings    = "UpdateAlbumSettings"
 	EndPointPingServer             = "PingServer"
 	EndPointValidateConnection     = "ValidateConnection"
 	EndPointGetServerStatistics    = "GetServerStatistics"
@@ -49,6 +54,9 @@ const (
 	EndPointGetUserInfo            = "GetUserInfo"
 	EndPointUpdateAdminOnboarding  = "UpdateAdminOnboarding"
 	EndPointCreateApiKey           = "CreateApiKey"
+	EndPointGetDuplicates          = "GetDuplicates"
+	EndPointGetServerStorage       = "GetServerStorage"
+	EndPointGetMyAPIKey            = "GetMyAPIKey"
 )
 
 type TooManyInternalError struct {
@@ -67,16 +75,23 @@ type serverCall struct {
 	err                error
 	ctx                context.Context
 	hasResponseHandler bool
+	requestID          string // the X-Request-Id header of the request, to find the call in the server's logs
+	streamedBody       bool   // the body can't be sent again
+	keyRetried         bool   // the call has been made again with the reloaded API key
+	keyReloaded        bool   // the API key has been reloaded, but the call can't be made again
 }
 
 // callError represents errors returned by the server
 type callError struct {
-	endPoint string
-	method   string
-	url      string
-	status   int
-	err      error
-	message  *ServerErrorMessage
+	endPoint  string
+	method    string
+	url       string
+	status    int
+	requestID string
+	err       error
+	message   *ServerErrorMessage
+
+	keyReloaded bool // the API key has been replaced since the call, by the API key file
 }
 
 type ServerErrorMessage struct {
@@ -102,6 +117,10 @@ func (ce callError) Error() string {
 		b.WriteString(", ")
 		b.WriteString(fmt.Sprintf("%d %s", ce.status, http.StatusText(ce.status)))
 	}
+	if ce.requestID != "" {
+		b.WriteString(", X-Request-Id: ")
+		b.WriteString(ce.requestID)
+	}
 	b.WriteRune('\n')
 	if ce.err != nil && !errors.Is(ce.err, &callError{}) {
 		b.WriteString(ce.err.Error())
@@ -116,6 +135,48 @@ func (ce callError) Error() string {
 	return b.String()
 }
 
+// StatusCode gives the HTTP status of the server's response to the failed call, 0 when the server hasn't answered
+func StatusCode(err error) int {
+	var ce callError
+	if errors.As(err, &ce) {
+		return ce.status
+	}
+	return 0
+}
+
+// RequestID gives the X-Request-Id header of the failed call, to be handed to the server's administrator.
+// It's empty when the request hasn't been built.
+func RequestID(err error) string {
+	var ce callError
+	if errors.As(err, &ce) {
+		return ce.requestID
+	}
+	return ""
+}
+
+// ErrUnsupportedFormat is given when the file's format isn't one of the server's media types
+var ErrUnsupportedFormat = errors.New("type file not supported")
+
+// IsUnsupportedFormat tells if the upload failed because the server doesn't support the file's format.
+// The format isn't one of the server's media types, or the server rejected the file
+// with the status 415, or with the status 400 and an unsupported file type message.
+func IsUnsupportedFormat(err error) bool {
+	if errors.Is(err, ErrUnsupportedFormat) {
+		return true
+	}
+	var ce callError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	switch ce.status {
+	case http.StatusUnsupportedMediaType:
+		return true
+	case http.StatusBadRequest:
+		return ce.message != nil && strings.Contains(strings.ToLower(ce.message.Message), "unsupported file type")
+	}
+	return false
+}
+
 func (ic *ImmichClient) newServerCall(ctx context.Context, api string) *serverCall {
 	sc := &serverCall{
 		endPoint: api,
@@ -127,8 +188,9 @@ func (ic *ImmichClient) newServerCall(ctx context.Context, api string) *serverCa
 
 func (sc *serverCall) Err(req *http.Request, resp *http.Response, msg *ServerErrorMessage) error {
 	ce := callError{
-		endPoint: sc.endPoint,
-		err:      sc.err,
+		endPoint:  sc.endPoint,
+		err:       sc.err,
+		requestID: sc.requestID,
 	}
 	if req != nil {
 		ce.method = req.Method
@@ -138,6 +200,7 @@ func (sc *serverCall) Err(req *http.Request, resp *http.Response, msg *ServerErr
 		ce.status = resp.StatusCode
 	}
 	ce.message = msg
+	ce.keyReloaded = sc.keyReloaded
 	return ce
 }
 
@@ -167,6 +230,11 @@ func (sc *serverCall) request(
 	if sc.joinError(err) != nil {
 		return nil
 	}
+	sc.requestID = uuid.NewString()
+	req.Header.Set("X-Request-Id", sc.requestID)
+	if sc.ic.userAgent != "" {
+		req.Header.Set("User-Agent", sc.ic.userAgent)
+	}
 	opts = append(opts, setAPIKey())
 	for _, opt := range opts {
 		if opt != nil {
@@ -217,6 +285,15 @@ func putRequest(url string, opts ...serverRequestOption) requestFunction {
 	}
 }
 
+func patchRequest(url string, opts ...serverRequestOption) requestFunction {
+	return func(sc *serverCall) *http.Request {
+		if sc.err != nil {
+			return nil
+		}
+		return sc.request(http.MethodPatch, sc.ic.endPoint+url, opts...)
+	}
+}
+
 func (sc *serverCall) do(fnRequest requestFunction, opts ...serverResponseOption) error {
 	var (
 		resp *http.Response
@@ -228,12 +305,32 @@ func (sc *serverCall) do(fnRequest requestFunction, opts ...serverResponseOption
 		return sc.Err(req, nil, nil)
 	}
 
-	resp, err = sc.ic.client.Do(req)
+	start := time.Now()
+	if sc.ic.errorSimulator.inject(sc.endPoint) {
+		resp = simulatedResponse(req)
+	} else {
+		resp, err = sc.ic.client.Do(req)
+	}
 	// any non nil error must be returned
 	if err != nil {
 		sc.err = err
+		sc.logCall(req, 0, time.Since(start))
 		return sc.Err(req, nil, nil)
 	}
+	defer func() { sc.logCall(req, resp.StatusCode, time.Since(start)) }()
+
+	// the API key has been rotated, the call is made again with the new key when possible
+	if resp.StatusCode == http.StatusUnauthorized && sc.ic.reloadAPIKey(req.Header.Get("x-api-key")) {
+		if !sc.streamedBody && !sc.keyRetried {
+			sc.keyRetried = true
+			if resp.Body != nil {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			return sc.do(fnRequest, opts...)
+		}
+		sc.keyReloaded = true
+	}
 
 	// Any StatusCode above 300 denotes a problem, we expect a JSON with the server's error
 	if resp.StatusCode >= 300 {
@@ -265,10 +362,36 @@ func (sc *serverCall) do(fnRequest requestFunction, opts ...serverResponseOption
 	return nil
 }
 
+// logCall logs the call duration in the http group. The slow calls are logged as warnings.
+func (sc *serverCall) logCall(req *http.Request, status int, d time.Duration) {
+	l := sc.ic.callLogger
+	if l == nil || sc.endPoint == EndPointGetJobs {
+		return
+	}
+	level := slog.LevelDebug
+	msg := "api call"
+	if sc.ic.slowCall > 0 && d >= sc.ic.slowCall {
+		level = slog.LevelWarn
+		msg = "slow api call"
+	}
+	if !l.Enabled(sc.ctx, level) {
+		return
+	}
+	l.LogAttrs(sc.ctx, level, msg, slog.Group("http",
+		slog.String("endpoint", sc.endPoint),
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.String("request_id", sc.requestID),
+		slog.Int("status", status),
+		slog.Duration("duration", d),
+	))
+}
+
 type serverRequestOption func(sc *serverCall, req *http.Request) error
 
 func setBody(body io.ReadCloser) serverRequestOption {
 	return func(sc *serverCall, req *http.Request) error {
+		sc.streamedBody = true
 		req.Body = body
 		return nil
 	}
@@ -300,7 +423,7 @@ func setOctetStream() serverRequestOption {
 
 func setAPIKey() serverRequestOption {
 	return func(sc *serverCall, req *http.Request) error {
-		req.Header.Set("x-api-key", sc.ic.key)
+		req.Header.Set("x-api-key", sc.ic.apiKey())
 		return nil
 	}
 }
@@ -388,6 +511,21 @@ func responseOctetStream(rc *io.ReadCloser) serverResponseOption {
 	}
 }
 
+// responseDate reads the server's clock from the Date header
+func responseDate(t *time.Time) serverResponseOption {
+	return func(sc *serverCall, resp *http.Response) error {
+		if resp == nil {
+			return nil
+		}
+		d, err := http.ParseTime(resp.Header.Get("Date"))
+		if err != nil {
+			return fmt.Errorf("can't read the server's date: %w", err)
+		}
+		*t = d
+		return nil
+	}
+}
+
 func isJSON(contentType string) bool {
 	mediaType, _, err := mime.ParseMediaType(contentType)
 	return err == nil && mediaType == "application/json"
@@ -1,19 +1,33 @@
 package immich
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 type testServer struct {
 	// endpoint       string
 	responseStatus int
 	responseBody   string
+	contentType    string
 }
 
 func (ts *testServer) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if ts.contentType != "" {
+		resp.Header().Set("Content-Type", ts.contentType)
+	}
 	resp.WriteHeader(ts.responseStatus)
 	_, _ = resp.Write([]byte(ts.responseBody))
 }
@@ -89,3 +103,216 @@ func TestCall(t *testing.T) {
 		})
 	}
 }
+
+func TestCallLogger(t *testing.T) {
+	ts := &testServer{responseStatus: http.StatusOK, responseBody: `{}`}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	for _, tst := range []struct {
+		name     string
+		slowCall time.Duration
+		expected string
+	}{
+		{name: "debug", slowCall: 0, expected: "level=DEBUG msg=\"api call\""},
+		{name: "slow", slowCall: time.Nanosecond, expected: "level=WARN msg=\"slow api call\""},
+	} {
+		t.Run(tst.name, func(t *testing.T) {
+			b := bytes.NewBuffer(nil)
+			l := slog.New(slog.NewTextHandler(b, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			ic, err := NewImmichClient(server.URL, "1234", OptionCallLogger(l, tst.slowCall))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ic.newServerCall(context.Background(), "test").do(getRequest("/assets", setAcceptJSON()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			log := b.String()
+			for _, s := range []string{tst.expected, "http.method=GET", "http.path=/api/assets", "http.request_id=", "http.status=200", "http.duration="} {
+				if !strings.Contains(log, s) {
+					t.Errorf("expected %q in the log: %s", s, log)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	received := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Request-Id"))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ic, err := NewImmichClient(server.URL, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		err = ic.newServerCall(context.Background(), "test").do(getRequest("/assets"))
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if len(received) != 2 || received[0] == "" || received[0] == received[1] {
+		t.Fatalf("expected 2 different request IDs, got %q", received)
+	}
+	if id := RequestID(err); id != received[1] {
+		t.Errorf("RequestID() = %q, expected %q", id, received[1])
+	}
+	if !strings.Contains(err.Error(), "X-Request-Id: "+received[1]) {
+		t.Errorf("the error should give the request ID: %s", err)
+	}
+}
+
+func TestSimulateErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ic, err := NewImmichClient(server.URL, "1234", OptionSimulateErrors(1, 42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ic.newServerCall(context.Background(), EndPointAssetUpload).do(postRequest("/assets", "application/json", setJSONBody(struct{}{})))
+	var ce callError
+	if !errors.As(err, &ce) || ce.status != http.StatusServiceUnavailable {
+		t.Errorf("expected a 503 error, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("the failing upload should not reach the server")
+	}
+
+	// other calls are not affected
+	err = ic.newServerCall(context.Background(), EndPointPingServer).do(getRequest("/server/ping"))
+	if err != nil {
+		t.Errorf("no error expected, got %v", err)
+	}
+
+	// the sequence of failures is reproducible
+	sequence := func() []bool {
+		ic, _ := NewImmichClient(server.URL, "1234", OptionSimulateErrors(0.5, 7))
+		es := ic.errorSimulator
+		r := []bool{}
+		for range 20 {
+			r = append(r, es.inject(EndPointAssetUpload))
+		}
+		return r
+	}
+	if a, b := sequence(), sequence(); !slices.Equal(a, b) {
+		t.Errorf("the sequences should be equal: %v, %v", a, b)
+	}
+}
+
+func TestIsUnsupportedFormat(t *testing.T) {
+	tt := []struct {
+		name   string
+		server testServer
+		want   bool
+	}{
+		{
+			name:   "unsupported media type",
+			server: testServer{responseStatus: http.StatusUnsupportedMediaType},
+			want:   true,
+		},
+		{
+			name:   "bad request, unsupported file type",
+			server: testServer{responseStatus: http.StatusBadRequest, contentType: "application/json", responseBody: `{"error": "Bad Request", "statusCode": 400, "message": "Unsupported file type foo.x3f"}`},
+			want:   true,
+		},
+		{
+			name:   "bad request, other reason",
+			server: testServer{responseStatus: http.StatusBadRequest, contentType: "application/json", responseBody: `{"error": "Bad Request", "statusCode": 400, "message": "Invalid date"}`},
+		},
+		{
+			name:   "internal error",
+			server: testServer{responseStatus: http.StatusInternalServerError},
+		},
+	}
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			server := httptest.NewServer(&tst.server)
+			defer server.Close()
+			ic, err := NewImmichClient(server.URL, "1234")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = ic.newServerCall(context.Background(), tst.name).do(postRequest("/assets", "application/json", setAcceptJSON()), responseJSON(&AssetResponse{}))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := IsUnsupportedFormat(err); got != tst.want {
+				t.Errorf("IsUnsupportedFormat() = %v, want %v, err: %s", got, tst.want, err)
+			}
+		})
+	}
+	if !IsUnsupportedFormat(fmt.Errorf("%w: .xyz", ErrUnsupportedFormat)) {
+		t.Error("the formats missing in the server's media types are unsupported")
+	}
+}
+
+func TestAPIKeyFileReload(t *testing.T) {
+	validKey := "new-key"
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("x-api-key"))
+		if r.Header.Get("x-api-key") != validKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("old-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := ReadAPIKeyFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic, err := NewImmichClient(server.URL, key, OptionAPIKeyFile(keyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the key is rejected, and the file gives no new key
+	err = ic.newServerCall(context.Background(), "test").do(getRequest("/server/about", setAcceptJSON()))
+	if !IsUnauthorized(err) || KeyReloaded(err) {
+		t.Fatalf("expected an unauthorized error without reloaded key, got %v", err)
+	}
+
+	// the key is rotated, the call is made again with the new key
+	if err := os.WriteFile(keyFile, []byte(validKey+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys = nil
+	err = ic.newServerCall(context.Background(), "test").do(getRequest("/server/about", setAcceptJSON()))
+	if err != nil {
+		t.Fatalf("expected the call to succeed with the new key, got %v", err)
+	}
+	if !slices.Equal(keys, []string{"old-key", validKey}) {
+		t.Errorf("keys sent = %v, want [old-key %s]", keys, validKey)
+	}
+
+	// a streamed body can't be sent again, the caller is told the key is reloaded
+	validKey = "newer-key"
+	if err := os.WriteFile(keyFile, []byte(validKey), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err = ic.newServerCall(context.Background(), "test").do(postRequest("/assets", "text/plain", setBody(io.NopCloser(strings.NewReader("data")))))
+	if !IsUnauthorized(err) || !KeyReloaded(err) {
+		t.Fatalf("expected an unauthorized error with reloaded key, got %v", err)
+	}
+	if got := ic.apiKey(); got != validKey {
+		t.Errorf("apiKey() = %q, want %q", got, validKey)
+	}
+}
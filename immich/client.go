@@ -2,10 +2,15 @@ package immich
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/simulot/immich-go/internal/filetypes"
@@ -22,13 +27,21 @@ type ImmichClient struct {
 	transport      *http.Transport
 	endPoint       string        // Server API url
 	key            string        // User KEY
+	keyLock        sync.RWMutex  // Protects the key, replaced when the key file gives a new one
+	keyFile        string        // File of the key, read again when the server rejects the key
 	DeviceUUID     string        // Device
 	Retries        int           // Number of attempts on 500 errors
 	RetriesDelay   time.Duration // Duration between retries
 	apiTraceWriter io.Writer     // If not nil, logs API calls to this writer
+	callLogger     *slog.Logger  // If not nil, logs the duration of API calls
+	slowCall       time.Duration // Calls longer than this are logged as warnings, 0 to disable
+	userAgent      string        // User-Agent header of the requests, Go's default when empty
 
 	supportedMediaTypes filetypes.SupportedMedia // Server's list of supported medias
 	dryRun              bool                     //  If true, do not send any data to the server
+	fetchConcurrency    int                      // Number of pages of a search requested at once
+	errorSimulator      *errorSimulator          // If not nil, injects synthetic errors in the upload calls
+	readOnly            *readOnlyTransport       // If not nil, the requests changing the server are refused
 }
 
 func (ic *ImmichClient) SetEndPoint(endPoint string) {
@@ -51,7 +64,7 @@ func (ic *ImmichClient) EnableAppTrace(rtd RoundTripperDecorator) {
 	if rtd != nil {
 		ic.client.Transport = rtd(ic.client.Transport)
 	} else {
-		ic.client.Transport = ic.transport
+		ic.client.Transport = ic.guardTransport(ic.transport)
 	}
 }
 
@@ -64,6 +77,16 @@ func OptionSetAPITrace(rtd RoundTripperDecorator) clientOption {
 	}
 }
 
+// OptionCallLogger logs the method, path, status and duration of each API call at DEBUG level.
+// The calls longer than slowCall are logged at WARN level.
+func OptionCallLogger(l *slog.Logger, slowCall time.Duration) clientOption {
+	return func(ic *ImmichClient) error {
+		ic.callLogger = l
+		ic.slowCall = slowCall
+		return nil
+	}
+}
+
 func OptionVerifySSL(verify bool) clientOption {
 	return func(ic *ImmichClient) error {
 		ic.transport.TLSClientConfig.InsecureSkipVerify = verify
@@ -71,6 +94,85 @@ func OptionVerifySSL(verify bool) clientOption {
 	}
 }
 
+// OptionCACert adds the certificates of the PEM file to the system's trusted authorities.
+// Use it for servers with a certificate signed by a private authority.
+func OptionCACert(name string) clientOption {
+	return func(ic *ImmichClient) error {
+		if name == "" {
+			return nil
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("can't read the CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(b) {
+			return fmt.Errorf("no PEM certificate found in %s", name)
+		}
+		ic.transport.TLSClientConfig.RootCAs = pool
+		return nil
+	}
+}
+
+// OptionProxy sends the requests through the proxy. The schemes http, https, socks5 and socks5h are supported.
+// Without proxy, the variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY of the environment are used.
+func OptionProxy(proxy string) clientOption {
+	return func(ic *ImmichClient) error {
+		if proxy == "" {
+			return nil
+		}
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("invalid proxy URL %q: the scheme must be http, https, socks5 or socks5h", u.Redacted())
+		}
+		ic.transport.Proxy = http.ProxyURL(u)
+		return nil
+	}
+}
+
+// OptionMaxConnsPerHost limits the number of TCP connections opened to the server, whatever the number of
+// concurrent tasks. The extra requests wait for a free connection. 0 means no limit.
+func OptionMaxConnsPerHost(n int) clientOption {
+	return func(ic *ImmichClient) error {
+		if n < 0 {
+			return fmt.Errorf("invalid maximum number of connections per host: %d", n)
+		}
+		ic.transport.MaxConnsPerHost = n
+		if n > 0 {
+			ic.transport.MaxIdleConnsPerHost = n
+		}
+		return nil
+	}
+}
+
+// OptionFetchConcurrency sets the number of pages of a search requested at once.
+// 0 or 1 reads the pages one after the other.
+func OptionFetchConcurrency(n int) clientOption {
+	return func(ic *ImmichClient) error {
+		if n < 0 {
+			return fmt.Errorf("invalid fetch concurrency: %d, expected a positive number", n)
+		}
+		ic.fetchConcurrency = n
+		return nil
+	}
+}
+
+// OptionUserAgent sets the User-Agent header sent with all the requests. An empty value keeps Go's default.
+func OptionUserAgent(ua string) clientOption {
+	return func(ic *ImmichClient) error {
+		ic.userAgent = ua
+		return nil
+	}
+}
+
 func OptionConnectionTimeout(d time.Duration) clientOption {
 	return func(ic *ImmichClient) error {
 		ic.client.Timeout = d
@@ -103,6 +205,7 @@ func NewImmichClient(endPoint string, key string, options ...clientOption) (*Imm
 	ic := ImmichClient{
 		endPoint: endPoint + "/api",
 		transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
 			MaxIdleConns:        100,
 			IdleConnTimeout:     90 * time.Second,
 			TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
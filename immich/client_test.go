@@ -5,7 +5,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/simulot/immich-go/immich"
 	"github.com/simulot/immich-go/internal/filetypes"
@@ -170,3 +173,165 @@ func TestDownloadAsset(t *testing.T) {
 		t.Errorf("expected content to be %v, got %v", expectedContent, string(content))
 	}
 }
+
+func TestGetServerStorage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/server/storage" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"diskAvailable":"1 GiB","diskAvailableRaw":1073741824,"diskSizeRaw":4294967296,"diskUseRaw":3221225472,"diskUsagePercentage":75}`))
+	}))
+	defer server.Close()
+
+	client, _ := immich.NewImmichClient(server.URL, "test-key")
+	s, err := client.GetServerStorage(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if s.DiskAvailableRaw != 1073741824 {
+		t.Errorf("expected 1073741824 bytes available, got %d", s.DiskAvailableRaw)
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"res":"pong"}`))
+	}))
+	defer server.Close()
+
+	client, _ := immich.NewImmichClient(server.URL, "test-key")
+	skew, err := client.ClockSkew(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if skew < 59*time.Minute || skew > 61*time.Minute {
+		t.Errorf("expected a skew of about 1h, got %s", skew)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	var host string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.URL.Host
+		_, _ = w.Write([]byte(`{"res":"pong"}`))
+	}))
+	defer proxy.Close()
+
+	client, err := immich.NewImmichClient("http://immich.invalid", "test-key", immich.OptionProxy(proxy.URL))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	err = client.PingServer(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if host != "immich.invalid" {
+		t.Errorf("the request should go through the proxy, got host %q", host)
+	}
+
+	_, err = immich.NewImmichClient("http://immich.invalid", "test-key", immich.OptionProxy("ftp://proxy:21"))
+	if err == nil {
+		t.Error("an unsupported proxy scheme should be an error")
+	}
+}
+
+func TestMaxConnsPerHost(t *testing.T) {
+	var (
+		lock            sync.Mutex
+		active, maxSeen int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		active++
+		maxSeen = max(maxSeen, active)
+		lock.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		lock.Lock()
+		active--
+		lock.Unlock()
+		_, _ = w.Write([]byte(`{"res":"pong"}`))
+	}))
+	defer server.Close()
+
+	client, err := immich.NewImmichClient(server.URL, "test-key", immich.OptionMaxConnsPerHost(1))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	wg := sync.WaitGroup{}
+	for range 4 {
+		wg.Go(func() {
+			if err := client.PingServer(context.Background()); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	if maxSeen != 1 {
+		t.Errorf("expected 1 connection at a time, got %d", maxSeen)
+	}
+
+	_, err = immich.NewImmichClient(server.URL, "test-key", immich.OptionMaxConnsPerHost(-1))
+	if err == nil {
+		t.Error("a negative number of connections should be an error")
+	}
+}
+
+func TestUpdateAlbumSettings(t *testing.T) {
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, strings.TrimSpace(string(b))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, _ := immich.NewImmichClient(server.URL, "test-key")
+	description, activity := "Trip, 2023", false
+	err := client.UpdateAlbumSettings(context.Background(), "album-id", immich.AlbumSettings{Description: &description, IsActivityEnabled: &activity})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if method != http.MethodPatch || path != "/api/albums/album-id" {
+		t.Errorf("expected PATCH /api/albums/album-id, got %s %s", method, path)
+	}
+	if expected := `{"description":"Trip, 2023","isActivityEnabled":false}`; body != expected {
+		t.Errorf("expected the body %s, got %s", expected, body)
+	}
+
+	err = client.UpdateAlbumSettings(context.Background(), "album-id", immich.AlbumSettings{IsActivityEnabled: &activity})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expected := `{"isActivityEnabled":false}`; body != expected {
+		t.Errorf("expected the body %s, got %s", expected, body)
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var ua string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua = r.Header.Get("User-Agent")
+		_, _ = w.Write([]byte(`{"res":"pong"}`))
+	}))
+	defer server.Close()
+
+	client, _ := immich.NewImmichClient(server.URL, "test-key", immich.OptionUserAgent("immich-go/1.2.3 (linux/amd64)"))
+	if err := client.PingServer(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expected := "immich-go/1.2.3 (linux/amd64)"; ua != expected {
+		t.Errorf("expected the User-Agent %q, got %q", expected, ua)
+	}
+
+	client, _ = immich.NewImmichClient(server.URL, "test-key")
+	if err := client.PingServer(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(ua, "Go-http-client/") {
+		t.Errorf("expected Go's default User-Agent, got %q", ua)
+	}
+}
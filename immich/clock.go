@@ -0,0 +1,29 @@
+package immich
+
+import (
+	"context"
+	"time"
+)
+
+// ImmichClockInterface is not a part of the immich client interface to simplify the client mocks
+type ImmichClockInterface interface {
+	ClockSkew(ctx context.Context) (time.Duration, error)
+}
+
+var _ ImmichClockInterface = (*ImmichClient)(nil)
+
+// ClockSkew measures the difference between the server's clock and the local one,
+// using the Date header of the ping response. A positive value means the server is ahead.
+// The Date header has a precision of one second.
+func (ic *ImmichClient) ClockSkew(ctx context.Context) (time.Duration, error) {
+	var serverTime time.Time
+	r := PingResponse{}
+	start := time.Now()
+	err := ic.newServerCall(ctx, EndPointPingServer).do(getRequest("/server/ping", setAcceptJSON()), responseDate(&serverTime), responseJSON(&r))
+	if err != nil {
+		return 0, err
+	}
+	// the server has answered in the middle of the call
+	local := start.Add(time.Since(start) / 2)
+	return serverTime.Sub(local).Truncate(time.Second), nil
+}
@@ -0,0 +1,27 @@
+package immich
+
+import "context"
+
+// DuplicateDetection is the ID of the server's duplicate detection job
+const DuplicateDetection = "duplicateDetection"
+
+// DuplicateSet is a group of assets detected as duplicates by the server
+type DuplicateSet struct {
+	DuplicateID string   `json:"duplicateId"`
+	Assets      []*Asset `json:"assets"`
+}
+
+// ImmichDuplicateInterface is not a part of the immich client interface to simplify the client mocks
+type ImmichDuplicateInterface interface {
+	GetDuplicates(ctx context.Context) ([]DuplicateSet, error)
+}
+
+var _ ImmichDuplicateInterface = (*ImmichClient)(nil)
+
+// GetDuplicates returns the duplicate sets found by the server's duplicate detection job
+func (ic *ImmichClient) GetDuplicates(ctx context.Context) ([]DuplicateSet, error) {
+	var r []DuplicateSet
+	err := ic.newServerCall(ctx, EndPointGetDuplicates).
+		do(getRequest("/duplicates", setAcceptJSON()), responseJSON(&r))
+	return r, err
+}
@@ -84,6 +84,10 @@ type ImmichAlbumInterface interface {
 	// GetAssetAlbums get all albums that an asset belongs to
 	GetAssetAlbums(ctx context.Context, assetID string) ([]AlbumSimplified, error)
 	DeleteAlbum(ctx context.Context, id string) error
+	// UpdateAlbumCover sets the asset shown as the album's cover
+	UpdateAlbumCover(ctx context.Context, albumID string, assetID string) error
+	// UpdateAlbumSettings changes the description and the activity of the album
+	UpdateAlbumSettings(ctx context.Context, albumID string, settings AlbumSettings) error
 }
 type ImmichTagInterface interface {
 	GetAllTags(ctx context.Context) ([]TagSimplified, error)
@@ -166,7 +170,8 @@ func (t ImmichTime) MarshalJSON() ([]byte, error) {
 		return json.Marshal("")
 	}
 
-	return json.Marshal(t.Format("\"" + time.RFC3339 + "\""))
+	// the format read back by UnmarshalJSON
+	return json.Marshal(t.UTC().Format("2006-01-02T15:04:05.000Z"))
 }
 
 type ImmichExifTime struct {
@@ -219,5 +224,6 @@ func (t ImmichExifTime) MarshalJSON() ([]byte, error) {
 		return json.Marshal("")
 	}
 
-	return json.Marshal(t.Format("\"" + time.RFC3339 + "\""))
+	// the format read back by UnmarshalJSON
+	return json.Marshal(t.UTC().Format("2006-01-02T15:04:05.000Z"))
 }
@@ -60,3 +60,21 @@ func TestImmichExifTimeUnmarshalJSON_Invalid(t *testing.T) {
 		})
 	}
 }
+
+func TestImmichTimeRoundTrip(t *testing.T) {
+	want := immich.ImmichTime{Time: time.Date(2024, 6, 1, 12, 34, 56, 789_000_000, time.UTC)}
+	b, err := want.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `"2024-06-01T12:34:56.789Z"` {
+		t.Errorf("MarshalJSON() = %s", b)
+	}
+	var got immich.ImmichTime
+	if err := got.UnmarshalJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want.Time) {
+		t.Errorf("read back %s, want %s", got, want)
+	}
+}
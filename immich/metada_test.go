@@ -2,7 +2,13 @@ package immich
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
 	"testing"
 )
 
@@ -139,3 +145,56 @@ func Test_searchMetadataRequest(t *testing.T) {
 		t.Errorf("expecting next page, got: %d", rest.Assets.NextPage)
 	}
 }
+
+func TestSearchMetadataPages(t *testing.T) {
+	const total, size = 2500, 1000
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var q SearchMetadataQuery
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			t.Error(err)
+		}
+		resp := map[string]any{}
+		items := []map[string]string{}
+		for i := (q.Page - 1) * size; i < min(q.Page*size, total); i++ {
+			items = append(items, map[string]string{"id": strconv.Itoa(i)})
+		}
+		resp["items"] = items
+		resp["count"] = len(items)
+		if q.Page*size < total {
+			resp["nextPage"] = strconv.Itoa(q.Page + 1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"assets": resp})
+	}))
+	defer server.Close()
+
+	for _, concurrency := range []int{1, 2, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			requests.Store(0)
+			ic, err := NewImmichClient(server.URL, "1234", OptionFetchConcurrency(concurrency))
+			if err != nil {
+				t.Fatal(err)
+			}
+			received := 0
+			err = ic.callSearchMetadata(context.Background(), &SearchMetadataQuery{}, func(a *Asset) error {
+				if a.ID != strconv.Itoa(received) {
+					t.Fatalf("expected the asset %d, got %s", received, a.ID)
+				}
+				received++
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if received != total {
+				t.Errorf("expected %d assets, got %d", total, received)
+			}
+			// the pages after the last one are requested by the last window
+			if want := int32(((total/size)/concurrency + 1) * concurrency); requests.Load() != want {
+				t.Errorf("expected %d requests, got %d", want, requests.Load())
+			}
+		})
+	}
+}
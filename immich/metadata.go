@@ -31,6 +31,7 @@ type searchOptions struct {
 	withOnlyState    string             // got only assets taken in this state
 	withOnlyCity     string             // got only assets taken in this city
 	withOrder        string             // sort order: "asc (oldest first)" or "desc(newest first)"
+	updatedAfter     time.Time          // get only assets updated since this date
 
 	// following filters are resolved as ID
 	withAlbums []string // album ids
@@ -116,6 +117,12 @@ func (so *searchOptions) WithDateRange(dr cliflags.DateRange) *searchOptions {
 	return so
 }
 
+// to get only the assets updated since the date
+func (so *searchOptions) WithUpdatedAfter(t time.Time) *searchOptions {
+	so.updatedAfter = t
+	return so
+}
+
 // to get only favorite assets
 func (so *searchOptions) WithOnlyFavorite() *searchOptions {
 	so.withOnlyFavorite = true
@@ -198,6 +205,9 @@ func (ic *ImmichClient) buildSearchQueries(so *searchOptions) []SearchMetadataQu
 	if !so.takenRange.After.IsZero() {
 		base.TakenAfter = so.takenRange.After.Format(TimeFormat)
 	}
+	if !so.updatedAfter.IsZero() {
+		base.UpdatedAfter = so.updatedAfter.UTC().Format(TimeFormat)
+	}
 
 	base.Make = so.withOnlyMake
 	base.Model = so.withOnlyModel
@@ -279,6 +289,7 @@ type SearchMetadataQuery struct {
 	PersonIds        []string          `json:"personIds,omitempty"`
 	TakenBefore      string            `json:"takenBefore,omitzero"`
 	TakenAfter       string            `json:"takenAfter,omitzero"`
+	UpdatedAfter     string            `json:"updatedAfter,omitzero"`
 	TrashedAfter     string            `json:"trashedAfter,omitzero"`
 	TrashedBefore    string            `json:"trashedBefore,omitzero"`
 	Model            string            `json:"model,omitempty"`
@@ -296,6 +307,9 @@ type SearchMetadataQuery struct {
 func (ic *ImmichClient) callSearchMetadata(ctx context.Context, query *SearchMetadataQuery, filter func(*Asset) error) error {
 	query.Page = 1
 	query.Size = 1000
+	if ic.fetchConcurrency > 1 {
+		return ic.callSearchMetadataPages(ctx, query, filter)
+	}
 	for {
 		select {
 		case <-ctx.Done():
@@ -322,6 +336,40 @@ func (ic *ImmichClient) callSearchMetadata(ctx context.Context, query *SearchMet
 	}
 }
 
+// callSearchMetadataPages requests the pages of the search by windows of --fetch-concurrency pages.
+// The page numbers are known in advance, the pages after the last one come back empty.
+// The assets are given to the filter in the order of the pages.
+func (ic *ImmichClient) callSearchMetadataPages(ctx context.Context, query *SearchMetadataQuery, filter func(*Asset) error) error {
+	n := ic.fetchConcurrency
+	for first := query.Page; ; first += n {
+		pages := make([]searchMetadataResponse, n)
+		wg, wgCtx := errgroup.WithContext(ctx)
+		for i := range pages {
+			q := *query
+			q.Page = first + i
+			wg.Go(func() error {
+				return ic.newServerCall(wgCtx, EndPointGetAllAssets).do(postRequest("/search/metadata", "application/json", setJSONBody(&q), setAcceptJSON()), responseJSON(&pages[i]))
+			})
+		}
+		if err := wg.Wait(); err != nil {
+			return err
+		}
+		for _, resp := range pages {
+			for _, a := range resp.Assets.Items {
+				if err := filter(a); err != nil {
+					return err
+				}
+			}
+			if resp.Assets.NextPage == 0 {
+				return nil
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
 func (ic *ImmichClient) GetAllAssetsWithFilter(ctx context.Context, query *SearchMetadataQuery, filter func(*Asset) error) error {
 	if query == nil {
 		query = &SearchMetadataQuery{Page: 1, WithExif: true, WithDeleted: true}
@@ -0,0 +1,39 @@
+package immich
+
+import (
+	"context"
+	"io"
+)
+
+// UploadProgressFn receives the number of bytes of the file sent to the server, and the file size.
+// It is called from the goroutine writing the request body.
+type UploadProgressFn func(sent, size int64)
+
+type ctxUploadProgressKey struct{}
+
+// WithUploadProgress gives a context reporting the progress of the file uploaded with it
+func WithUploadProgress(ctx context.Context, fn UploadProgressFn) context.Context {
+	return context.WithValue(ctx, ctxUploadProgressKey{}, fn)
+}
+
+func uploadProgressFromContext(ctx context.Context) UploadProgressFn {
+	fn, _ := ctx.Value(ctxUploadProgressKey{}).(UploadProgressFn)
+	return fn
+}
+
+// progressReader counts the bytes read from the file
+type progressReader struct {
+	r    io.Reader
+	sent int64
+	size int64
+	fn   UploadProgressFn
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	if n > 0 {
+		pr.sent += int64(n)
+		pr.fn(pr.sent, pr.size)
+	}
+	return n, err
+}
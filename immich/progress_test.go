@@ -0,0 +1,35 @@
+package immich
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestProgressReader(t *testing.T) {
+	var sent, size int64
+	ctx := WithUploadProgress(context.Background(), func(s, sz int64) { sent, size = s, sz })
+	fn := uploadProgressFromContext(ctx)
+	if fn == nil {
+		t.Fatal("no progress function in the context")
+	}
+	if uploadProgressFromContext(context.Background()) != nil {
+		t.Error("unexpected progress function in the context")
+	}
+
+	pr := &progressReader{r: io.LimitReader(strings.NewReader(strings.Repeat("x", 100)), 100), size: 100, fn: fn}
+	b := make([]byte, 30)
+	if _, err := pr.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	if sent != 30 || size != 100 {
+		t.Errorf("got %d/%d, want 30/100", sent, size)
+	}
+	if _, err := io.Copy(io.Discard, pr); err != nil {
+		t.Fatal(err)
+	}
+	if sent != 100 {
+		t.Errorf("got %d sent, want 100", sent)
+	}
+}
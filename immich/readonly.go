@@ -0,0 +1,91 @@
+package immich
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrReadOnly is given for the requests refused by the client in read-only mode
+var ErrReadOnly = errors.New("request refused by the read-only mode")
+
+// IsReadOnly tells if the call has been refused by the read-only mode
+func IsReadOnly(err error) bool {
+	if errors.Is(err, ErrReadOnly) {
+		return true
+	}
+	var ce callError
+	return errors.As(err, &ce) && errors.Is(ce.err, ErrReadOnly)
+}
+
+// readOnlyPaths are the non-GET endpoints that don't change anything on the server
+var readOnlyPaths = []string{
+	"/api/search/",
+}
+
+// readOnlyTransport refuses the requests that could change the server, whatever the caller is.
+// It's the last barrier behind the dry-run checks of the client's methods.
+type readOnlyTransport struct {
+	next    http.RoundTripper
+	blocked *atomic.Int64
+	log     *slog.Logger
+}
+
+// isReadOnlyRequest tells if the request doesn't change the server
+func isReadOnlyRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	for _, p := range readOnlyPaths {
+		if strings.Contains(req.URL.Path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isReadOnlyRequest(req) {
+		return t.next.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	t.blocked.Add(1)
+	if t.log != nil {
+		t.log.Warn("request blocked by --read-only", "method", req.Method, "path", req.URL.Path)
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrReadOnly, req.Method, req.URL.Path)
+}
+
+// OptionReadOnly makes the client refuse, at the HTTP level, the requests that could change the server.
+// The refused requests are logged and counted.
+func OptionReadOnly(readOnly bool, l *slog.Logger) clientOption {
+	return func(ic *ImmichClient) error {
+		if readOnly {
+			ic.readOnly = &readOnlyTransport{blocked: &atomic.Int64{}, log: l}
+			ic.client.Transport = ic.guardTransport(ic.client.Transport)
+		}
+		return nil
+	}
+}
+
+// guardTransport puts the read-only barrier in front of the transport, when enabled
+func (ic *ImmichClient) guardTransport(rt http.RoundTripper) http.RoundTripper {
+	if ic.readOnly == nil {
+		return rt
+	}
+	return &readOnlyTransport{next: rt, blocked: ic.readOnly.blocked, log: ic.readOnly.log}
+}
+
+// BlockedRequests gives the number of requests refused by the read-only mode
+func (ic *ImmichClient) BlockedRequests() int64 {
+	if ic.readOnly == nil {
+		return 0
+	}
+	return ic.readOnly.blocked.Load()
+}
@@ -0,0 +1,56 @@
+package immich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ic, err := NewImmichClient(server.URL, "key", OptionReadOnly(true, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// reads go through, even the searches sent with POST
+	if err := ic.newServerCall(ctx, "get").do(getRequest("/albums")); err != nil {
+		t.Errorf("GET refused: %v", err)
+	}
+	if err := ic.newServerCall(ctx, "search").do(postRequest("/search/metadata", "application/json", setJSONBody(struct{}{}))); err != nil {
+		t.Errorf("search refused: %v", err)
+	}
+
+	// changes are refused, even when the dry-run isn't checked
+	for _, fn := range []requestFunction{
+		postRequest("/albums", "application/json", setJSONBody(struct{}{})),
+		putRequest("/jobs/thumbnailGeneration", setJSONBody(struct{}{})),
+		deleteRequest("/albums/a1"),
+	} {
+		err := ic.newServerCall(ctx, "change").do(fn)
+		if !IsReadOnly(err) {
+			t.Errorf("expected ErrReadOnly, got %v", err)
+		}
+	}
+	ic.EnableAppTrace(nil)
+	if err := ic.DeleteAlbum(ctx, "a2"); !IsReadOnly(err) {
+		t.Errorf("expected ErrReadOnly after resetting the transport, got %v", err)
+	}
+
+	if n := received.Load(); n != 2 {
+		t.Errorf("expected 2 requests received by the server, got %d", n)
+	}
+	if n := ic.BlockedRequests(); n != 4 {
+		t.Errorf("expected 4 blocked requests, got %d", n)
+	}
+}
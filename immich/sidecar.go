@@ -0,0 +1,25 @@
+package immich
+
+import (
+	"context"
+	"strings"
+
+	"github.com/simulot/immich-go/internal/assets"
+)
+
+type ctxNoSidecarKey struct{}
+
+// WithoutSidecar gives a context uploading the asset without its XMP sidecar
+func WithoutSidecar(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxNoSidecarKey{}, true)
+}
+
+func sidecarSkipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(ctxNoSidecarKey{}).(bool)
+	return skip
+}
+
+// HasXMPSidecar tells if the asset has a XMP sidecar to send with the file
+func HasXMPSidecar(la *assets.Asset) bool {
+	return la.FromSideCar != nil && strings.HasSuffix(strings.ToLower(la.FromSideCar.File.Name()), ".xmp")
+}
@@ -0,0 +1,70 @@
+package immich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/filetypes"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+func TestUploadSidecar(t *testing.T) {
+	var parts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts = nil
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+		}
+		for name := range r.MultipartForm.File {
+			parts = append(parts, name)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"1","status":"created"}`))
+	}))
+	defer server.Close()
+
+	ic, err := NewImmichClient(server.URL, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ic.supportedMediaTypes = filetypes.DefaultSupportedMedia
+
+	fsys := fstest.MapFS{
+		"photo.jpg": {Data: []byte("jpeg")},
+		"photo.xmp": {Data: []byte("<x:xmpmeta/>")},
+	}
+	newAsset := func() *assets.Asset {
+		a := &assets.Asset{File: fshelper.FSName(fsys, "photo.jpg"), OriginalFileName: "photo.jpg"}
+		a.FromSideCar = &assets.Metadata{File: fshelper.FSName(fsys, "photo.xmp")}
+		return a
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want int
+	}{
+		{"with sidecar", context.Background(), 2},
+		{"without sidecar", WithoutSidecar(context.Background()), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAsset()
+			defer a.Close()
+			if !HasXMPSidecar(a) {
+				t.Fatal("the asset should have a XMP sidecar")
+			}
+			if _, err := ic.AssetUpload(tt.ctx, a); err != nil {
+				t.Fatal(err)
+			}
+			if len(parts) != tt.want {
+				t.Errorf("expected %d file parts, got %q", tt.want, parts)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package immich
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// errorSimulator injects synthetic server errors in the upload calls.
+// It's a testing tool for the error handling of the callers: the
+// failing requests are never sent to the server.
+type errorSimulator struct {
+	lock sync.Mutex
+	rate float64
+	rnd  *rand.Rand
+}
+
+// OptionSimulateErrors makes the upload calls fail with a 503 status at the given rate, between 0 and 1.
+// The seed makes the sequence of failures reproducible. For testing only.
+func OptionSimulateErrors(rate float64, seed uint64) clientOption {
+	return func(ic *ImmichClient) error {
+		if rate <= 0 {
+			return nil
+		}
+		ic.errorSimulator = &errorSimulator{
+			rate: rate,
+			rnd:  rand.New(rand.NewPCG(seed, seed)), //nolint:gosec
+		}
+		return nil
+	}
+}
+
+// inject tells if the call must fail
+func (es *errorSimulator) inject(endPoint string) bool {
+	if es == nil || (endPoint != EndPointAssetUpload && endPoint != EndPointAssetReplace) {
+		return false
+	}
+	es.lock.Lock()
+	defer es.lock.Unlock()
+	return es.rnd.Float64() < es.rate
+}
+
+const simulatedErrorBody = `{"error":"Service Unavailable","statusCode":503,"message":"simulated server error"}`
+
+// simulatedResponse gives the response of a failing call
+func simulatedResponse(req *http.Request) *http.Response {
+	// the request body isn't read, close it as the transport would do
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return &http.Response{
+		Status:     "503 Service Unavailable",
+		StatusCode: http.StatusServiceUnavailable,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(simulatedErrorBody)),
+		Request:    req,
+	}
+}
@@ -0,0 +1,26 @@
+package immich
+
+import "context"
+
+// ServerStorage gives the disk usage of the server's storage
+type ServerStorage struct {
+	DiskAvailableRaw    int64   `json:"diskAvailableRaw"`
+	DiskSizeRaw         int64   `json:"diskSizeRaw"`
+	DiskUseRaw          int64   `json:"diskUseRaw"`
+	DiskUsagePercentage float64 `json:"diskUsagePercentage"`
+}
+
+// ImmichStorageInterface is not a part of the immich client interface to simplify the client mocks
+type ImmichStorageInterface interface {
+	GetServerStorage(ctx context.Context) (ServerStorage, error)
+}
+
+var _ ImmichStorageInterface = (*ImmichClient)(nil)
+
+// GetServerStorage returns the disk usage of the server's storage
+func (ic *ImmichClient) GetServerStorage(ctx context.Context) (ServerStorage, error) {
+	var s ServerStorage
+	err := ic.newServerCall(ctx, EndPointGetServerStorage).
+		do(getRequest("/server/storage", setAcceptJSON()), responseJSON(&s))
+	return s, err
+}
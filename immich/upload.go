@@ -54,7 +54,7 @@ func (ic *ImmichClient) uploadAsset(ctx context.Context, la *assets.Asset, endPo
 	switch mtype {
 	case "video", "image":
 	default:
-		return ar, fmt.Errorf("type file not supported: %s", path.Ext(la.OriginalFileName))
+		return ar, fmt.Errorf("%w: %s", ErrUnsupportedFormat, path.Ext(la.OriginalFileName))
 	}
 
 	f, err := la.OpenFile()
@@ -69,6 +69,10 @@ func (ic *ImmichClient) uploadAsset(ctx context.Context, la *assets.Asset, endPo
 	}
 
 	callValues := ic.prepareCallValues(la, s, ext, mtype)
+	var fileReader io.Reader = f
+	if fn := uploadProgressFromContext(ctx); fn != nil {
+		fileReader = &progressReader{r: f, size: s.Size(), fn: fn}
+	}
 	body, pw := io.Pipe()
 	m := multipart.NewWriter(pw)
 
@@ -86,13 +90,13 @@ func (ic *ImmichClient) uploadAsset(ctx context.Context, la *assets.Asset, endPo
 			return
 		}
 
-		gErr = ic.writeFilePart(m, f, la.OriginalFileName, mtype)
+		gErr = ic.writeFilePart(m, fileReader, la.OriginalFileName, mtype)
 		if gErr != nil {
 			errChan <- gErr
 			return
 		}
 
-		if la.FromSideCar != nil && strings.HasSuffix(strings.ToLower(la.FromSideCar.File.Name()), ".xmp") {
+		if HasXMPSidecar(la) && !sidecarSkipped(ctx) {
 			gErr = ic.writeSideCarPart(m, la)
 			if gErr != nil {
 				errChan <- gErr
@@ -135,12 +139,21 @@ func (ic *ImmichClient) prepareCallValues(la *assets.Asset, s fs.FileInfo, ext,
 	callValues["fileExtension"] = ext
 	callValues["duration"] = formatDuration(0)
 	callValues["isReadOnly"] = "false"
+	callValues["visibility"] = string(UploadVisibility(la))
+	return callValues
+}
+
+// UploadVisibility gives the visibility of the asset on the server: its own visibility when given,
+// the archive for an archived asset, the timeline otherwise. The assets of a locked folder go to the timeline.
+func UploadVisibility(la *assets.Asset) assets.Visibility {
+	switch la.Visibility {
+	case assets.VisibilityTimeline, assets.VisibilityArchive, assets.VisibilityHidden:
+		return la.Visibility
+	}
 	if la.Archived {
-		callValues["visibility"] = "archive"
-	} else {
-		callValues["visibility"] = "timeline"
+		return assets.VisibilityArchive
 	}
-	return callValues
+	return assets.VisibilityTimeline
 }
 
 func (ic *ImmichClient) writeMultipartFields(m *multipart.Writer, callValues map[string]string) error {
@@ -16,4 +16,6 @@ type User struct {
 	DeletedAt            time.Time `json:"deletedAt"`
 	UpdatedAt            time.Time `json:"updatedAt"`
 	OauthID              string    `json:"oauthId"`
+	QuotaSizeInBytes     int64     `json:"quotaSizeInBytes,omitempty"` // 0 when the user has no quota
+	QuotaUsageInBytes    int64     `json:"quotaUsageInBytes,omitempty"`
 }
@@ -43,6 +43,8 @@ type Asset struct {
 	ID       string    // Immich ID after upload
 	Checksum string    // Hash of the file as delivered by Immich
 
+	LocalChecksum string // Hash of the file with the local checksum algorithm, when it isn't SHA1
+
 	// Common fields
 	OriginalFileName string // File name as delivered to Immich/Google
 	Description      string // Google Photos may a have description
@@ -114,6 +116,12 @@ func (a *Asset) UseMetadata(md *Metadata) *Metadata {
 	a.FromPartner = md.FromPartner
 	a.Trashed = md.Trashed
 	a.Archived = md.Archived
+	if md.Visibility != VisibilityUnknown {
+		a.Visibility = md.Visibility
+	}
+	if md.Locked {
+		a.Visibility = VisibilityLocked
+	}
 	a.Favorite = md.Favorited
 	a.Rating = int(md.Rating)
 	a.MergeAlbums(md.Albums)
@@ -199,3 +207,39 @@ func (a *Asset) GetChecksum() (string, error) {
 	a.Checksum = sha1Hash
 	return a.Checksum, nil
 }
+
+// GetLocalChecksum returns the checksum of the asset computed with the given algorithm.
+// The server comparison always needs the SHA1 checksum: when it isn't known yet, it's computed in the same read of the file.
+func (a *Asset) GetLocalChecksum(algo hash.Algorithm) (string, error) {
+	if algo == hash.AlgoSHA1 {
+		return a.GetChecksum()
+	}
+	if a.LocalChecksum != "" {
+		return a.LocalChecksum, nil
+	}
+	if a.File.FS() == nil {
+		return "", errors.New("no file to compute checksum")
+	}
+
+	f, err := a.File.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if a.Checksum != "" {
+		localHash, err := hash.Base64Encode(algo.GetHash(f))
+		if err != nil {
+			return "", err
+		}
+		a.LocalChecksum = localHash
+		return a.LocalChecksum, nil
+	}
+	sums, err := hash.GetHashes(f, hash.AlgoSHA1, algo)
+	if err != nil {
+		return "", err
+	}
+	a.Checksum, _ = hash.Base64Encode(sums[0], nil)
+	a.LocalChecksum, _ = hash.Base64Encode(sums[1], nil)
+	return a.LocalChecksum, nil
+}
@@ -0,0 +1,79 @@
+package assets
+
+import (
+	"io/fs"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/simulot/immich-go/internal/fshelper"
+	"github.com/simulot/immich-go/internal/fshelper/hash"
+)
+
+// openCounter counts the files opened
+type openCounter struct {
+	fs.FS
+	opens atomic.Int64
+}
+
+func (c *openCounter) Open(name string) (fs.File, error) {
+	c.opens.Add(1)
+	return c.FS.Open(name)
+}
+
+func TestGetLocalChecksum(t *testing.T) {
+	for _, algo := range []hash.Algorithm{hash.AlgoBLAKE3, hash.AlgoXXHash} {
+		t.Run(algo.String(), func(t *testing.T) {
+			fsys := &openCounter{FS: fstest.MapFS{"photo.jpg": &fstest.MapFile{Data: []byte("abc")}}}
+			a := &Asset{File: fshelper.FSName(fsys, "photo.jpg")}
+			local, err := a.GetLocalChecksum(algo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if local == "" || a.LocalChecksum != local {
+				t.Errorf("LocalChecksum = %q, want %q", a.LocalChecksum, local)
+			}
+			sha1, err := a.GetChecksum()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sha1 != "qZk+NkcGgWq6PiVxeFDCbJzQ2J0=" || sha1 == local {
+				t.Errorf("GetChecksum() = %q", sha1)
+			}
+			// both checksums come from a single read of the file
+			if n := fsys.opens.Load(); n != 1 {
+				t.Errorf("the file is opened %d times, want 1", n)
+			}
+		})
+	}
+
+	// with SHA1, the local checksum is the server one
+	fsys := fstest.MapFS{"photo.jpg": &fstest.MapFile{Data: []byte("abc")}}
+	a := &Asset{File: fshelper.FSName(fsys, "photo.jpg")}
+	local, err := a.GetLocalChecksum(hash.AlgoSHA1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if local != a.Checksum || a.LocalChecksum != "" {
+		t.Errorf("GetLocalChecksum(sha1) = %q, Checksum = %q, LocalChecksum = %q", local, a.Checksum, a.LocalChecksum)
+	}
+}
+
+func BenchmarkChecksums(b *testing.B) {
+	data := make([]byte, 8<<20)
+	fsys := fstest.MapFS{"photo.jpg": &fstest.MapFile{Data: data}}
+	for _, algo := range []hash.Algorithm{hash.AlgoSHA1, hash.AlgoBLAKE3, hash.AlgoXXHash} {
+		b.Run(algo.String(), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for b.Loop() {
+				a := &Asset{File: fshelper.FSName(fsys, "photo.jpg")}
+				if _, err := a.GetLocalChecksum(algo); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := a.GetChecksum(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
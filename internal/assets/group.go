@@ -7,11 +7,12 @@ import (
 type GroupBy int
 
 const (
-	GroupByNone    GroupBy = iota
-	GroupByBurst           // Group by burst
-	GroupByRawJpg          // Group by raw/jpg
-	GroupByHeicJpg         // Group by heic/jpg
-	GroupByOther           // Group by other (same radical, not previous cases)
+	GroupByNone      GroupBy = iota
+	GroupByBurst             // Group by burst
+	GroupByRawJpg            // Group by raw/jpg
+	GroupByHeicJpg           // Group by heic/jpg
+	GroupByOther             // Group by other (same radical, not previous cases)
+	GroupByLivePhoto         // Group a live photo image with its video
 )
 
 type removed struct {
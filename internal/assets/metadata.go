@@ -23,6 +23,8 @@ type Metadata struct {
 	Rating      byte               `json:"rating,omitempty"`      // 0 to 5
 	Trashed     bool               `json:"trashed,omitempty"`     // Flag to indicate if the image has been trashed
 	Archived    bool               `json:"archived,omitempty"`    // Flag to indicate if the image has been archived
+	Locked      bool               `json:"locked,omitempty"`      // Flag to indicate if the image was in a locked folder
+	Visibility  Visibility         `json:"visibility,omitempty"`  // Immich visibility: timeline, archive or hidden
 	Favorited   bool               `json:"favorited,omitempty"`   // Flag to indicate if the image has been favorited
 	FromPartner bool               `json:"fromPartner,omitempty"` // Flag to indicate if the image is from a partner
 }
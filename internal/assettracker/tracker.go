@@ -297,6 +297,30 @@ func (at *AssetTracker) GetAllAssets() []AssetRecord {
 	return assets
 }
 
+// IsProcessed tells if the asset has already reached the PROCESSED state
+func (at *AssetTracker) IsProcessed(file fshelper.FSAndName) bool {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+
+	record, exists := at.assets[file.FullName()]
+	return exists && record.State == StateProcessed
+}
+
+// CountProcessed returns the number of assets processed with the event code.
+// Each asset is counted once, whatever the number of events recorded for it.
+func (at *AssetTracker) CountProcessed(eventCode fileevent.Code) int64 {
+	at.mu.RLock()
+	defer at.mu.RUnlock()
+
+	var n int64
+	for _, record := range at.assets {
+		if record.State == StateProcessed && record.EventCode == eventCode {
+			n++
+		}
+	}
+	return n
+}
+
 // IsComplete returns true if all assets have reached a final state
 func (at *AssetTracker) IsComplete() bool {
 	at.mu.RLock()
@@ -2,6 +2,7 @@ package assettracker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/fs"
@@ -457,3 +458,19 @@ func TestStatusMethods(t *testing.T) {
 		t.Errorf("expected error size 256, got %d", size)
 	}
 }
+
+func TestAssetCountersJSON(t *testing.T) {
+	b, err := json.Marshal(AssetCounters{Pending: 1, AssetSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"pending", "processed", "discarded", "errors", "asset_size", "processed_size", "discarded_size", "error_size", "pending_size"} {
+		if _, ok := m[k]; !ok {
+			t.Errorf("missing key %q in %s", k, b)
+		}
+	}
+}
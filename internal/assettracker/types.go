@@ -56,17 +56,17 @@ type EventRecord struct {
 // AssetCounters provides summary statistics for tracked assets
 type AssetCounters struct {
 	// Asset counts (images/videos tracked through lifecycle)
-	Pending   int64 // Assets not yet finalized
-	Processed int64 // Assets successfully handled
-	Discarded int64 // Assets skipped (immediate or during processing)
-	Errors    int64 // Assets that failed
+	Pending   int64 `json:"pending"`   // Assets not yet finalized
+	Processed int64 `json:"processed"` // Assets successfully handled
+	Discarded int64 `json:"discarded"` // Assets skipped (immediate or during processing)
+	Errors    int64 `json:"errors"`    // Assets that failed
 
 	// Asset size tracking
-	AssetSize     int64 // Total asset bytes (all states)
-	ProcessedSize int64 // Processed asset bytes
-	DiscardedSize int64 // Discarded asset bytes
-	ErrorSize     int64 // Errored asset bytes
-	PendingSize   int64 // Bytes pending
+	AssetSize     int64 `json:"asset_size"`     // Total asset bytes (all states)
+	ProcessedSize int64 `json:"processed_size"` // Processed asset bytes
+	DiscardedSize int64 `json:"discarded_size"` // Discarded asset bytes
+	ErrorSize     int64 `json:"error_size"`     // Errored asset bytes
+	PendingSize   int64 `json:"pending_size"`   // Bytes pending
 }
 
 // Total returns the total number of assets tracked
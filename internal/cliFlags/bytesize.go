@@ -0,0 +1,58 @@
+package cliflags
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes, given with an optional suffix: 500K, 10MB, 1.5G, 2TB.
+// The suffixes are multiple of 1024.
+type ByteSize int64
+
+var byteSizeUnits = []struct {
+	suffix string
+	value  int64
+}{
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+}
+
+// Implements the flag interface
+func (b *ByteSize) Set(s string) error {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	v = strings.TrimSuffix(strings.TrimSuffix(v, "IB"), "B")
+	if v == "" {
+		*b = 0
+		return nil
+	}
+	mult := int64(1)
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(v, u.suffix) {
+			mult = u.value
+			v = strings.TrimSpace(strings.TrimSuffix(v, u.suffix))
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		return fmt.Errorf("invalid size: %q, expected a number with an optional suffix K, M, G or T", s)
+	}
+	*b = ByteSize(f * float64(mult))
+	return nil
+}
+
+func (b ByteSize) String() string {
+	for _, u := range byteSizeUnits {
+		if b != 0 && int64(b)%u.value == 0 {
+			return fmt.Sprintf("%d%s", int64(b)/u.value, u.suffix)
+		}
+	}
+	return strconv.FormatInt(int64(b), 10)
+}
+
+func (b ByteSize) Type() string {
+	return "ByteSize"
+}
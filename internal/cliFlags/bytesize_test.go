@@ -0,0 +1,40 @@
+package cliflags
+
+import "testing"
+
+func TestByteSize_Set(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ByteSize
+		str     string
+		wantErr bool
+	}{
+		{in: "", want: 0, str: "0"},
+		{in: "1000", want: 1000, str: "1000"},
+		{in: "10k", want: 10 << 10, str: "10K"},
+		{in: "10KB", want: 10 << 10, str: "10K"},
+		{in: "1.5G", want: 3 << 29, str: "1536M"},
+		{in: "2 MiB", want: 2 << 20, str: "2M"},
+		{in: "1T", want: 1 << 40, str: "1T"},
+		{in: "-1", wantErr: true},
+		{in: "big", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var b ByteSize
+			err := b.Set(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if b != tt.want {
+				t.Errorf("Set(%q) = %d, want %d", tt.in, b, tt.want)
+			}
+			if b.String() != tt.str {
+				t.Errorf("String() = %q, want %q", b.String(), tt.str)
+			}
+		})
+	}
+}
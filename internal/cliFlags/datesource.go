@@ -0,0 +1,82 @@
+package cliflags
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// DateSource tells which capture date wins when the sidecar and the embedded metadata disagree
+type DateSource string
+
+const (
+	DateSourceSidecar DateSource = "sidecar" // the date of the sidecar (JSON or XMP)
+	DateSourceEXIF    DateSource = "exif"    // the date embedded in the file
+	DateSourceNewest  DateSource = "newest"  // the most recent of the two dates
+	DateSourceOldest  DateSource = "oldest"  // the oldest of the two dates
+)
+
+func (ds *DateSource) Set(s string) error {
+	s = strings.TrimSpace(strings.ToLower(s))
+	switch DateSource(s) {
+	case DateSourceSidecar, DateSourceEXIF, DateSourceNewest, DateSourceOldest:
+		*ds = DateSource(s)
+		return nil
+	default:
+		return fmt.Errorf("invalid value for --date-source: %q, expected exif, sidecar, newest or oldest", s)
+	}
+}
+
+func (ds *DateSource) Type() string {
+	return "DateSource"
+}
+
+func (ds *DateSource) String() string {
+	return string(*ds)
+}
+
+// DateSourceFlags governs the capture date when the file and its sidecar disagree
+type DateSourceFlags struct {
+	Source    DateSource
+	Threshold time.Duration // the dates differing by more than this are reported as a conflict
+}
+
+func (flags *DateSourceFlags) RegisterFlags(fs *pflag.FlagSet) {
+	flags.Source = DateSourceSidecar
+	fs.Var(&flags.Source, "date-source", "Capture date used when the sidecar and the file's embedded date disagree (exif|sidecar|newest|oldest). The embedded date is read only when it isn't sidecar")
+	fs.DurationVar(&flags.Threshold, "date-conflict-threshold", time.Minute, "The sidecar and embedded dates differing by more than this are reported as a conflict")
+}
+
+// NeedsEmbeddedDate tells if the date embedded in the file must be read to be compared with the sidecar's one
+func (flags DateSourceFlags) NeedsEmbeddedDate() bool {
+	return flags.Source != "" && flags.Source != DateSourceSidecar
+}
+
+// Resolve gives the capture date from the sidecar's and the embedded dates, and tells
+// if they differ by more than the threshold. A missing date gives the other one.
+func (flags DateSourceFlags) Resolve(sidecar, embedded time.Time) (time.Time, bool) {
+	switch {
+	case sidecar.IsZero():
+		return embedded, false
+	case embedded.IsZero():
+		return sidecar, false
+	}
+	d := sidecar.Sub(embedded)
+	conflict := d > flags.Threshold || d < -flags.Threshold
+
+	switch flags.Source {
+	case DateSourceEXIF:
+		return embedded, conflict
+	case DateSourceNewest:
+		if embedded.After(sidecar) {
+			return embedded, conflict
+		}
+	case DateSourceOldest:
+		if embedded.Before(sidecar) {
+			return embedded, conflict
+		}
+	}
+	return sidecar, conflict
+}
@@ -0,0 +1,43 @@
+package cliflags
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateSourceResolve(t *testing.T) {
+	sidecar := time.Date(2023, 7, 14, 10, 0, 0, 0, time.UTC)
+	embedded := sidecar.Add(3 * time.Hour)
+
+	tests := []struct {
+		source            DateSource
+		sidecar, embedded time.Time
+		expected          time.Time
+		expectedConflict  bool
+	}{
+		{DateSourceSidecar, sidecar, embedded, sidecar, true},
+		{DateSourceEXIF, sidecar, embedded, embedded, true},
+		{DateSourceNewest, sidecar, embedded, embedded, true},
+		{DateSourceOldest, sidecar, embedded, sidecar, true},
+		{DateSourceEXIF, sidecar, sidecar.Add(30 * time.Second), sidecar.Add(30 * time.Second), false},
+		{DateSourceEXIF, time.Time{}, embedded, embedded, false},
+		{DateSourceEXIF, sidecar, time.Time{}, sidecar, false},
+	}
+	for _, tt := range tests {
+		flags := DateSourceFlags{Source: tt.source, Threshold: time.Minute}
+		got, conflict := flags.Resolve(tt.sidecar, tt.embedded)
+		if !got.Equal(tt.expected) || conflict != tt.expectedConflict {
+			t.Errorf("%s: Resolve(%s, %s) = %s, %v, expected %s, %v", tt.source, tt.sidecar, tt.embedded, got, conflict, tt.expected, tt.expectedConflict)
+		}
+	}
+}
+
+func TestDateSourceSet(t *testing.T) {
+	var ds DateSource
+	if err := ds.Set("EXIF"); err != nil || ds != DateSourceEXIF {
+		t.Errorf("Set(EXIF) = %v, %q", err, ds)
+	}
+	if err := ds.Set("camera"); err == nil {
+		t.Errorf("Set(camera) accepted")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/simulot/immich-go/internal/fileevent"
 	"github.com/simulot/immich-go/internal/filetypes"
 	"github.com/spf13/pflag"
 )
@@ -14,13 +15,34 @@ type InclusionFlags struct {
 	IncludedExtensions ExtensionList
 	IncludedType       IncludeType
 	DateRange          DateRange
+	MinSize            ByteSize // Files smaller than this are discarded
+	MaxSize            ByteSize // Files bigger than this are discarded, 0 for no limit
 }
 
 func (flags *InclusionFlags) RegisterFlags(fs *pflag.FlagSet, prefix string) {
 	fs.Var(&flags.DateRange, prefix+"date-range", "Only import photos taken within the specified date range")
 	fs.Var(&flags.ExcludedExtensions, prefix+"exclude-extensions", "Comma-separated list of extension to exclude. (e.g. .gif,.PM) (default: none)")
-	fs.Var(&flags.IncludedExtensions, prefix+"include-extensions", "Comma-separated list of extension to include. (e.g. .jpg,.heic) (default: all)")
+	fs.Var(&flags.IncludedExtensions, prefix+"include-extensions", "Comma-separated list of extension to include. (e.g. .jpg,.heic) (default: the media types supported by the server)")
+	// Short forms, sharing the same lists
+	fs.Var(&flags.ExcludedExtensions, prefix+"exclude-ext", "Extension to exclude, can be repeated (same as --"+prefix+"exclude-extensions)")
+	fs.Var(&flags.IncludedExtensions, prefix+"include-ext", "Extension to include, can be repeated (same as --"+prefix+"include-extensions)")
 	fs.Var(&flags.IncludedType, prefix+"include-type", "Single file type to include. (VIDEO or IMAGE) (default: all)")
+	fs.Var(&flags.MinSize, prefix+"min-size", "Discard the files smaller than this size (e.g. 10K, 1.5M)")
+	fs.Var(&flags.MaxSize, prefix+"max-size", "Discard the files bigger than this size (e.g. 2G), 0 for no limit")
+}
+
+// CheckSize gives the event and the reason when a file must be discarded because of its size.
+// Empty files are always discarded.
+func (flags *InclusionFlags) CheckSize(size int64) (fileevent.Code, string, bool) {
+	switch {
+	case size == 0:
+		return fileevent.DiscardedEmptyFile, "empty file", false
+	case size < int64(flags.MinSize):
+		return fileevent.DiscardedBySize, "smaller than --min-size", false
+	case flags.MaxSize > 0 && size > int64(flags.MaxSize):
+		return fileevent.DiscardedBySize, "bigger than --max-size", false
+	}
+	return fileevent.NotHandled, "", true
 }
 
 // An IncludeType is either of the constants below which
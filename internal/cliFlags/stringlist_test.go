@@ -247,3 +247,18 @@ func TestStringList_IncludeType_Set(t *testing.T) {
 		})
 	}
 }
+
+func TestStringList_Repeated(t *testing.T) {
+	var sl ExtensionList
+	_ = sl.Set("JPG,.heic")
+	_ = sl.Set(".MP4")
+	sl = sl.Validate()
+
+	want := ExtensionList{".jpg", ".heic", ".mp4"}
+	if !slices.Equal(sl, want) {
+		t.Errorf("got %v, want %v", sl, want)
+	}
+	if !sl.Include(".Mp4") {
+		t.Errorf("the extension .Mp4 should be included")
+	}
+}
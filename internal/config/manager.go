@@ -6,6 +6,7 @@ package config
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"strings"
 
@@ -169,6 +170,12 @@ func getViperKey(cmd *cobra.Command, f *pflag.Flag) string {
 	}
 }
 
+// GetBool returns the boolean value of a key, whatever its source.
+// It gives access to keys that aren't bound to a flag.
+func (cm *ConfigurationManager) GetBool(key string) bool {
+	return cm.v.GetBool(key)
+}
+
 // GetFlagOrigin returns the origin source of a flag's value.
 // Possible origins are: "cli", "environment", "config file", or "default".
 func (cm *ConfigurationManager) GetFlagOrigin(cmd *cobra.Command, flag *pflag.Flag) string {
@@ -190,3 +197,21 @@ func (cm *ConfigurationManager) GetConfigFile() string {
 func (cm *ConfigurationManager) Save(fileName string) error {
 	return cm.v.WriteConfigAs(fileName)
 }
+
+// SaveAs writes the current configuration to the specified file, without the keys for which skip returns true.
+// An existing file is kept unless overwrite is true, the error wraps fs.ErrExist.
+func (cm *ConfigurationManager) SaveAs(fileName string, overwrite bool, skip func(key string) bool) error {
+	if !overwrite {
+		if _, err := os.Stat(fileName); err == nil {
+			return fmt.Errorf("%s: %w", fileName, fs.ErrExist)
+		}
+	}
+	v := viper.New()
+	for _, key := range cm.v.AllKeys() {
+		if skip != nil && skip(key) {
+			continue
+		}
+		v.Set(key, cm.v.Get(key))
+	}
+	return v.WriteConfigAs(fileName)
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -330,3 +331,45 @@ func TestConfigurationManager_ProcessCommand_Idempotent(t *testing.T) {
 	assert.NoError(t, err2)
 	assert.True(t, cm.processed)
 }
+
+func TestGetBool(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "immich-go.yaml")
+	err := os.WriteFile(file, []byte("no_banner: true\n"), 0o644)
+	require.NoError(t, err)
+
+	cm := New()
+	err = cm.Init(file)
+	require.NoError(t, err)
+
+	assert.True(t, cm.GetBool("no_banner"))
+	assert.False(t, cm.GetBool("unknown_key"))
+}
+
+func TestSaveAs(t *testing.T) {
+	cm := New()
+	err := cm.Init("")
+	require.NoError(t, err)
+
+	cm.v.Set("upload.api-key", "secret")
+	cm.v.Set("upload.server", "http://localhost:2283")
+
+	tempFile := filepath.Join(t.TempDir(), "test.yaml")
+	skip := func(key string) bool { return strings.HasSuffix(key, "api-key") }
+	err = cm.SaveAs(tempFile, false, skip)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(tempFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "localhost:2283")
+	assert.NotContains(t, string(content), "secret")
+
+	// the existing file is kept
+	err = cm.SaveAs(tempFile, false, nil)
+	assert.ErrorIs(t, err, fs.ErrExist)
+
+	err = cm.SaveAs(tempFile, true, nil)
+	require.NoError(t, err)
+	content, err = os.ReadFile(tempFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "secret")
+}
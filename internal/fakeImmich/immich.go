@@ -77,6 +77,14 @@ func (c *MockedCLient) DeleteAlbum(ctx context.Context, id string) error {
 	return nil
 }
 
+func (c *MockedCLient) UpdateAlbumCover(ctx context.Context, albumID string, assetID string) error {
+	return nil
+}
+
+func (c *MockedCLient) UpdateAlbumSettings(ctx context.Context, albumID string, settings immich.AlbumSettings) error {
+	return nil
+}
+
 func (c *MockedCLient) SupportedMedia() filetypes.SupportedMedia {
 	return filetypes.DefaultSupportedMedia
 }
@@ -12,8 +12,13 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
+
+	"github.com/simulot/immich-go/internal/filetypes"
 )
 
 /*
@@ -46,17 +51,29 @@ const (
 	// ===== Asset Lifecycle Events - To PROCESSED =====
 	ProcessedUploadSuccess   // Asset successfully uploaded
 	ProcessedUploadUpgraded  // Server asset upgraded with input
+	ProcessedAssetReplaced   // Server asset replaced by the changed input, with --replace-existing
 	ProcessedMetadataUpdated // Asset metadata updated on server
 	ProcessedFileArchived    // Asset successfully archived to disk
 
 	// ===== Asset Lifecycle Events - To DISCARDED =====
-	DiscardedServerDuplicate // Server already has this asset
-	DiscardedBanned          // Asset with banned filename
-	DiscardedUnsupported     // Asset with unsupported format (deprecated, use DiscoveredUnsupported)
-	DiscardedFiltered        // Asset filtered out by user settings
-	DiscardedLocalDuplicate  // Duplicate asset in input
-	DiscardedNotSelected     // Asset not selected for processing
-	DiscardedServerBetter    // Server has better version of asset
+	DiscardedServerDuplicate   // Server already has this asset
+	DiscardedBanned            // Asset with banned filename
+	DiscardedUnsupported       // Asset with unsupported format (deprecated, use DiscoveredUnsupported)
+	DiscardedFiltered          // Asset filtered out by user settings
+	DiscardedLocalDuplicate    // Duplicate asset in input
+	DiscardedNotSelected       // Asset not selected for processing
+	DiscardedServerBetter      // Server has better version of asset
+	DiscardedByIncremental     // Asset not modified since the last run
+	DiscardedAlreadyArchived   // Asset already present in the archive
+	DiscardedNoServerMatch     // No server asset to update with --metadata-only
+	DiscardedByExtension       // Asset extension not included or excluded
+	DiscardedBySize            // Asset out of the --min-size and --max-size limits
+	DiscardedEmptyFile         // Asset file is empty
+	DiscardedEditedPolicy      // Original or edited version discarded by --edited-photos
+	DiscardedMotionVideo       // Video part of a motion photo, exported next to the image, discarded by --skip-motion-videos
+	DiscardedUnsupportedFormat // Asset rejected by the server because of its format
+	DiscardedByPathExclude     // File or folder matching an --exclude-path pattern, skipped during the walk
+	DiscardedInExcludeAlbum    // Asset present in the server's album given by --skip-if-in-album
 
 	// ===== Asset Lifecycle Events - To ERROR =====
 	ErrorUploadFailed // Upload failed
@@ -68,10 +85,22 @@ const (
 	// These don't change asset state
 	ProcessedAssociatedMetadata // Metadata file associated with asset
 	ProcessedMissingMetadata    // Expected metadata file missing
+	ProcessedOrphanSidecar      // Metadata file whose media is missing
 	ProcessedStacked            // Asset added to stack
+	ProcessedStackCreated       // Stack created on the server with the assets of a group, recorded with its cover
 	ProcessedAlbumAdded         // Asset added to album
+	ProcessedSharedAlbumAdded   // Asset added to an album shared by another user
 	ProcessedTagged             // Asset tagged
 	ProcessedLivePhoto          // Live photo processed
+	ProcessedTrashed            // Trashed asset handled (archived to the trash bucket or trashed on the server)
+	ProcessedArchived           // Asset imported with the archived state
+	ProcessedLocked             // Asset coming from a locked folder
+	ProcessedDescriptionSet     // Asset description set from the sidecar
+	ProcessedGPSFromSidecar     // Asset GPS coordinates set from the sidecar
+	ProcessedSidecarAttached    // XMP sidecar sent to the server with the asset
+	ProcessedDateConflict       // The sidecar and the embedded metadata disagree on the capture date
+	ProcessedUploadAttempt      // Upload request sent to the server, a retried asset gives several attempts
+	ProcessedNameCollision      // Another file with the same name was sent to the same album
 
 	MaxCode
 )
@@ -93,17 +122,29 @@ var _code = map[Code]string{
 	// To PROCESSED
 	ProcessedUploadSuccess:   "uploaded successfully",
 	ProcessedUploadUpgraded:  "server asset upgraded",
+	ProcessedAssetReplaced:   "server asset replaced",
 	ProcessedMetadataUpdated: "metadata updated",
 	ProcessedFileArchived:    "file archived",
 
 	// To DISCARDED
-	DiscardedServerDuplicate: "server has duplicate",
-	DiscardedBanned:          "discarded banned",
-	DiscardedUnsupported:     "discarded unsupported",
-	DiscardedFiltered:        "discarded filtered",
-	DiscardedLocalDuplicate:  "discarded local duplicate",
-	DiscardedNotSelected:     "discarded not selected",
-	DiscardedServerBetter:    "discarded server better",
+	DiscardedServerDuplicate:   "server has duplicate",
+	DiscardedBanned:            "discarded banned",
+	DiscardedUnsupported:       "discarded unsupported",
+	DiscardedFiltered:          "discarded filtered",
+	DiscardedLocalDuplicate:    "discarded local duplicate",
+	DiscardedNotSelected:       "discarded not selected",
+	DiscardedServerBetter:      "discarded server better",
+	DiscardedByIncremental:     "discarded not modified since last run",
+	DiscardedAlreadyArchived:   "discarded already archived",
+	DiscardedNoServerMatch:     "discarded no server match",
+	DiscardedByExtension:       "discarded by extension",
+	DiscardedBySize:            "discarded by size",
+	DiscardedEmptyFile:         "discarded empty file",
+	DiscardedEditedPolicy:      "discarded by edited photos policy",
+	DiscardedMotionVideo:       "discarded motion photo video",
+	DiscardedUnsupportedFormat: "discarded unsupported by server",
+	DiscardedByPathExclude:     "discarded by path exclusion",
+	DiscardedInExcludeAlbum:    "discarded in exclude album",
 
 	// To ERROR
 	ErrorUploadFailed: "upload failed",
@@ -114,10 +155,22 @@ var _code = map[Code]string{
 	// Processing Events
 	ProcessedAssociatedMetadata: "associated metadata",
 	ProcessedMissingMetadata:    "missing metadata",
+	ProcessedOrphanSidecar:      "orphan sidecar",
 	ProcessedStacked:            "stacked",
+	ProcessedStackCreated:       "stack created",
 	ProcessedAlbumAdded:         "added to album",
+	ProcessedSharedAlbumAdded:   "added to shared album",
 	ProcessedTagged:             "tagged",
 	ProcessedLivePhoto:          "live photo",
+	ProcessedTrashed:            "trashed",
+	ProcessedArchived:           "archived",
+	ProcessedLocked:             "locked folder",
+	ProcessedDescriptionSet:     "description set",
+	ProcessedGPSFromSidecar:     "GPS from sidecar",
+	ProcessedSidecarAttached:    "sidecar attached",
+	ProcessedDateConflict:       "date conflict",
+	ProcessedUploadAttempt:      "upload attempt",
+	ProcessedNameCollision:      "name collision",
 }
 
 var _logLevels = map[Code]slog.Level{
@@ -137,17 +190,29 @@ var _logLevels = map[Code]slog.Level{
 	// To PROCESSED
 	ProcessedUploadSuccess:   slog.LevelInfo,
 	ProcessedUploadUpgraded:  slog.LevelInfo,
+	ProcessedAssetReplaced:   slog.LevelInfo,
 	ProcessedMetadataUpdated: slog.LevelInfo,
 	ProcessedFileArchived:    slog.LevelInfo,
 
 	// To DISCARDED
-	DiscardedServerDuplicate: slog.LevelInfo,
-	DiscardedBanned:          slog.LevelWarn,
-	DiscardedUnsupported:     slog.LevelWarn,
-	DiscardedFiltered:        slog.LevelWarn,
-	DiscardedLocalDuplicate:  slog.LevelWarn,
-	DiscardedNotSelected:     slog.LevelWarn,
-	DiscardedServerBetter:    slog.LevelInfo,
+	DiscardedServerDuplicate:   slog.LevelInfo,
+	DiscardedBanned:            slog.LevelWarn,
+	DiscardedUnsupported:       slog.LevelWarn,
+	DiscardedFiltered:          slog.LevelWarn,
+	DiscardedLocalDuplicate:    slog.LevelWarn,
+	DiscardedNotSelected:       slog.LevelWarn,
+	DiscardedServerBetter:      slog.LevelInfo,
+	DiscardedByIncremental:     slog.LevelDebug,
+	DiscardedAlreadyArchived:   slog.LevelDebug,
+	DiscardedNoServerMatch:     slog.LevelWarn,
+	DiscardedByExtension:       slog.LevelInfo,
+	DiscardedBySize:            slog.LevelInfo,
+	DiscardedEmptyFile:         slog.LevelWarn,
+	DiscardedEditedPolicy:      slog.LevelInfo,
+	DiscardedMotionVideo:       slog.LevelInfo,
+	DiscardedUnsupportedFormat: slog.LevelWarn,
+	DiscardedByPathExclude:     slog.LevelInfo,
+	DiscardedInExcludeAlbum:    slog.LevelInfo,
 
 	// To ERROR
 	ErrorUploadFailed: slog.LevelError,
@@ -158,10 +223,22 @@ var _logLevels = map[Code]slog.Level{
 	// Processing Events
 	ProcessedAssociatedMetadata: slog.LevelInfo,
 	ProcessedMissingMetadata:    slog.LevelWarn,
+	ProcessedOrphanSidecar:      slog.LevelWarn,
 	ProcessedStacked:            slog.LevelInfo,
+	ProcessedStackCreated:       slog.LevelInfo,
 	ProcessedAlbumAdded:         slog.LevelInfo,
+	ProcessedSharedAlbumAdded:   slog.LevelInfo,
 	ProcessedTagged:             slog.LevelInfo,
 	ProcessedLivePhoto:          slog.LevelInfo,
+	ProcessedTrashed:            slog.LevelInfo,
+	ProcessedArchived:           slog.LevelInfo,
+	ProcessedLocked:             slog.LevelInfo,
+	ProcessedDescriptionSet:     slog.LevelInfo,
+	ProcessedGPSFromSidecar:     slog.LevelInfo,
+	ProcessedSidecarAttached:    slog.LevelInfo,
+	ProcessedDateConflict:       slog.LevelWarn,
+	ProcessedUploadAttempt:      slog.LevelDebug,
+	ProcessedNameCollision:      slog.LevelWarn,
 }
 
 func (e Code) String() string {
@@ -171,12 +248,107 @@ func (e Code) String() string {
 	return fmt.Sprintf("unknown event code: %d", int(e))
 }
 
+// _codeIDs gives the stable identifier of each code, the key of the events in the JSON summary.
+// Unlike the text given by String, it doesn't change with the wording of the report.
+var _codeIDs = map[Code]string{
+	NotHandled: "not_handled",
+
+	// Discovery - Assets
+	DiscoveredImage: "discovered_image",
+	DiscoveredVideo: "discovered_video",
+
+	// Discovery - Non-Assets
+	DiscoveredSidecar:     "discovered_sidecar",
+	DiscoveredMetadata:    "discovered_metadata",
+	DiscoveredUnknown:     "discovered_unknown",
+	DiscoveredBanned:      "discovered_banned",
+	DiscoveredUnsupported: "discovered_unsupported",
+
+	// To PROCESSED
+	ProcessedUploadSuccess:   "processed_upload_success",
+	ProcessedUploadUpgraded:  "processed_upload_upgraded",
+	ProcessedAssetReplaced:   "processed_asset_replaced",
+	ProcessedMetadataUpdated: "processed_metadata_updated",
+	ProcessedFileArchived:    "processed_file_archived",
+
+	// To DISCARDED
+	DiscardedServerDuplicate:   "discarded_server_duplicate",
+	DiscardedBanned:            "discarded_banned",
+	DiscardedUnsupported:       "discarded_unsupported",
+	DiscardedFiltered:          "discarded_filtered",
+	DiscardedLocalDuplicate:    "discarded_local_duplicate",
+	DiscardedNotSelected:       "discarded_not_selected",
+	DiscardedServerBetter:      "discarded_server_better",
+	DiscardedByIncremental:     "discarded_by_incremental",
+	DiscardedAlreadyArchived:   "discarded_already_archived",
+	DiscardedNoServerMatch:     "discarded_no_server_match",
+	DiscardedByExtension:       "discarded_by_extension",
+	DiscardedBySize:            "discarded_by_size",
+	DiscardedEmptyFile:         "discarded_empty_file",
+	DiscardedEditedPolicy:      "discarded_edited_policy",
+	DiscardedMotionVideo:       "discarded_motion_video",
+	DiscardedUnsupportedFormat: "discarded_unsupported_format",
+	DiscardedByPathExclude:     "discarded_by_path_exclude",
+	DiscardedInExcludeAlbum:    "discarded_in_exclude_album",
+
+	// To ERROR
+	ErrorUploadFailed: "error_upload_failed",
+	ErrorServerError:  "error_server_error",
+	ErrorFileAccess:   "error_file_access",
+	ErrorIncomplete:   "error_incomplete",
+
+	// Processing Events
+	ProcessedAssociatedMetadata: "processed_associated_metadata",
+	ProcessedMissingMetadata:    "processed_missing_metadata",
+	ProcessedOrphanSidecar:      "processed_orphan_sidecar",
+	ProcessedStacked:            "processed_stacked",
+	ProcessedStackCreated:       "processed_stack_created",
+	ProcessedAlbumAdded:         "processed_album_added",
+	ProcessedSharedAlbumAdded:   "processed_shared_album_added",
+	ProcessedTagged:             "processed_tagged",
+	ProcessedLivePhoto:          "processed_live_photo",
+	ProcessedTrashed:            "processed_trashed",
+	ProcessedArchived:           "processed_archived",
+	ProcessedLocked:             "processed_locked",
+	ProcessedDescriptionSet:     "processed_description_set",
+	ProcessedGPSFromSidecar:     "processed_gps_from_sidecar",
+	ProcessedSidecarAttached:    "processed_sidecar_attached",
+	ProcessedDateConflict:       "processed_date_conflict",
+	ProcessedUploadAttempt:      "processed_upload_attempt",
+	ProcessedNameCollision:      "processed_name_collision",
+}
+
+// ID gives the stable snake_case identifier of the code, for the machine readable outputs
+func (e Code) ID() string {
+	if s, ok := _codeIDs[e]; ok {
+		return s
+	}
+	return fmt.Sprintf("unknown_%d", int(e))
+}
+
 type Recorder struct {
 	counts counts
 	sizes  counts // Size tracking for each event code
 	log    *slog.Logger
+
+	lock         sync.Mutex
+	accessErrors []string          // the files and folders that can't be read
+	unmapped     map[string]int64  // the unknown keys of the sidecar files, by key
+	byType       map[string]Totals // the transferred assets, by media type
+
+	ownedAlbums  int64 // the albums found in the source, owned by the user
+	sharedAlbums int64 // the albums found in the source, shared with other users
+}
+
+// Totals gives a number of files and their size
+type Totals struct {
+	Count int64
+	Size  int64
 }
 
+// maxListedAccessErrors is the number of access errors listed in the report, the others are in the log
+const maxListedAccessErrors = 20
+
 type counts []int64
 
 func NewRecorder(l *slog.Logger) *Recorder {
@@ -201,6 +373,14 @@ func (r *Recorder) RecordWithSize(ctx context.Context, code Code, file slog.LogV
 	if fileSize > 0 {
 		atomic.AddInt64(&r.sizes[code], fileSize)
 	}
+	if code == ErrorFileAccess && file != nil {
+		r.lock.Lock()
+		r.accessErrors = append(r.accessErrors, file.LogValue().String())
+		r.lock.Unlock()
+	}
+	if slices.Contains(transferredCodes, code) {
+		r.recordType(file, fileSize)
+	}
 	if r.log != nil {
 		level := _logLevels[code]
 		if file != nil {
@@ -261,6 +441,129 @@ func (r *Recorder) TotalAssets() int64 {
 	return atomic.LoadInt64(&r.counts[DiscoveredImage]) + atomic.LoadInt64(&r.counts[DiscoveredVideo])
 }
 
+// TotalErrors returns the number of errors recorded so far
+func (r *Recorder) TotalErrors() int64 {
+	var n int64
+	for _, c := range []Code{ErrorUploadFailed, ErrorServerError, ErrorFileAccess, ErrorIncomplete} {
+		n += atomic.LoadInt64(&r.counts[c])
+	}
+	return n
+}
+
+// AccessErrors returns the files and folders that can't be read
+func (r *Recorder) AccessErrors() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return slices.Clone(r.accessErrors)
+}
+
+// RecordUnmappedField records a key of the sidecar file that isn't mapped to a metadata field
+func (r *Recorder) RecordUnmappedField(ctx context.Context, file slog.LogValuer, key string) {
+	r.lock.Lock()
+	if r.unmapped == nil {
+		r.unmapped = map[string]int64{}
+	}
+	r.unmapped[key]++
+	r.lock.Unlock()
+	if r.log != nil {
+		r.log.Log(ctx, slog.LevelDebug, "unmapped sidecar field", "file", file.LogValue(), "key", key)
+	}
+}
+
+// RecordSourceAlbum counts an album found in the source, owned or shared with other users
+func (r *Recorder) RecordSourceAlbum(shared bool) {
+	if shared {
+		atomic.AddInt64(&r.sharedAlbums, 1)
+		return
+	}
+	atomic.AddInt64(&r.ownedAlbums, 1)
+}
+
+// SourceAlbums returns the number of owned and shared albums found in the source
+func (r *Recorder) SourceAlbums() (owned, shared int64) {
+	return atomic.LoadInt64(&r.ownedAlbums), atomic.LoadInt64(&r.sharedAlbums)
+}
+
+// UnmappedFields returns the number of occurrences of the sidecar keys that aren't mapped, by key
+func (r *Recorder) UnmappedFields() map[string]int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return maps.Clone(r.unmapped)
+}
+
+// transferredCodes are the events of the assets uploaded to the server or downloaded from it
+var transferredCodes = []Code{ProcessedUploadSuccess, ProcessedUploadUpgraded, ProcessedAssetReplaced, ProcessedFileArchived}
+
+// recordType counts the transferred asset by its media type, given by the file's extension
+func (r *Recorder) recordType(file slog.LogValuer, fileSize int64) {
+	t := "other"
+	if f, ok := file.(interface{ Name() string }); ok {
+		if mt := filetypes.DefaultSupportedMedia.TypeFromName(f.Name()); mt == filetypes.TypeImage || mt == filetypes.TypeVideo {
+			t = mt
+		}
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.byType == nil {
+		r.byType = map[string]Totals{}
+	}
+	tt := r.byType[t]
+	tt.Count++
+	tt.Size += fileSize
+	r.byType[t] = tt
+}
+
+// TypeTotals returns the number and the size of the transferred assets, by media type: image, video or other
+func (r *Recorder) TypeTotals() map[string]Totals {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return maps.Clone(r.byType)
+}
+
+// duplicateCodes are the events of the assets not transferred because the destination already has them
+var duplicateCodes = []Code{DiscardedServerDuplicate, DiscardedLocalDuplicate, DiscardedServerBetter, DiscardedAlreadyArchived}
+
+// DuplicateTotals returns the number and the size of the assets not transferred because the destination already has them
+func (r *Recorder) DuplicateTotals() (int64, int64) {
+	var n, size int64
+	for _, c := range duplicateCodes {
+		n += atomic.LoadInt64(&r.counts[c])
+		size += atomic.LoadInt64(&r.sizes[c])
+	}
+	return n, size
+}
+
+// skipReasons groups the events of the discarded assets by reason, in the order of the report
+var skipReasons = []struct {
+	reason string
+	codes  []Code
+}{
+	{"duplicate", duplicateCodes},
+	{"filter", []Code{DiscardedFiltered, DiscardedNotSelected}},
+	{"extension", []Code{DiscardedByExtension, DiscardedUnsupported, DiscardedUnsupportedFormat}},
+	{"size", []Code{DiscardedBySize, DiscardedEmptyFile}},
+	{"excluded", []Code{DiscardedBanned, DiscardedByPathExclude, DiscardedInExcludeAlbum}},
+	{"unchanged", []Code{DiscardedByIncremental}},
+	{"other", []Code{DiscardedNoServerMatch, DiscardedEditedPolicy, DiscardedMotionVideo}},
+}
+
+// SkippedBreakdown returns the number and the size of the discarded assets, by reason.
+// The reasons without discarded assets are left out.
+func (r *Recorder) SkippedBreakdown() map[string]Totals {
+	breakdown := map[string]Totals{}
+	for _, sr := range skipReasons {
+		var t Totals
+		for _, c := range sr.codes {
+			t.Count += atomic.LoadInt64(&r.counts[c])
+			t.Size += atomic.LoadInt64(&r.sizes[c])
+		}
+		if t.Count > 0 {
+			breakdown[sr.reason] = t
+		}
+	}
+	return breakdown
+}
+
 // GenerateEventReport creates a comprehensive report of all events
 func (r *Recorder) GenerateEventReport() string {
 	sb := strings.Builder{}
@@ -300,7 +603,7 @@ func (r *Recorder) GenerateEventReport() string {
 
 	// Asset Lifecycle - To PROCESSED
 	hasProcessed := false
-	for _, c := range []Code{ProcessedUploadSuccess, ProcessedUploadUpgraded, ProcessedMetadataUpdated, ProcessedFileArchived} {
+	for _, c := range []Code{ProcessedUploadSuccess, ProcessedUploadUpgraded, ProcessedAssetReplaced, ProcessedMetadataUpdated, ProcessedFileArchived} {
 		if eventCounts[c] > 0 {
 			hasProcessed = true
 			break
@@ -308,7 +611,7 @@ func (r *Recorder) GenerateEventReport() string {
 	}
 	if hasProcessed {
 		sb.WriteString("\nAsset Lifecycle (PROCESSED):\n")
-		for _, c := range []Code{ProcessedUploadSuccess, ProcessedUploadUpgraded, ProcessedMetadataUpdated, ProcessedFileArchived} {
+		for _, c := range []Code{ProcessedUploadSuccess, ProcessedUploadUpgraded, ProcessedAssetReplaced, ProcessedMetadataUpdated, ProcessedFileArchived} {
 			if count := eventCounts[c]; count > 0 {
 				if size := eventSizes[c]; size > 0 {
 					sb.WriteString(fmt.Sprintf("  %-35s: %7d  (%s)\n", c.String(), count, formatEventBytes(size)))
@@ -329,6 +632,17 @@ func (r *Recorder) GenerateEventReport() string {
 		DiscardedLocalDuplicate,
 		DiscardedNotSelected,
 		DiscardedServerBetter,
+		DiscardedByIncremental,
+		DiscardedAlreadyArchived,
+		DiscardedNoServerMatch,
+		DiscardedByExtension,
+		DiscardedBySize,
+		DiscardedEmptyFile,
+		DiscardedEditedPolicy,
+		DiscardedMotionVideo,
+		DiscardedUnsupportedFormat,
+		DiscardedByPathExclude,
+		DiscardedInExcludeAlbum,
 	} {
 		if eventCounts[c] > 0 {
 			hasDiscarded = true
@@ -345,6 +659,17 @@ func (r *Recorder) GenerateEventReport() string {
 			DiscardedLocalDuplicate,
 			DiscardedNotSelected,
 			DiscardedServerBetter,
+			DiscardedByIncremental,
+			DiscardedAlreadyArchived,
+			DiscardedNoServerMatch,
+			DiscardedByExtension,
+			DiscardedBySize,
+			DiscardedEmptyFile,
+			DiscardedEditedPolicy,
+			DiscardedMotionVideo,
+			DiscardedUnsupportedFormat,
+			DiscardedByPathExclude,
+			DiscardedInExcludeAlbum,
 		} {
 			if count := eventCounts[c]; count > 0 {
 				if size := eventSizes[c]; size > 0 {
@@ -356,6 +681,29 @@ func (r *Recorder) GenerateEventReport() string {
 		}
 	}
 
+	if breakdown := r.SkippedBreakdown(); len(breakdown) > 0 {
+		sb.WriteString("\nSkipped by reason:\n")
+		for _, sr := range skipReasons {
+			if t, ok := breakdown[sr.reason]; ok {
+				sb.WriteString(fmt.Sprintf("  %-35s: %7d  (%s)\n", sr.reason, t.Count, formatEventBytes(t.Size)))
+			}
+		}
+	}
+
+	if byType := r.TypeTotals(); len(byType) > 0 {
+		sb.WriteString("\nTransferred by type:\n")
+		for _, t := range []string{filetypes.TypeImage, filetypes.TypeVideo, "other"} {
+			if tt, ok := byType[t]; ok {
+				sb.WriteString(fmt.Sprintf("  %-35s: %7d  (%s)\n", t, tt.Count, formatEventBytes(tt.Size)))
+			}
+		}
+	}
+
+	if n, size := r.DuplicateTotals(); n > 0 {
+		sb.WriteString("\nDuplicates (not transferred):\n")
+		sb.WriteString(fmt.Sprintf("  %-35s: %7d  (%s)\n", "total", n, formatEventBytes(size)))
+	}
+
 	// Asset Lifecycle - To ERROR
 	hasErrors := false
 	for _, c := range []Code{ErrorUploadFailed, ErrorServerError, ErrorFileAccess, ErrorIncomplete} {
@@ -368,20 +716,60 @@ func (r *Recorder) GenerateEventReport() string {
 		sb.WriteString("\nAsset Lifecycle (ERROR):\n")
 		for _, c := range []Code{ErrorUploadFailed, ErrorServerError, ErrorFileAccess, ErrorIncomplete} {
 			if count := eventCounts[c]; count > 0 {
-				sb.WriteString(fmt.Sprintf("  %-35s: %7d\n", c.String(), count))
+				if size := eventSizes[c]; size > 0 {
+					sb.WriteString(fmt.Sprintf("  %-35s: %7d  (%s)\n", c.String(), count, formatEventBytes(size)))
+				} else {
+					sb.WriteString(fmt.Sprintf("  %-35s: %7d\n", c.String(), count))
+				}
+			}
+		}
+	}
+
+	if paths := r.AccessErrors(); len(paths) > 0 {
+		sb.WriteString("\nFile access errors:\n")
+		for i, p := range paths {
+			if i == maxListedAccessErrors {
+				sb.WriteString(fmt.Sprintf("  ... and %d more, see the log\n", len(paths)-i))
+				break
 			}
+			sb.WriteString("  " + p + "\n")
 		}
 	}
 
+	if fields := r.UnmappedFields(); len(fields) > 0 {
+		sb.WriteString("\nUnmapped sidecar fields:\n")
+		for _, k := range slices.Sorted(maps.Keys(fields)) {
+			sb.WriteString(fmt.Sprintf("  %-35s: %7d\n", k, fields[k]))
+		}
+	}
+
+	if owned, shared := r.SourceAlbums(); owned+shared > 0 {
+		sb.WriteString("\nAlbums found in the source:\n")
+		sb.WriteString(fmt.Sprintf("  %-35s: %7d\n", "owned", owned))
+		sb.WriteString(fmt.Sprintf("  %-35s: %7d\n", "shared", shared))
+	}
+
 	// Processing Events
 	hasProcessingEvents := false
 	for _, c := range []Code{
 		ProcessedAssociatedMetadata,
 		ProcessedMissingMetadata,
+		ProcessedOrphanSidecar,
 		ProcessedStacked,
+		ProcessedStackCreated,
 		ProcessedAlbumAdded,
+		ProcessedSharedAlbumAdded,
 		ProcessedTagged,
 		ProcessedLivePhoto,
+		ProcessedTrashed,
+		ProcessedArchived,
+		ProcessedLocked,
+		ProcessedDescriptionSet,
+		ProcessedGPSFromSidecar,
+		ProcessedSidecarAttached,
+		ProcessedDateConflict,
+		ProcessedUploadAttempt,
+		ProcessedNameCollision,
 	} {
 		if eventCounts[c] > 0 {
 			hasProcessingEvents = true
@@ -393,10 +781,22 @@ func (r *Recorder) GenerateEventReport() string {
 		for _, c := range []Code{
 			ProcessedAssociatedMetadata,
 			ProcessedMissingMetadata,
+			ProcessedOrphanSidecar,
 			ProcessedStacked,
+			ProcessedStackCreated,
 			ProcessedAlbumAdded,
+			ProcessedSharedAlbumAdded,
 			ProcessedTagged,
 			ProcessedLivePhoto,
+			ProcessedTrashed,
+			ProcessedArchived,
+			ProcessedLocked,
+			ProcessedDescriptionSet,
+			ProcessedGPSFromSidecar,
+			ProcessedSidecarAttached,
+			ProcessedDateConflict,
+			ProcessedUploadAttempt,
+			ProcessedNameCollision,
 		} {
 			if count := eventCounts[c]; count > 0 {
 				sb.WriteString(fmt.Sprintf("  %-35s: %7d\n", c.String(), count))
@@ -4,8 +4,11 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/simulot/immich-go/internal/fshelper"
 )
 
 func TestRecorderSizeTracking(t *testing.T) {
@@ -45,6 +48,39 @@ func TestRecorderSizeTracking(t *testing.T) {
 	}
 }
 
+func TestRecorderLifecycleSizes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	recorder := NewRecorder(logger)
+
+	ctx := context.Background()
+
+	recorder.RecordWithSize(ctx, ProcessedUploadSuccess, nil, 1024, "test", "uploaded1")
+	recorder.RecordWithSize(ctx, ProcessedUploadSuccess, nil, 2048, "test", "uploaded2")
+	recorder.RecordWithSize(ctx, DiscardedServerDuplicate, nil, 4096, "test", "duplicate")
+	recorder.RecordWithSize(ctx, DiscardedFiltered, nil, 512, "test", "filtered")
+	recorder.RecordWithSize(ctx, ErrorServerError, nil, 256, "test", "error")
+
+	eventSizes := recorder.GetEventSizes()
+	for c, want := range map[Code]int64{
+		ProcessedUploadSuccess:   3072,
+		DiscardedServerDuplicate: 4096,
+		DiscardedFiltered:        512,
+		ErrorServerError:         256,
+	} {
+		if got := eventSizes[c]; got != want {
+			t.Errorf("Expected %d bytes for %s, got %d", want, c, got)
+		}
+	}
+
+	report := recorder.GenerateEventReport()
+	if !strings.Contains(report, "(3.0 KB)") {
+		t.Error("Report should show the size of uploaded assets")
+	}
+	if !strings.Contains(report, "(256 B)") {
+		t.Error("Report should show the size of assets in error")
+	}
+}
+
 func TestRecordBackwardCompatibility(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 	recorder := NewRecorder(logger)
@@ -175,3 +211,102 @@ func TestGetEventCountsMap(t *testing.T) {
 		t.Error("Should not have entry for DiscoveredSidecar")
 	}
 }
+
+func TestTotalErrors(t *testing.T) {
+	recorder := NewRecorder(nil)
+	ctx := context.Background()
+
+	recorder.Record(ctx, ErrorUploadFailed, nil)
+	recorder.Record(ctx, ErrorServerError, nil)
+	recorder.Record(ctx, ErrorFileAccess, nil)
+	recorder.Record(ctx, ProcessedUploadSuccess, nil)
+
+	if got := recorder.TotalErrors(); got != 3 {
+		t.Errorf("Expected 3 errors, got %d", got)
+	}
+}
+
+func TestDuplicateTotals(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	recorder := NewRecorder(logger)
+	ctx := context.Background()
+
+	if !strings.Contains(recorder.GenerateEventReport(), "No events") {
+		t.Error("Empty recorder should report no events")
+	}
+
+	recorder.RecordWithSize(ctx, DiscardedServerDuplicate, nil, 1000)
+	recorder.RecordWithSize(ctx, DiscardedLocalDuplicate, nil, 200)
+	recorder.RecordWithSize(ctx, DiscardedServerBetter, nil, 30)
+	recorder.RecordWithSize(ctx, DiscardedFiltered, nil, 4)
+
+	n, size := recorder.DuplicateTotals()
+	if n != 3 || size != 1230 {
+		t.Errorf("Expected 3 duplicates of 1230 bytes, got %d / %d", n, size)
+	}
+	if report := recorder.GenerateEventReport(); !strings.Contains(report, "Duplicates (not transferred):") {
+		t.Errorf("Report should contain the duplicates section:\n%s", report)
+	}
+}
+
+func TestUnmappedFields(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	recorder := NewRecorder(logger)
+	ctx := context.Background()
+	file := fshelper.FSName(nil, "photo.jpg.json")
+
+	if len(recorder.UnmappedFields()) != 0 {
+		t.Error("Empty recorder should have no unmapped fields")
+	}
+
+	recorder.Record(ctx, DiscoveredSidecar, file)
+	recorder.RecordUnmappedField(ctx, file, "newField")
+	recorder.RecordUnmappedField(ctx, file, "newField")
+	recorder.RecordUnmappedField(ctx, file, "otherField")
+
+	fields := recorder.UnmappedFields()
+	if fields["newField"] != 2 || fields["otherField"] != 1 {
+		t.Errorf("Expected newField: 2 and otherField: 1, got %v", fields)
+	}
+	if report := recorder.GenerateEventReport(); !strings.Contains(report, "Unmapped sidecar fields:") {
+		t.Errorf("Report should contain the unmapped fields section:\n%s", report)
+	}
+}
+
+func TestSourceAlbums(t *testing.T) {
+	recorder := NewRecorder(nil)
+
+	recorder.Record(context.Background(), DiscoveredSidecar, fshelper.FSName(nil, "metadata.json"))
+	recorder.RecordSourceAlbum(false)
+	recorder.RecordSourceAlbum(true)
+	recorder.RecordSourceAlbum(false)
+
+	if owned, shared := recorder.SourceAlbums(); owned != 2 || shared != 1 {
+		t.Errorf("Expected 2 owned and 1 shared albums, got %d and %d", owned, shared)
+	}
+	if report := recorder.GenerateEventReport(); !strings.Contains(report, "Albums found in the source:") {
+		t.Errorf("Report should contain the albums section:\n%s", report)
+	}
+}
+
+func TestCodeIDs(t *testing.T) {
+	snake := regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	seen := map[string]Code{}
+	for c := NotHandled; c < MaxCode; c++ {
+		id, ok := _codeIDs[c]
+		if !ok {
+			t.Errorf("code %q has no ID", c)
+			continue
+		}
+		if !snake.MatchString(id) {
+			t.Errorf("code %q: the ID %q isn't snake_case", c, id)
+		}
+		if other, ok := seen[id]; ok {
+			t.Errorf("codes %q and %q have the same ID %q", other, c, id)
+		}
+		seen[id] = c
+	}
+	if id := ProcessedUploadSuccess.ID(); id != "processed_upload_success" {
+		t.Errorf("ProcessedUploadSuccess.ID() = %q, the IDs must not change", id)
+	}
+}
@@ -3,6 +3,8 @@ package fileprocessor
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/simulot/immich-go/internal/assettracker"
 	"github.com/simulot/immich-go/internal/fileevent"
@@ -12,8 +14,23 @@ import (
 // FileProcessor coordinates AssetTracker and EventLogger to provide
 // a unified interface for tracking file processing lifecycle.
 type FileProcessor struct {
-	tracker *assettracker.AssetTracker
-	logger  *fileevent.Recorder
+	tracker   *assettracker.AssetTracker
+	logger    *fileevent.Recorder
+	processed sync.Map // processedEvent of the assets, recorded once
+
+	orphansChecked atomic.Bool // the source reports its orphans, given in the summary
+}
+
+// processedEvent is an event recorded for a processed asset
+type processedEvent struct {
+	file string
+	code fileevent.Code
+}
+
+// CheckOrphans tells that the source looks for its orphan sidecars and media, like a takeout.
+// The summary then gives their counts, even when there is none.
+func (fp *FileProcessor) CheckOrphans() {
+	fp.orphansChecked.Store(true)
 }
 
 // New creates a new FileProcessor with the given tracker and logger
@@ -51,11 +68,25 @@ func (fp *FileProcessor) RecordAssetDiscardedImmediately(ctx context.Context, fi
 
 // RecordAssetProcessed transitions an asset to PROCESSED state.
 // The state change is tracked and the event is logged.
+// The same event of an asset, like a retried upload succeeding twice, isn't counted again.
+// Another event of an asset already processed, like the upgrade of the server's asset, is logged,
+// the asset keeps its state.
 func (fp *FileProcessor) RecordAssetProcessed(ctx context.Context, file fshelper.FSAndName, size int64, code fileevent.Code) {
-	fp.tracker.SetProcessed(file, code)
+	if _, seen := fp.processed.LoadOrStore(processedEvent{file: file.FullName(), code: code}, struct{}{}); seen {
+		return
+	}
+	if !fp.tracker.IsProcessed(file) {
+		fp.tracker.SetProcessed(file, code)
+	}
 	fp.logger.RecordWithSize(ctx, code, file, size)
 }
 
+// RecordUploadAttempt counts a request sending the asset to the server.
+// The asset's state doesn't change: the attempts are counted apart from the successes.
+func (fp *FileProcessor) RecordUploadAttempt(ctx context.Context, file fshelper.FSAndName, size int64) {
+	fp.logger.RecordWithSize(ctx, fileevent.ProcessedUploadAttempt, file, size)
+}
+
 // RecordAssetDiscarded transitions an asset to DISCARDED state.
 // The state change is tracked and the event is logged with the reason.
 func (fp *FileProcessor) RecordAssetDiscarded(ctx context.Context, file fshelper.FSAndName, size int64, code fileevent.Code, reason string) {
@@ -63,6 +94,17 @@ func (fp *FileProcessor) RecordAssetDiscarded(ctx context.Context, file fshelper
 	fp.logger.RecordWithSize(ctx, code, file, size, "reason", reason)
 }
 
+// DiscardPending transitions the assets still pending to DISCARDED state, like the ones
+// discovered but never processed when --limit stops the browsing.
+// It must be called once the processing is over. It returns the number of discarded assets.
+func (fp *FileProcessor) DiscardPending(ctx context.Context, code fileevent.Code, reason string) int {
+	pending := fp.tracker.GetPending()
+	for _, a := range pending {
+		fp.RecordAssetDiscarded(ctx, a.File, a.FileSize, code, reason)
+	}
+	return len(pending)
+}
+
 // RecordAssetError transitions an asset to ERROR state.
 // The state change is tracked and the error event is logged.
 func (fp *FileProcessor) RecordAssetError(ctx context.Context, file fshelper.FSAndName, size int64, code fileevent.Code, err error) {
@@ -143,6 +143,10 @@ func TestRecordAssetProcessed(t *testing.T) {
 	if eventCounts[fileevent.ProcessedUploadSuccess] != 1 {
 		t.Errorf("Expected 1 UploadedSuccess event, got %d", eventCounts[fileevent.ProcessedUploadSuccess])
 	}
+	eventSizes := fp.GetEventSizes()
+	if eventSizes[fileevent.ProcessedUploadSuccess] != 1024 {
+		t.Errorf("Expected 1024 bytes for UploadedSuccess, got %d", eventSizes[fileevent.ProcessedUploadSuccess])
+	}
 }
 
 func TestRecordAssetDiscarded(t *testing.T) {
@@ -177,6 +181,10 @@ func TestRecordAssetDiscarded(t *testing.T) {
 	if eventCounts[fileevent.DiscardedLocalDuplicate] != 1 {
 		t.Errorf("Expected 1 DiscardedLocalDuplicate event, got %d", eventCounts[fileevent.DiscardedLocalDuplicate])
 	}
+	eventSizes := fp.GetEventSizes()
+	if eventSizes[fileevent.DiscardedLocalDuplicate] != 2048 {
+		t.Errorf("Expected 2048 bytes for DiscardedLocalDuplicate, got %d", eventSizes[fileevent.DiscardedLocalDuplicate])
+	}
 }
 
 func TestRecordAssetError(t *testing.T) {
@@ -504,3 +512,46 @@ func TestCompleteWorkflow(t *testing.T) {
 		t.Errorf("Expected 1 DiscardedBanned event (asset), got %d", eventCounts[fileevent.DiscardedBanned])
 	}
 }
+
+func TestDiscardPending(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	fp := New(assettracker.New(), fileevent.NewRecorder(logger))
+	ctx := context.Background()
+
+	done := newTestFile("/test/done.jpg")
+	fp.RecordAssetDiscovered(ctx, done, 100, fileevent.DiscoveredImage)
+	fp.RecordAssetProcessed(ctx, done, 100, fileevent.ProcessedUploadSuccess)
+	fp.RecordAssetDiscovered(ctx, newTestFile("/test/left1.jpg"), 200, fileevent.DiscoveredImage)
+	fp.RecordAssetDiscovered(ctx, newTestFile("/test/left2.jpg"), 300, fileevent.DiscoveredImage)
+
+	if n := fp.DiscardPending(ctx, fileevent.DiscardedFiltered, "--limit reached"); n != 2 {
+		t.Errorf("Expected 2 discarded assets, got %d", n)
+	}
+	counters := fp.GetAssetCounters()
+	if counters.Pending != 0 || counters.Processed != 1 || counters.Discarded != 2 || counters.DiscardedSize != 500 {
+		t.Errorf("Unexpected counters: %+v", counters)
+	}
+	if !fp.IsComplete() {
+		t.Error("The processing should be complete")
+	}
+}
+
+func TestRecordAssetProcessedOtherCode(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	fp := New(assettracker.New(), fileevent.NewRecorder(logger))
+
+	ctx := context.Background()
+	file := newTestFile("/test/upgraded.jpg")
+	fp.RecordAssetDiscovered(ctx, file, 1000, fileevent.DiscoveredImage)
+	fp.RecordAssetProcessed(ctx, file, 1000, fileevent.DiscardedServerDuplicate)
+	fp.RecordAssetProcessed(ctx, file, 1000, fileevent.ProcessedUploadUpgraded)
+	fp.RecordAssetProcessed(ctx, file, 1000, fileevent.ProcessedUploadUpgraded)
+
+	counts := fp.GetEventCounts()
+	if counts[fileevent.DiscardedServerDuplicate] != 1 || counts[fileevent.ProcessedUploadUpgraded] != 1 {
+		t.Errorf("server duplicate: %d, upgraded: %d, want 1 and 1", counts[fileevent.DiscardedServerDuplicate], counts[fileevent.ProcessedUploadUpgraded])
+	}
+	if c := fp.GetAssetCounters(); c.Processed != 1 || c.Pending != 0 {
+		t.Errorf("unexpected counters: %+v", c)
+	}
+}
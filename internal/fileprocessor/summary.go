@@ -0,0 +1,187 @@
+package fileprocessor
+
+import (
+	"github.com/simulot/immich-go/internal/assettracker"
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+// Types of the RunSummary
+const (
+	SummaryFinal      = "summary"    // written when the run ends
+	SummaryCheckpoint = "checkpoint" // written during the run by --checkpoint-interval
+)
+
+// RunSummary is a machine readable summary of a run.
+// It is built from the same counters as the text report.
+type RunSummary struct {
+	Type       string                     `json:"type,omitempty"`   // summary for the final summary, checkpoint for the ones written during the run
+	RunID      string                     `json:"run_id,omitempty"` // identifies the run, to aggregate the outputs of concurrent runs
+	Command    string                     `json:"command"`
+	ConfigFile string                     `json:"config_file,omitempty"` // the configuration file used by the run, if any
+	Status     string                     `json:"status"`
+	Error      string                     `json:"error,omitempty"`
+	Limit      int                        `json:"limit,omitempty"` // the --limit cap on the number of assets, the run didn't read the whole input
+	Assets     assettracker.AssetCounters `json:"assets"`
+	Events     map[string]EventSummary    `json:"events"`
+
+	// ByType gives the number and the size of the transferred assets by media type: image, video or other
+	ByType map[string]EventSummary `json:"by_type,omitempty"`
+
+	// Uploaded is the number of assets uploaded, each asset is counted once.
+	// UploadAttempts is the number of upload requests, greater when some uploads were retried.
+	Uploaded       int64 `json:"uploaded"`
+	UploadAttempts int64 `json:"upload_attempts"`
+
+	// SkippedBreakdown gives the number and the size of the discarded assets by reason:
+	// duplicate, filter, extension, size, excluded, unchanged or other
+	SkippedBreakdown map[string]EventSummary `json:"skipped_breakdown,omitempty"`
+
+	// Duplicates gives the number and the size of the assets not transferred because the destination already has them
+	Duplicates EventSummary `json:"duplicates"`
+
+	// SuppressedLogRecords counts the context canceled errors dropped from the log.
+	// A clean interruption gives some of them, not a storm of real errors.
+	SuppressedLogRecords int64 `json:"suppressed_log_records"`
+
+	// UnmappedSidecarFields counts the keys of the sidecar files not mapped to a metadata field, with --warn-unmapped.
+	// New keys show a change of the takeout format.
+	UnmappedSidecarFields map[string]int64 `json:"unmapped_sidecar_fields,omitempty"`
+
+	// OrphanSidecars counts the sidecar files whose media is missing from the source.
+	// OrphanMedia counts the media without sidecar file, that got no metadata from it.
+	// Only the sources checking their orphans, like the takeouts, give them.
+	OrphanSidecars *int64 `json:"orphan_sidecars,omitempty"`
+	OrphanMedia    *int64 `json:"orphan_media,omitempty"`
+
+	// SourceAlbums gives the number of albums found in the source, owned by the user or shared with other users
+	SourceAlbums *AlbumCounts `json:"source_albums,omitempty"`
+
+	// ManifestFile is the checksum manifest written by the archive command, with --manifest
+	ManifestFile string `json:"manifest_file,omitempty"`
+
+	// PeakMemory is the highest memory used by the process during the run, in bytes
+	PeakMemory uint64 `json:"peak_memory,omitempty"`
+
+	// MemoryThrottled is the number of times the work has waited for the memory to drop under --max-memory
+	MemoryThrottled int64 `json:"memory_throttled,omitempty"`
+
+	// BlockedRequests is the number of requests refused by --read-only, nil without it
+	BlockedRequests *int64 `json:"blocked_requests,omitempty"`
+
+	// InvalidMedia is the number of truncated or corrupt files not uploaded, with --validate-media
+	InvalidMedia int64 `json:"invalid_media,omitempty"`
+
+	// MergedAlbums gives the albums whose names were merged by --album-name-match
+	MergedAlbums []MergedAlbumSummary `json:"merged_albums,omitempty"`
+
+	// ClockSkew is the difference between the server's clock and the local one, when measured.
+	ClockSkew string `json:"clock_skew,omitempty"`
+
+	// Visibility gives the number of assets uploaded by the run, by visibility: timeline, archive or hidden
+	Visibility map[string]int64 `json:"visibility,omitempty"`
+
+	// Storage compares the space needed by the upload with the space available on the server, when known
+	Storage *StorageSummary `json:"storage,omitempty"`
+
+	// DuplicateSetsFound is the number of duplicate sets known by the server after the upload, with --run-dedup
+	DuplicateSetsFound *int `json:"duplicate_sets_found,omitempty"`
+
+	// AlbumCovers gives the covers set on the created albums, with --set-album-cover
+	AlbumCovers *AlbumCoversSummary `json:"album_covers,omitempty"`
+
+	// Stacks gives the stacks created during the upload, like with --stack-on-upload
+	Stacks *StacksSummary `json:"stacks,omitempty"`
+
+	// CreatedAlbums gives the albums created by the run, with their server ID.
+	// The existing albums that received assets are listed too when requested.
+	CreatedAlbums []AlbumSummary `json:"created_albums,omitempty"`
+}
+
+// StorageSummary gives the space available for the upload, and the space needed by it, in bytes
+type StorageSummary struct {
+	Source    string `json:"source"`          // user quota or server disk
+	Available int64  `json:"available"`       // the space available when the upload started
+	Required  int64  `json:"required"`        // the size of the assets to upload
+	Input     int64  `json:"input,omitempty"` // the size of the input files, checked before the upload
+}
+
+// AlbumCoversSummary gives the number of album covers set, set with a fallback member, or not set
+type AlbumCoversSummary struct {
+	Set      int `json:"set"`
+	Fallback int `json:"fallback"`
+	Failed   int `json:"failed"`
+}
+
+// StacksSummary gives the number of stacks created during the upload, and of the ones the server refused
+type StacksSummary struct {
+	Created int64 `json:"created"`
+	Failed  int64 `json:"failed"`
+}
+
+// AlbumSummary gives an album updated by the run
+type AlbumSummary struct {
+	Name       string `json:"name"`
+	ID         string `json:"id"`
+	AssetCount int    `json:"asset_count"`        // the assets added by the run
+	Existing   bool   `json:"existing,omitempty"` // the album was already on the server
+}
+
+// MergedAlbumSummary gives the name kept for an album, and the other names merged into it
+type MergedAlbumSummary struct {
+	Name   string   `json:"name"`
+	Merged []string `json:"merged"`
+}
+
+// EventSummary gives the number of events of a kind and the size of the related files
+type EventSummary struct {
+	Count int64 `json:"count"`
+	Size  int64 `json:"size"`
+}
+
+// AlbumCounts gives the number of owned and shared albums
+type AlbumCounts struct {
+	Owned  int64 `json:"owned"`
+	Shared int64 `json:"shared"`
+}
+
+// RunSummary builds the summary of the run from the tracker and the event counters
+func (fp *FileProcessor) RunSummary(command string, status string, err error) RunSummary {
+	s := RunSummary{
+		Command: command,
+		Status:  status,
+		Assets:  fp.tracker.GetCounters(),
+		Events:  map[string]EventSummary{},
+	}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.Uploaded = fp.tracker.CountProcessed(fileevent.ProcessedUploadSuccess)
+	counts := fp.logger.GetEventCounts()
+	s.UploadAttempts = counts[fileevent.ProcessedUploadAttempt]
+	s.Duplicates.Count, s.Duplicates.Size = fp.logger.DuplicateTotals()
+	s.UnmappedSidecarFields = fp.logger.UnmappedFields()
+	if fp.orphansChecked.Load() {
+		sidecars, media := counts[fileevent.ProcessedOrphanSidecar], counts[fileevent.ProcessedMissingMetadata]
+		s.OrphanSidecars, s.OrphanMedia = &sidecars, &media
+	}
+	if owned, shared := fp.logger.SourceAlbums(); owned+shared > 0 {
+		s.SourceAlbums = &AlbumCounts{Owned: owned, Shared: shared}
+	}
+	if byType := fp.logger.TypeTotals(); len(byType) > 0 {
+		s.ByType = map[string]EventSummary{}
+		for t, tt := range byType {
+			s.ByType[t] = EventSummary{Count: tt.Count, Size: tt.Size}
+		}
+	}
+	if breakdown := fp.logger.SkippedBreakdown(); len(breakdown) > 0 {
+		s.SkippedBreakdown = map[string]EventSummary{}
+		for reason, t := range breakdown {
+			s.SkippedBreakdown[reason] = EventSummary{Count: t.Count, Size: t.Size}
+		}
+	}
+	sizes := fp.logger.GetEventSizes()
+	for c, n := range fp.logger.GetEventCounts() {
+		s.Events[c.ID()] = EventSummary{Count: n, Size: sizes[c]}
+	}
+	return s
+}
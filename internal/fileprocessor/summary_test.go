@@ -0,0 +1,199 @@
+package fileprocessor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/simulot/immich-go/internal/assettracker"
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+func TestRunSummary(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	fp := New(assettracker.New(), fileevent.NewRecorder(logger))
+
+	ctx := context.Background()
+	file := newTestFile("/test/image.jpg")
+	fp.RecordAssetDiscovered(ctx, file, 1024, fileevent.DiscoveredImage)
+	fp.RecordAssetProcessed(ctx, file, 1024, fileevent.ProcessedUploadSuccess)
+
+	s := fp.RunSummary("immich-go upload from-folder", "failed", errors.New("boom"))
+	if s.Error != "boom" {
+		t.Errorf("Expected error boom, got %q", s.Error)
+	}
+	if s.Assets.Processed != 1 || s.Assets.ProcessedSize != 1024 {
+		t.Errorf("Expected 1 processed asset of 1024 bytes, got %d / %d", s.Assets.Processed, s.Assets.ProcessedSize)
+	}
+	e, ok := s.Events["processed_upload_success"]
+	if !ok || e.Count != 1 || e.Size != 1024 {
+		t.Errorf("Unexpected upload event summary: %+v", e)
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"command", "status", "error", "assets", "events"} {
+		if _, ok := m[k]; !ok {
+			t.Errorf("Missing key %q in %s", k, b)
+		}
+	}
+}
+
+func TestRunSummaryDuplicates(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	fp := New(assettracker.New(), fileevent.NewRecorder(logger))
+
+	ctx := context.Background()
+	file := newTestFile("/test/image.jpg")
+	fp.RecordAssetDiscovered(ctx, file, 2048, fileevent.DiscoveredImage)
+	fp.RecordAssetDiscarded(ctx, file, 2048, fileevent.DiscardedServerDuplicate, "already on the server")
+
+	s := fp.RunSummary("immich-go upload from-folder", "completed", nil)
+	if s.Duplicates.Count != 1 || s.Duplicates.Size != 2048 {
+		t.Errorf("Expected 1 duplicate of 2048 bytes, got %+v", s.Duplicates)
+	}
+}
+
+func TestRunSummarySkippedBreakdown(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	fp := New(assettracker.New(), fileevent.NewRecorder(logger))
+
+	ctx := context.Background()
+	for i, c := range []fileevent.Code{fileevent.DiscardedServerDuplicate, fileevent.DiscardedLocalDuplicate, fileevent.DiscardedFiltered, fileevent.DiscardedBySize} {
+		file := newTestFile(fmt.Sprintf("/test/image%d.jpg", i))
+		fp.RecordAssetDiscovered(ctx, file, 100, fileevent.DiscoveredImage)
+		fp.RecordAssetDiscarded(ctx, file, 100, c, "test")
+	}
+
+	s := fp.RunSummary("immich-go upload from-folder", "completed", nil)
+	expected := map[string]EventSummary{
+		"duplicate": {Count: 2, Size: 200},
+		"filter":    {Count: 1, Size: 100},
+		"size":      {Count: 1, Size: 100},
+	}
+	if !reflect.DeepEqual(s.SkippedBreakdown, expected) {
+		t.Errorf("Expected the breakdown %v, got %v", expected, s.SkippedBreakdown)
+	}
+	if report := fp.Logger().GenerateEventReport(); !strings.Contains(report, "Skipped by reason:") {
+		t.Errorf("Report should contain the skipped section:\n%s", report)
+	}
+}
+
+func TestRunSummaryByType(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	fp := New(assettracker.New(), fileevent.NewRecorder(logger))
+
+	ctx := context.Background()
+	for _, f := range []struct {
+		name string
+		size int64
+	}{
+		{"/test/image1.jpg", 1000},
+		{"/test/image2.heic", 2000},
+		{"/test/video.mp4", 50000},
+	} {
+		file := newTestFile(f.name)
+		fp.RecordAssetDiscovered(ctx, file, f.size, fileevent.DiscoveredImage)
+		fp.RecordAssetProcessed(ctx, file, f.size, fileevent.ProcessedUploadSuccess)
+	}
+	dup := newTestFile("/test/dup.jpg")
+	fp.RecordAssetDiscovered(ctx, dup, 500, fileevent.DiscoveredImage)
+	fp.RecordAssetDiscarded(ctx, dup, 500, fileevent.DiscardedServerDuplicate, "already on the server")
+
+	s := fp.RunSummary("immich-go upload from-folder", "completed", nil)
+	if got := s.ByType["image"]; got.Count != 2 || got.Size != 3000 {
+		t.Errorf("Expected 2 images of 3000 bytes, got %+v", got)
+	}
+	if got := s.ByType["video"]; got.Count != 1 || got.Size != 50000 {
+		t.Errorf("Expected 1 video of 50000 bytes, got %+v", got)
+	}
+	if len(s.ByType) != 2 {
+		t.Errorf("Unexpected types: %+v", s.ByType)
+	}
+}
+
+func TestRunSummaryRetriedUpload(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	fp := New(assettracker.New(), fileevent.NewRecorder(logger))
+
+	ctx := context.Background()
+	retried := newTestFile("/test/retried.jpg")
+	fp.RecordAssetDiscovered(ctx, retried, 1000, fileevent.DiscoveredImage)
+	for range 3 {
+		fp.RecordUploadAttempt(ctx, retried, 1000)
+	}
+	fp.RecordAssetProcessed(ctx, retried, 1000, fileevent.ProcessedUploadSuccess)
+	// a late success of a previous attempt isn't counted again
+	fp.RecordAssetProcessed(ctx, retried, 1000, fileevent.ProcessedUploadSuccess)
+
+	other := newTestFile("/test/other.jpg")
+	fp.RecordAssetDiscovered(ctx, other, 2000, fileevent.DiscoveredImage)
+	fp.RecordUploadAttempt(ctx, other, 2000)
+	fp.RecordAssetProcessed(ctx, other, 2000, fileevent.ProcessedUploadSuccess)
+
+	s := fp.RunSummary("immich-go upload from-folder", "completed", nil)
+	if s.Uploaded != 2 || s.UploadAttempts != 4 {
+		t.Errorf("Expected 2 uploaded assets and 4 attempts, got %d / %d", s.Uploaded, s.UploadAttempts)
+	}
+	if e := s.Events["processed_upload_success"]; e.Count != 2 || e.Size != 3000 {
+		t.Errorf("Expected 2 upload events of 3000 bytes, got %+v", e)
+	}
+	if s.Assets.Processed != 2 || s.Assets.ProcessedSize != 3000 {
+		t.Errorf("Expected 2 processed assets of 3000 bytes, got %d / %d", s.Assets.Processed, s.Assets.ProcessedSize)
+	}
+	if got := s.ByType["image"]; got.Count != 2 {
+		t.Errorf("Expected 2 transferred images, got %+v", got)
+	}
+}
+
+func TestRunSummaryOrphans(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	fp := New(assettracker.New(), fileevent.NewRecorder(logger))
+
+	// the sources without orphan check, like the folders, don't give them
+	b, err := json.Marshal(fp.RunSummary("immich-go upload from-folder", "completed", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "orphan") {
+		t.Errorf("orphan counts in %s", b)
+	}
+	fp.CheckOrphans()
+	b, err = json.Marshal(fp.RunSummary("immich-go upload from-google-photos", "completed", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"orphan_sidecars":0`) || !strings.Contains(string(b), `"orphan_media":0`) {
+		t.Errorf("missing zero orphan counts in %s", b)
+	}
+
+	ctx := context.Background()
+	fp.RecordNonAsset(ctx, newTestFile("/takeout/IMG_0001.jpg.json"), 0, fileevent.ProcessedOrphanSidecar)
+	fp.RecordNonAsset(ctx, newTestFile("/takeout/IMG_0002.jpg.json"), 0, fileevent.ProcessedOrphanSidecar)
+	fp.RecordNonAsset(ctx, newTestFile("/takeout/IMG_0003.jpg"), 0, fileevent.ProcessedMissingMetadata)
+
+	s := fp.RunSummary("immich-go upload from-google-photos", "completed", nil)
+	if *s.OrphanSidecars != 2 || *s.OrphanMedia != 1 {
+		t.Errorf("Expected 2 orphan sidecars and 1 orphan media, got %d / %d", *s.OrphanSidecars, *s.OrphanMedia)
+	}
+
+	b, err = json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"orphan_sidecars":2`) || !strings.Contains(string(b), `"orphan_media":1`) {
+		t.Errorf("Missing orphan counts in %s", b)
+	}
+}
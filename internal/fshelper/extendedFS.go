@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/simulot/immich-go/internal/fshelper/debugfiles"
 )
@@ -23,6 +24,20 @@ type FSCanRemove interface {
 	Remove(name string) error
 }
 
+type FSCanRename interface {
+	Rename(oldName, newName string) error
+}
+
+type FSCanChtimes interface {
+	Chtimes(name string, atime, mtime time.Time) error
+}
+
+// FSCanSetCreationTime is implemented by the file systems able to change the creation time of a file.
+// The method returns errors.ErrUnsupported when the platform can't do it.
+type FSCanSetCreationTime interface {
+	SetCreationTime(name string, t time.Time) error
+}
+
 type FSCanStat interface {
 	Stat(name string) (fs.FileInfo, error)
 }
@@ -83,6 +98,27 @@ func Remove(fsys fs.FS, name string) error {
 	return errors.New("remove not supported")
 }
 
+func Rename(fsys fs.FS, oldName, newName string) error {
+	if fsys, ok := fsys.(FSCanRename); ok {
+		return fsys.Rename(oldName, newName)
+	}
+	return errors.New("rename not supported")
+}
+
+func Chtimes(fsys fs.FS, name string, atime, mtime time.Time) error {
+	if fsys, ok := fsys.(FSCanChtimes); ok {
+		return fsys.Chtimes(name, atime, mtime)
+	}
+	return errors.New("chtimes not supported")
+}
+
+func SetCreationTime(fsys fs.FS, name string, t time.Time) error {
+	if fsys, ok := fsys.(FSCanSetCreationTime); ok {
+		return fsys.SetCreationTime(name, t)
+	}
+	return errors.ErrUnsupported
+}
+
 func Stat(fsys fs.FS, name string) (fs.FileInfo, error) {
 	if fsys, ok := fsys.(FSCanStat); ok {
 		return fsys.Stat(name)
@@ -0,0 +1,192 @@
+package fshelper
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileListFS is a FS limited to a list of files and their folders.
+// ReadDir lists only the given files, but all files of their folders
+// can be opened, so the sidecars are found next to the listed files.
+type FileListFS struct {
+	rootFS NameFS
+	dirs   map[string]map[string]fs.DirEntry
+}
+
+var (
+	_ fs.FS        = (*FileListFS)(nil)
+	_ fs.ReadDirFS = (*FileListFS)(nil)
+	_ fs.StatFS    = (*FileListFS)(nil)
+	_ NameFS       = (*FileListFS)(nil)
+)
+
+// ReadFileList reads a list of file paths separated by sep, usually '\n' or 0.
+// Empty lines are ignored.
+func ReadFileList(r io.Reader, sep byte) ([]string, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, sep); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+
+	files := []string{}
+	for s.Scan() {
+		f := s.Text()
+		if sep == '\n' {
+			f = strings.TrimSuffix(f, "\r")
+		}
+		if strings.TrimSpace(f) == "" {
+			continue
+		}
+		files = append(files, f)
+	}
+	return files, s.Err()
+}
+
+// NewFileListFS creates a FS giving access to the listed files.
+// The relative paths are resolved against baseDir, or the current directory when empty.
+// The FS is rooted at baseDir when given, or at the deepest folder common to all files.
+func NewFileListFS(baseDir string, files []string) (*FileListFS, error) {
+	if len(files) == 0 {
+		return nil, errors.New("the file list is empty")
+	}
+	base := baseDir
+	if base == "" {
+		base, _ = os.Getwd()
+	}
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs error
+	abs := make([]string, 0, len(files))
+	for _, f := range files {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(base, f)
+		}
+		f = filepath.Clean(f)
+		s, err := os.Stat(f)
+		switch {
+		case err != nil:
+			errs = errors.Join(errs, err)
+			continue
+		case s.IsDir():
+			errs = errors.Join(errs, fmt.Errorf("%s: is a folder, the list must give files", f))
+			continue
+		}
+		abs = append(abs, f)
+	}
+	if errs != nil {
+		return nil, errs
+	}
+
+	root := base
+	if baseDir == "" {
+		root = filepath.Dir(abs[0])
+		for _, f := range abs[1:] {
+			root = commonDir(root, filepath.Dir(f))
+		}
+	}
+
+	ffs := &FileListFS{
+		rootFS: NewFSWithName(root),
+		dirs:   map[string]map[string]fs.DirEntry{},
+	}
+	for _, f := range abs {
+		rel, err := filepath.Rel(root, f)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			errs = errors.Join(errs, fmt.Errorf("%s: is outside of the base folder %s", f, root))
+			continue
+		}
+		if err := ffs.add(filepath.ToSlash(rel)); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return ffs, nil
+}
+
+// add registers the file and its parent folders
+func (ffs *FileListFS) add(name string) error {
+	for name != "." {
+		dir := path.Dir(name)
+		entries, ok := ffs.dirs[dir]
+		if !ok {
+			entries = map[string]fs.DirEntry{}
+			ffs.dirs[dir] = entries
+		}
+		base := path.Base(name)
+		if _, ok := entries[base]; ok {
+			return nil
+		}
+		info, err := fs.Stat(ffs.rootFS, name)
+		if err != nil {
+			return err
+		}
+		entries[base] = fs.FileInfoToDirEntry(info)
+		name = dir
+	}
+	return nil
+}
+
+// commonDir gives the deepest folder containing both folders
+func commonDir(a, b string) string {
+	for {
+		rel, err := filepath.Rel(a, b)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return a
+		}
+		parent := filepath.Dir(a)
+		if parent == a {
+			return a
+		}
+		a = parent
+	}
+}
+
+// Open opens any file of the root folder, to give access to the sidecars
+func (ffs *FileListFS) Open(name string) (fs.File, error) {
+	return ffs.rootFS.Open(name)
+}
+
+// Stat gives the information of any file of the root folder
+func (ffs *FileListFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(ffs.rootFS, name)
+}
+
+// ReadDir returns the listed files of the folder and the folders containing listed files
+func (ffs *FileListFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, ok := ffs.dirs[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	r := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		r = append(r, e)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].Name() < r[j].Name() })
+	return r, nil
+}
+
+// Name gives the name of the root folder
+func (ffs *FileListFS) Name() string {
+	return ffs.rootFS.Name()
+}
@@ -0,0 +1,81 @@
+package fshelper
+
+import (
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFileList(t *testing.T) {
+	tc := []struct {
+		name     string
+		input    string
+		sep      byte
+		expected []string
+	}{
+		{name: "lines", input: "a.jpg\nb c.jpg\r\n\nd.jpg", sep: '\n', expected: []string{"a.jpg", "b c.jpg", "d.jpg"}},
+		{name: "nul", input: "a.jpg\x00b\nc.jpg\x00", sep: 0, expected: []string{"a.jpg", "b\nc.jpg"}},
+		{name: "empty", input: "", sep: '\n', expected: []string{}},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			files, err := ReadFileList(strings.NewReader(c.input), c.sep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(c.expected, files) {
+				t.Errorf("expected %q, got %q", c.expected, files)
+			}
+		})
+	}
+}
+
+func TestFileListFS(t *testing.T) {
+	fsys, err := NewFileListFS("", []string{"TESTDATA/A/T/10.jpg", "TESTDATA/A/1.jpg", "TESTDATA/B/4.jpg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fsys.Name() != "TESTDATA" {
+		t.Errorf("unexpected FSName; expected TESTDATA, got %s", fsys.Name())
+	}
+
+	files := []string{}
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"A/1.jpg", "A/T/10.jpg", "B/4.jpg"}
+	if !reflect.DeepEqual(expected, files) {
+		t.Errorf("unexpected filelist; expected %v, got %v", expected, files)
+	}
+
+	// the sidecars are reachable
+	if _, err := fs.Stat(fsys, "B/4.xmp"); err != nil {
+		t.Errorf("the sidecar should be reachable: %v", err)
+	}
+
+	// files are relative to the base folder
+	fsys, err = NewFileListFS("TESTDATA/A", []string{"1.jpg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fsys.Name() != "A" {
+		t.Errorf("unexpected FSName; expected A, got %s", fsys.Name())
+	}
+
+	if _, err := NewFileListFS("TESTDATA/A", []string{"1.jpg", "missing.jpg"}); err == nil {
+		t.Error("a missing file should be an error")
+	}
+	if _, err := NewFileListFS("TESTDATA/A", []string{"../B/4.jpg"}); err == nil {
+		t.Error("a file outside of the base folder should be an error")
+	}
+}
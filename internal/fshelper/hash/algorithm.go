@@ -0,0 +1,131 @@
+package hash
+
+import (
+	"crypto/sha1"
+	"fmt"
+	gohash "hash"
+	"io"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/zeebo/blake3"
+)
+
+// Algorithm is the hash algorithm used for local checksums.
+// Immich uses SHA1, so any other algorithm is only usable for comparing local files.
+type Algorithm int
+
+const (
+	AlgoSHA1 Algorithm = iota
+	AlgoBLAKE3
+	AlgoXXHash
+)
+
+// New returns a new hash.Hash for the algorithm
+func (a Algorithm) New() gohash.Hash {
+	switch a {
+	case AlgoBLAKE3:
+		return blake3.New()
+	case AlgoXXHash:
+		return xxhash.New()
+	default:
+		return sha1.New()
+	}
+}
+
+// GetHash computes the hash of the reader with the algorithm
+func (a Algorithm) GetHash(r io.Reader) ([]byte, error) {
+	h := a.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// Implement spf13 flag.Value interface
+
+func (a *Algorithm) Set(value string) error {
+	switch strings.ToLower(value) {
+	case "", "sha1":
+		*a = AlgoSHA1
+	case "blake3":
+		*a = AlgoBLAKE3
+	case "xxhash":
+		*a = AlgoXXHash
+	default:
+		return fmt.Errorf("invalid value %q for checksum algorithm, accepted values: sha1, blake3, xxhash", value)
+	}
+	return nil
+}
+
+func (a Algorithm) String() string {
+	switch a {
+	case AlgoSHA1:
+		return "sha1"
+	case AlgoBLAKE3:
+		return "blake3"
+	case AlgoXXHash:
+		return "xxhash"
+	default:
+		return "unknown"
+	}
+}
+
+func (a Algorithm) Type() string {
+	return "ChecksumAlgo"
+}
+
+// MarshalJSON implements json.Marshaler
+func (a Algorithm) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + a.String() + `"`), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (a *Algorithm) UnmarshalJSON(data []byte) error {
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("invalid JSON string for checksum algorithm")
+	}
+	return a.Set(string(data[1 : len(data)-1]))
+}
+
+// MarshalYAML implements yaml.Marshaler
+func (a Algorithm) MarshalYAML() (interface{}, error) {
+	return a.String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (a *Algorithm) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return a.Set(s)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (a Algorithm) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (a *Algorithm) UnmarshalText(data []byte) error {
+	return a.Set(string(data))
+}
+
+// GetHashes computes the hashes of the reader with each algorithm, in a single read
+func GetHashes(r io.Reader, algos ...Algorithm) ([][]byte, error) {
+	hs := make([]gohash.Hash, len(algos))
+	ws := make([]io.Writer, len(algos))
+	for i, a := range algos {
+		hs[i] = a.New()
+		ws[i] = hs[i]
+	}
+	if _, err := io.Copy(io.MultiWriter(ws...), r); err != nil {
+		return nil, err
+	}
+	sums := make([][]byte, len(hs))
+	for i, h := range hs {
+		sums[i] = h.Sum(nil)
+	}
+	return sums, nil
+}
@@ -0,0 +1,71 @@
+package hash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAlgorithms(t *testing.T) {
+	tests := []struct {
+		name string
+		algo Algorithm
+		want string // hash of "abc"
+	}{
+		{name: "sha1", algo: AlgoSHA1, want: "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{name: "blake3", algo: AlgoBLAKE3, want: "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85"},
+		{name: "xxhash", algo: AlgoXXHash, want: "44bc2cf5ad770999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.algo.GetHash(strings.NewReader("abc"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(b); got != tt.want {
+				t.Errorf("GetHash() = %s, want %s", got, tt.want)
+			}
+			if tt.algo.String() != tt.name {
+				t.Errorf("String() = %s, want %s", tt.algo.String(), tt.name)
+			}
+
+			var a Algorithm
+			if err := a.Set(strings.ToUpper(tt.name)); err != nil || a != tt.algo {
+				t.Errorf("Set(%q) = %v, %v", tt.name, a, err)
+			}
+			j, err := json.Marshal(tt.algo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a = -1
+			if err := json.Unmarshal(j, &a); err != nil || a != tt.algo {
+				t.Errorf("JSON round trip of %s = %v, %v", j, a, err)
+			}
+		})
+	}
+}
+
+func TestAlgorithmSet(t *testing.T) {
+	var a Algorithm = AlgoXXHash
+	if err := a.Set(""); err != nil || a != AlgoSHA1 {
+		t.Errorf("Set(\"\") = %v, %v, want sha1", a, err)
+	}
+	if err := a.Set("md5"); err == nil {
+		t.Error("Set(\"md5\") should fail")
+	}
+}
+
+func TestGetHashes(t *testing.T) {
+	sums, err := GetHashes(strings.NewReader("abc"), AlgoSHA1, AlgoBLAKE3, AlgoXXHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, algo := range []Algorithm{AlgoSHA1, AlgoBLAKE3, AlgoXXHash} {
+		want, _ := algo.GetHash(strings.NewReader("abc"))
+		if !bytes.Equal(sums[i], want) {
+			t.Errorf("%s: GetHashes() = %x, want %x", algo, sums[i], want)
+		}
+	}
+}
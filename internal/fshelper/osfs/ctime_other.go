@@ -0,0 +1,13 @@
+//go:build !windows
+
+package osfs
+
+import (
+	"errors"
+	"time"
+)
+
+// setCreationTime isn't supported: the creation time can't be changed on most unix file systems
+func setCreationTime(name string, t time.Time) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package osfs
+
+import (
+	"syscall"
+	"time"
+)
+
+// setCreationTime changes the creation time of the file, leaving the other times untouched
+func setCreationTime(name string, t time.Time) error {
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	h, err := syscall.CreateFile(p, syscall.FILE_WRITE_ATTRIBUTES, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE, nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.CloseHandle(h)
+	ft := syscall.NsecToFiletime(t.UnixNano())
+	return syscall.SetFileTime(h, &ft, nil, nil)
+}
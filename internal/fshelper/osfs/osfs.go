@@ -4,6 +4,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/simulot/immich-go/internal/fshelper"
 	"github.com/simulot/immich-go/internal/fshelper/debugfiles"
@@ -21,9 +22,12 @@ func DirFS(name string) fs.FS {
 var (
 	_ fshelper.FSCanWrite = dirFS("")
 	// _ fshelper.FSCanMkdirAll = dirFS("")
-	_ fshelper.FSCanRemove = dirFS("")
-	_ fshelper.FSCanStat   = dirFS("")
-	_ fshelper.FSCanLink   = dirFS("")
+	_ fshelper.FSCanRemove          = dirFS("")
+	_ fshelper.FSCanRename          = dirFS("")
+	_ fshelper.FSCanStat            = dirFS("")
+	_ fshelper.FSCanLink            = dirFS("")
+	_ fshelper.FSCanChtimes         = dirFS("")
+	_ fshelper.FSCanSetCreationTime = dirFS("")
 )
 
 type dirFS string
@@ -68,6 +72,18 @@ func (dir dirFS) Remove(name string) error {
 	return os.Remove(filepath.Join(string(dir), name))
 }
 
+func (dir dirFS) Rename(oldName, newName string) error {
+	return os.Rename(filepath.Join(string(dir), oldName), filepath.Join(string(dir), newName))
+}
+
+func (dir dirFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(filepath.Join(string(dir), name), atime, mtime)
+}
+
+func (dir dirFS) SetCreationTime(name string, t time.Time) error {
+	return setCreationTime(filepath.Join(string(dir), name), t)
+}
+
 type OSFS interface {
 	fs.File
 	Name() string
@@ -0,0 +1,46 @@
+package fshelper
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/simulot/immich-go/internal/loghelper"
+)
+
+// RetryDelay is the wait before the first retry of a failing directory read. It doubles at each retry.
+var RetryDelay = 500 * time.Millisecond
+
+// ReadDirRetry reads the directory, and retries the reads failing with a transient error,
+// like the input/output errors of a flaky network mount.
+func ReadDirRetry(ctx context.Context, fsys fs.FS, name string, retries int) ([]fs.DirEntry, error) {
+	delay := RetryDelay
+	for attempt := 1; ; attempt++ {
+		entries, err := fs.ReadDir(fsys, name)
+		if err == nil || attempt > retries || !IsTransient(err) {
+			return entries, err
+		}
+		loghelper.Warn("can't read the directory, retrying", "directory", FSName(fsys, name), "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// IsTransient tells if a file system error may disappear when retried.
+// The missing files and the permission errors are permanent.
+func IsTransient(err error) bool {
+	switch {
+	case errors.Is(err, fs.ErrNotExist),
+		errors.Is(err, fs.ErrPermission),
+		errors.Is(err, fs.ErrInvalid),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+	return true
+}
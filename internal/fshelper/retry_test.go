@@ -0,0 +1,65 @@
+package fshelper
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// flakyFS fails the first directory reads
+type flakyFS struct {
+	fs.FS
+	failures int
+	err      error
+	reads    int
+}
+
+func (f *flakyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f.reads++
+	if f.reads <= f.failures {
+		return nil, &fs.PathError{Op: "readdirent", Path: name, Err: f.err}
+	}
+	return fs.ReadDir(f.FS, name)
+}
+
+func TestReadDirRetry(t *testing.T) {
+	RetryDelay = time.Millisecond
+	ctx := context.Background()
+	mfs := fstest.MapFS{"dir/a.jpg": &fstest.MapFile{}}
+
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		retries   int
+		wantErr   bool
+		wantReads int
+	}{
+		{name: "transient error", failures: 2, err: syscall.EIO, retries: 3, wantReads: 3},
+		{name: "too many failures", failures: 5, err: syscall.EIO, retries: 3, wantErr: true, wantReads: 4},
+		{name: "no retry", failures: 1, err: syscall.EIO, retries: 0, wantErr: true, wantReads: 1},
+		{name: "permanent error", failures: 1, err: fs.ErrPermission, retries: 3, wantErr: true, wantReads: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &flakyFS{FS: mfs, failures: tt.failures, err: tt.err}
+			entries, err := ReadDirRetry(ctx, f, "dir", tt.retries)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantErr && len(entries) != 1 {
+				t.Errorf("got %d entries, want 1", len(entries))
+			}
+			if err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("got error %v, want %v", err, tt.err)
+			}
+			if f.reads != tt.wantReads {
+				t.Errorf("got %d reads, want %d", f.reads, tt.wantReads)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package fshelper
+
+import (
+	"context"
+	"io/fs"
+)
+
+// TotalSize gives the size of the regular files of the file systems.
+// The files that can't be read are not counted.
+func TotalSize(ctx context.Context, fsyss []fs.FS) (int64, error) {
+	var total int64
+	for _, fsys := range fsyss {
+		err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,32 @@
+package fshelper
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTotalSize(t *testing.T) {
+	a := fstest.MapFS{
+		"photos/IMG_001.jpg": &fstest.MapFile{Data: make([]byte, 100)},
+		"photos/IMG_002.jpg": &fstest.MapFile{Data: make([]byte, 50)},
+		"empty":              &fstest.MapFile{Mode: fs.ModeDir},
+	}
+	b := fstest.MapFS{
+		"video.mp4": &fstest.MapFile{Data: make([]byte, 1000)},
+	}
+	got, err := TotalSize(context.Background(), []fs.FS{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1150 {
+		t.Errorf("TotalSize() = %d, want 1150", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := TotalSize(ctx, []fs.FS{a}); err == nil {
+		t.Error("a canceled walk should fail")
+	}
+}
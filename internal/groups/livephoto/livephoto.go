@@ -0,0 +1,173 @@
+package livephoto
+
+/* This package implements a group builder for Apple live photos.
+A live photo is a still image (HEIC or JPG) and a short MOV video sharing
+the same base name, taken at the same time. Both files carry the same
+content identifier, an UUID written in their metadata.
+*/
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/filetypes"
+)
+
+const (
+	// maximum difference between the capture dates of the image and the video
+	threshold = 3 * time.Second
+
+	// the content identifier is searched in the first bytes of the files
+	maxContentIDSearch = 8 * 1024 * 1024
+)
+
+// Group groups the live photos images with their video.
+// The in channel receives assets sorted by radical, then by date taken.
+// The group's cover is the image.
+func Group(ctx context.Context, in <-chan *assets.Asset, out chan<- *assets.Asset, gOut chan<- *assets.Group) {
+	currentKey := ""
+	currentGroup := []*assets.Asset{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case a, ok := <-in:
+			if !ok {
+				if len(currentGroup) > 0 {
+					sendGroup(ctx, out, gOut, currentGroup)
+				}
+				return
+			}
+			key := path.Join(path.Dir(a.File.FullName()), a.Radical)
+			if key != currentKey {
+				if len(currentGroup) > 0 {
+					sendGroup(ctx, out, gOut, currentGroup)
+					currentGroup = []*assets.Asset{}
+				}
+				currentKey = key
+			}
+			currentGroup = append(currentGroup, a)
+		}
+	}
+}
+
+// sendGroup sends the live photo found in the assets sharing the same radical.
+// The other assets are sent individually.
+func sendGroup(ctx context.Context, out chan<- *assets.Asset, gOut chan<- *assets.Group, as []*assets.Asset) {
+	image, video := -1, -1
+	for i, a := range as {
+		switch {
+		case a.Type == filetypes.TypeVideo && a.Ext == ".mov":
+			if video >= 0 {
+				// several videos, can't tell which one is the live part
+				sendAssets(ctx, out, as)
+				return
+			}
+			video = i
+		case a.Type == filetypes.TypeImage && a.Kind != assets.KindEdited && isStillExt(a.Ext):
+			// prefer the HEIC image over the JPG one
+			if image < 0 || (as[image].Ext != ".heic" && a.Ext == ".heic") {
+				image = i
+			}
+		}
+	}
+
+	if image < 0 || video < 0 || !isPair(as[image], as[video]) {
+		sendAssets(ctx, out, as)
+		return
+	}
+
+	g := assets.NewGroup(assets.GroupByLivePhoto, as[image], as[video])
+	g.CoverIndex = 0
+	select {
+	case <-ctx.Done():
+		return
+	case gOut <- g:
+	}
+
+	others := make([]*assets.Asset, 0, len(as)-2)
+	for i, a := range as {
+		if i != image && i != video {
+			others = append(others, a)
+		}
+	}
+	sendAssets(ctx, out, others)
+}
+
+func isStillExt(ext string) bool {
+	switch ext {
+	case ".heic", ".heif", ".jpg", ".jpeg":
+		return true
+	}
+	return false
+}
+
+// isPair checks the capture dates and the content identifiers of the image and the video.
+// Missing information doesn't prevent the pairing.
+func isPair(image, video *assets.Asset) bool {
+	di, dv := captureDate(image), captureDate(video)
+	if !di.IsZero() && !dv.IsZero() && (di.Sub(dv) > threshold || dv.Sub(di) > threshold) {
+		return false
+	}
+
+	idsI := contentIdentifiers(image)
+	idsV := contentIdentifiers(video)
+	if len(idsI) == 0 || len(idsV) == 0 {
+		return true
+	}
+	for id := range idsI {
+		if _, ok := idsV[id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func captureDate(a *assets.Asset) time.Time {
+	if !a.CaptureDate.IsZero() {
+		return a.CaptureDate
+	}
+	return a.FileDate
+}
+
+var reUUID = regexp.MustCompile(`(?i)[0-9A-F]{8}-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{4}-[0-9A-F]{12}`)
+
+// contentIdentifiers returns the UUIDs found in the first bytes of the file.
+// Apple writes the content identifier in the maker notes of the image, and in the
+// metadata of the video.
+func contentIdentifiers(a *assets.Asset) map[string]struct{} {
+	if a.File.FS() == nil {
+		return nil
+	}
+	f, err := a.File.Open()
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	b, err := io.ReadAll(io.LimitReader(f, maxContentIDSearch))
+	if err != nil {
+		return nil
+	}
+	ids := map[string]struct{}{}
+	for _, id := range reUUID.FindAll(b, -1) {
+		ids[string(bytes.ToUpper(id))] = struct{}{}
+	}
+	return ids
+}
+
+// sendAssets sends the assets individually to the output channel
+func sendAssets(ctx context.Context, out chan<- *assets.Asset, as []*assets.Asset) {
+	for _, a := range as {
+		select {
+		case out <- a:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
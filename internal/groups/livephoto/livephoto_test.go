@@ -0,0 +1,110 @@
+package livephoto
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/filenames"
+	"github.com/simulot/immich-go/internal/filetypes"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+const (
+	uuid1 = "4F1B2C3D-AAAA-BBBB-CCCC-0123456789AB"
+	uuid2 = "9E8D7C6B-DDDD-EEEE-FFFF-BA9876543210"
+)
+
+var fsys = fstest.MapFS{
+	"IMG_0001.HEIC": {Data: []byte("...Apple iOS..." + uuid1 + "...")},
+	"IMG_0001.MOV":  {Data: []byte("...com.apple.quicktime.content.identifier..." + uuid1 + "...")},
+	"IMG_0002.HEIC": {Data: []byte("..." + uuid1 + "...")},
+	"IMG_0002.MOV":  {Data: []byte("..." + uuid2 + "...")},
+	"IMG_0003.JPG":  {Data: []byte("no identifier")},
+	"IMG_0003.MOV":  {Data: []byte("no identifier")},
+	"IMG_0004.HEIC": {Data: []byte("no identifier")},
+	"IMG_0004.MOV":  {Data: []byte("no identifier")},
+	"IMG_0005.MOV":  {Data: []byte("no identifier")},
+}
+
+func mockAsset(ic *filenames.InfoCollector, name string, dateTaken time.Time) *assets.Asset {
+	a := assets.Asset{
+		File:        fshelper.FSName(fsys, name),
+		FileDate:    dateTaken,
+		CaptureDate: dateTaken,
+	}
+	a.SetNameInfo(ic.GetInfo(name))
+	return &a
+}
+
+func TestGroup(t *testing.T) {
+	ctx := context.Background()
+	ic := filenames.NewInfoCollector(time.Local, filetypes.DefaultSupportedMedia)
+	baseTime := time.Date(2023, 6, 1, 12, 0, 0, 0, time.Local)
+
+	testAssets := []*assets.Asset{
+		mockAsset(ic, "IMG_0001.HEIC", baseTime), // live photo, same content identifier
+		mockAsset(ic, "IMG_0001.MOV", baseTime.Add(100*time.Millisecond)),
+		mockAsset(ic, "IMG_0002.HEIC", baseTime.Add(time.Minute)), // different content identifiers
+		mockAsset(ic, "IMG_0002.MOV", baseTime.Add(time.Minute)),
+		mockAsset(ic, "IMG_0003.JPG", baseTime.Add(2*time.Minute)), // live photo, no identifier
+		mockAsset(ic, "IMG_0003.MOV", baseTime.Add(2*time.Minute+time.Second)),
+		mockAsset(ic, "IMG_0004.HEIC", baseTime.Add(3*time.Minute)), // taken too far apart
+		mockAsset(ic, "IMG_0004.MOV", baseTime.Add(4*time.Minute)),
+		mockAsset(ic, "IMG_0005.MOV", baseTime.Add(5*time.Minute)), // movie alone
+	}
+
+	in := make(chan *assets.Asset)
+	out := make(chan *assets.Asset)
+	gOut := make(chan *assets.Group)
+
+	go func() {
+		for _, a := range testAssets {
+			in <- a
+		}
+		close(in)
+	}()
+
+	go func() {
+		Group(ctx, in, out, gOut)
+		close(out)
+		close(gOut)
+	}()
+
+	gotAssets := []string{}
+	gotGroups := []string{}
+	for out != nil || gOut != nil {
+		select {
+		case a, ok := <-out:
+			if !ok {
+				out = nil
+				continue
+			}
+			gotAssets = append(gotAssets, a.File.Name())
+		case g, ok := <-gOut:
+			if !ok {
+				gOut = nil
+				continue
+			}
+			if g.Grouping != assets.GroupByLivePhoto || len(g.Assets) != 2 || g.CoverIndex != 0 {
+				t.Errorf("unexpected group: %+v", g)
+				continue
+			}
+			gotGroups = append(gotGroups, g.Assets[0].File.Name()+"+"+g.Assets[1].File.Name())
+		}
+	}
+
+	expectedGroups := []string{"IMG_0001.HEIC+IMG_0001.MOV", "IMG_0003.JPG+IMG_0003.MOV"}
+	expectedAssets := []string{"IMG_0002.HEIC", "IMG_0002.MOV", "IMG_0004.HEIC", "IMG_0004.MOV", "IMG_0005.MOV"}
+	sort.Strings(gotAssets)
+	if !slices.Equal(expectedGroups, gotGroups) {
+		t.Errorf("expected groups %v, got %v", expectedGroups, gotGroups)
+	}
+	if !slices.Equal(expectedAssets, gotAssets) {
+		t.Errorf("expected assets %v, got %v", expectedAssets, gotAssets)
+	}
+}
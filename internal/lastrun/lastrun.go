@@ -0,0 +1,76 @@
+// Package lastrun keeps the time of the last successful run for a source.
+//
+// The markers are stored in a small JSON file, by default in the immich-go cache folder.
+package lastrun
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Store holds the last run markers, indexed by source
+type Store struct {
+	path string
+	runs map[string]time.Time
+}
+
+// DefaultPath returns the default path of the state file
+func DefaultPath() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "immich-go_last-runs.json"
+	}
+	return filepath.Join(cacheDir, "immich-go", "last-runs.json")
+}
+
+// Load reads the state file. A missing file gives an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		runs: map[string]time.Time{},
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &s.runs); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the time of the last successful run for the source
+func (s *Store) Get(source string) (time.Time, bool) {
+	t, ok := s.runs[source]
+	return t, ok
+}
+
+// Set records the time of the last successful run for the source
+func (s *Store) Set(source string, t time.Time) {
+	s.runs[source] = t
+}
+
+// Save writes the state file. The file is replaced atomically.
+func (s *Store) Save() error {
+	b, err := json.MarshalIndent(s.runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(s.path), 0o700)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	err = os.WriteFile(tmp, b, 0o600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
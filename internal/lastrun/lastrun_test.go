@@ -0,0 +1,37 @@
+package lastrun
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "last-runs.json")
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() on a missing file: %v", err)
+	}
+	if _, ok := s.Get("/photos"); ok {
+		t.Fatal("Get() on an empty store should return false")
+	}
+
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	s.Set("/photos", now)
+	if err = s.Save(); err != nil {
+		t.Fatalf("Save(): %v", err)
+	}
+
+	s, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load(): %v", err)
+	}
+	got, ok := s.Get("/photos")
+	if !ok || !got.Equal(now) {
+		t.Errorf("Get() = %v, %v, want %v, true", got, ok, now)
+	}
+	if _, ok := s.Get("/other"); ok {
+		t.Error("Get() should return false for an unknown source")
+	}
+}
@@ -0,0 +1,255 @@
+// Package mediacheck detects the truncated or corrupt media files by checking their structure.
+// Only the headers and the trailers are read, the image and video data aren't decoded.
+// The trailers are read only when the reader can seek, like a file on the disk: a file read
+// sequentially, like a file in a zip archive, gets its header checked only.
+package mediacheck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalid is wrapped by the errors of the files with an invalid structure
+var ErrInvalid = errors.New("invalid media structure")
+
+const (
+	jpegHeaderSize = 256 * 1024 // the JPEG segments before the image data are searched in this size
+	tailSize       = 64 * 1024  // the trailer is searched in this size
+)
+
+// errNoTrailer tells that the trailer can't be read without reading the whole file
+var errNoTrailer = errors.New("the trailer can't be reached")
+
+// Check reads the structure of the file of the given size, and returns an error wrapping ErrInvalid
+// when the file is truncated or corrupt. The JPEG, PNG, MP4 and MOV files are checked, the other ones are accepted.
+// The reader is read forward only. A reader implementing io.Seeker skips the data between the header and the trailer,
+// the other ones get their header checked only.
+func Check(r io.Reader, size int64, ext string) error {
+	c := &cursor{r: r, size: size}
+	c.seeker, _ = r.(io.Seeker)
+	var check func() error
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg":
+		check = c.checkJPEG
+	case ".png":
+		check = c.checkPNG
+	case ".mp4", ".m4v", ".mov", ".3gp":
+		check = c.checkMP4
+	default:
+		return nil
+	}
+	if size == 0 {
+		return invalid("empty file")
+	}
+	err := check()
+	if errors.Is(err, errNoTrailer) {
+		return nil
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: the file is shorter than its size", ErrInvalid)
+	}
+	return err
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: "+format, append([]any{ErrInvalid}, args...)...)
+}
+
+// cursor reads the file forward
+type cursor struct {
+	r      io.Reader
+	seeker io.Seeker // nil when the reader can't seek
+	pos    int64
+	size   int64
+}
+
+// read reads the next n bytes
+func (c *cursor) read(n int64) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(c.r, b)
+	c.pos += n
+	return b, err
+}
+
+// skipTo moves the cursor to the position. Without seeker, the bytes are read, and errNoTrailer
+// is returned when they are more than the trailer's size.
+func (c *cursor) skipTo(pos int64) error {
+	if pos <= c.pos {
+		return nil
+	}
+	if c.seeker != nil {
+		if _, err := c.seeker.Seek(pos, io.SeekStart); err == nil {
+			c.pos = pos
+			return nil
+		}
+	}
+	if pos-c.pos > tailSize {
+		return errNoTrailer
+	}
+	n, err := io.CopyN(io.Discard, c.r, pos-c.pos)
+	c.pos += n
+	return err
+}
+
+// tail reads the last n bytes of the file
+func (c *cursor) tail(n int64) ([]byte, error) {
+	start := max(c.size-n, c.pos)
+	if err := c.skipTo(start); err != nil {
+		return nil, err
+	}
+	return c.read(c.size - start)
+}
+
+// checkJPEG checks the SOI marker, the segments before the image data, and the EOI marker.
+// The segments are read one by one, only the APP1 ones are read entirely.
+// The motion photos and the Samsung files have data after the EOI marker, the trailer isn't checked for them.
+func (c *cursor) checkJPEG() error {
+	soi, err := c.read(min(c.size, 2))
+	if err != nil {
+		return err
+	}
+	if len(soi) < 2 || soi[0] != 0xFF || soi[1] != 0xD8 {
+		return invalid("no JPEG start of image")
+	}
+
+	trailer := false
+	for c.pos < jpegHeaderSize {
+		start := c.pos
+		if c.size-start < 4 {
+			return invalid("truncated JPEG segment at %d", start)
+		}
+		m, err := c.read(2)
+		if err != nil {
+			return err
+		}
+		if m[0] != 0xFF {
+			return invalid("corrupt JPEG segment at %d", start)
+		}
+		marker := m[1]
+		for marker == 0xFF { // fill bytes
+			b, err := c.read(1)
+			if err != nil {
+				return err
+			}
+			marker = b[0]
+		}
+		if marker == 0xDA { // start of scan, the image data follow
+			break
+		}
+		b, err := c.read(2)
+		if err != nil {
+			return err
+		}
+		l := int64(binary.BigEndian.Uint16(b))
+		if l < 2 {
+			return invalid("corrupt JPEG segment at %d", start)
+		}
+		end := c.pos + l - 2
+		if end > c.size {
+			return invalid("JPEG segment beyond the end of the file")
+		}
+		if marker == 0xE1 {
+			seg, err := c.read(l - 2)
+			if err != nil {
+				return err
+			}
+			if bytes.Contains(seg, []byte("MotionPhoto")) || bytes.Contains(seg, []byte("MicroVideo")) {
+				trailer = true
+			}
+		}
+		if err := c.skipTo(end); err != nil {
+			return err
+		}
+	}
+	if trailer {
+		return nil
+	}
+
+	t, err := c.tail(tailSize)
+	if err != nil {
+		return err
+	}
+	if bytes.HasSuffix(t, []byte("SEFT")) {
+		// Samsung trailer
+		return nil
+	}
+	t = bytes.TrimRight(t, "\x00")
+	if !bytes.HasSuffix(t, []byte{0xFF, 0xD9}) {
+		return invalid("no JPEG end of image, the file is truncated")
+	}
+	return nil
+}
+
+var (
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+	pngIEND      = []byte("IEND\xae\x42\x60\x82")
+)
+
+// checkPNG checks the PNG signature and the IEND chunk
+func (c *cursor) checkPNG() error {
+	head, err := c.read(min(c.size, int64(len(pngSignature))))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(head, pngSignature) {
+		return invalid("no PNG signature")
+	}
+	t, err := c.tail(1024)
+	if err != nil {
+		return err
+	}
+	if !bytes.Contains(t, pngIEND) {
+		return invalid("no PNG end chunk, the file is truncated")
+	}
+	return nil
+}
+
+// checkMP4 walks the top level boxes, checks they fit in the file and that the moov box is present
+func (c *cursor) checkMP4() error {
+	moov := false
+	for c.pos < c.size {
+		if c.size-c.pos < 8 {
+			return invalid("truncated box header at %d", c.pos)
+		}
+		start := c.pos
+		h, err := c.read(8)
+		if err != nil {
+			return err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(h))
+		boxType := string(h[4:8])
+		switch boxSize {
+		case 0: // the box extends to the end of the file
+			boxSize = c.size - start
+		case 1: // 64 bits size
+			b, err := c.read(8)
+			if err != nil {
+				return err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(b))
+		}
+		if boxSize < c.pos-start {
+			return invalid("corrupt box %q at %d", boxType, start)
+		}
+		if start+boxSize > c.size {
+			return invalid("box %q goes beyond the end of the file, the file is truncated", boxType)
+		}
+		if start == 0 && boxType != "ftyp" && boxType != "wide" && boxType != "free" && boxType != "mdat" && boxType != "moov" {
+			return invalid("not an MP4 file")
+		}
+		if boxType == "moov" {
+			moov = true
+		}
+		if err := c.skipTo(start + boxSize); err != nil {
+			return err
+		}
+	}
+	if !moov {
+		return invalid("no moov box, the video can't be played")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package mediacheck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+)
+
+func box(typ string, payload []byte) []byte {
+	b := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(b, uint32(8+len(payload)))
+	copy(b[4:], typ)
+	return append(b, payload...)
+}
+
+// sequential hides the io.Seeker of the reader, like a file in a zip archive
+type sequential struct{ io.Reader }
+
+func TestCheck(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	jpg := bytes.NewBuffer(nil)
+	if err := jpeg.Encode(jpg, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	pngFile := bytes.NewBuffer(nil)
+	if err := png.Encode(pngFile, img); err != nil {
+		t.Fatal(err)
+	}
+	mp4 := bytes.Join([][]byte{box("ftyp", []byte("isom")), box("mdat", make([]byte, 1000)), box("moov", make([]byte, 100))}, nil)
+	noMoov := bytes.Join([][]byte{box("ftyp", []byte("isom")), box("mdat", make([]byte, 1000))}, nil)
+
+	tests := []struct {
+		name    string
+		ext     string
+		data    []byte
+		invalid bool
+	}{
+		{name: "jpeg", ext: ".JPG", data: jpg.Bytes()},
+		{name: "jpeg with padding", ext: ".jpg", data: append(bytes.Clone(jpg.Bytes()), 0, 0, 0)},
+		{name: "truncated jpeg", ext: ".jpg", data: jpg.Bytes()[:jpg.Len()-10], invalid: true},
+		{name: "not a jpeg", ext: ".jpg", data: pngFile.Bytes(), invalid: true},
+		{name: "empty jpeg", ext: ".jpg", data: nil, invalid: true},
+		{name: "png", ext: ".png", data: pngFile.Bytes()},
+		{name: "truncated png", ext: ".png", data: pngFile.Bytes()[:pngFile.Len()-12], invalid: true},
+		{name: "mp4", ext: ".mp4", data: mp4},
+		{name: "truncated mp4", ext: ".mp4", data: mp4[:len(mp4)-50], invalid: true},
+		{name: "mp4 without moov", ext: ".mov", data: noMoov, invalid: true},
+		{name: "unknown type", ext: ".heic", data: []byte("anything")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, r := range []io.Reader{bytes.NewReader(tt.data), sequential{bytes.NewReader(tt.data)}} {
+				err := Check(r, int64(len(tt.data)), tt.ext)
+				if tt.invalid != errors.Is(err, ErrInvalid) {
+					t.Errorf("got %v, want invalid %v", err, tt.invalid)
+				}
+			}
+		})
+	}
+
+	// the file is shorter than the size given by the directory
+	err := Check(bytes.NewReader(mp4[:500]), int64(len(mp4)), ".mp4")
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("got %v, want an invalid file", err)
+	}
+}
+
+// counting counts the bytes read, and hides the io.Seeker of the reader
+type counting struct {
+	r io.Reader
+	n int64
+}
+
+func (c *counting) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	return n, err
+}
+
+func TestCheckSequential(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	jpg := bytes.NewBuffer(nil)
+	if err := jpeg.Encode(jpg, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	// fill bytes before the first segment, and large image data
+	big := append([]byte{0xFF, 0xD8, 0xFF, 0xFF}, jpg.Bytes()[3:]...)
+	big = append(big[:len(big)-2], make([]byte, 1<<20)...)
+	big = append(big, 0xFF, 0xD9)
+	mp4 := bytes.Join([][]byte{box("ftyp", []byte("isom")), box("mdat", make([]byte, 1<<20)), box("moov", make([]byte, 100))}, nil)
+
+	tests := []struct {
+		name string
+		ext  string
+		data []byte
+	}{
+		{name: "jpeg", ext: ".jpg", data: big},
+		{name: "mp4", ext: ".mp4", data: mp4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Check(bytes.NewReader(tt.data), int64(len(tt.data)), tt.ext); err != nil {
+				t.Errorf("got %v, want a valid file", err)
+			}
+			truncated := tt.data[:len(tt.data)-50]
+
+			// the seekable file gets its trailer checked
+			if err := Check(bytes.NewReader(truncated), int64(len(truncated)), tt.ext); !errors.Is(err, ErrInvalid) {
+				t.Errorf("got %v, want an invalid file", err)
+			}
+
+			// the sequential file gets its header checked only, the whole file isn't read
+			r := &counting{r: bytes.NewReader(truncated)}
+			if err := Check(r, int64(len(truncated)), tt.ext); err != nil {
+				t.Errorf("got %v, the trailer can't be checked", err)
+			}
+			if r.n > 2*tailSize {
+				t.Errorf("%d bytes read to check the header", r.n)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package motionphoto detects the videos exported next to the Android motion photos.
+//
+// A motion photo is a still image embedding a short video. Some exports write the video
+// a second time as a separate MP4 file sharing the image's name, like:
+//
+//	MVIMG_20190512_103021.jpg
+//	MVIMG_20190512_103021.mp4
+//	PXL_20231026_205755225.MP.jpg
+//	PXL_20231026_205755225.MP.mp4
+//
+// Immich extracts the video from the image, the separate file is a duplicate.
+package motionphoto
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/filetypes"
+)
+
+const (
+	// maximum difference between the capture dates of the image and the video
+	threshold = 3 * time.Second
+
+	// the motion photo markers are searched in the XMP packet, at the beginning of the image
+	maxMarkerSearch = 256 * 1024
+)
+
+// Videos gives the MP4 videos of the list that are the motion part of a motion photo of the list.
+// The video shares the directory and the radical of the image, and is taken at the same time.
+// The image is a motion photo by its name (MVIMG_ prefix, .MP. infix of the Pixel phones),
+// or by the motion photo markers of its metadata. The videos not matching these rules are real videos.
+func Videos(as []*assets.Asset) []*assets.Asset {
+	images := map[string][]*assets.Asset{}
+	for _, a := range as {
+		if a.Type == filetypes.TypeImage && isStillExt(a.Ext) {
+			k := key(a)
+			images[k] = append(images[k], a)
+		}
+	}
+
+	var videos []*assets.Asset
+	for _, v := range as {
+		if v.Type != filetypes.TypeVideo || v.Ext != ".mp4" {
+			continue
+		}
+		for _, i := range images[key(v)] {
+			if sameTime(i, v) && IsMotionPhoto(i) {
+				videos = append(videos, v)
+				break
+			}
+		}
+	}
+	return videos
+}
+
+// IsMotionPhoto tells if the image is a motion photo, by its name or by its metadata
+func IsMotionPhoto(a *assets.Asset) bool {
+	base := path.Base(a.File.Name())
+	if strings.HasPrefix(strings.ToUpper(base), "MVIMG_") || strings.Contains(strings.ToUpper(base), ".MP.") {
+		return true
+	}
+	if a.File.FS() == nil {
+		return false
+	}
+	f, err := a.File.Open()
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	b, err := io.ReadAll(io.LimitReader(f, maxMarkerSearch))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(b, []byte("MotionPhoto")) || bytes.Contains(b, []byte("MicroVideo"))
+}
+
+func key(a *assets.Asset) string {
+	return path.Join(path.Dir(a.File.Name()), a.Radical)
+}
+
+func isStillExt(ext string) bool {
+	switch ext {
+	case ".jpg", ".jpeg", ".heic", ".heif":
+		return true
+	}
+	return false
+}
+
+// sameTime checks the capture dates. Missing dates don't prevent the pairing.
+func sameTime(image, video *assets.Asset) bool {
+	di, dv := captureDate(image), captureDate(video)
+	if di.IsZero() || dv.IsZero() {
+		return true
+	}
+	d := di.Sub(dv)
+	return d <= threshold && d >= -threshold
+}
+
+func captureDate(a *assets.Asset) time.Time {
+	if !a.CaptureDate.IsZero() {
+		return a.CaptureDate
+	}
+	return a.FileDate
+}
@@ -0,0 +1,67 @@
+package motionphoto
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/simulot/immich-go/internal/assets"
+	"github.com/simulot/immich-go/internal/filenames"
+	"github.com/simulot/immich-go/internal/filetypes"
+	"github.com/simulot/immich-go/internal/fshelper"
+)
+
+var fsys = fstest.MapFS{
+	"MVIMG_20190512_103021.jpg":       {Data: []byte("jpeg")},
+	"MVIMG_20190512_103021.mp4":       {Data: []byte("video")},
+	"PXL_20231026_205755225.MP.jpg":   {Data: []byte("jpeg")},
+	"PXL_20231026_205755225.MP.mp4":   {Data: []byte("video")},
+	"20230101_120000.jpg":             {Data: []byte("...<GCamera:MotionPhoto>1</GCamera:MotionPhoto>...")},
+	"20230101_120000.mp4":             {Data: []byte("video")},
+	"IMG_0001.jpg":                    {Data: []byte("a plain photo")},
+	"IMG_0001.mp4":                    {Data: []byte("a real video")},
+	"MVIMG_20190512_110000.jpg":       {Data: []byte("jpeg")},
+	"MVIMG_20190512_110000.mp4":       {Data: []byte("taken later")},
+	"VID_20190512_103021.mp4":         {Data: []byte("video alone")},
+	"other/MVIMG_20190512_103021.mp4": {Data: []byte("another directory")},
+}
+
+func mockAsset(ic *filenames.InfoCollector, name string, dateTaken time.Time) *assets.Asset {
+	a := assets.Asset{
+		File:        fshelper.FSName(fsys, name),
+		FileDate:    dateTaken,
+		CaptureDate: dateTaken,
+	}
+	a.SetNameInfo(ic.GetInfo(name))
+	return &a
+}
+
+func TestVideos(t *testing.T) {
+	ic := filenames.NewInfoCollector(time.Local, filetypes.DefaultSupportedMedia)
+	baseTime := time.Date(2023, 6, 1, 12, 0, 0, 0, time.Local)
+
+	as := []*assets.Asset{
+		mockAsset(ic, "MVIMG_20190512_103021.jpg", baseTime), // named motion photo
+		mockAsset(ic, "MVIMG_20190512_103021.mp4", baseTime.Add(time.Second)),
+		mockAsset(ic, "PXL_20231026_205755225.MP.jpg", baseTime), // Pixel motion photo
+		mockAsset(ic, "PXL_20231026_205755225.MP.mp4", time.Time{}),
+		mockAsset(ic, "20230101_120000.jpg", baseTime), // motion photo by its metadata
+		mockAsset(ic, "20230101_120000.mp4", baseTime),
+		mockAsset(ic, "IMG_0001.jpg", baseTime), // not a motion photo
+		mockAsset(ic, "IMG_0001.mp4", baseTime),
+		mockAsset(ic, "MVIMG_20190512_110000.jpg", baseTime), // taken too far apart
+		mockAsset(ic, "MVIMG_20190512_110000.mp4", baseTime.Add(time.Minute)),
+		mockAsset(ic, "VID_20190512_103021.mp4", baseTime),         // video alone
+		mockAsset(ic, "other/MVIMG_20190512_103021.mp4", baseTime), // not in the image's directory
+	}
+
+	var got []string
+	for _, v := range Videos(as) {
+		got = append(got, v.File.Name())
+	}
+	want := []string{"MVIMG_20190512_103021.mp4", "PXL_20231026_205755225.MP.mp4", "20230101_120000.mp4"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,198 @@
+package namematcher
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// PathList is a list of glob patterns matching whole paths, relative to the root of the source.
+// Unlike List, a pattern is anchored at the root: `#recycle` matches only the folder at the root,
+// `**/#recycle` matches it at any depth.
+//
+//   - `*` matches any sequence of characters, but /
+//   - `?` matches any character, but /
+//   - `**` matches any number of folders
+//   - `[]` matches a character class, `[!]` or `[^]` a negated one, but never /
+//
+// The paths are compared without case.
+type PathList struct {
+	entries []patternEntry
+}
+
+// Match tells if the path matches one of the patterns
+func (l PathList) Match(name string) bool {
+	name = strings.TrimPrefix(name, "./")
+	for _, entry := range l.entries {
+		if entry.re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty tells if the list has no pattern
+func (l PathList) IsEmpty() bool {
+	return len(l.entries) == 0
+}
+
+// pathPatternToRe transforms a path glob pattern into an anchored regular expression
+func pathPatternToRe(pattern string) (*regexp.Regexp, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(pattern, "./"), "/")
+	p = strings.TrimSuffix(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("invalid path pattern: %q", pattern)
+	}
+
+	var r strings.Builder
+	r.WriteString("(?i)^")
+	for len(p) > 0 {
+		switch {
+		case strings.HasPrefix(p, "**/"):
+			r.WriteString("(.*/)?")
+			p = p[3:]
+		case p == "/**":
+			r.WriteString("(/.*)?")
+			p = ""
+		case strings.HasPrefix(p, "**"):
+			r.WriteString(".*")
+			p = p[2:]
+		case p[0] == '*':
+			r.WriteString("[^/]*")
+			p = p[1:]
+		case p[0] == '?':
+			r.WriteString("[^/]")
+			p = p[1:]
+		case p[0] == '[':
+			class, rest, ok := classToRe(p)
+			if !ok {
+				return nil, fmt.Errorf("invalid path pattern: %q", pattern)
+			}
+			r.WriteString(class)
+			p = rest
+		default:
+			_, size := utf8.DecodeRuneInString(p)
+			r.WriteString(regexp.QuoteMeta(p[:size]))
+			p = p[size:]
+		}
+	}
+	r.WriteString("$")
+	re, err := regexp.Compile(r.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid path pattern: %q", pattern)
+	}
+	return re, nil
+}
+
+// classToRe transforms the character class at the start of the pattern into a regular expression class,
+// and gives the rest of the pattern. `[!...]` and `[^...]` are negated classes, a `]` first in the class
+// is a member, `\` escapes the next character. Like `*` and `?`, a class never matches /.
+func classToRe(p string) (string, string, bool) {
+	i := 1
+	negated := i < len(p) && (p[i] == '!' || p[i] == '^')
+	if negated {
+		i++
+	}
+	// next gives the member at i, escaped or not
+	next := func() (rune, bool) {
+		if i < len(p) && p[i] == '\\' {
+			i++
+		}
+		if i >= len(p) {
+			return 0, false
+		}
+		c, size := utf8.DecodeRuneInString(p[i:])
+		i += size
+		return c, true
+	}
+
+	var r strings.Builder
+	r.WriteByte('[')
+	if negated {
+		r.WriteString("^/")
+	}
+	members := 0
+	var add func(lo, hi rune)
+	add = func(lo, hi rune) {
+		if !negated && lo <= '/' && '/' <= hi {
+			// the class doesn't match /: the range is split around it
+			if lo < '/' {
+				add(lo, '/'-1)
+			}
+			if '/' < hi {
+				add('/'+1, hi)
+			}
+			return
+		}
+		r.WriteString(regexp.QuoteMeta(string(lo)))
+		if hi != lo {
+			r.WriteByte('-')
+			r.WriteString(regexp.QuoteMeta(string(hi)))
+		}
+		members++
+	}
+	for first := true; ; first = false {
+		if i >= len(p) {
+			return "", "", false // no closing ]
+		}
+		if p[i] == ']' && !first {
+			i++
+			break
+		}
+		lo, ok := next()
+		if !ok {
+			return "", "", false
+		}
+		hi := lo
+		if i+1 < len(p) && p[i] == '-' && p[i+1] != ']' {
+			i++
+			if hi, ok = next(); !ok || hi < lo {
+				return "", "", false
+			}
+		}
+		add(lo, hi)
+	}
+	if members == 0 {
+		return "", "", false // a class matching only /
+	}
+	r.WriteByte(']')
+	return r.String(), p[i:], true
+}
+
+/*
+	Implements the flag.Value interface for the list of excluded paths
+*/
+
+func (l *PathList) Set(s string) error {
+	if l == nil {
+		return errors.New("namematcher path list not initialized")
+	}
+	if s == "" {
+		return nil
+	}
+	re, err := pathPatternToRe(s)
+	if err != nil {
+		return err
+	}
+	l.entries = append(l.entries, patternEntry{re: re, raw: s})
+	return nil
+}
+
+func (l PathList) String() string {
+	var s strings.Builder
+	for i, entry := range l.entries {
+		if i > 0 {
+			s.WriteString(", ")
+		}
+		s.WriteRune('\'')
+		s.WriteString(entry.raw)
+		s.WriteRune('\'')
+	}
+	return s.String()
+}
+
+func (l PathList) Type() string {
+	return "PathList"
+}
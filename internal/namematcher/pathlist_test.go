@@ -0,0 +1,137 @@
+package namematcher
+
+import "testing"
+
+func TestPathList_Match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    map[string]bool
+	}{
+		{
+			pattern: "#recycle",
+			want: map[string]bool{
+				"#recycle":           true,
+				"#RECYCLE":           true,
+				"photos/#recycle":    false,
+				"#recycle/photo.jpg": false,
+				"#recycled":          false,
+				"./#recycle":         true,
+			},
+		},
+		{
+			pattern: "**/@eaDir",
+			want: map[string]bool{
+				"@eaDir":             true,
+				"2023/@eaDir":        true,
+				"2023/summer/@eaDir": true,
+				"2023/@eaDir.bak":    false,
+			},
+		},
+		{
+			pattern: "2023/**/*.tmp",
+			want: map[string]bool{
+				"2023/a.tmp":            true,
+				"2023/summer/a.tmp":     true,
+				"2024/a.tmp":            false,
+				"2023/summer/a.tmp.jpg": false,
+			},
+		},
+		{
+			pattern: "backup/**",
+			want: map[string]bool{
+				"backup":         true,
+				"backup/a/b.jpg": true,
+				"backups":        false,
+			},
+		},
+		{
+			pattern: "/raw?/",
+			want: map[string]bool{
+				"raw1":       true,
+				"raw":        false,
+				"raw1/a.jpg": false,
+			},
+		},
+		{
+			pattern: "[ab]*.jpg",
+			want: map[string]bool{
+				"a1.jpg":    true,
+				"b.jpg":     true,
+				"c.jpg":     false,
+				"sub/a.jpg": false,
+			},
+		},
+		{
+			pattern: "[!x]*.jpg",
+			want: map[string]bool{
+				"a.jpg":   true,
+				"x.jpg":   false,
+				"X.jpg":   false,
+				"/a.jpg":  false,
+				"!a.jpg":  true,
+				"sub/a.j": false,
+			},
+		},
+		{
+			pattern: "img[^0-4].jpg",
+			want: map[string]bool{
+				"img5.jpg": true,
+				"img3.jpg": false,
+				"img/.jpg": false,
+			},
+		},
+		{
+			// a class never matches /, even when given or in a range
+			pattern: "a[/b]c",
+			want: map[string]bool{
+				"abc": true,
+				"a/c": false,
+			},
+		},
+		{
+			pattern: "a[+-0]c",
+			want: map[string]bool{
+				"a+c": true,
+				"a.c": true,
+				"a0c": true,
+				"a/c": false,
+			},
+		},
+		{
+			pattern: `[]\[]x`,
+			want: map[string]bool{
+				"]x": true,
+				"[x": true,
+				"ax": false,
+			},
+		},
+		{
+			pattern: "[a-c.]",
+			want: map[string]bool{
+				"b": true,
+				".": true,
+				"d": false,
+			},
+		},
+	}
+	for _, tt := range tests {
+		var l PathList
+		if err := l.Set(tt.pattern); err != nil {
+			t.Fatalf("Set(%q): %v", tt.pattern, err)
+		}
+		for name, want := range tt.want {
+			if got := l.Match(name); got != want {
+				t.Errorf("%q.Match(%q) = %v, want %v", tt.pattern, name, got, want)
+			}
+		}
+	}
+}
+
+func TestPathList_Invalid(t *testing.T) {
+	var l PathList
+	for _, p := range []string{"/", "photos/[ab", "[/]", "[b-a]", "[!", "[]", `[a\`} {
+		if err := l.Set(p); err == nil {
+			t.Errorf("Set(%q) accepted", p)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package servercache keeps the inventory of the server's assets and albums on disk,
+// to reuse it in the next runs against the same server.
+//
+// An inventory is kept by server and user. It's used until its time to live expires.
+// The assets updated on the server since the latest update of the inventory, like the
+// assets uploaded by the previous run, are merged into it. The inventory becomes stale
+// when the server's asset statistics change more than the merged assets explain: an
+// asset deleted for good isn't seen otherwise.
+package servercache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/simulot/immich-go/immich"
+)
+
+// Inventory is the content of the server saved in the cache
+type Inventory struct {
+	Server     string                `json:"server"`
+	UserID     string                `json:"user_id"`
+	SavedAt    time.Time             `json:"saved_at"`
+	Statistics immich.UserStatistics `json:"statistics"` // the server's statistics when the assets were read or merged
+	Assets     []*immich.Asset       `json:"assets"`
+	Albums     map[string]Album      `json:"albums,omitempty"` // by album ID
+}
+
+// Album gives the assets of an album. They are reused while the album has the same number of assets.
+type Album struct {
+	AssetCount int      `json:"asset_count"`
+	AssetIDs   []string `json:"asset_ids"`
+}
+
+// Cache is a folder of inventories, one file by server and user
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// DefaultDir returns the default folder of the cache
+func DefaultDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "immich-go_server-cache"
+	}
+	return filepath.Join(cacheDir, "immich-go", "server-cache")
+}
+
+// New gives the cache kept in the folder. The inventories older than ttl aren't used.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+// fileName gives the file of the inventory of the server and the user
+func (c *Cache) fileName(server, userID string) string {
+	h := sha256.Sum256([]byte(server + "\n" + userID))
+	return filepath.Join(c.dir, hex.EncodeToString(h[:8])+".json")
+}
+
+// Load gives the inventory of the server and the user when it is younger than the time to live.
+// The assets updated since must be merged before using it.
+// The reason why the inventory isn't used is given otherwise.
+func (c *Cache) Load(server, userID string) (*Inventory, string) {
+	b, err := os.ReadFile(c.fileName(server, userID))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, "no inventory saved"
+		}
+		return nil, err.Error()
+	}
+	var inv Inventory
+	if err = json.Unmarshal(b, &inv); err != nil {
+		return nil, "unreadable inventory: " + err.Error()
+	}
+	switch {
+	case inv.Server != server || inv.UserID != userID:
+		return nil, "inventory of another server"
+	case time.Since(inv.SavedAt) > c.ttl:
+		return nil, "inventory expired"
+	}
+	return &inv, ""
+}
+
+// LastUpdate gives the latest update of the inventory's assets.
+// The assets updated on the server after it aren't in the inventory.
+func (inv *Inventory) LastUpdate() time.Time {
+	var last time.Time
+	for _, a := range inv.Assets {
+		if a.UpdatedAt.After(last) {
+			last = a.UpdatedAt.Time
+		}
+	}
+	return last
+}
+
+// Merge adds the assets updated on the server since the last update to the inventory, and
+// replaces the ones already there. It returns false when the server's statistics have changed
+// more than the merged assets: some assets have been deleted for good, and the inventory must be
+// read again.
+func (inv *Inventory) Merge(updated []*immich.Asset, stats immich.UserStatistics) bool {
+	before := countAssets(inv.Assets)
+	byID := make(map[string]int, len(inv.Assets))
+	for i, a := range inv.Assets {
+		byID[a.ID] = i
+	}
+	for _, a := range updated {
+		if i, ok := byID[a.ID]; ok {
+			inv.Assets[i] = a
+			continue
+		}
+		byID[a.ID] = len(inv.Assets)
+		inv.Assets = append(inv.Assets, a)
+	}
+	after := countAssets(inv.Assets)
+	if stats.Images-inv.Statistics.Images != after.Images-before.Images ||
+		stats.Videos-inv.Statistics.Videos != after.Videos-before.Videos ||
+		stats.Total-inv.Statistics.Total != after.Total-before.Total {
+		return false
+	}
+	inv.Statistics = stats
+	return true
+}
+
+// countAssets counts the assets like the server's statistics, without the trashed ones
+func countAssets(list []*immich.Asset) immich.UserStatistics {
+	var s immich.UserStatistics
+	for _, a := range list {
+		if a.IsTrashed {
+			continue
+		}
+		switch a.Type {
+		case "IMAGE":
+			s.Images++
+		case "VIDEO":
+			s.Videos++
+		}
+		s.Total++
+	}
+	return s
+}
+
+// Save writes the inventory. The file is replaced atomically.
+func (c *Cache) Save(inv *Inventory) error {
+	b, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(c.dir, 0o700)
+	if err != nil {
+		return err
+	}
+	name := c.fileName(inv.Server, inv.UserID)
+	tmp := name + ".tmp"
+	err = os.WriteFile(tmp, b, 0o600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,122 @@
+package servercache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/simulot/immich-go/immich"
+)
+
+func TestCache(t *testing.T) {
+	c := New(t.TempDir(), time.Hour)
+	stats := immich.UserStatistics{Images: 2, Videos: 1, Total: 3}
+
+	if inv, reason := c.Load("https://immich.example.com", "user1"); inv != nil || reason == "" {
+		t.Fatalf("Load() on an empty cache should fail, got %v %q", inv, reason)
+	}
+
+	err := c.Save(&Inventory{
+		Server:     "https://immich.example.com",
+		UserID:     "user1",
+		SavedAt:    time.Now(),
+		Statistics: stats,
+		Assets:     []*immich.Asset{{ID: "a1"}, {ID: "a2"}, {ID: "a3"}},
+		Albums:     map[string]Album{"al1": {AssetCount: 1, AssetIDs: []string{"a1"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inv, reason := c.Load("https://immich.example.com", "user1")
+	if inv == nil {
+		t.Fatalf("Load() failed: %s", reason)
+	}
+	if len(inv.Assets) != 3 || inv.Assets[2].ID != "a3" || inv.Albums["al1"].AssetIDs[0] != "a1" {
+		t.Errorf("unexpected inventory: %+v", inv)
+	}
+
+	if inv, _ := c.Load("https://immich.example.com", "user2"); inv != nil {
+		t.Error("the inventory of another user should not be used")
+	}
+	if inv, _ := c.Load("https://other.example.com", "user1"); inv != nil {
+		t.Error("the inventory of another server should not be used")
+	}
+	expired := New(c.dir, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if inv, reason := expired.Load("https://immich.example.com", "user1"); inv != nil || reason != "inventory expired" {
+		t.Errorf("an expired inventory should not be used, got %v %q", inv, reason)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	date := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	inventory := func() *Inventory {
+		return &Inventory{
+			Statistics: immich.UserStatistics{Images: 2, Videos: 1, Total: 3},
+			Assets: []*immich.Asset{
+				{ID: "a1", Type: "IMAGE", UpdatedAt: immich.ImmichTime{Time: date}},
+				{ID: "a2", Type: "IMAGE", UpdatedAt: immich.ImmichTime{Time: date.Add(time.Hour)}},
+				{ID: "a3", Type: "VIDEO", UpdatedAt: immich.ImmichTime{Time: date}},
+			},
+		}
+	}
+	later := immich.ImmichTime{Time: date.Add(2 * time.Hour)}
+
+	if got := inventory().LastUpdate(); !got.Equal(date.Add(time.Hour)) {
+		t.Errorf("LastUpdate() = %s", got)
+	}
+
+	tests := []struct {
+		name    string
+		updated []*immich.Asset
+		stats   immich.UserStatistics
+		valid   bool
+		assets  int
+	}{
+		{name: "unchanged", stats: immich.UserStatistics{Images: 2, Videos: 1, Total: 3}, valid: true, assets: 3},
+		{
+			name:    "uploaded",
+			updated: []*immich.Asset{{ID: "a2", Type: "IMAGE", UpdatedAt: immich.ImmichTime{Time: date.Add(time.Hour)}}, {ID: "a4", Type: "IMAGE", UpdatedAt: later}},
+			stats:   immich.UserStatistics{Images: 3, Videos: 1, Total: 4},
+			valid:   true,
+			assets:  4,
+		},
+		{
+			name:    "trashed",
+			updated: []*immich.Asset{{ID: "a3", Type: "VIDEO", IsTrashed: true, UpdatedAt: later}},
+			stats:   immich.UserStatistics{Images: 2, Videos: 0, Total: 2},
+			valid:   true,
+			assets:  3,
+		},
+		{name: "deleted for good", stats: immich.UserStatistics{Images: 1, Videos: 1, Total: 2}},
+		{
+			// the same counts, but an asset has been deleted and another one added
+			name:    "deleted and added",
+			updated: []*immich.Asset{{ID: "a4", Type: "IMAGE", UpdatedAt: later}},
+			stats:   immich.UserStatistics{Images: 2, Videos: 1, Total: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := inventory()
+			if got := inv.Merge(tt.updated, tt.stats); got != tt.valid {
+				t.Fatalf("Merge() = %v, want %v", got, tt.valid)
+			}
+			if !tt.valid {
+				return
+			}
+			if len(inv.Assets) != tt.assets || inv.Statistics != tt.stats {
+				t.Errorf("unexpected inventory: %d assets, %+v", len(inv.Assets), inv.Statistics)
+			}
+			for _, u := range tt.updated {
+				found := false
+				for _, a := range inv.Assets {
+					found = found || a == u
+				}
+				if !found {
+					t.Errorf("%s isn't merged", u.ID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Package twopass keeps the server IDs of the files uploaded by the first pass of a two-pass upload,
+// for the second pass to enrich the same assets.
+//
+// The IDs are stored in a JSON file, by default one file by server and user in the immich-go cache folder.
+package twopass
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Map gives the server's asset ID of the uploaded files, indexed by the file's name in the input.
+// It is safe for concurrent use.
+type Map struct {
+	path   string
+	lock   sync.Mutex
+	Server string            `json:"server"`
+	UserID string            `json:"user_id"`
+	Assets map[string]string `json:"assets"`
+}
+
+// DefaultPath returns the default file of the server and the user
+func DefaultPath(server, userID string) string {
+	h := sha256.Sum256([]byte(server + "\n" + userID))
+	name := hex.EncodeToString(h[:8]) + ".json"
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "immich-go_two-pass_" + name
+	}
+	return filepath.Join(cacheDir, "immich-go", "two-pass", name)
+}
+
+// Load reads the file of the server and the user. A missing file gives an empty map.
+// A file written for another server or user is an error.
+func Load(path, server, userID string) (*Map, error) {
+	m := &Map{
+		path:   path,
+		Server: server,
+		UserID: userID,
+		Assets: map[string]string{},
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return m, nil
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("can't read the two-pass file %s: %w", path, err)
+	}
+	switch {
+	case m.Server != server:
+		return nil, fmt.Errorf("the two-pass file %s was written for the server %s", path, m.Server)
+	case m.UserID != userID:
+		return nil, fmt.Errorf("the two-pass file %s was written for another user", path)
+	}
+	if m.Assets == nil {
+		m.Assets = map[string]string{}
+	}
+	return m, nil
+}
+
+// Get returns the server's asset ID of the file
+func (m *Map) Get(name string) (string, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	id, ok := m.Assets[name]
+	return id, ok
+}
+
+// Set records the server's asset ID of the file
+func (m *Map) Set(name, id string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.Assets[name] = id
+}
+
+// Len gives the number of files in the map
+func (m *Map) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.Assets)
+}
+
+// Save writes the file. The file is replaced atomically.
+func (m *Map) Save() error {
+	m.lock.Lock()
+	b, err := json.Marshal(m)
+	m.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(m.path), 0o700)
+	if err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	err = os.WriteFile(tmp, b, 0o600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
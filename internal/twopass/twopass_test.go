@@ -0,0 +1,42 @@
+package twopass
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "two-pass.json")
+
+	m, err := Load(path, "http://nas:2283", "u1")
+	if err != nil {
+		t.Fatalf("Load() on a missing file: %v", err)
+	}
+	if _, ok := m.Get("photos:a.jpg"); ok {
+		t.Fatal("Get() on an empty map should return false")
+	}
+
+	m.Set("photos:a.jpg", "id-a")
+	m.Set("photos:b.nef", "id-b")
+	if err = m.Save(); err != nil {
+		t.Fatalf("Save(): %v", err)
+	}
+
+	m, err = Load(path, "http://nas:2283", "u1")
+	if err != nil {
+		t.Fatalf("Load(): %v", err)
+	}
+	if id, ok := m.Get("photos:a.jpg"); !ok || id != "id-a" {
+		t.Errorf("Get() = %q, %v, want %q, true", id, ok, "id-a")
+	}
+	if m.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", m.Len())
+	}
+
+	if _, err = Load(path, "http://other:2283", "u1"); err == nil {
+		t.Error("Load() should fail on the file of another server")
+	}
+	if _, err = Load(path, "http://nas:2283", "u2"); err == nil {
+		t.Error("Load() should fail on the file of another user")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 
+	"github.com/simulot/immich-go/app"
 	"github.com/simulot/immich-go/app/root"
 )
 
@@ -19,7 +20,7 @@ func main() {
 			err = e
 		}
 		_, _ = fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(app.ExitCode(err))
 	}
 }
 
@@ -41,9 +42,14 @@ func immichGoMain(ctx context.Context) error {
 
 	c, a := root.RootImmichGoCommand(ctx)
 	// let's start
-	err := c.ExecuteContext(ctx)
+	cmd, err := c.ExecuteContextC(ctx)
 	if err != nil && a.Log().GetSLog() != nil {
 		a.Log().Error(err.Error())
 	}
+	a.CloseStatusFile(cmd, err)
+	a.CloseCheckpoints(cmd, err)
+	a.WriteSummaryFile(cmd, err)
+	a.SyslogSummary(cmd, err)
+	a.NotifyCompletion(cmd, err)
 	return err
 }